@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/pkg/database"
+)
+
+// Rebuilds product_associations on demand, e.g. right after deploying or to
+// try other options before changing the nightly job's config
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to config file")
+	lookback := flag.Duration("lookback", 0, "only use orders placed within this window (default: recommendation.lookback)")
+	minSupport := flag.Int("min-support", 0, "minimum orders containing both products (default: recommendation.min_support)")
+	maxPerProduct := flag.Int("max-per-product", 0, "associations kept per product (default: recommendation.max_per_product)")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	opts := service.RecommendBuildOptions{
+		Lookback:      cfg.Recommend.Lookback,
+		MinSupport:    cfg.Recommend.MinSupport,
+		MaxPerProduct: cfg.Recommend.MaxPerProduct,
+	}
+	if *lookback > 0 {
+		opts.Lookback = *lookback
+	}
+	if *minSupport > 0 {
+		opts.MinSupport = *minSupport
+	}
+	if *maxPerProduct > 0 {
+		opts.MaxPerProduct = *maxPerProduct
+	}
+
+	db, err := database.NewConnection(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	svc := service.NewRecommendationService(repository.NewRecommendationRepository(db), repository.NewProductRepository(db), nil)
+	report, err := svc.Rebuild(opts)
+	if err != nil {
+		log.Fatalf("Rebuild failed: %v", err)
+	}
+	fmt.Printf("Stored %d associations for %d products in %s\n", report.Associations, report.Products, report.Took)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/pkg/database"
+)
+
+// Checks that every product's stock equals the sum of its stock_movements.
+// Exits with status 1 when a product differs, so it can run from cron or CI.
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to config file")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.NewConnection(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+
+	svc := service.NewInventoryService(repository.NewProductRepository(db), nil)
+	mismatches, err := svc.Reconcile()
+	sqlDB.Close()
+	if err != nil {
+		log.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(mismatches) == 0 {
+		fmt.Println("Stock matches the ledger for every product")
+		return
+	}
+
+	for _, m := range mismatches {
+		fmt.Printf("product %d %q: stock %d, ledger %d (diff %+d)\n", m.ProductID, m.Name, m.Stock, m.Ledger, m.Stock-m.Ledger)
+	}
+	fmt.Printf("%d product(s) out of sync with the ledger\n", len(mismatches))
+	os.Exit(1)
+}
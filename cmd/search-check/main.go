@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
+	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/pkg/database"
+)
+
+// Dry run of the product search index: builds a throwaway index from the
+// database, optionally runs a query against it, and exits. It does not
+// rebuild the index of a running server, which lives in that server's
+// memory; use POST /admin/products/reindex on each instance for that.
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to config file")
+	query := flag.String("query", "", "search the rebuilt index and list the matching products")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.NewConnection(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	productRepo := repository.NewProductRepository(db)
+	translations := service.NewTranslationService(repository.NewTranslationRepository(db), cfg.I18n.DefaultLocale, cfg.I18n.Locales)
+	index := search.NewInvertedIndex()
+	svc := service.NewProductService(productRepo, repository.NewCategoryRepository(db), nil, cfg.App.BaseURL, cfg.App.APIURL, nil, index, nil, nil, nil, translations)
+	n, err := svc.RebuildSearchIndex()
+	if err != nil {
+		log.Fatalf("Rebuild failed: %v", err)
+	}
+	fmt.Printf("Indexed %d products\n", n)
+
+	if *query == "" {
+		return
+	}
+	ids := index.Search(*query)
+	products, err := productRepo.FindByIDs(ids)
+	if err != nil {
+		log.Fatalf("Failed to load matching products: %v", err)
+	}
+	names := make(map[uint]string, len(products))
+	for _, p := range products {
+		names[p.ID] = p.Name
+	}
+	for _, id := range ids {
+		fmt.Printf("product %d %q\n", id, names[id])
+	}
+	fmt.Printf("%d product(s) match %q\n", len(ids), *query)
+}
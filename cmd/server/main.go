@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"strconv"
+	_ "time/tzdata" // availability.timezone must load on hosts without zoneinfo
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -14,10 +15,13 @@ import (
 	_ "github.com/kha/foods-drinks/docs"
 	"github.com/kha/foods-drinks/internal/config"
 	"github.com/kha/foods-drinks/internal/handler"
+	"github.com/kha/foods-drinks/internal/httpcache"
 	"github.com/kha/foods-drinks/internal/middleware"
 	"github.com/kha/foods-drinks/internal/repository"
 	"github.com/kha/foods-drinks/internal/routes"
+	"github.com/kha/foods-drinks/internal/search"
 	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/internal/storage"
 	"github.com/kha/foods-drinks/pkg/database"
 	customValidator "github.com/kha/foods-drinks/pkg/validator"
 	swaggerFiles "github.com/swaggo/files"
@@ -76,25 +80,73 @@ func main() {
 	orderNotificationRepo := repository.NewOrderNotificationRepository(db)
 	ratingRepo := repository.NewRatingRepository(db)
 	suggestionRepo := repository.NewSuggestionRepository(db)
+	modifierRepo := repository.NewModifierRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	searchQueryRepo := repository.NewSearchQueryRepository(db)
+	recommendationRepo := repository.NewRecommendationRepository(db)
+	availabilityRepo := repository.NewAvailabilityRepository(db)
+	priceRuleRepo := repository.NewPriceRuleRepository(db)
+	translationRepo := repository.NewTranslationRepository(db)
 
-	cartService := service.NewCartService(cartRepo, productRepo)
+	var responseCache *httpcache.Cache
+	if cfg.HTTPCache.Enabled {
+		responseCache = httpcache.New(cfg.HTTPCache.TTL, cfg.HTTPCache.MaxEntries, cfg.HTTPCache.MaxAge)
+	}
+	availabilityService, err := service.NewAvailabilityService(availabilityRepo, productRepo, categoryRepo, cfg.Availability.Timezone, responseCache)
+	if err != nil {
+		log.Fatalf("Failed to configure availability: %v", err)
+	}
+
+	pricingService := service.NewPricingService(priceRuleRepo, productRepo, categoryRepo, availabilityService.Location(), responseCache)
+
+	cartService := service.NewCartService(cartRepo, productRepo, modifierRepo, availabilityService, pricingService)
 	authService := service.NewAuthService(userRepo, cartService, &cfg.JWT)
 	oauthService := service.NewOAuthService(userRepo, socialAuthRepo, cartRepo, authService, &cfg.OAuth)
-	profileService := service.NewProfileService(userRepo, &cfg.Upload, routes.UploadURLPrefix)
-	categoryService := service.NewCategoryService(categoryRepo)
-	productService := service.NewProductService(productRepo, categoryRepo, cfg.App.BaseURL)
+	uploadStore, err := storage.New(&cfg.Upload, routes.UploadURLPrefix)
+	if err != nil {
+		log.Fatalf("Failed to configure upload storage: %v", err)
+	}
+	uploadService := service.NewUploadService(&cfg.Upload, uploadStore, routes.UploadURLPrefix)
+	profileService := service.NewProfileService(userRepo, uploadService)
+	searchService := service.NewSearchService(productRepo, categoryRepo, searchQueryRepo)
+	cursorCodec := service.NewCursorCodec(cfg.JWT.Secret)
 	emailNotificationService := service.NewEmailNotificationService(&cfg.Email, orderNotificationRepo)
 	chatworkNotificationService := service.NewChatworkNotificationService(&cfg.Chatwork, orderNotificationRepo)
+	stockAlertService := service.NewStockAlertService(productRepo, emailNotificationService, chatworkNotificationService)
+	translationService := service.NewTranslationService(translationRepo, cfg.I18n.DefaultLocale, cfg.I18n.Locales)
+	productService := service.NewProductService(productRepo, categoryRepo, uploadService, cfg.App.BaseURL, cfg.App.APIURL, cursorCodec, search.NewInvertedIndex(), availabilityService, stockAlertService, pricingService, translationService, searchService, responseCache)
+	categoryService := service.NewCategoryService(categoryRepo, uploadService, productService, translationService, searchService, responseCache)
+	if n, err := productService.RebuildSearchIndex(); err != nil {
+		log.Fatalf("Failed to build product search index: %v", err)
+	} else {
+		log.Printf("Product search index built with %d products", n)
+	}
 	notifier := service.NewMultiOrderNotifier(emailNotificationService, chatworkNotificationService)
-	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo, notifier)
-	ratingService := service.NewRatingService(ratingRepo, productRepo)
+	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo, modifierRepo, notifier, cursorCodec, availabilityService, stockAlertService, pricingService)
+	ratingService := service.NewRatingService(ratingRepo, productRepo, uploadService, cursorCodec, cfg.Rating.MaxPhotos, cfg.Rating.BannedWords)
+	recommendationService := service.NewRecommendationService(recommendationRepo, productRepo, productService)
 	suggestionService := service.NewSuggestionService(suggestionRepo, categoryRepo)
 	adminUserService := service.NewAdminUserService(userRepo)
+	modifierService := service.NewModifierService(modifierRepo, productRepo)
+	inventoryService := service.NewInventoryService(productRepo, stockAlertService, responseCache)
+	productImportService := service.NewProductImportService(productService, cfg.Catalog.PriceChangeConfirmPercent)
 
 	scheduler := service.NewMonthlyReportScheduler(&cfg.Scheduler, &cfg.Email, orderService)
 	scheduler.Start()
 	defer scheduler.Stop()
 
+	uploadGCScheduler := service.NewUploadGCScheduler(&cfg.Upload.GC, service.NewUploadGCService(uploadService, uploadRepo))
+	uploadGCScheduler.Start()
+	defer uploadGCScheduler.Stop()
+
+	recommendationScheduler := service.NewRecommendationScheduler(&cfg.Recommend, recommendationService)
+	recommendationScheduler.Start()
+	defer recommendationScheduler.Stop()
+
+	lowStockDigestScheduler := service.NewLowStockDigestScheduler(&cfg.Inventory, stockAlertService)
+	lowStockDigestScheduler.Start()
+	defer lowStockDigestScheduler.Stop()
+
 	funcMap := template.FuncMap{
 		"inc": func(i int) int { return i + 1 },
 		"dec": func(i int) int { return i - 1 },
@@ -141,42 +193,81 @@ func main() {
 	}
 
 	healthHandler := handler.NewHealthHandler()
+	metricsHandler := handler.NewMetricsHandler(responseCache)
 	authHandler := handler.NewAuthHandler(authService)
 	oauthHandler := handler.NewOAuthHandler(oauthService)
 	profileHandler := handler.NewProfileHandler(profileService)
-	adminCategoryHandler := handler.NewAdminCategoryHandler(categoryService, funcMap)
-	productHandler := handler.NewProductHandler(productService)
-	adminProductHandler := handler.NewAdminProductHandler(productService, categoryService, funcMap)
+	adminCategoryHandler := handler.NewAdminCategoryHandler(categoryService, availabilityService, translationService, funcMap)
+	productHandler := handler.NewProductHandler(productService, searchService, translationService, responseCache)
+	categoryHandler := handler.NewCategoryHandler(categoryService, productService, translationService, responseCache)
+	searchHandler := handler.NewSearchHandler(searchService)
+	var sharePageHandler *handler.SharePageHandler
+	if cfg.App.APIURL != "" {
+		sharePageHandler = handler.NewSharePageHandler(productService, translationService, responseCache, cfg.App.APIURL, funcMap)
+	} else {
+		log.Println("app.api_url is not set; share pages, sitemap.xml and robots.txt are disabled")
+	}
+	adminSearchHandler := handler.NewAdminSearchHandler(searchService, funcMap)
+	adminProductHandler := handler.NewAdminProductHandler(productService, categoryService, availabilityService, translationService, cfg.Catalog.PriceChangeConfirmPercent, funcMap)
 	adminOrderHandler := handler.NewAdminOrderHandler(orderService, funcMap)
 	adminOrderStatsHandler := handler.NewAdminOrderStatisticsHandler(orderService, funcMap)
 	adminSuggestionHandler := handler.NewAdminSuggestionHandler(suggestionService, funcMap)
+	adminRatingHandler := handler.NewAdminRatingHandler(ratingService, funcMap)
 	adminUserHandler := handler.NewAdminUserHandler(adminUserService, funcMap)
+	adminTrashHandler := handler.NewAdminTrashHandler(categoryService, productService, funcMap)
+	adminModifierHandler := handler.NewAdminModifierHandler(modifierService, productService, categoryService, funcMap)
+	adminInventoryHandler := handler.NewAdminInventoryHandler(inventoryService, productService, funcMap)
+	adminPriceRuleHandler := handler.NewAdminPriceRuleHandler(pricingService, productService, categoryService, funcMap)
+	adminProductImportHandler := handler.NewAdminProductImportHandler(productImportService, funcMap)
 	cartHandler := handler.NewCartHandler(cartService)
+	modifierHandler := handler.NewModifierHandler(modifierService)
 	orderHandler := handler.NewOrderHandler(orderService)
 	ratingHandler := handler.NewRatingHandler(ratingService)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService, translationService)
 	suggestionHandler := handler.NewSuggestionHandler(suggestionService)
 
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 
+	var uploadHandler *handler.UploadHandler
+	if !storage.IsLocal(uploadStore) {
+		uploadHandler = handler.NewUploadHandler(uploadStore)
+	}
+
 	deps := &routes.RouterDependencies{
-		HealthHandler:          healthHandler,
-		AuthHandler:            authHandler,
-		OAuthHandler:           oauthHandler,
-		ProfileHandler:         profileHandler,
-		AdminCategoryHandler:   adminCategoryHandler,
-		ProductHandler:         productHandler,
-		AdminProductHandler:    adminProductHandler,
-		AdminOrderHandler:      adminOrderHandler,
-		AdminOrderStatsHandler: adminOrderStatsHandler,
-		AdminSuggestionHandler: adminSuggestionHandler,
-		AdminUserHandler:       adminUserHandler,
-		CartHandler:            cartHandler,
-		OrderHandler:           orderHandler,
-		RatingHandler:          ratingHandler,
-		SuggestionHandler:      suggestionHandler,
-		CorsMiddleware:         middleware.CORSConfig(),
-		AuthMiddleware:         authMiddleware,
-		UploadPath:             cfg.Upload.Path,
+		HealthHandler:             healthHandler,
+		MetricsHandler:            metricsHandler,
+		AuthHandler:               authHandler,
+		OAuthHandler:              oauthHandler,
+		ProfileHandler:            profileHandler,
+		AdminCategoryHandler:      adminCategoryHandler,
+		ProductHandler:            productHandler,
+		CategoryHandler:           categoryHandler,
+		AdminProductHandler:       adminProductHandler,
+		AdminOrderHandler:         adminOrderHandler,
+		AdminOrderStatsHandler:    adminOrderStatsHandler,
+		AdminSuggestionHandler:    adminSuggestionHandler,
+		AdminRatingHandler:        adminRatingHandler,
+		AdminSearchHandler:        adminSearchHandler,
+		AdminUserHandler:          adminUserHandler,
+		AdminTrashHandler:         adminTrashHandler,
+		AdminModifierHandler:      adminModifierHandler,
+		AdminInventoryHandler:     adminInventoryHandler,
+		AdminPriceRuleHandler:     adminPriceRuleHandler,
+		AdminProductImportHandler: adminProductImportHandler,
+		CartHandler:               cartHandler,
+		ModifierHandler:           modifierHandler,
+		OrderHandler:              orderHandler,
+		RatingHandler:             ratingHandler,
+		RecommendationHandler:     recommendationHandler,
+		SuggestionHandler:         suggestionHandler,
+		SearchHandler:             searchHandler,
+		SharePageHandler:          sharePageHandler,
+		UploadHandler:             uploadHandler,
+		CorsMiddleware:            middleware.CORSConfig(),
+		AuthMiddleware:            authMiddleware,
+		SuggestRateLimiter:        middleware.NewRateLimiter(cfg.Search.SuggestRateLimit, cfg.Search.SuggestRateWindow),
+		UploadPath:                cfg.Upload.Path,
+		TrustedProxies:            cfg.App.TrustedProxies,
 	}
 	router := routes.SetupRouter(deps)
 	if cfg.App.Env != "production" {
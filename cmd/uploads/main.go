@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/routes"
+	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/internal/storage"
+	"github.com/kha/foods-drinks/pkg/database"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to config file")
+	command := flag.String("command", "migrate", "uploads command: migrate, gc")
+	source := flag.String("source", "", "migrate: local directory to migrate from (default: upload.path)")
+	dryRun := flag.Bool("dry-run", false, "list what would be done without changing anything")
+	deleteLocal := flag.Bool("delete-local", false, "migrate: delete local files after they were copied")
+	grace := flag.Duration("grace", service.DefaultUploadGCGracePeriod, "gc: keep unreferenced files younger than this")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	switch *command {
+	case "migrate":
+		dir := *source
+		if dir == "" {
+			dir = cfg.Upload.Path
+		}
+		store, err := storage.New(&cfg.Upload, routes.UploadURLPrefix)
+		if err != nil {
+			log.Fatalf("Failed to configure upload storage: %v", err)
+		}
+		if storage.IsLocal(store) {
+			log.Fatal("upload.storage.driver is local; configure the target backend before migrating")
+		}
+		if err := migrateLocalFiles(context.Background(), dir, store, *dryRun, *deleteLocal); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+	case "gc":
+		if err := collectGarbage(cfg, *grace, *dryRun); err != nil {
+			log.Fatalf("Garbage collection failed: %v", err)
+		}
+
+	default:
+		fmt.Println("Available commands: migrate, gc")
+		os.Exit(1)
+	}
+}
+
+// collectGarbage deletes stored files no record references and reports the
+// space reclaimed; with dryRun it only lists them.
+func collectGarbage(cfg *config.Config, grace time.Duration, dryRun bool) error {
+	db, err := database.NewConnection(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	store, err := storage.New(&cfg.Upload, routes.UploadURLPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to configure upload storage: %w", err)
+	}
+	uploads := service.NewUploadService(&cfg.Upload, store, routes.UploadURLPrefix)
+	gc := service.NewUploadGCService(uploads, repository.NewUploadRepository(db))
+
+	report, err := gc.Run(context.Background(), service.UploadGCOptions{GracePeriod: grace, DryRun: dryRun})
+	if err != nil {
+		return err
+	}
+
+	verb, reclaimed := "deleted", "Reclaimed"
+	if dryRun {
+		verb, reclaimed = "would delete", "Would reclaim"
+	}
+	for _, obj := range report.Deleted {
+		fmt.Printf("%s %s (%s, modified %s)\n", verb, obj.Key, service.FormatBytes(obj.Size), obj.ModTime.Format(time.RFC3339))
+	}
+	fmt.Printf("Scanned %d files: %d referenced, %d within grace period, %d %s, %d failed\n",
+		report.Scanned, report.Referenced, report.Recent, len(report.Deleted), verb, report.Failed)
+	fmt.Printf("%s %s\n", reclaimed, service.FormatBytes(report.BytesReclaimed))
+	if report.Failed > 0 {
+		return fmt.Errorf("%d files could not be deleted", report.Failed)
+	}
+	return nil
+}
+
+// migrateLocalFiles copies every file under dir into store, keyed by its path
+// relative to dir. Stored URLs keep working because they only contain the key.
+func migrateLocalFiles(ctx context.Context, dir string, store storage.Storage, dryRun, deleteLocal bool) error {
+	copied, failed := 0, 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key, err := storage.CleanKey(filepath.ToSlash(rel))
+		if err != nil {
+			log.Printf("Skipping %s: %v", p, err)
+			return nil
+		}
+
+		if dryRun {
+			fmt.Printf("would copy %s -> %s\n", p, key)
+			copied++
+			return nil
+		}
+
+		if err := copyFile(ctx, store, p, key); err != nil {
+			log.Printf("Failed to copy %s: %v", p, err)
+			failed++
+			return nil
+		}
+		copied++
+		fmt.Printf("copied %s -> %s\n", p, key)
+
+		if deleteLocal {
+			if err := os.Remove(p); err != nil {
+				log.Printf("Warning: failed to delete %s: %v", p, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migration completed: %d copied, %d failed\n", copied, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be copied", failed)
+	}
+	return nil
+}
+
+func copyFile(ctx context.Context, store storage.Storage, p, key string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, key, f, info.Size(), mime.TypeByExtension(filepath.Ext(p)))
+}
@@ -385,6 +385,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "product_unavailable: outside the product's serving hours",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -514,6 +520,251 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/cart/lines/{item_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update quantity for a single cart line, e.g. a product with a specific set of modifiers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Update cart line quantity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update cart item request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCartItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a single cart line from current user cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Remove cart line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/categories": {
+            "get": {
+                "description": "Public API list active categories ordered by sort order, with their count of active products",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "List categories",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of top-rated products to include per category (0-12)",
+                        "name": "preview",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales; the default locale is used when none is supported",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryBrowseListResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/categories/{slug}": {
+            "get": {
+                "description": "Public API get an active category by slug, with its count of active products",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get category detail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of top-rated products to include (0-12)",
+                        "name": "preview",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales; the default locale is used when none is supported",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryBrowseResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/orders": {
             "get": {
                 "security": [
@@ -561,6 +812,26 @@ const docTemplate = `{
                         "description": "To date (YYYY-MM-DD)",
                         "name": "to_date",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "created_at|total_amount",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "asc|desc",
+                        "name": "sort_dir",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous response; skips the total count and ignores page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -643,6 +914,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "product_unavailable: a cart item is outside its serving hours",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -712,7 +989,7 @@ const docTemplate = `{
         },
         "/api/v1/products": {
             "get": {
-                "description": "Public API list products with filter, sort, search and pagination",
+                "description": "Public API list products with filter, sort, search and pagination, optionally with facet counts",
                 "produces": [
                     "application/json"
                 ],
@@ -747,15 +1024,21 @@ const docTemplate = `{
                         "name": "category_id",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Category slug",
+                        "name": "category_slug",
+                        "in": "query"
+                    },
                     {
                         "type": "number",
-                        "description": "Min price",
+                        "description": "Min regular price (original_price when on sale, otherwise price)",
                         "name": "min_price",
                         "in": "query"
                     },
                     {
                         "type": "number",
-                        "description": "Max price",
+                        "description": "Max regular price (original_price when on sale, otherwise price)",
                         "name": "max_price",
                         "in": "query"
                     },
@@ -767,14 +1050,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Full-text search",
+                        "description": "Accent-insensitive search on name and description",
                         "name": "search",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "default": "created_at",
-                        "description": "price|rating_average|name|created_at",
+                        "description": "First letter of the name, accents ignored; # for names not starting with a-z",
+                        "name": "starts_with",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "relevance|price|rating_average|name|created_at (relevance when searching, otherwise created_at); price sorts by the regular price",
                         "name": "sort_by",
                         "in": "query"
                     },
@@ -784,28 +1072,79 @@ const docTemplate = `{
                         "description": "asc|desc",
                         "name": "sort_dir",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/dto.PaginatedResponse"
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/v1/products/{slug}": {
-            "get": {
-                "description": "Public API get product detail by slug",
-                "produces": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated allergens to leave out (peanut, tree-nut, milk, egg, soy, gluten, fish, shellfish, sesame)",
+                        "name": "exclude_allergens",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated dietary tags the products must all have, e.g. vegan",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum kcal per serving; products without nutrition info are left out",
+                        "name": "max_calories",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include per-filter counts (each facet ignores its own filter)",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous response; skips the total count and ignores page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales; the default locale is used when none is supported",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProductListResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{slug}": {
+            "get": {
+                "description": "Public API get product detail by slug",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
@@ -819,6 +1158,24 @@ const docTemplate = `{
                         "name": "slug",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales; the default locale is used when none is supported",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -828,11 +1185,58 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ProductResponse"
                         }
                     },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{slug}/modifiers": {
+            "get": {
+                "description": "Public API list the add-on groups and options available for a product",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "List product modifiers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ModifierGroupResponse"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -868,13 +1272,25 @@ const docTemplate = `{
                         "description": "Page size",
                         "name": "page_size",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous response; skips the total count and ignores page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "newest (default), helpful or with_photos",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.PaginatedResponse"
+                            "$ref": "#/definitions/dto.RatingListResponse"
                         }
                     },
                     "400": {
@@ -1040,29 +1456,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/profile": {
-            "get": {
+        "/api/v1/products/{slug}/ratings/photos": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the profile of the currently authenticated user",
+                "description": "Attach images to the authenticated user's rating of a product, up to the configured number per rating",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "ratings"
+                ],
+                "summary": "Add photos to own rating",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image files",
+                        "name": "photos",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "Get current user profile",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UserResponse"
+                            "$ref": "#/definitions/dto.RatingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1071,6 +1509,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1078,40 +1522,44 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/products/{slug}/ratings/photos/{photoId}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the profile of the currently authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete one photo of the authenticated user's rating of a product",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "ratings"
                 ],
-                "summary": "Update current user profile",
+                "summary": "Remove a photo from own rating",
                 "parameters": [
                     {
-                        "description": "Update profile request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateProfileRequest"
-                        }
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Photo ID",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UserResponse"
+                            "$ref": "#/definitions/dto.RatingResponse"
                         }
                     },
                     "400": {
@@ -1126,6 +1574,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1135,38 +1589,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/profile/avatar": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Upload an avatar image for the currently authenticated user",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/api/v1/products/{slug}/related": {
+            "get": {
+                "description": "Products frequently bought together with this one (from delivered orders, refreshed nightly), topped up with the best rated products of the same category and then the same classify",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "recommendations"
                 ],
-                "summary": "Upload user avatar",
+                "summary": "Related products",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Avatar image file",
-                        "name": "avatar",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Max products (1-24)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.AvatarResponse"
+                            "$ref": "#/definitions/dto.RecommendationResponse"
                         }
                     },
                     "400": {
@@ -1175,8 +1634,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -1188,35 +1647,31 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/profile": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove the avatar of the currently authenticated user",
+                "description": "Get the profile of the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "profile"
                 ],
-                "summary": "Delete user avatar",
+                "summary": "Get current user profile",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
+                            "$ref": "#/definitions/dto.UserResponse"
                         }
                     },
                     "401": {
@@ -1225,12 +1680,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/dto.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1238,16 +1687,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/suggestions": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Authenticated user creates a new food/drink suggestion",
+                "description": "Update the profile of the currently authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1255,25 +1702,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "suggestions"
+                    "profile"
                 ],
-                "summary": "Create product suggestion",
+                "summary": "Update current user profile",
                 "parameters": [
                     {
-                        "description": "Create suggestion request",
+                        "description": "Update profile request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateSuggestionRequest"
+                            "$ref": "#/definitions/dto.UpdateProfileRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.SuggestionResponse"
+                            "$ref": "#/definitions/dto.UserResponse"
                         }
                     },
                     "400": {
@@ -1297,16 +1744,74 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Check if the server is running",
+        "/api/v1/profile/avatar": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload an avatar image for the currently authenticated user",
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "profile"
                 ],
-                "summary": "Health check endpoint",
+                "summary": "Upload user avatar",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Avatar image file",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AvatarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the avatar of the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Delete user avatar",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1316,286 +1821,1488 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/ratings/{id}/helpful": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark another user's rating as helpful. Each user has one vote per rating; voting again changes nothing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Vote a rating helpful",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rating ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RatingVoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the authenticated user's helpful vote from a rating",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Withdraw a helpful vote",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rating ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RatingVoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/ratings/{id}/report": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report another user's rating for moderation. Each user can report a rating once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Report a rating",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rating ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReportRatingRequest"
+                        }
                     }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RatingReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/recommendations/for-you": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Products bought together with what the current user ordered before, then the best rated of their favourite categories, then recent best sellers. Products already ordered are left out; users without orders get best sellers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Personal recommendations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Max products (1-24)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions (vi, en); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RecommendationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/search/suggest": {
+            "get": {
+                "description": "Search-as-you-type suggestions mixing product names, categories and popular searches. Accent-insensitive; every word of q must be the start of a word in the suggestion. Rate limited per IP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search suggestions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text typed so far",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Max suggestions (1-20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchSuggestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/suggestions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Authenticated user creates a new food/drink suggestion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suggestions"
+                ],
+                "summary": "Create product suggestion",
+                "parameters": [
+                    {
+                        "description": "Create suggestion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateSuggestionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SuggestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the server is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Counters of the in-process response cache for public catalog endpoints. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Runtime metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MetricsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{slug}": {
+            "get": {
+                "description": "HTML page with Open Graph and Twitter card tags for link previews. Browsers are sent on to the frontend product page.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "share"
+                ],
+                "summary": "Product share page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Content locale, e.g. vi or en",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the frontend when the product is not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/robots.txt": {
+            "get": {
+                "description": "Lets crawlers read the share pages but not the API or admin, and points them at the sitemap",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "share"
+                ],
+                "summary": "robots.txt",
+                "responses": {
+                    "200": {
+                        "description": "robots.txt",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemap.xml": {
+            "get": {
+                "description": "Frontend pages of the products that are not inactive, for search engines",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "share"
+                ],
+                "summary": "Sitemap",
+                "responses": {
+                    "200": {
+                        "description": "sitemap.xml",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AddCartItemRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "quantity"
+            ],
+            "properties": {
+                "modifier_option_ids": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "dto.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/dto.UserResponse"
+                }
+            }
+        },
+        "dto.AvatarResponse": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.BundleComponentResponse": {
+            "type": "object",
+            "properties": {
+                "allergens": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CartItemModifierResponse": {
+            "type": "object",
+            "properties": {
+                "group_name": {
+                    "type": "string"
+                },
+                "option_id": {
+                    "type": "integer"
+                },
+                "option_name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.CartItemResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CartItemModifierResponse"
+                    }
+                },
+                "modifiers_price": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "original_price": {
+                    "description": "OriginalPrice is the regular price while the product is on sale",
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.CartResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CartItemResponse"
+                    }
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CategoryBreadcrumb": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CategoryBrowseListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryBrowseResponse"
+                    }
+                }
+            }
+        },
+        "dto.CategoryBrowseResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "product_count": {
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ProductResponse"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CategoryFacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateOrderRequest": {
+            "type": "object",
+            "required": [
+                "shipping_address",
+                "shipping_phone"
+            ],
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "shipping_address": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "shipping_phone": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "minLength": 8
+                }
+            }
+        },
+        "dto.CreateRatingRequest": {
+            "type": "object",
+            "required": [
+                "rating"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "dto.CreateSuggestionRequest": {
+            "type": "object",
+            "required": [
+                "classify",
+                "name"
+            ],
+            "properties": {
+                "allergens": {
+                    "description": "Allergens are keys of the fixed allergen list; DietaryTags are free-form, e.g. vegan",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "classify": {
+                    "type": "string",
+                    "enum": [
+                        "food",
+                        "drink"
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "dietary_tags": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2
+                }
+            }
+        },
+        "dto.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MetricsResponse": {
+            "type": "object",
+            "properties": {
+                "response_cache": {
+                    "$ref": "#/definitions/dto.ResponseCacheMetrics"
+                }
+            }
+        },
+        "dto.ModifierGroupResponse": {
+            "type": "object",
+            "properties": {
+                "category_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_select": {
+                    "type": "integer"
+                },
+                "min_select": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ModifierOptionResponse"
+                    }
+                },
+                "product_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ModifierOptionResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.NutritionFacts": {
+            "type": "object",
+            "properties": {
+                "caffeine_mg": {
+                    "type": "integer"
+                },
+                "calories": {
+                    "type": "integer"
+                },
+                "sugar_grams": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.OAuthProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.OAuthURLResponse": {
+            "type": "object",
+            "properties": {
+                "provider": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderItemComponentResponse": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.OrderItemModifierResponse": {
+            "type": "object",
+            "properties": {
+                "group_name": {
+                    "type": "string"
+                },
+                "option_id": {
+                    "type": "integer"
+                },
+                "option_name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.OrderItemResponse": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderItemComponentResponse"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderItemModifierResponse"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "product_price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderItemResponse"
+                    }
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "shipping_address": {
+                    "type": "string"
+                },
+                "shipping_phone": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_email": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.AddCartItemRequest": {
+        },
+        "dto.PaginatedResponse": {
             "type": "object",
-            "required": [
-                "product_id",
-                "quantity"
-            ],
             "properties": {
-                "product_id": {
+                "items": {},
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
                     "type": "integer"
                 },
-                "quantity": {
-                    "type": "integer",
-                    "minimum": 1
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.AuthResponse": {
+        "dto.PriceRangeFacetCount": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
-                },
-                "expires_in": {
+                "count": {
                     "type": "integer"
                 },
-                "token_type": {
-                    "type": "string"
+                "max": {
+                    "type": "number"
                 },
-                "user": {
-                    "$ref": "#/definitions/dto.UserResponse"
+                "min": {
+                    "type": "number"
                 }
             }
         },
-        "dto.AvatarResponse": {
+        "dto.ProductFacets": {
             "type": "object",
             "properties": {
-                "avatar_url": {
-                    "type": "string"
+                "category": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryFacetCount"
+                    }
+                },
+                "classify": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetCount"
+                    }
+                },
+                "price": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PriceRangeFacetCount"
+                    }
+                },
+                "rating": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RatingFacetCount"
+                    }
+                },
+                "starts_with": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FacetCount"
+                    }
                 }
             }
         },
-        "dto.CartItemResponse": {
+        "dto.ProductImageResponse": {
             "type": "object",
             "properties": {
+                "alt_text": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "image_url": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "number"
+                "is_primary": {
+                    "type": "boolean"
                 },
-                "product_id": {
-                    "type": "integer"
+                "medium_url": {
+                    "type": "string"
                 },
-                "quantity": {
+                "sort_order": {
                     "type": "integer"
                 },
-                "subtotal": {
-                    "type": "number"
+                "thumbnail_url": {
+                    "type": "string"
                 }
             }
         },
-        "dto.CartResponse": {
+        "dto.ProductListResponse": {
             "type": "object",
             "properties": {
-                "id": {
+                "facets": {
+                    "$ref": "#/definitions/dto.ProductFacets"
+                },
+                "items": {},
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
                     "type": "integer"
                 },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.CartItemResponse"
-                    }
+                "page_size": {
+                    "type": "integer"
                 },
-                "total_amount": {
-                    "type": "number"
+                "total": {
+                    "type": "integer"
                 },
-                "total_items": {
+                "total_pages": {
                     "type": "integer"
                 }
             }
         },
-        "dto.CreateOrderRequest": {
+        "dto.ProductResponse": {
             "type": "object",
-            "required": [
-                "shipping_address",
-                "shipping_phone"
-            ],
             "properties": {
-                "notes": {
-                    "type": "string",
-                    "maxLength": 5000
+                "allergens": {
+                    "description": "Allergens are keys of the fixed allergen list; a bundle also has those\nof its components. DietaryTags are free-form.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "shipping_address": {
-                    "type": "string",
-                    "maxLength": 2000
+                "available_now": {
+                    "description": "AvailableNow is false outside the product's serving hours",
+                    "type": "boolean"
                 },
-                "shipping_phone": {
-                    "type": "string",
-                    "maxLength": 20,
-                    "minLength": 8
-                }
-            }
-        },
-        "dto.CreateRatingRequest": {
-            "type": "object",
-            "required": [
-                "rating"
-            ],
-            "properties": {
-                "comment": {
-                    "type": "string",
-                    "maxLength": 2000
+                "breadcrumbs": {
+                    "description": "Breadcrumbs is the category path of the product, top level first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryBreadcrumb"
+                    }
                 },
-                "rating": {
-                    "type": "integer",
-                    "maximum": 5,
-                    "minimum": 1
-                }
-            }
-        },
-        "dto.CreateSuggestionRequest": {
-            "type": "object",
-            "required": [
-                "classify",
-                "name"
-            ],
-            "properties": {
                 "category_id": {
                     "type": "integer"
                 },
+                "category_name": {
+                    "type": "string"
+                },
                 "classify": {
-                    "type": "string",
-                    "enum": [
-                        "food",
-                        "drink"
-                    ]
+                    "type": "string"
+                },
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BundleComponentResponse"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
                 },
                 "description": {
-                    "type": "string",
-                    "maxLength": 5000
+                    "type": "string"
                 },
-                "name": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 2
-                }
-            }
-        },
-        "dto.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "details": {
-                    "type": "object",
-                    "additionalProperties": {
+                "dietary_tags": {
+                    "type": "array",
+                    "items": {
                         "type": "string"
                     }
                 },
-                "error": {
+                "id": {
+                    "type": "integer"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ProductImageResponse"
+                    }
+                },
+                "is_bundle": {
+                    "description": "IsBundle marks a combo; its stock is the number of complete bundles the components allow",
+                    "type": "boolean"
+                },
+                "low_stock_threshold": {
+                    "description": "LowStockThreshold is the stock level at or below which admins are alerted",
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "message": {
+                "nutrition": {
+                    "description": "Nutrition is per serving, omitted when nothing is known",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.NutritionFacts"
+                        }
+                    ]
+                },
+                "original_price": {
+                    "description": "OriginalPrice is the regular price while a sale lowers Price, and\nSaleEndsAt when that sale stops",
+                    "type": "number"
+                },
+                "price": {
+                    "description": "Price is what the product sells for now, sale included",
+                    "type": "number"
+                },
+                "primary_image": {
+                    "$ref": "#/definitions/dto.ProductImageResponse"
+                },
+                "rating_average": {
+                    "type": "number"
+                },
+                "rating_count": {
+                    "type": "integer"
+                },
+                "sale_ends_at": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "social_share": {
+                    "$ref": "#/definitions/dto.ProductSocialShareResponse"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "dto.LoginRequest": {
+        "dto.ProductSocialShareResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "facebook": {
                     "type": "string"
                 },
-                "password": {
+                "twitter": {
                     "type": "string"
                 }
             }
         },
-        "dto.OAuthProvidersResponse": {
+        "dto.RatingFacetCount": {
             "type": "object",
             "properties": {
-                "providers": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "count": {
+                    "type": "integer"
+                },
+                "min_rating": {
+                    "type": "number"
                 }
             }
         },
-        "dto.OAuthURLResponse": {
+        "dto.RatingListResponse": {
             "type": "object",
             "properties": {
-                "provider": {
+                "items": {},
+                "next_cursor": {
                     "type": "string"
                 },
-                "state": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "url": {
-                    "type": "string"
+                "page_size": {
+                    "type": "integer"
+                },
+                "summary": {
+                    "$ref": "#/definitions/dto.RatingSummary"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.OrderItemResponse": {
+        "dto.RatingPhotoResponse": {
             "type": "object",
             "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "product_id": {
-                    "type": "integer"
+                "image_url": {
+                    "type": "string"
                 },
-                "product_name": {
+                "medium_url": {
                     "type": "string"
                 },
-                "product_price": {
-                    "type": "number"
+                "thumbnail_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RatingReplyResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
                 },
-                "quantity": {
+                "replied_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RatingReportResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "rating_id": {
                     "type": "integer"
                 },
-                "subtotal": {
-                    "type": "number"
+                "reason": {
+                    "type": "string"
                 }
             }
         },
-        "dto.OrderResponse": {
+        "dto.RatingResponse": {
             "type": "object",
             "properties": {
+                "comment": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "helpful_count": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "item_count": {
+                "order_id": {
                     "type": "integer"
                 },
-                "items": {
+                "photos": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.OrderItemResponse"
+                        "$ref": "#/definitions/dto.RatingPhotoResponse"
                     }
                 },
-                "notes": {
-                    "type": "string"
-                },
-                "order_number": {
-                    "type": "string"
-                },
-                "shipping_address": {
-                    "type": "string"
-                },
-                "shipping_phone": {
-                    "type": "string"
+                "product_id": {
+                    "type": "integer"
                 },
-                "status": {
-                    "type": "string"
+                "rating": {
+                    "type": "integer"
                 },
-                "total_amount": {
-                    "type": "number"
+                "shop_reply": {
+                    "$ref": "#/definitions/dto.RatingReplyResponse"
                 },
                 "updated_at": {
                     "type": "string"
                 },
-                "user_email": {
+                "user_avatar": {
                     "type": "string"
                 },
                 "user_id": {
@@ -1606,47 +3313,80 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.PaginatedResponse": {
+        "dto.RatingStarCount": {
             "type": "object",
             "properties": {
-                "items": {},
-                "page": {
+                "count": {
                     "type": "integer"
                 },
-                "page_size": {
+                "star": {
                     "type": "integer"
+                }
+            }
+        },
+        "dto.RatingSummary": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
                 },
-                "total": {
+                "count": {
                     "type": "integer"
                 },
-                "total_pages": {
-                    "type": "integer"
+                "distribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RatingStarCount"
+                    }
                 }
             }
         },
-        "dto.ProductImageResponse": {
+        "dto.RatingVoteResponse": {
             "type": "object",
             "properties": {
-                "alt_text": {
-                    "type": "string"
-                },
-                "id": {
+                "helpful_count": {
                     "type": "integer"
                 },
-                "image_url": {
-                    "type": "string"
+                "rating_id": {
+                    "type": "integer"
                 },
-                "is_primary": {
+                "voted": {
                     "type": "boolean"
-                },
-                "sort_order": {
-                    "type": "integer"
                 }
             }
         },
-        "dto.ProductResponse": {
+        "dto.RecommendationResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RecommendedProduct"
+                    }
+                }
+            }
+        },
+        "dto.RecommendedProduct": {
             "type": "object",
             "properties": {
+                "allergens": {
+                    "description": "Allergens are keys of the fixed allergen list; a bundle also has those\nof its components. DietaryTags are free-form.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "available_now": {
+                    "description": "AvailableNow is false outside the product's serving hours",
+                    "type": "boolean"
+                },
+                "breadcrumbs": {
+                    "description": "Breadcrumbs is the category path of the product, top level first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryBreadcrumb"
+                    }
+                },
                 "category_id": {
                     "type": "integer"
                 },
@@ -1656,12 +3396,24 @@ const docTemplate = `{
                 "classify": {
                     "type": "string"
                 },
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BundleComponentResponse"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "dietary_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1671,10 +3423,31 @@ const docTemplate = `{
                         "$ref": "#/definitions/dto.ProductImageResponse"
                     }
                 },
+                "is_bundle": {
+                    "description": "IsBundle marks a combo; its stock is the number of complete bundles the components allow",
+                    "type": "boolean"
+                },
+                "low_stock_threshold": {
+                    "description": "LowStockThreshold is the stock level at or below which admins are alerted",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "nutrition": {
+                    "description": "Nutrition is per serving, omitted when nothing is known",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.NutritionFacts"
+                        }
+                    ]
+                },
+                "original_price": {
+                    "description": "OriginalPrice is the regular price while a sale lowers Price, and\nSaleEndsAt when that sale stops",
+                    "type": "number"
+                },
                 "price": {
+                    "description": "Price is what the product sells for now, sale included",
                     "type": "number"
                 },
                 "primary_image": {
@@ -1686,6 +3459,12 @@ const docTemplate = `{
                 "rating_count": {
                     "type": "integer"
                 },
+                "reason": {
+                    "type": "string"
+                },
+                "sale_ends_at": {
+                    "type": "string"
+                },
                 "slug": {
                     "type": "string"
                 },
@@ -1703,74 +3482,109 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.ProductSocialShareResponse": {
+        "dto.RegisterRequest": {
             "type": "object",
+            "required": [
+                "email",
+                "full_name",
+                "password"
+            ],
             "properties": {
-                "facebook": {
-                    "type": "string"
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
                 },
-                "twitter": {
-                    "type": "string"
+                "full_name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2
+                },
+                "password": {
+                    "description": "Password must be 8-72 characters. Max 72 is bcrypt's limit.\nMust contain at least one uppercase, one lowercase, one digit, and one special character.",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
                 }
             }
         },
-        "dto.RatingResponse": {
+        "dto.ReportRatingRequest": {
             "type": "object",
+            "required": [
+                "reason"
+            ],
             "properties": {
-                "comment": {
-                    "type": "string"
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
                 },
-                "created_at": {
-                    "type": "string"
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "spam",
+                        "offensive",
+                        "off_topic",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "dto.ResponseCacheMetrics": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
                 },
-                "id": {
+                "entries": {
                     "type": "integer"
                 },
-                "order_id": {
+                "evictions": {
                     "type": "integer"
                 },
-                "product_id": {
-                    "type": "integer"
+                "hit_ratio": {
+                    "type": "number"
                 },
-                "rating": {
+                "hits": {
                     "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
-                "user_avatar": {
-                    "type": "string"
-                },
-                "user_id": {
+                "invalidations": {
                     "type": "integer"
                 },
-                "user_name": {
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SearchSuggestResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
                     "type": "string"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SearchSuggestion"
+                    }
                 }
             }
         },
-        "dto.RegisterRequest": {
+        "dto.SearchSuggestion": {
             "type": "object",
-            "required": [
-                "email",
-                "full_name",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "id": {
+                    "type": "integer"
+                },
+                "slug": {
                     "type": "string",
-                    "maxLength": 255
+                    "example": "tra-sua-tran-chau"
                 },
-                "full_name": {
+                "text": {
                     "type": "string",
-                    "maxLength": 255,
-                    "minLength": 2
+                    "example": "Trà sữa trân châu"
                 },
-                "password": {
-                    "description": "Password must be 8-72 characters. Max 72 is bcrypt's limit.\nMust contain at least one uppercase, one lowercase, one digit, and one special character.",
+                "type": {
                     "type": "string",
-                    "maxLength": 72,
-                    "minLength": 8
+                    "example": "product"
                 }
             }
         },
@@ -1780,6 +3594,12 @@ const docTemplate = `{
                 "admin_note": {
                     "type": "string"
                 },
+                "allergens": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "category_id": {
                     "type": "integer"
                 },
@@ -1795,6 +3615,12 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "dietary_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
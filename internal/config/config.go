@@ -11,14 +11,22 @@ import (
 )
 
 type Config struct {
-	App       AppConfig       `mapstructure:"app"`
-	Database  DatabaseConfig  `mapstructure:"database"`
-	JWT       JWTConfig       `mapstructure:"jwt"`
-	OAuth     OAuthConfig     `mapstructure:"oauth"`
-	Upload    UploadConfig    `mapstructure:"upload"`
-	Email     EmailConfig     `mapstructure:"email"`
-	Chatwork  ChatworkConfig  `mapstructure:"chatwork"`
-	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	App          AppConfig          `mapstructure:"app"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	JWT          JWTConfig          `mapstructure:"jwt"`
+	OAuth        OAuthConfig        `mapstructure:"oauth"`
+	Upload       UploadConfig       `mapstructure:"upload"`
+	Search       SearchConfig       `mapstructure:"search"`
+	HTTPCache    HTTPCacheConfig    `mapstructure:"http_cache"`
+	Recommend    RecommendConfig    `mapstructure:"recommendation"`
+	Availability AvailabilityConfig `mapstructure:"availability"`
+	Inventory    InventoryConfig    `mapstructure:"inventory"`
+	Catalog      CatalogConfig      `mapstructure:"catalog"`
+	Rating       RatingConfig       `mapstructure:"rating"`
+	I18n         I18nConfig         `mapstructure:"i18n"`
+	Email        EmailConfig        `mapstructure:"email"`
+	Chatwork     ChatworkConfig     `mapstructure:"chatwork"`
+	Scheduler    SchedulerConfig    `mapstructure:"scheduler"`
 }
 
 type EmailConfig struct {
@@ -58,10 +66,110 @@ type SchedulerConfig struct {
 	ReportTemplatePath string `mapstructure:"report_template_path"`
 }
 
+// SearchConfig throttles the search-as-you-type endpoint per client IP
+type SearchConfig struct {
+	SuggestRateLimit  int           `mapstructure:"suggest_rate_limit"`
+	SuggestRateWindow time.Duration `mapstructure:"suggest_rate_window"`
+}
+
+// HTTPCacheConfig controls the in-process cache of public catalog responses.
+// TTL bounds how long changes made outside the product and category services
+// (stock, ratings, direct database edits) can go unseen; MaxAge is the
+// Cache-Control max-age sent to clients.
+type HTTPCacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	TTL        time.Duration `mapstructure:"ttl"`
+	MaxEntries int           `mapstructure:"max_entries"`
+	MaxAge     time.Duration `mapstructure:"max_age"`
+}
+
+// RecommendConfig schedules the nightly rebuild of "bought together" scores
+// from delivered orders placed within Lookback
+type RecommendConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Cron          string        `mapstructure:"cron"`
+	Lookback      time.Duration `mapstructure:"lookback"`
+	MinSupport    int           `mapstructure:"min_support"`
+	MaxPerProduct int           `mapstructure:"max_per_product"`
+}
+
+// AvailabilityConfig sets the timezone product serving hours are read in,
+// Asia/Ho_Chi_Minh when empty
+type AvailabilityConfig struct {
+	Timezone string `mapstructure:"timezone"`
+}
+
+// InventoryConfig schedules the daily digest of products at or below their
+// low-stock threshold, sent through the email and Chatwork channels
+type InventoryConfig struct {
+	DigestEnabled bool   `mapstructure:"low_stock_digest_enabled"`
+	DigestCron    string `mapstructure:"low_stock_digest_cron"`
+}
+
+// CatalogConfig tunes the admin product editor and imports. A price edit or
+// import row moving the price by more than PriceChangeConfirmPercent must be
+// confirmed, 50 when unset.
+type CatalogConfig struct {
+	PriceChangeConfirmPercent float64 `mapstructure:"price_change_confirm_percent"`
+}
+
+// RatingConfig limits customer reviews. MaxPhotos is the number of images a
+// rating may carry, 5 when unset. A review whose comment contains one of
+// BannedWords (whole words, case-insensitive) is flagged for moderation.
+type RatingConfig struct {
+	MaxPhotos   int      `mapstructure:"max_photos"`
+	BannedWords []string `mapstructure:"banned_words"`
+}
+
+// I18nConfig lists the locales product and category content is offered in.
+// DefaultLocale is the language of the records themselves, "vi" when empty;
+// Locales defaults to vi and en.
+type I18nConfig struct {
+	DefaultLocale string   `mapstructure:"default_locale"`
+	Locales       []string `mapstructure:"locales"`
+}
+
 type UploadConfig struct {
-	Path         string   `mapstructure:"path"`
-	MaxSize      int64    `mapstructure:"max_size"`
-	AllowedTypes []string `mapstructure:"allowed_types"`
+	Path         string         `mapstructure:"path"`
+	MaxSize      int64          `mapstructure:"max_size"`
+	AllowedTypes []string       `mapstructure:"allowed_types"`
+	Storage      StorageConfig  `mapstructure:"storage"`
+	GC           UploadGCConfig `mapstructure:"gc"`
+}
+
+// UploadGCConfig schedules removal of uploaded files no record points at
+type UploadGCConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Cron    string `mapstructure:"cron"`
+	// GracePeriod protects recent files whose record may not be saved yet
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+	DryRun      bool          `mapstructure:"dry_run"`
+}
+
+// StorageConfig selects the backend uploaded files are written to.
+// Driver is "local" (default, files under upload.path) or "s3".
+type StorageConfig struct {
+	Driver string   `mapstructure:"driver"`
+	S3     S3Config `mapstructure:"s3"`
+}
+
+// S3Config configures an S3-compatible object store (AWS S3, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	UsePathStyle    bool   `mapstructure:"use_path_style"`
+	// PublicBaseURL is used for unsigned URLs, e.g. a CDN in front of the bucket.
+	// Defaults to the endpoint/bucket URL.
+	PublicBaseURL string `mapstructure:"public_base_url"`
+	// SignedURLs serves every file through short-lived presigned URLs so the
+	// bucket does not have to allow public reads. Private files are always
+	// signed, and only for their owner or an admin.
+	SignedURLs      bool          `mapstructure:"signed_urls"`
+	SignedURLExpiry time.Duration `mapstructure:"signed_url_expiry"`
+	TimeoutSeconds  int           `mapstructure:"timeout_seconds"`
 }
 
 type AppConfig struct {
@@ -69,6 +177,14 @@ type AppConfig struct {
 	Env     string `mapstructure:"env"`
 	Port    int    `mapstructure:"port"`
 	BaseURL string `mapstructure:"base_url"`
+	// APIURL is the public address of this server. When set, the share
+	// pages, sitemap.xml and robots.txt are served with URLs built from it,
+	// and share links point at the /p/:slug pages.
+	APIURL string `mapstructure:"api_url"`
+	// TrustedProxies are the addresses or CIDRs of reverse proxies whose
+	// X-Forwarded-For header names the client. Empty trusts none, so the
+	// client is the connecting address and per-IP limits cannot be spoofed.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -145,6 +261,10 @@ func applySensitiveEnvOverrides(cfg *Config) {
 	if value := strings.TrimSpace(os.Getenv("JWT_SECRET")); value != "" {
 		cfg.JWT.Secret = value
 	}
+
+	if value := strings.TrimSpace(os.Getenv("S3_SECRET_ACCESS_KEY")); value != "" {
+		cfg.Upload.Storage.S3.SecretAccessKey = value
+	}
 }
 
 func (d *DatabaseConfig) DSN() string {
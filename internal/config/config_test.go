@@ -54,11 +54,13 @@ func TestApplySensitiveEnvOverrides(t *testing.T) {
 	cfg.Chatwork.APIToken = "old-chat"
 	cfg.Database.Password = "old-db"
 	cfg.JWT.Secret = "old-jwt"
+	cfg.Upload.Storage.S3.SecretAccessKey = "old-s3"
 
 	t.Setenv("EMAIL_PASSWORD", "new-email")
 	t.Setenv("CHATWORK_API_TOKEN", "new-chat")
 	t.Setenv("DATABASE_PASSWORD", "new-db")
 	t.Setenv("JWT_SECRET", "new-jwt")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "new-s3")
 
 	applySensitiveEnvOverrides(cfg)
 
@@ -74,6 +76,9 @@ func TestApplySensitiveEnvOverrides(t *testing.T) {
 	if cfg.JWT.Secret != "new-jwt" {
 		t.Fatalf("jwt secret = %s", cfg.JWT.Secret)
 	}
+	if cfg.Upload.Storage.S3.SecretAccessKey != "new-s3" {
+		t.Fatalf("s3 secret = %s", cfg.Upload.Storage.S3.SecretAccessKey)
+	}
 }
 
 func TestLoadConfig_WithEnvOverrides(t *testing.T) {
@@ -0,0 +1,25 @@
+package dto
+
+// AvailabilityWindowInput is one weekly row of the admin schedule editor.
+// Weekday follows time.Weekday (0 is Sunday); times are "HH:MM".
+type AvailabilityWindowInput struct {
+	Weekday   int
+	StartTime string
+	EndTime   string
+}
+
+// AvailabilityExceptionInput is one date row of the admin schedule editor.
+// Leaving both times empty closes the whole day.
+type AvailabilityExceptionInput struct {
+	Date      string
+	StartTime string
+	EndTime   string
+	Note      string
+}
+
+// AvailabilitySchedule is the schedule of a product or a category as shown
+// in and submitted from the admin editor
+type AvailabilitySchedule struct {
+	Windows    []AvailabilityWindowInput
+	Exceptions []AvailabilityExceptionInput
+}
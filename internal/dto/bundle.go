@@ -0,0 +1,7 @@
+package dto
+
+// BundleItemInput is one component row of the admin bundle builder
+type BundleItemInput struct {
+	ProductID uint
+	Quantity  int
+}
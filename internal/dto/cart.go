@@ -1,13 +1,25 @@
 package dto
 
+type CartItemModifierResponse struct {
+	OptionID   uint    `json:"option_id"`
+	GroupName  string  `json:"group_name"`
+	OptionName string  `json:"option_name"`
+	Price      float64 `json:"price"`
+}
+
 type CartItemResponse struct {
 	ID        uint    `json:"id"`
 	ProductID uint    `json:"product_id"`
 	Name      string  `json:"name"`
 	Price     float64 `json:"price"`
-	Quantity  int     `json:"quantity"`
-	Subtotal  float64 `json:"subtotal"`
-	ImageURL  string  `json:"image_url,omitempty"`
+	// OriginalPrice is the regular price while the product is on sale
+	OriginalPrice  float64                    `json:"original_price,omitempty"`
+	Modifiers      []CartItemModifierResponse `json:"modifiers,omitempty"`
+	ModifiersPrice float64                    `json:"modifiers_price"`
+	UnitPrice      float64                    `json:"unit_price"`
+	Quantity       int                        `json:"quantity"`
+	Subtotal       float64                    `json:"subtotal"`
+	ImageURL       string                     `json:"image_url,omitempty"`
 }
 
 type CartResponse struct {
@@ -18,8 +30,9 @@ type CartResponse struct {
 }
 
 type AddCartItemRequest struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	ProductID         uint   `json:"product_id" binding:"required"`
+	Quantity          int    `json:"quantity" binding:"required,min=1"`
+	ModifierOptionIDs []uint `json:"modifier_option_ids" binding:"omitempty,max=50,dive,min=1"`
 }
 
 type UpdateCartItemRequest struct {
@@ -4,35 +4,72 @@ import "time"
 
 // CreateCategoryRequest represents the request body for creating a category
 type CreateCategoryRequest struct {
+	// ParentID places the category under another one; nil or 0 means top level
+	ParentID    *uint   `json:"parent_id"`
 	Name        string  `json:"name" binding:"required,min=2,max=255"`
 	Slug        *string `json:"slug" binding:"omitempty,min=2,max=255"`
 	Description *string `json:"description" binding:"omitempty,max=2000"`
 	ImageURL    *string `json:"image_url" binding:"omitempty,url,max=500"`
 	SortOrder   *int    `json:"sort_order" binding:"omitempty,min=0"`
 	Status      *string `json:"status" binding:"omitempty,oneof=active inactive"`
+	// Translations holds the name and description in other locales, keyed by locale
+	Translations map[string]TranslationInput `json:"translations,omitempty"`
 }
 
 // UpdateCategoryRequest represents the request body for updating a category
 type UpdateCategoryRequest struct {
+	// ParentID moves the category; 0 moves it to the top level
+	ParentID    *uint   `json:"parent_id"`
 	Name        *string `json:"name" binding:"omitempty,min=2,max=255"`
 	Slug        *string `json:"slug" binding:"omitempty,min=2,max=255"`
 	Description *string `json:"description" binding:"omitempty,max=2000"`
 	ImageURL    *string `json:"image_url" binding:"omitempty,url,max=500"`
 	SortOrder   *int    `json:"sort_order" binding:"omitempty,min=0"`
 	Status      *string `json:"status" binding:"omitempty,oneof=active inactive"`
+	// Translations replaces the text of the locales it lists; nil keeps all
+	Translations map[string]TranslationInput `json:"translations,omitempty"`
 }
 
 // CategoryResponse represents a category in API responses
 type CategoryResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description *string   `json:"description,omitempty"`
-	ImageURL    *string   `json:"image_url,omitempty"`
-	SortOrder   int       `json:"sort_order"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint      `json:"id"`
+	ParentID     *uint     `json:"parent_id,omitempty"`
+	Name         string    `json:"name"`
+	Slug         string    `json:"slug"`
+	Description  *string   `json:"description,omitempty"`
+	ImageURL     *string   `json:"image_url,omitempty"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	SortOrder    int       `json:"sort_order"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CategoryTreeItem is a category in the depth-first listing of the tree
+type CategoryTreeItem struct {
+	CategoryResponse
+	Depth      int `json:"depth"`
+	ChildCount int `json:"child_count"`
+	// ProductCount counts the products directly in the category, any status
+	ProductCount int64 `json:"product_count"`
+}
+
+// CategoryBrowseRequest holds the query of the public category endpoints
+type CategoryBrowseRequest struct {
+	// Preview is how many top-rated products to include per category
+	Preview int `form:"preview" binding:"omitempty,min=0,max=12"`
+}
+
+// CategoryBrowseResponse is an active category on the public API
+type CategoryBrowseResponse struct {
+	CategoryResponse
+	ProductCount int64             `json:"product_count"`
+	Products     []ProductResponse `json:"products,omitempty"`
+}
+
+// CategoryBrowseListResponse lists the active categories in display order
+type CategoryBrowseListResponse struct {
+	Items []CategoryBrowseResponse `json:"items"`
 }
 
 // CategoryListRequest represents query parameters for listing categories
@@ -46,10 +83,14 @@ type CategoryListRequest struct {
 }
 
 // PaginatedResponse represents a paginated list response
+// PaginatedResponse is one page of a list. NextCursor is set while more rows
+// follow; passing it back as cursor fetches the next page without counting,
+// in which case Total, Page and TotalPages are left at zero.
 type PaginatedResponse struct {
 	Items      interface{} `json:"items"`
 	Total      int64       `json:"total"`
 	Page       int         `json:"page"`
 	PageSize   int         `json:"page_size"`
 	TotalPages int         `json:"total_pages"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type StockMovementResponse struct {
+	ID          uint      `json:"id"`
+	ProductID   uint      `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Delta       int       `json:"delta"`
+	Reason      string    `json:"reason"`
+	ReferenceID *uint     `json:"reference_id,omitempty"`
+	Note        *string   `json:"note,omitempty"`
+	CreatedBy   *uint     `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AdminStockMovementRequest is a delivery received or stock written off
+// from the admin inventory page
+type AdminStockMovementRequest struct {
+	ProductID uint   `form:"product_id" binding:"required"`
+	Quantity  int    `form:"quantity"   binding:"required,min=1"`
+	Note      string `form:"note"       binding:"omitempty,max=255"`
+}
+
+type AdminStockMovementListRequest struct {
+	Page      int
+	PageSize  int
+	ProductID uint
+	Reason    string
+}
+
+func (q AdminStockMovementListRequest) URLParams() string {
+	params := url.Values{}
+	if q.ProductID > 0 {
+		params.Set("product_id", strconv.FormatUint(uint64(q.ProductID), 10))
+	}
+	if q.Reason != "" {
+		params.Set("reason", q.Reason)
+	}
+	return params.Encode()
+}
+
+// StockLedgerMismatch is a product whose stock differs from its ledger sum
+type StockLedgerMismatch struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Stock     int    `json:"stock"`
+	Ledger    int    `json:"ledger"`
+}
+
+// LowStockProduct is a product at or below its low-stock threshold
+type LowStockProduct struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+	Status    string `json:"status"`
+}
+
+// LowStockAlert is sent to admins when products cross their threshold, or
+// as the daily digest of every product still below it
+type LowStockAlert struct {
+	Digest   bool              `json:"digest"`
+	Products []LowStockProduct `json:"products"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+// ResponseCacheMetrics are the counters of the public catalog response cache
+// since the server started
+type ResponseCacheMetrics struct {
+	Enabled       bool    `json:"enabled"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Entries       int     `json:"entries"`
+	Invalidations uint64  `json:"invalidations"`
+	Evictions     uint64  `json:"evictions"`
+}
+
+type MetricsResponse struct {
+	ResponseCache ResponseCacheMetrics `json:"response_cache"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// ModifierOptionResponse represents a selectable add-on in API responses
+type ModifierOptionResponse struct {
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	SortOrder int     `json:"sort_order"`
+	Status    string  `json:"status"`
+}
+
+// ModifierGroupResponse represents a modifier group with its selection rules
+type ModifierGroupResponse struct {
+	ID          uint                     `json:"id"`
+	Name        string                   `json:"name"`
+	MinSelect   int                      `json:"min_select"`
+	MaxSelect   int                      `json:"max_select"`
+	SortOrder   int                      `json:"sort_order"`
+	Status      string                   `json:"status"`
+	Options     []ModifierOptionResponse `json:"options"`
+	ProductIDs  []uint                   `json:"product_ids,omitempty"`
+	CategoryIDs []uint                   `json:"category_ids,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+// ModifierOptionInput is one option row submitted from the admin group form.
+// ID is zero for a new option.
+type ModifierOptionInput struct {
+	ID        uint
+	Name      string
+	Price     float64
+	SortOrder int
+}
+
+// SaveModifierGroupRequest holds the admin form data for creating or updating a group
+type SaveModifierGroupRequest struct {
+	Name        string
+	MinSelect   int
+	MaxSelect   int
+	SortOrder   int
+	Status      string
+	Options     []ModifierOptionInput
+	ProductIDs  []uint
+	CategoryIDs []uint
+}
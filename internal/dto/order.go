@@ -11,13 +11,31 @@ type CreateOrderRequest struct {
 	Notes           *string `json:"notes" binding:"omitempty,max=5000"`
 }
 
+type OrderItemModifierResponse struct {
+	OptionID   uint    `json:"option_id"`
+	GroupName  string  `json:"group_name"`
+	OptionName string  `json:"option_name"`
+	Price      float64 `json:"price"`
+}
+
+// OrderItemComponentResponse is a component of an ordered bundle. Quantity
+// covers the whole order line.
+type OrderItemComponentResponse struct {
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
 type OrderItemResponse struct {
-	ID           uint    `json:"id"`
-	ProductID    uint    `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	ProductPrice float64 `json:"product_price"`
-	Quantity     int     `json:"quantity"`
-	Subtotal     float64 `json:"subtotal"`
+	ID           uint                         `json:"id"`
+	ProductID    uint                         `json:"product_id"`
+	ProductName  string                       `json:"product_name"`
+	ProductPrice float64                      `json:"product_price"`
+	Modifiers    []OrderItemModifierResponse  `json:"modifiers,omitempty"`
+	Components   []OrderItemComponentResponse `json:"components,omitempty"`
+	UnitPrice    float64                      `json:"unit_price"`
+	Quantity     int                          `json:"quantity"`
+	Subtotal     float64                      `json:"subtotal"`
 }
 
 type OrderResponse struct {
@@ -43,6 +61,9 @@ type OrderListRequest struct {
 	Status   string `form:"status" binding:"omitempty,oneof=pending confirmed processing shipping delivered cancelled"`
 	FromDate string `form:"from_date" binding:"omitempty"`
 	ToDate   string `form:"to_date" binding:"omitempty"`
+	SortBy   string `form:"sort_by,default=created_at" binding:"omitempty,oneof=created_at total_amount"`
+	SortDir  string `form:"sort_dir,default=desc" binding:"omitempty,oneof=asc desc"`
+	Cursor   string `form:"cursor" binding:"omitempty,max=512"`
 }
 
 type AdminOrderListRequest struct {
@@ -26,9 +26,19 @@ type AdminOrderStatisticsSummary struct {
 	CancelledCount int64   `json:"cancelled_count"`
 }
 
+// AdminProductSales is one row of the best sellers table. Bundle sales are
+// credited to their components.
+type AdminProductSales struct {
+	ProductID     uint    `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	Quantity      int64   `json:"quantity"`
+	RevenueAmount float64 `json:"revenue_amount"`
+}
+
 type AdminOrderStatisticsResponse struct {
-	Summary AdminOrderStatisticsSummary `json:"summary"`
-	Series  []AdminOrderStatisticsPoint `json:"series"`
+	Summary     AdminOrderStatisticsSummary `json:"summary"`
+	Series      []AdminOrderStatisticsPoint `json:"series"`
+	TopProducts []AdminProductSales         `json:"top_products"`
 }
 
 func (q AdminOrderStatisticsRequest) URLParams() string {
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// PriceRuleRequest is a scheduled price submitted from the admin. Exactly one
+// of ProductID and CategoryID is set; times are "YYYY-MM-DDTHH:MM" and
+// "HH:MM" in the shop timezone.
+type PriceRuleRequest struct {
+	ProductID  uint    `form:"product_id"`
+	CategoryID uint    `form:"category_id"`
+	Name       string  `form:"name"`
+	Kind       string  `form:"kind"`
+	Value      float64 `form:"value"`
+	StartsAt   string  `form:"starts_at"`
+	EndsAt     string  `form:"ends_at"`
+	DailyStart string  `form:"daily_start"`
+	DailyEnd   string  `form:"daily_end"`
+}
+
+type PriceRuleResponse struct {
+	ID           uint      `json:"id"`
+	ProductID    *uint     `json:"product_id,omitempty"`
+	ProductName  string    `json:"product_name,omitempty"`
+	CategoryID   *uint     `json:"category_id,omitempty"`
+	CategoryName string    `json:"category_name,omitempty"`
+	Name         string    `json:"name"`
+	Kind         string    `json:"kind"`
+	Value        float64   `json:"value"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	DailyStart   string    `json:"daily_start,omitempty"`
+	DailyEnd     string    `json:"daily_end,omitempty"`
+	// Status is "scheduled", "running" or "ended"
+	Status string `json:"status"`
+}
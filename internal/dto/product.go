@@ -3,11 +3,13 @@ package dto
 import "time"
 
 type ProductImageResponse struct {
-	ID        uint    `json:"id"`
-	ImageURL  string  `json:"image_url"`
-	AltText   *string `json:"alt_text,omitempty"`
-	SortOrder int     `json:"sort_order"`
-	IsPrimary bool    `json:"is_primary"`
+	ID           uint    `json:"id"`
+	ImageURL     string  `json:"image_url"`
+	MediumURL    *string `json:"medium_url,omitempty"`
+	ThumbnailURL *string `json:"thumbnail_url,omitempty"`
+	AltText      *string `json:"alt_text,omitempty"`
+	SortOrder    int     `json:"sort_order"`
+	IsPrimary    bool    `json:"is_primary"`
 }
 
 type ProductSocialShareResponse struct {
@@ -15,24 +17,86 @@ type ProductSocialShareResponse struct {
 	Twitter  string `json:"twitter"`
 }
 
+// SitemapEntry is a page listed in sitemap.xml
+type SitemapEntry struct {
+	URL          string
+	LastModified time.Time
+}
+
+// BundleComponentResponse is one product included in a bundle
+type BundleComponentResponse struct {
+	ProductID uint     `json:"product_id"`
+	Name      string   `json:"name"`
+	Slug      string   `json:"slug"`
+	Price     float64  `json:"price"`
+	Quantity  int      `json:"quantity"`
+	Allergens []string `json:"allergens,omitempty"`
+}
+
 type ProductResponse struct {
-	ID            uint                       `json:"id"`
-	CategoryID    uint                       `json:"category_id"`
-	CategoryName  string                     `json:"category_name,omitempty"`
-	Name          string                     `json:"name"`
-	Slug          string                     `json:"slug"`
-	Description   *string                    `json:"description,omitempty"`
-	Classify      string                     `json:"classify"`
-	Price         float64                    `json:"price"`
-	Stock         int                        `json:"stock"`
-	RatingAverage float64                    `json:"rating_average"`
-	RatingCount   int                        `json:"rating_count"`
-	Status        string                     `json:"status"`
-	Images        []ProductImageResponse     `json:"images,omitempty"`
-	PrimaryImage  *ProductImageResponse      `json:"primary_image,omitempty"`
-	SocialShare   ProductSocialShareResponse `json:"social_share"`
-	CreatedAt     time.Time                  `json:"created_at"`
-	UpdatedAt     time.Time                  `json:"updated_at"`
+	ID           uint   `json:"id"`
+	CategoryID   uint   `json:"category_id"`
+	CategoryName string `json:"category_name,omitempty"`
+	// Breadcrumbs is the category path of the product, top level first
+	Breadcrumbs []CategoryBreadcrumb `json:"breadcrumbs,omitempty"`
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug"`
+	Description *string              `json:"description,omitempty"`
+	Classify    string               `json:"classify"`
+	// Nutrition is per serving, omitted when nothing is known
+	Nutrition *NutritionFacts `json:"nutrition,omitempty"`
+	// Allergens are keys of the fixed allergen list; a bundle also has those
+	// of its components. DietaryTags are free-form.
+	Allergens   []string `json:"allergens"`
+	DietaryTags []string `json:"dietary_tags"`
+	// DeclaredAllergens are the ones set on the product itself, which is
+	// what the admin editor changes
+	DeclaredAllergens []string `json:"-"`
+	// Price is what the product sells for now, sale included
+	Price float64 `json:"price"`
+	// OriginalPrice is the regular price while a sale lowers Price, and
+	// SaleEndsAt when that sale stops
+	OriginalPrice float64    `json:"original_price,omitempty"`
+	SaleEndsAt    *time.Time `json:"sale_ends_at,omitempty"`
+	Stock         int        `json:"stock"`
+	// LowStockThreshold is the stock level at or below which admins are alerted
+	LowStockThreshold int     `json:"low_stock_threshold"`
+	RatingAverage     float64 `json:"rating_average"`
+	RatingCount       int     `json:"rating_count"`
+	Status            string  `json:"status"`
+	// AvailableNow is false outside the product's serving hours
+	AvailableNow bool `json:"available_now"`
+	// IsBundle marks a combo; its stock is the number of complete bundles the components allow
+	IsBundle     bool                       `json:"is_bundle"`
+	Components   []BundleComponentResponse  `json:"components,omitempty"`
+	Images       []ProductImageResponse     `json:"images,omitempty"`
+	PrimaryImage *ProductImageResponse      `json:"primary_image,omitempty"`
+	SocialShare  ProductSocialShareResponse `json:"social_share"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	UpdatedAt    time.Time                  `json:"updated_at"`
+}
+
+// RegularPrice is the price without any running sale, the one admins edit
+// and the one price filters, facets and sorting use
+func (r ProductResponse) RegularPrice() float64 {
+	if r.OriginalPrice > 0 {
+		return r.OriginalPrice
+	}
+	return r.Price
+}
+
+// NutritionFacts are per serving; nil fields are unknown
+type NutritionFacts struct {
+	Calories   *int     `json:"calories,omitempty"`
+	SugarGrams *float64 `json:"sugar_grams,omitempty"`
+	CaffeineMg *int     `json:"caffeine_mg,omitempty"`
+}
+
+// CategoryBreadcrumb is one category on the path to a product
+type CategoryBreadcrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type CreateProductRequest struct {
@@ -44,6 +108,14 @@ type CreateProductRequest struct {
 	Price       float64 `form:"price"       json:"price"       binding:"required,min=0"`
 	Stock       int     `form:"stock"       json:"stock"       binding:"min=0"`
 	Status      string  `form:"status"      json:"status"      binding:"omitempty,oneof=active inactive out_of_stock"`
+	// LowStockThreshold alerts admins once stock falls to it or below
+	LowStockThreshold int `form:"low_stock_threshold" json:"low_stock_threshold" binding:"min=0,max=100000"`
+	// Translations holds the name and description in other locales, keyed by locale
+	Translations map[string]TranslationInput `form:"-" json:"translations,omitempty"`
+	// Nutrition, Allergens and DietaryTags describe what the product contains
+	Nutrition   *NutritionFacts `form:"-" json:"nutrition,omitempty"`
+	Allergens   []string        `form:"-" json:"allergens,omitempty"`
+	DietaryTags []string        `form:"-" json:"dietary_tags,omitempty"`
 }
 
 type UpdateProductRequest struct {
@@ -55,18 +127,80 @@ type UpdateProductRequest struct {
 	Price       *float64 `form:"price"       json:"price"       binding:"omitempty,min=0"`
 	Stock       *int     `form:"stock"       json:"stock"       binding:"omitempty,min=0"`
 	Status      *string  `form:"status"      json:"status"      binding:"omitempty,oneof=active inactive out_of_stock"`
+	// LowStockThreshold alerts admins once stock falls to it or below
+	LowStockThreshold *int `form:"low_stock_threshold" json:"low_stock_threshold" binding:"omitempty,min=0,max=100000"`
+	// Translations replaces the text of the locales it lists; nil keeps all
+	Translations map[string]TranslationInput `form:"-" json:"translations,omitempty"`
+	// Nutrition, Allergens and DietaryTags replace the current values; nil keeps them
+	Nutrition   *NutritionFacts `form:"-" json:"nutrition,omitempty"`
+	Allergens   []string        `form:"-" json:"allergens,omitempty"`
+	DietaryTags []string        `form:"-" json:"dietary_tags,omitempty"`
 }
 
 type ProductListRequest struct {
-	Page      int     `form:"page,default=1"        binding:"min=1"`
-	PageSize  int     `form:"page_size,default=20"  binding:"min=1,max=100"`
-	Classify  string  `form:"classify"              binding:"omitempty,oneof=food drink"`
-	Category  uint    `form:"category_id"           binding:"omitempty"`
-	MinPrice  float64 `form:"min_price"             binding:"omitempty,min=0"`
-	MaxPrice  float64 `form:"max_price"             binding:"omitempty,min=0"`
-	MinRating float64 `form:"min_rating"            binding:"omitempty,min=0,max=5"`
-	Status    string  `form:"status"                binding:"omitempty,oneof=active inactive out_of_stock"`
-	Search    string  `form:"search"                binding:"omitempty,max=255"`
-	SortBy    string  `form:"sort_by,default=created_at" binding:"omitempty,oneof=price rating_average name created_at"`
-	SortDir   string  `form:"sort_dir,default=desc"      binding:"omitempty,oneof=asc desc"`
+	Page         int    `form:"page,default=1"        binding:"min=1"`
+	PageSize     int    `form:"page_size,default=20"  binding:"min=1,max=100"`
+	Classify     string `form:"classify"              binding:"omitempty,oneof=food drink"`
+	Category     uint   `form:"category_id"           binding:"omitempty"`
+	CategorySlug string `form:"category_slug"         binding:"omitempty,max=255"`
+	// MinPrice, MaxPrice and sorting by price use the regular price, see
+	// ProductResponse.RegularPrice, since sales are resolved per request
+	MinPrice   float64 `form:"min_price"             binding:"omitempty,min=0"`
+	MaxPrice   float64 `form:"max_price"             binding:"omitempty,min=0"`
+	MinRating  float64 `form:"min_rating"            binding:"omitempty,min=0,max=5"`
+	Status     string  `form:"status"                binding:"omitempty,oneof=active inactive out_of_stock"`
+	Search     string  `form:"search"                binding:"omitempty,max=255"`
+	StartsWith string  `form:"starts_with"           binding:"omitempty,max=1"`
+	Facets     bool    `form:"facets"`
+	SortBy     string  `form:"sort_by"               binding:"omitempty,oneof=relevance price rating_average name created_at"`
+	SortDir    string  `form:"sort_dir,default=desc" binding:"omitempty,oneof=asc desc"`
+	Cursor     string  `form:"cursor"                binding:"omitempty,max=512"`
+	// ExcludeAllergens is a comma-separated list of allergens the products
+	// must not contain, Tags one of dietary tags they must all have
+	ExcludeAllergens string `form:"exclude_allergens" binding:"omitempty,max=255"`
+	Tags             string `form:"tags"              binding:"omitempty,max=255"`
+	MaxCalories      int    `form:"max_calories"      binding:"omitempty,min=0"`
+	// Locale is the negotiated language of names and descriptions, set by the
+	// handler; empty means the default locale
+	Locale string `form:"-"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type CategoryFacetCount struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeFacetCount counts products with Min <= regular price < Max; Max is omitted for the last range
+type PriceRangeFacetCount struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// RatingFacetCount counts products rated MinRating stars or more
+type RatingFacetCount struct {
+	MinRating float64 `json:"min_rating"`
+	Count     int64   `json:"count"`
+}
+
+// ProductFacets counts the products each filter value would return, given
+// all other active filters. Every value is listed, including those with no products.
+type ProductFacets struct {
+	Classify   []FacetCount           `json:"classify"`
+	Category   []CategoryFacetCount   `json:"category"`
+	Rating     []RatingFacetCount     `json:"rating"`
+	Price      []PriceRangeFacetCount `json:"price"`
+	StartsWith []FacetCount           `json:"starts_with"`
+}
+
+type ProductListResponse struct {
+	PaginatedResponse
+	Facets *ProductFacets `json:"facets,omitempty"`
 }
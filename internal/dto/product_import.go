@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// ProductImportRow is one data row of an import file after validation
+type ProductImportRow struct {
+	Line        int                       `json:"line"`
+	Slug        string                    `json:"slug"`
+	Name        string                    `json:"name"`
+	Action      string                    `json:"action"` // create or update
+	PriceChange *ProductImportPriceChange `json:"price_change,omitempty"`
+	Errors      []string                  `json:"errors,omitempty"`
+}
+
+// ProductImportPriceChange is an update moving the price by more than the
+// confirmation threshold; Percent is the change in percent of Old
+type ProductImportPriceChange struct {
+	Old     float64 `json:"old"`
+	New     float64 `json:"new"`
+	Percent float64 `json:"percent"`
+}
+
+// ProductImportPreview is the dry run of an import file. Token is only set
+// when every row is valid and is what applies the import. Applying it with
+// PriceChanges above zero needs those changes, updates moving the price by
+// more than PriceThreshold percent, confirmed.
+type ProductImportPreview struct {
+	Token          string             `json:"token,omitempty"`
+	Rows           []ProductImportRow `json:"rows"`
+	Creates        int                `json:"creates"`
+	Updates        int                `json:"updates"`
+	Invalid        int                `json:"invalid"`
+	PriceChanges   int                `json:"price_changes"`
+	PriceThreshold float64            `json:"price_threshold"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+}
+
+// ProductImportJob reports the progress of an applied import
+type ProductImportJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Created    int        `json:"created"`
+	Updated    int        `json:"updated"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// ProductFieldChange is one field an edit changed, with both values
+// formatted for display
+type ProductFieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ProductRevisionResponse is one entry of a product's edit history
+type ProductRevisionResponse struct {
+	ID        uint      `json:"id"`
+	ProductID uint      `json:"product_id"`
+	AdminName string    `json:"admin_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// RollbackOf is the revision this edit undid
+	RollbackOf *uint                `json:"rollback_of,omitempty"`
+	Changes    []ProductFieldChange `json:"changes"`
+}
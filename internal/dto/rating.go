@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 type CreateRatingRequest struct {
 	Rating  uint8   `json:"rating" binding:"required,min=1,max=5"`
@@ -12,20 +15,114 @@ type UpdateRatingRequest struct {
 	Comment *string `json:"comment" binding:"omitempty,max=2000"`
 }
 
+type RatingPhotoResponse struct {
+	ID           uint    `json:"id"`
+	ImageURL     string  `json:"image_url"`
+	MediumURL    *string `json:"medium_url,omitempty"`
+	ThumbnailURL *string `json:"thumbnail_url,omitempty"`
+}
+
 type RatingResponse struct {
-	ID         uint      `json:"id"`
-	UserID     uint      `json:"user_id"`
-	UserName   string    `json:"user_name"`
-	UserAvatar *string   `json:"user_avatar,omitempty"`
-	ProductID  uint      `json:"product_id"`
-	OrderID    *uint     `json:"order_id,omitempty"`
-	Rating     uint8     `json:"rating"`
-	Comment    *string   `json:"comment,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID           uint                  `json:"id"`
+	UserID       uint                  `json:"user_id"`
+	UserName     string                `json:"user_name"`
+	UserAvatar   *string               `json:"user_avatar,omitempty"`
+	ProductID    uint                  `json:"product_id"`
+	OrderID      *uint                 `json:"order_id,omitempty"`
+	Rating       uint8                 `json:"rating"`
+	Comment      *string               `json:"comment,omitempty"`
+	HelpfulCount int                   `json:"helpful_count"`
+	Photos       []RatingPhotoResponse `json:"photos,omitempty"`
+	ShopReply    *RatingReplyResponse  `json:"shop_reply,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// RatingReplyResponse is the shop's official answer shown under a rating
+type RatingReplyResponse struct {
+	Content   string    `json:"content"`
+	RepliedAt time.Time `json:"replied_at"`
 }
 
+// Orders of the rating list
+const (
+	RatingSortNewest     = "newest"
+	RatingSortHelpful    = "helpful"
+	RatingSortWithPhotos = "with_photos"
+)
+
 type RatingListRequest struct {
-	Page     int `form:"page,default=1" binding:"min=1"`
-	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	Cursor   string `form:"cursor" binding:"omitempty,max=512"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest helpful with_photos"`
+}
+
+// RatingStarCount is one bar of the rating histogram
+type RatingStarCount struct {
+	Star  uint8 `json:"star"`
+	Count int64 `json:"count"`
+}
+
+// RatingSummary describes all ratings of a product, whatever page is listed.
+// Distribution runs from 5 stars down to 1.
+type RatingSummary struct {
+	Average      float64           `json:"average"`
+	Count        int64             `json:"count"`
+	Distribution []RatingStarCount `json:"distribution"`
+}
+
+// RatingListResponse is a page of ratings with the product's rating summary
+type RatingListResponse struct {
+	PaginatedResponse
+	Summary RatingSummary `json:"summary"`
+}
+
+// RatingVoteResponse is a rating's helpful count after a vote
+type RatingVoteResponse struct {
+	RatingID     uint `json:"rating_id"`
+	HelpfulCount int  `json:"helpful_count"`
+	Voted        bool `json:"voted"`
+}
+
+type ReportRatingRequest struct {
+	Reason string  `json:"reason" binding:"required,oneof=spam offensive off_topic other"`
+	Note   *string `json:"note" binding:"omitempty,max=500"`
+}
+
+type RatingReportResponse struct {
+	RatingID  uint      `json:"rating_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminRatingListRequest filters the moderation queue. Status is flagged,
+// hidden or visible; empty lists every rating.
+type AdminRatingListRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=15" binding:"min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=flagged hidden visible"`
+	Search   string `form:"search" binding:"omitempty,max=255"`
+}
+
+// URLParams always carries status, as the list shows flagged ratings when it
+// is missing
+func (q AdminRatingListRequest) URLParams() string {
+	params := url.Values{}
+	params.Set("status", q.Status)
+	if q.Search != "" {
+		params.Set("search", q.Search)
+	}
+	return params.Encode()
+}
+
+// AdminRatingResponse is a rating with its moderation state
+type AdminRatingResponse struct {
+	RatingResponse
+	ProductName string  `json:"product_name"`
+	ProductSlug string  `json:"product_slug"`
+	Status      string  `json:"status"`
+	Flagged     bool    `json:"flagged"`
+	FlagReason  *string `json:"flag_reason,omitempty"`
+	ReportCount int     `json:"report_count"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+type RecommendationRequest struct {
+	Limit int `form:"limit,default=8" binding:"omitempty,min=1,max=24"`
+	// Locale is the negotiated language of names and descriptions, set by the
+	// handler; empty means the default locale
+	Locale string `form:"-"`
+}
+
+// RecommendedProduct is a product with why it was picked: bought_together,
+// same_category, same_classify, based_on_history, favorite_category or popular
+type RecommendedProduct struct {
+	ProductResponse
+	Reason string `json:"reason"`
+}
+
+type RecommendationResponse struct {
+	Items []RecommendedProduct `json:"items"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+type SearchSuggestRequest struct {
+	Q     string `form:"q"     binding:"required,max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
+}
+
+// SearchSuggestion is one autocomplete entry. Slug is set for products and categories.
+type SearchSuggestion struct {
+	Type string `json:"type" example:"product"`
+	ID   uint   `json:"id,omitempty"`
+	Text string `json:"text" example:"Trà sữa trân châu"`
+	Slug string `json:"slug,omitempty" example:"tra-sua-tran-chau"`
+}
+
+type SearchSuggestResponse struct {
+	Query       string             `json:"query"`
+	Suggestions []SearchSuggestion `json:"suggestions"`
+}
+
+type AdminSearchInsightsRequest struct {
+	Days int `form:"days,default=30" binding:"omitempty,oneof=1 7 30 90"`
+}
+
+type AdminSearchQueryStat struct {
+	Query          string  `json:"query"`
+	SearchCount    int64   `json:"search_count"`
+	AvgResultCount float64 `json:"avg_result_count"`
+}
+
+type AdminSearchInsightsResponse struct {
+	Days          int                    `json:"days"`
+	TotalSearches int64                  `json:"total_searches"`
+	ZeroSearches  int64                  `json:"zero_searches"`
+	ZeroRate      float64                `json:"zero_rate"` // percentage of searches that found nothing
+	TopQueries    []AdminSearchQueryStat `json:"top_queries"`
+	ZeroQueries   []AdminSearchQueryStat `json:"zero_queries"`
+}
@@ -10,6 +10,9 @@ type CreateSuggestionRequest struct {
 	Description *string `json:"description" binding:"omitempty,max=5000"`
 	Classify    string  `json:"classify" binding:"required,oneof=food drink"`
 	CategoryID  *uint   `json:"category_id" binding:"omitempty"`
+	// Allergens are keys of the fixed allergen list; DietaryTags are free-form, e.g. vegan
+	Allergens   []string `json:"allergens" binding:"omitempty,max=20"`
+	DietaryTags []string `json:"dietary_tags" binding:"omitempty,max=10"`
 }
 
 type SuggestionResponse struct {
@@ -21,6 +24,8 @@ type SuggestionResponse struct {
 	Classify     string    `json:"classify"`
 	CategoryID   *uint     `json:"category_id,omitempty"`
 	CategoryName string    `json:"category_name,omitempty"`
+	Allergens    []string  `json:"allergens"`
+	DietaryTags  []string  `json:"dietary_tags"`
 	Status       string    `json:"status"`
 	AdminNote    *string   `json:"admin_note,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
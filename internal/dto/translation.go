@@ -0,0 +1,8 @@
+package dto
+
+// TranslationInput is the text of a product or category in one locale other
+// than the default. Empty fields fall back to the default locale.
+type TranslationInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+// TrashedCategoryResponse is a soft-deleted category in the admin trash
+type TrashedCategoryResponse struct {
+	CategoryResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashedProductResponse is a soft-deleted product in the admin trash
+type TrashedProductResponse struct {
+	ProductResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	// CategoryDeleted is set while the product's category is in the trash
+	// too, which blocks restoring the product
+	CategoryDeleted bool `json:"category_deleted"`
+}
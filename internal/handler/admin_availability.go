@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+type weekdayOption struct {
+	Value int
+	Label string
+}
+
+// availabilityWeekdays lists the weekdays Monday first, as shops read a week
+var availabilityWeekdays = []weekdayOption{
+	{1, "Thứ 2"}, {2, "Thứ 3"}, {3, "Thứ 4"}, {4, "Thứ 5"}, {5, "Thứ 6"}, {6, "Thứ 7"}, {0, "Chủ nhật"},
+}
+
+// availabilityEditorData is what templates/admin/availability.html renders
+type availabilityEditorData struct {
+	Action   string
+	Hint     string
+	Timezone string
+	Schedule *dto.AvailabilitySchedule
+	Weekdays []weekdayOption
+}
+
+func newAvailabilityEditorData(action, hint string, availability *service.AvailabilityService, schedule *dto.AvailabilitySchedule) *availabilityEditorData {
+	if schedule == nil {
+		schedule = &dto.AvailabilitySchedule{}
+	}
+	return &availabilityEditorData{
+		Action:   action,
+		Hint:     hint,
+		Timezone: availability.Location().String(),
+		Schedule: schedule,
+		Weekdays: availabilityWeekdays,
+	}
+}
+
+// parseAvailabilityForm reads the editor rows; the inputs of a row share its index
+func parseAvailabilityForm(c *gin.Context) *dto.AvailabilitySchedule {
+	schedule := &dto.AvailabilitySchedule{}
+
+	weekdays := c.PostFormArray("window_weekday")
+	starts := c.PostFormArray("window_start")
+	ends := c.PostFormArray("window_end")
+	for i, raw := range weekdays {
+		weekday, err := strconv.Atoi(raw)
+		if err != nil {
+			weekday = -1
+		}
+		schedule.Windows = append(schedule.Windows, dto.AvailabilityWindowInput{
+			Weekday:   weekday,
+			StartTime: formValueAt(starts, i),
+			EndTime:   formValueAt(ends, i),
+		})
+	}
+
+	dates := c.PostFormArray("exception_date")
+	starts = c.PostFormArray("exception_start")
+	ends = c.PostFormArray("exception_end")
+	notes := c.PostFormArray("exception_note")
+	for i, date := range dates {
+		schedule.Exceptions = append(schedule.Exceptions, dto.AvailabilityExceptionInput{
+			Date:      date,
+			StartTime: formValueAt(starts, i),
+			EndTime:   formValueAt(ends, i),
+			Note:      formValueAt(notes, i),
+		})
+	}
+	return schedule
+}
+
+func formValueAt(values []string, i int) string {
+	if i < len(values) {
+		return strings.TrimSpace(values[i])
+	}
+	return ""
+}
+
+// availabilityErrMessage returns the flash message for a failed schedule save
+func availabilityErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidAvailability):
+		detail := strings.TrimPrefix(err.Error(), service.ErrInvalidAvailability.Error()+": ")
+		return "Lịch phục vụ không hợp lệ (" + detail + ")."
+	case errors.Is(err, service.ErrProductNotFound):
+		return "Không tìm thấy sản phẩm."
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return "Không tìm thấy danh mục."
+	default:
+		return "Không thể lưu lịch phục vụ: " + err.Error()
+	}
+}
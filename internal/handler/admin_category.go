@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/service"
 )
 
@@ -25,6 +27,7 @@ type flash struct {
 }
 
 type categoryFormData struct {
+	ParentID    uint
 	Name        string
 	Slug        string
 	Description string
@@ -54,6 +57,50 @@ func (q categoryListQuery) URLParams() string {
 	return strings.Join(parts, "&")
 }
 
+// categoryTreeRow is a row of the category tree on the list page
+type categoryTreeRow struct {
+	dto.CategoryTreeItem
+	// Indent is the left padding in pixels for the row's depth
+	Indent int
+	// Prefix marks the depth in select options
+	Prefix string
+	// Parent is the parent ID, 0 at the top level
+	Parent uint
+	// First and Last mark the ends of the row's siblings, which cannot move further
+	First bool
+	Last  bool
+}
+
+// buildCategoryTreeRows flags the first and last row of every sibling group
+func buildCategoryTreeRows(items []dto.CategoryTreeItem) []categoryTreeRow {
+	parentOf := func(item dto.CategoryTreeItem) uint {
+		if item.Depth == 0 || item.ParentID == nil {
+			return 0
+		}
+		return *item.ParentID
+	}
+	remaining := make(map[uint]int)
+	for _, item := range items {
+		remaining[parentOf(item)]++
+	}
+	seen := make(map[uint]bool)
+	rows := make([]categoryTreeRow, len(items))
+	for i, item := range items {
+		parent := parentOf(item)
+		remaining[parent]--
+		rows[i] = categoryTreeRow{
+			CategoryTreeItem: item,
+			Parent:           parent,
+			Indent:           item.Depth * 24,
+			Prefix:           strings.Repeat("— ", item.Depth),
+			First:            !seen[parent],
+			Last:             remaining[parent] == 0,
+		}
+		seen[parent] = true
+	}
+	return rows
+}
+
 type paginationData struct {
 	Page       int
 	TotalPages int
@@ -63,21 +110,25 @@ type paginationData struct {
 
 // AdminCategoryHandler handles SSR pages for admin category management
 type AdminCategoryHandler struct {
-	categoryService *service.CategoryService
-	listTmpl        *template.Template
-	formTmpl        *template.Template
+	categoryService     *service.CategoryService
+	availabilityService *service.AvailabilityService
+	translationService  *service.TranslationService
+	listTmpl            *template.Template
+	formTmpl            *template.Template
 }
 
 // NewAdminCategoryHandler creates a new AdminCategoryHandler and pre-parses templates.
-func NewAdminCategoryHandler(categoryService *service.CategoryService, funcMap template.FuncMap) *AdminCategoryHandler {
+func NewAdminCategoryHandler(categoryService *service.CategoryService, availabilityService *service.AvailabilityService, translationService *service.TranslationService, funcMap template.FuncMap) *AdminCategoryHandler {
 	layout := "templates/admin/layout.html"
 	return &AdminCategoryHandler{
-		categoryService: categoryService,
+		categoryService:     categoryService,
+		availabilityService: availabilityService,
+		translationService:  translationService,
 		listTmpl: template.Must(
 			template.New("list").Funcs(funcMap).ParseFiles(layout, "templates/admin/categories/list.html"),
 		),
 		formTmpl: template.Must(
-			template.New("form").Funcs(funcMap).ParseFiles(layout, "templates/admin/categories/form.html"),
+			template.New("form").Funcs(funcMap).ParseFiles(layout, "templates/admin/categories/form.html", "templates/admin/availability.html", "templates/admin/translations.html"),
 		),
 	}
 }
@@ -137,6 +188,27 @@ func (h *AdminCategoryHandler) List(c *gin.Context) {
 		q.Page = p
 	}
 
+	// Without filters the categories are shown as a tree in display order
+	if q.Search == "" && q.Status == "" && q.SortBy == "sort_order" {
+		items, err := h.categoryService.Tree()
+		if err != nil {
+			h.render(c, http.StatusInternalServerError, h.listTmpl, gin.H{
+				"Title":      "Danh mục",
+				"ActiveMenu": "categories",
+				"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh sách: " + err.Error()},
+			})
+			return
+		}
+		h.render(c, http.StatusOK, h.listTmpl, gin.H{
+			"Title":      "Danh mục",
+			"ActiveMenu": "categories",
+			"Flash":      h.getFlash(c),
+			"Tree":       buildCategoryTreeRows(items),
+			"Query":      q,
+		})
+		return
+	}
+
 	pageSize := 15
 	req := &dto.CategoryListRequest{
 		Page:     q.Page,
@@ -178,20 +250,65 @@ func (h *AdminCategoryHandler) List(c *gin.Context) {
 
 // New renders the create category form
 func (h *AdminCategoryHandler) New(c *gin.Context) {
+	form := categoryFormData{Status: "active"}
+	if parentID, err := strconv.ParseUint(c.Query("parent_id"), 10, 32); err == nil {
+		form.ParentID = uint(parentID)
+	}
 	h.render(c, http.StatusOK, h.formTmpl, gin.H{
-		"Title":      "Thêm danh mục",
-		"ActiveMenu": "categories",
-		"Flash":      h.getFlash(c),
-		"Form":       categoryFormData{Status: "active"},
+		"Title":        "Thêm danh mục",
+		"ActiveMenu":   "categories",
+		"Flash":        h.getFlash(c),
+		"Form":         form,
+		"Translations": h.translationEditor(form.Name, form.Description, nil),
+		"Parents":      h.parentOptions(0),
+		"ParentID":     form.ParentID,
 	})
 }
 
+// translationEditor returns the locale tabs of the form
+func (h *AdminCategoryHandler) translationEditor(name, description string, values map[string]dto.TranslationInput) *translationEditorData {
+	return newTranslationEditorData(h.translationService, "VD: Đồ uống, Món ăn...", "Mô tả ngắn về danh mục...", name, description, values)
+}
+
+// parentOptions lists the categories the category excludeID can be placed
+// under, leaving out excludeID and its subtree
+func (h *AdminCategoryHandler) parentOptions(excludeID uint) []categoryTreeRow {
+	items, err := h.categoryService.Tree()
+	if err != nil {
+		return nil
+	}
+	options := make([]categoryTreeRow, 0, len(items))
+	skipBelow := -1
+	for _, row := range buildCategoryTreeRows(items) {
+		if skipBelow >= 0 && row.Depth > skipBelow {
+			continue
+		}
+		skipBelow = -1
+		if row.ID == excludeID {
+			skipBelow = row.Depth
+			continue
+		}
+		options = append(options, row)
+	}
+	return options
+}
+
+func categoryParentID(cat *dto.CategoryResponse) uint {
+	if cat.ParentID == nil {
+		return 0
+	}
+	return *cat.ParentID
+}
+
 // Create handles POST /admin/categories
 func (h *AdminCategoryHandler) Create(c *gin.Context) {
 	form := h.parseForm(c)
+	translations := parseTranslationsForm(c, h.translationService)
 
 	req := &dto.CreateCategoryRequest{
-		Name: form.Name,
+		ParentID:     &form.ParentID,
+		Name:         form.Name,
+		Translations: translations,
 	}
 	if form.Slug != "" {
 		req.Slug = &form.Slug
@@ -205,18 +322,29 @@ func (h *AdminCategoryHandler) Create(c *gin.Context) {
 	req.SortOrder = &form.SortOrder
 	req.Status = &form.Status
 
-	_, err := h.categoryService.Create(req)
+	created, err := h.categoryService.Create(req)
 	if err != nil {
 		errs := h.serviceErrMessages(err)
 		h.render(c, http.StatusUnprocessableEntity, h.formTmpl, gin.H{
-			"Title":      "Thêm danh mục",
-			"ActiveMenu": "categories",
-			"Errors":     errs,
-			"Form":       form,
+			"Title":        "Thêm danh mục",
+			"ActiveMenu":   "categories",
+			"Errors":       errs,
+			"Form":         form,
+			"Translations": h.translationEditor(form.Name, form.Description, translations),
+			"Parents":      h.parentOptions(0),
+			"ParentID":     form.ParentID,
 		})
 		return
 	}
 
+	if file, err := c.FormFile("image_file"); err == nil && file.Size > 0 {
+		if _, err := h.categoryService.UploadImage(created.ID, file); err != nil {
+			h.setFlash(c, flashTypeErr, "Đã tạo danh mục nhưng không thể tải ảnh lên: "+h.serviceErrMessages(err)[0])
+			c.Redirect(http.StatusFound, fmt.Sprintf("/admin/categories/%d/edit", created.ID))
+			return
+		}
+	}
+
 	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã tạo danh mục \"%s\" thành công.", form.Name))
 	c.Redirect(http.StatusFound, "/admin/categories")
 }
@@ -235,15 +363,53 @@ func (h *AdminCategoryHandler) Edit(c *gin.Context) {
 		c.Redirect(http.StatusFound, "/admin/categories")
 		return
 	}
+	translations, err := h.translationService.Get(models.TranslationEntityCategory, id)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Lỗi khi tải bản dịch: "+err.Error())
+		c.Redirect(http.StatusFound, "/admin/categories")
+		return
+	}
 
 	h.render(c, http.StatusOK, h.formTmpl, gin.H{
-		"Title":      "Sửa danh mục",
-		"ActiveMenu": "categories",
-		"Flash":      h.getFlash(c),
-		"Category":   cat,
+		"Title":        "Sửa danh mục",
+		"ActiveMenu":   "categories",
+		"Flash":        h.getFlash(c),
+		"Category":     cat,
+		"Translations": h.translationEditor(cat.Name, derefString(cat.Description), translations),
+		"Parents":      h.parentOptions(id),
+		"ParentID":     categoryParentID(cat),
+		"Availability": h.availabilityEditor(id),
 	})
 }
 
+// SaveAvailability handles POST /admin/categories/:id/availability
+func (h *AdminCategoryHandler) SaveAvailability(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/categories")
+		return
+	}
+
+	if err := h.availabilityService.SaveCategorySchedule(id, parseAvailabilityForm(c)); err != nil {
+		h.setFlash(c, flashTypeErr, availabilityErrMessage(err))
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã lưu lịch phục vụ.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/categories/%d/edit", id))
+}
+
+func (h *AdminCategoryHandler) availabilityEditor(categoryID uint) *availabilityEditorData {
+	schedule, err := h.availabilityService.GetCategorySchedule(categoryID)
+	if err != nil {
+		return nil
+	}
+	return newAvailabilityEditorData(
+		fmt.Sprintf("/admin/categories/%d/availability", categoryID),
+		"Áp dụng cho mọi sản phẩm trong danh mục không có lịch riêng.",
+		h.availabilityService, schedule,
+	)
+}
+
 // Update handles POST /admin/categories/:id/update
 func (h *AdminCategoryHandler) Update(c *gin.Context) {
 	id, ok := h.parseIDParam(c)
@@ -254,10 +420,14 @@ func (h *AdminCategoryHandler) Update(c *gin.Context) {
 
 	form := h.parseForm(c)
 
+	translations := parseTranslationsForm(c, h.translationService)
+
 	req := &dto.UpdateCategoryRequest{
-		Name:      &form.Name,
-		SortOrder: &form.SortOrder,
-		Status:    &form.Status,
+		ParentID:     &form.ParentID,
+		Name:         &form.Name,
+		SortOrder:    &form.SortOrder,
+		Status:       &form.Status,
+		Translations: translations,
 	}
 	if form.Slug != "" {
 		req.Slug = &form.Slug
@@ -280,14 +450,25 @@ func (h *AdminCategoryHandler) Update(c *gin.Context) {
 	if err != nil {
 		errs := h.serviceErrMessages(err)
 		h.render(c, http.StatusUnprocessableEntity, h.formTmpl, gin.H{
-			"Title":      "Sửa danh mục",
-			"ActiveMenu": "categories",
-			"Errors":     errs,
-			"Category":   cat,
+			"Title":        "Sửa danh mục",
+			"ActiveMenu":   "categories",
+			"Errors":       errs,
+			"Category":     cat,
+			"Translations": h.translationEditor(form.Name, form.Description, translations),
+			"Parents":      h.parentOptions(id),
+			"ParentID":     form.ParentID,
 		})
 		return
 	}
 
+	if file, err := c.FormFile("image_file"); err == nil && file.Size > 0 {
+		if _, err := h.categoryService.UploadImage(id, file); err != nil {
+			h.setFlash(c, flashTypeErr, "Đã cập nhật danh mục nhưng không thể tải ảnh lên: "+h.serviceErrMessages(err)[0])
+			c.Redirect(http.StatusFound, fmt.Sprintf("/admin/categories/%d/edit", id))
+			return
+		}
+	}
+
 	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã cập nhật danh mục \"%s\".", form.Name))
 	c.Redirect(http.StatusFound, "/admin/categories")
 }
@@ -300,8 +481,9 @@ func (h *AdminCategoryHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryService.Delete(id); err != nil {
-		h.setFlash(c, flashTypeErr, "Không thể xoá danh mục: "+err.Error())
+	moveTo, _ := strconv.ParseUint(c.PostForm("move_products_to"), 10, 32)
+	if err := h.categoryService.Delete(id, c.PostForm("children"), uint(moveTo)); err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể xoá danh mục: "+h.serviceErrMessages(err)[0])
 	} else {
 		h.setFlash(c, flashTypeOK, "Đã xoá danh mục.")
 	}
@@ -309,6 +491,37 @@ func (h *AdminCategoryHandler) Delete(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/admin/categories")
 }
 
+// Move handles POST /admin/categories/:id/move
+func (h *AdminCategoryHandler) Move(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/categories")
+		return
+	}
+
+	parentID, _ := strconv.ParseUint(c.PostForm("parent_id"), 10, 32)
+	if err := h.categoryService.Move(id, uint(parentID)); err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể chuyển danh mục: "+h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã chuyển danh mục.")
+	}
+	c.Redirect(http.StatusFound, "/admin/categories")
+}
+
+// Reorder handles POST /admin/categories/:id/reorder
+func (h *AdminCategoryHandler) Reorder(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/categories")
+		return
+	}
+
+	if err := h.categoryService.Reorder(id, c.PostForm("direction") == "up"); err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể đổi thứ tự: "+h.serviceErrMessages(err)[0])
+	}
+	c.Redirect(http.StatusFound, "/admin/categories")
+}
+
 func (h *AdminCategoryHandler) parseIDParam(c *gin.Context) (uint, bool) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -320,11 +533,13 @@ func (h *AdminCategoryHandler) parseIDParam(c *gin.Context) (uint, bool) {
 
 func (h *AdminCategoryHandler) parseForm(c *gin.Context) categoryFormData {
 	sortOrder, _ := strconv.Atoi(c.PostForm("sort_order"))
+	parentID, _ := strconv.ParseUint(c.PostForm("parent_id"), 10, 32)
 	status := c.PostForm("status")
 	if status == "" {
 		status = "active"
 	}
 	return categoryFormData{
+		ParentID:    uint(parentID),
 		Name:        strings.TrimSpace(c.PostForm("name")),
 		Slug:        strings.TrimSpace(c.PostForm("slug")),
 		Description: strings.TrimSpace(c.PostForm("description")),
@@ -342,6 +557,26 @@ func (h *AdminCategoryHandler) serviceErrMessages(err error) []string {
 		return []string{"Slug này đã được sử dụng, vui lòng chọn slug khác."}
 	case err == service.ErrEmptySlug:
 		return []string{"Tên danh mục phải chứa ít nhất một ký tự chữ hoặc số."}
+	case err == service.ErrParentCategoryNotFound:
+		return []string{"Không tìm thấy danh mục cha."}
+	case err == service.ErrCategoryCycle:
+		return []string{"Không thể đặt danh mục vào chính nó hoặc danh mục con của nó."}
+	case err == service.ErrCategoryHasChildren:
+		return []string{"Danh mục có danh mục con, hãy chọn chuyển lên cấp trên hoặc xoá cùng."}
+	case err == service.ErrCategoryHasProducts:
+		return []string{"Danh mục vẫn còn sản phẩm, hãy chọn danh mục nhận các sản phẩm này."}
+	case err == service.ErrInvalidProductTarget:
+		return []string{"Danh mục nhận sản phẩm không tồn tại hoặc cũng đang bị xoá."}
+	case errors.Is(err, service.ErrInvalidTranslation):
+		return []string{translationErrMessage(err)}
+	case errors.Is(err, service.ErrFileTooLarge):
+		return []string{"Ảnh vượt quá dung lượng cho phép."}
+	case errors.Is(err, service.ErrInvalidFileType):
+		return []string{"Định dạng ảnh không được hỗ trợ."}
+	case errors.Is(err, service.ErrImageTooLarge):
+		return []string{"Kích thước ảnh quá lớn."}
+	case errors.Is(err, service.ErrUploadUnavailable):
+		return []string{"Chức năng tải ảnh chưa được cấu hình."}
 	default:
 		return []string{"Đã có lỗi xảy ra: " + err.Error()}
 	}
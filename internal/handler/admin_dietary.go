@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+// allergenLabels are the Vietnamese names of the allergens
+var allergenLabels = map[string]string{
+	models.AllergenPeanut:    "Đậu phộng",
+	models.AllergenTreeNut:   "Các loại hạt",
+	models.AllergenMilk:      "Sữa",
+	models.AllergenEgg:       "Trứng",
+	models.AllergenSoy:       "Đậu nành",
+	models.AllergenGluten:    "Gluten",
+	models.AllergenFish:      "Cá",
+	models.AllergenShellfish: "Hải sản có vỏ",
+	models.AllergenSesame:    "Mè",
+}
+
+// dietaryEditorData feeds the nutrition, allergen and tag fields of the
+// product form. Nutrition values are strings so unknown ones stay empty.
+type dietaryEditorData struct {
+	Calories   string
+	SugarGrams string
+	CaffeineMg string
+	Allergens  []allergenOption
+	Tags       string
+}
+
+type allergenOption struct {
+	Value   string
+	Label   string
+	Checked bool
+}
+
+func newDietaryEditorData(nutrition *dto.NutritionFacts, allergens, tags []string) *dietaryEditorData {
+	data := &dietaryEditorData{Tags: strings.Join(tags, ", ")}
+	if nutrition != nil {
+		if nutrition.Calories != nil {
+			data.Calories = strconv.Itoa(*nutrition.Calories)
+		}
+		if nutrition.SugarGrams != nil {
+			data.SugarGrams = strconv.FormatFloat(*nutrition.SugarGrams, 'f', -1, 64)
+		}
+		if nutrition.CaffeineMg != nil {
+			data.CaffeineMg = strconv.Itoa(*nutrition.CaffeineMg)
+		}
+	}
+	data.Allergens = allergenOptions(allergens)
+	return data
+}
+
+// allergenOptions lists every allergen, checking those in selected
+func allergenOptions(selected []string) []allergenOption {
+	options := make([]allergenOption, len(models.Allergens))
+	for i, a := range models.Allergens {
+		options[i] = allergenOption{Value: a, Label: allergenLabels[a], Checked: slices.Contains(selected, a)}
+	}
+	return options
+}
+
+// allergenNames returns the labels of allergens for display
+func allergenNames(allergens []string) []string {
+	names := make([]string, len(allergens))
+	for i, a := range allergens {
+		if label, ok := allergenLabels[a]; ok {
+			names[i] = label
+		} else {
+			names[i] = a
+		}
+	}
+	return names
+}
+
+// parseDietaryForm reads the dietary fields of the product form. Empty
+// nutrition fields are unknown; the lists are never nil, so unchecking every
+// allergen clears them.
+func parseDietaryForm(c *gin.Context) (*dto.NutritionFacts, []string, []string) {
+	nutrition := &dto.NutritionFacts{}
+	if v, err := strconv.Atoi(strings.TrimSpace(c.PostForm("calories"))); err == nil {
+		nutrition.Calories = &v
+	}
+	if v, err := strconv.ParseFloat(strings.TrimSpace(c.PostForm("sugar_grams")), 64); err == nil {
+		nutrition.SugarGrams = &v
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(c.PostForm("caffeine_mg"))); err == nil {
+		nutrition.CaffeineMg = &v
+	}
+	allergens := append([]string{}, c.PostFormArray("allergens")...)
+	tags := []string{}
+	for _, tag := range strings.Split(c.PostForm("dietary_tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return nutrition, allergens, tags
+}
+
+// dietaryErrMessage describes invalid dietary info on the admin forms
+func dietaryErrMessage(err error) string {
+	return "Thông tin dinh dưỡng không hợp lệ (" + strings.TrimPrefix(err.Error(), service.ErrInvalidDietaryInfo.Error()+": ") + ")."
+}
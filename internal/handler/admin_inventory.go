@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminInventoryMenu     = "inventory"
+	adminInventoryTitle    = "Kho hàng"
+	adminInventoryPath     = "/admin/inventory"
+	adminInventoryFlashKey = "flash_inventory"
+)
+
+// stockReasonLabels are the Vietnamese names of the ledger reasons, in the
+// order they appear in the filter
+var stockReasonLabels = []struct {
+	Value string
+	Label string
+}{
+	{models.StockReasonRestock, "Nhập hàng"},
+	{models.StockReasonWaste, "Hao hụt"},
+	{models.StockReasonOrder, "Đơn hàng"},
+	{models.StockReasonCancellation, "Huỷ đơn"},
+	{models.StockReasonAdjustment, "Điều chỉnh"},
+}
+
+type AdminInventoryHandler struct {
+	inventoryService *service.InventoryService
+	productService   *service.ProductService
+	listTmpl         *template.Template
+}
+
+func NewAdminInventoryHandler(inventoryService *service.InventoryService, productService *service.ProductService, funcMap template.FuncMap) *AdminInventoryHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminInventoryHandler{
+		inventoryService: inventoryService,
+		productService:   productService,
+		listTmpl: template.Must(
+			template.New("inventory_list").Funcs(funcMap).ParseFiles(layout, "templates/admin/inventory/list.html"),
+		),
+	}
+}
+
+func (h *AdminInventoryHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminInventoryHandler) setFlash(c *gin.Context, t, msg string) {
+	c.SetCookie(adminInventoryFlashKey, t+"|"+msg, 0, "/", "", false, true)
+}
+
+func (h *AdminInventoryHandler) getFlash(c *gin.Context) *flash {
+	val, err := c.Cookie(adminInventoryFlashKey)
+	if err != nil || val == "" {
+		return nil
+	}
+	c.SetCookie(adminInventoryFlashKey, "", -1, "/", "", false, true)
+	parts := strings.SplitN(val, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &flash{Type: parts[0], Message: parts[1]}
+}
+
+// List handles GET /admin/inventory: the receive/waste forms, the stock
+// movement history and any product whose stock disagrees with the ledger
+func (h *AdminInventoryHandler) List(c *gin.Context) {
+	q := dto.AdminStockMovementListRequest{
+		Page:     1,
+		PageSize: 20,
+		Reason:   strings.TrimSpace(c.Query("reason")),
+	}
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		q.Page = p
+	}
+	if id, err := strconv.ParseUint(c.Query("product_id"), 10, 32); err == nil {
+		q.ProductID = uint(id)
+	}
+	validReason := q.Reason == ""
+	for _, r := range stockReasonLabels {
+		if r.Value == q.Reason {
+			validReason = true
+		}
+	}
+	if !validReason {
+		q.Reason = ""
+	}
+
+	data := gin.H{
+		"Title":      adminInventoryTitle,
+		"ActiveMenu": adminInventoryMenu,
+		"Query":      q,
+		"Reasons":    stockReasonLabels,
+		"ReasonLabel": func(reason string) string {
+			for _, r := range stockReasonLabels {
+				if r.Value == reason {
+					return r.Label
+				}
+			}
+			return reason
+		},
+	}
+
+	products, err := h.productService.ListBundleCandidates(0)
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh sách sản phẩm: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+	data["Products"] = products
+
+	result, err := h.inventoryService.ListMovements(&q)
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi tải lịch sử kho: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+	movements, ok := result.Items.([]dto.StockMovementResponse)
+	if !ok {
+		movements = []dto.StockMovementResponse{}
+	}
+
+	mismatches, err := h.inventoryService.Reconcile()
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi đối soát kho: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+
+	data["Flash"] = h.getFlash(c)
+	data["Movements"] = movements
+	data["Mismatches"] = mismatches
+	data["Pagination"] = paginationData{
+		Page:       q.Page,
+		TotalPages: result.TotalPages,
+		Total:      result.Total,
+		Pages:      buildPages(q.Page, result.TotalPages),
+	}
+	h.render(c, http.StatusOK, h.listTmpl, data)
+}
+
+// Receive handles POST /admin/inventory/receive
+func (h *AdminInventoryHandler) Receive(c *gin.Context) {
+	h.record(c, h.inventoryService.Receive, "Đã nhập %d × %s.")
+}
+
+// Waste handles POST /admin/inventory/waste
+func (h *AdminInventoryHandler) Waste(c *gin.Context) {
+	h.record(c, h.inventoryService.Waste, "Đã ghi hao hụt %d × %s.")
+}
+
+func (h *AdminInventoryHandler) record(
+	c *gin.Context,
+	apply func(adminID uint, req *dto.AdminStockMovementRequest) (*dto.StockMovementResponse, error),
+	okFormat string,
+) {
+	productID, err := strconv.ParseUint(c.PostForm("product_id"), 10, 32)
+	if err != nil || productID == 0 {
+		h.setFlash(c, flashTypeErr, "Vui lòng chọn sản phẩm.")
+		c.Redirect(http.StatusFound, adminInventoryPath)
+		return
+	}
+	quantity, err := strconv.Atoi(strings.TrimSpace(c.PostForm("quantity")))
+	if err != nil || quantity < 1 {
+		h.setFlash(c, flashTypeErr, "Số lượng phải là số nguyên lớn hơn 0.")
+		c.Redirect(http.StatusFound, adminInventoryPath)
+		return
+	}
+	note := strings.TrimSpace(c.PostForm("note"))
+	if len([]rune(note)) > 255 {
+		h.setFlash(c, flashTypeErr, "Ghi chú tối đa 255 ký tự.")
+		c.Redirect(http.StatusFound, adminInventoryPath)
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	movement, err := apply(adminID, &dto.AdminStockMovementRequest{
+		ProductID: uint(productID),
+		Quantity:  quantity,
+		Note:      note,
+	})
+	if err != nil {
+		h.setFlash(c, flashTypeErr, inventoryErrMessage(err))
+		c.Redirect(http.StatusFound, adminInventoryPath)
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, fmt.Sprintf(okFormat, quantity, movement.ProductName))
+	c.Redirect(http.StatusFound, adminInventoryPath)
+}
+
+// inventoryErrMessage returns the flash message for a failed stock movement
+func inventoryErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		return "Không tìm thấy sản phẩm."
+	case errors.Is(err, service.ErrInsufficientStock):
+		return "Số lượng hao hụt vượt quá tồn kho hiện tại."
+	case errors.Is(err, service.ErrInvalidStockMovement):
+		return "Không thể ghi nhận: combo lấy tồn kho từ các sản phẩm thành phần."
+	default:
+		return "Không thể ghi nhận thay đổi kho: " + err.Error()
+	}
+}
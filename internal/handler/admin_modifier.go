@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminModifiersMenu     = "modifiers"
+	adminModifiersTitle    = "Tuỳ chọn thêm"
+	adminModifiersPath     = "/admin/modifiers"
+	adminModifiersFlashKey = "flash_modifier"
+	// modifierBlankOptionRows is the number of empty option rows appended to the form
+	modifierBlankOptionRows = 3
+)
+
+type modifierFormData struct {
+	ID          uint
+	Name        string
+	MinSelect   int
+	MaxSelect   int
+	SortOrder   int
+	Status      string
+	Options     []dto.ModifierOptionInput
+	ProductIDs  map[uint]bool
+	CategoryIDs map[uint]bool
+}
+
+// AdminModifierHandler handles SSR pages for admin modifier group management
+type AdminModifierHandler struct {
+	modifierService *service.ModifierService
+	productService  *service.ProductService
+	categoryService *service.CategoryService
+	listTmpl        *template.Template
+	formTmpl        *template.Template
+}
+
+func NewAdminModifierHandler(
+	modifierService *service.ModifierService,
+	productService *service.ProductService,
+	categoryService *service.CategoryService,
+	funcMap template.FuncMap,
+) *AdminModifierHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminModifierHandler{
+		modifierService: modifierService,
+		productService:  productService,
+		categoryService: categoryService,
+		listTmpl: template.Must(
+			template.New("modifier_list").Funcs(funcMap).ParseFiles(layout, "templates/admin/modifiers/list.html"),
+		),
+		formTmpl: template.Must(
+			template.New("modifier_form").Funcs(funcMap).ParseFiles(layout, "templates/admin/modifiers/form.html"),
+		),
+	}
+}
+
+func (h *AdminModifierHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminModifierHandler) setFlash(c *gin.Context, t, msg string) {
+	c.SetCookie(adminModifiersFlashKey, t+"|"+msg, 0, "/", "", false, true)
+}
+
+func (h *AdminModifierHandler) getFlash(c *gin.Context) *flash {
+	val, err := c.Cookie(adminModifiersFlashKey)
+	if err != nil || val == "" {
+		return nil
+	}
+	c.SetCookie(adminModifiersFlashKey, "", -1, "/", "", false, true)
+	parts := strings.SplitN(val, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &flash{Type: parts[0], Message: parts[1]}
+}
+
+func (h *AdminModifierHandler) loadProducts() []dto.ProductResponse {
+	result, err := h.productService.List(&dto.ProductListRequest{
+		Page: 1, PageSize: 500, SortBy: "name", SortDir: "asc",
+	})
+	if err != nil {
+		return nil
+	}
+	products, _ := result.Items.([]dto.ProductResponse)
+	return products
+}
+
+func (h *AdminModifierHandler) loadCategories() []dto.CategoryResponse {
+	result, err := h.categoryService.List(&dto.CategoryListRequest{
+		Page: 1, PageSize: 200, SortBy: "name", SortDir: "asc",
+	})
+	if err != nil {
+		return nil
+	}
+	cats, _ := result.Items.([]dto.CategoryResponse)
+	return cats
+}
+
+func (h *AdminModifierHandler) renderForm(c *gin.Context, status int, form modifierFormData, errs []string) {
+	title := "Thêm nhóm tuỳ chọn"
+	if form.ID != 0 {
+		title = "Sửa nhóm tuỳ chọn"
+	}
+	for i := 0; i < modifierBlankOptionRows; i++ {
+		form.Options = append(form.Options, dto.ModifierOptionInput{})
+	}
+	h.render(c, status, h.formTmpl, gin.H{
+		"Title":      title,
+		"ActiveMenu": adminModifiersMenu,
+		"Flash":      h.getFlash(c),
+		"Errors":     errs,
+		"Form":       form,
+		"Products":   h.loadProducts(),
+		"Categories": h.loadCategories(),
+	})
+}
+
+// List renders the modifier group list page
+func (h *AdminModifierHandler) List(c *gin.Context) {
+	groups, err := h.modifierService.ListGroups()
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, h.listTmpl, gin.H{
+			"Title":      adminModifiersTitle,
+			"ActiveMenu": adminModifiersMenu,
+			"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh sách: " + err.Error()},
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, h.listTmpl, gin.H{
+		"Title":      adminModifiersTitle,
+		"ActiveMenu": adminModifiersMenu,
+		"Flash":      h.getFlash(c),
+		"Groups":     groups,
+	})
+}
+
+// New renders the create modifier group form
+func (h *AdminModifierHandler) New(c *gin.Context) {
+	h.renderForm(c, http.StatusOK, modifierFormData{
+		MaxSelect:   1,
+		Status:      "active",
+		ProductIDs:  map[uint]bool{},
+		CategoryIDs: map[uint]bool{},
+	}, nil)
+}
+
+// Create handles POST /admin/modifiers
+func (h *AdminModifierHandler) Create(c *gin.Context) {
+	form, req := h.parseForm(c)
+
+	if _, err := h.modifierService.CreateGroup(req); err != nil {
+		h.renderForm(c, http.StatusUnprocessableEntity, form, h.serviceErrMessages(err))
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã tạo nhóm tuỳ chọn \"%s\".", form.Name))
+	c.Redirect(http.StatusFound, adminModifiersPath)
+}
+
+// Edit renders the edit modifier group form
+func (h *AdminModifierHandler) Edit(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, adminModifiersPath)
+		return
+	}
+
+	group, err := h.modifierService.GetGroup(id)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Không tìm thấy nhóm tuỳ chọn.")
+		c.Redirect(http.StatusFound, adminModifiersPath)
+		return
+	}
+
+	form := modifierFormData{
+		ID:          group.ID,
+		Name:        group.Name,
+		MinSelect:   group.MinSelect,
+		MaxSelect:   group.MaxSelect,
+		SortOrder:   group.SortOrder,
+		Status:      group.Status,
+		ProductIDs:  map[uint]bool{},
+		CategoryIDs: map[uint]bool{},
+	}
+	for _, opt := range group.Options {
+		// Removed options are kept inactive for old cart lines; hide them here
+		if opt.Status != "active" {
+			continue
+		}
+		form.Options = append(form.Options, dto.ModifierOptionInput{
+			ID:        opt.ID,
+			Name:      opt.Name,
+			Price:     opt.Price,
+			SortOrder: opt.SortOrder,
+		})
+	}
+	for _, pid := range group.ProductIDs {
+		form.ProductIDs[pid] = true
+	}
+	for _, cid := range group.CategoryIDs {
+		form.CategoryIDs[cid] = true
+	}
+
+	h.renderForm(c, http.StatusOK, form, nil)
+}
+
+// Update handles POST /admin/modifiers/:id/update
+func (h *AdminModifierHandler) Update(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, adminModifiersPath)
+		return
+	}
+
+	form, req := h.parseForm(c)
+	form.ID = id
+
+	if _, err := h.modifierService.UpdateGroup(id, req); err != nil {
+		if errors.Is(err, service.ErrModifierGroupNotFound) {
+			h.setFlash(c, flashTypeErr, "Không tìm thấy nhóm tuỳ chọn.")
+			c.Redirect(http.StatusFound, adminModifiersPath)
+			return
+		}
+		h.renderForm(c, http.StatusUnprocessableEntity, form, h.serviceErrMessages(err))
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã cập nhật nhóm tuỳ chọn \"%s\".", form.Name))
+	c.Redirect(http.StatusFound, adminModifiersPath)
+}
+
+// Delete handles POST /admin/modifiers/:id/delete
+func (h *AdminModifierHandler) Delete(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, adminModifiersPath)
+		return
+	}
+
+	if err := h.modifierService.DeleteGroup(id); err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể xoá nhóm tuỳ chọn: "+err.Error())
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã xoá nhóm tuỳ chọn.")
+	}
+
+	c.Redirect(http.StatusFound, adminModifiersPath)
+}
+
+func (h *AdminModifierHandler) parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseForm reads the group fields, the option rows (parallel option_* arrays)
+// and the product/category multi-selects.
+func (h *AdminModifierHandler) parseForm(c *gin.Context) (modifierFormData, *dto.SaveModifierGroupRequest) {
+	minSelect, _ := strconv.Atoi(c.PostForm("min_select"))
+	maxSelect, _ := strconv.Atoi(c.PostForm("max_select"))
+	sortOrder, _ := strconv.Atoi(c.PostForm("sort_order"))
+	status := c.PostForm("status")
+	if status == "" {
+		status = "active"
+	}
+
+	form := modifierFormData{
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		MinSelect:   minSelect,
+		MaxSelect:   maxSelect,
+		SortOrder:   sortOrder,
+		Status:      status,
+		ProductIDs:  map[uint]bool{},
+		CategoryIDs: map[uint]bool{},
+	}
+
+	ids := c.PostFormArray("option_id")
+	names := c.PostFormArray("option_name")
+	prices := c.PostFormArray("option_price")
+	sorts := c.PostFormArray("option_sort_order")
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		opt := dto.ModifierOptionInput{Name: name}
+		if i < len(ids) {
+			if id, err := strconv.ParseUint(ids[i], 10, 32); err == nil {
+				opt.ID = uint(id)
+			}
+		}
+		if i < len(prices) {
+			opt.Price, _ = strconv.ParseFloat(strings.TrimSpace(prices[i]), 64)
+		}
+		if i < len(sorts) {
+			opt.SortOrder, _ = strconv.Atoi(sorts[i])
+		}
+		form.Options = append(form.Options, opt)
+	}
+
+	req := &dto.SaveModifierGroupRequest{
+		Name:      form.Name,
+		MinSelect: form.MinSelect,
+		MaxSelect: form.MaxSelect,
+		SortOrder: form.SortOrder,
+		Status:    form.Status,
+		Options:   form.Options,
+	}
+	for _, raw := range c.PostFormArray("product_ids") {
+		if id, err := strconv.ParseUint(raw, 10, 32); err == nil && id > 0 {
+			form.ProductIDs[uint(id)] = true
+			req.ProductIDs = append(req.ProductIDs, uint(id))
+		}
+	}
+	for _, raw := range c.PostFormArray("category_ids") {
+		if id, err := strconv.ParseUint(raw, 10, 32); err == nil && id > 0 {
+			form.CategoryIDs[uint(id)] = true
+			req.CategoryIDs = append(req.CategoryIDs, uint(id))
+		}
+	}
+
+	return form, req
+}
+
+func (h *AdminModifierHandler) serviceErrMessages(err error) []string {
+	switch {
+	case errors.Is(err, service.ErrModifierGroupNameRequired):
+		return []string{"Tên nhóm tuỳ chọn là bắt buộc."}
+	case errors.Is(err, service.ErrInvalidModifierGroup):
+		return []string{"Dữ liệu nhóm tuỳ chọn không hợp lệ: " + err.Error()}
+	default:
+		return []string{"Đã có lỗi xảy ra: " + err.Error()}
+	}
+}
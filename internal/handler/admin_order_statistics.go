@@ -60,6 +60,7 @@ func (h *AdminOrderStatisticsHandler) List(c *gin.Context) {
 			"Query":       q,
 			"Summary":     dto.AdminOrderStatisticsSummary{},
 			"Series":      []dto.AdminOrderStatisticsPoint{},
+			"TopProducts": []dto.AdminProductSales{},
 			"LabelsJSON":  template.JS("[]"),
 			"OrdersJSON":  template.JS("[]"),
 			"RevenueJSON": template.JS("[]"),
@@ -75,6 +76,7 @@ func (h *AdminOrderStatisticsHandler) List(c *gin.Context) {
 		"Query":       q,
 		"Summary":     result.Summary,
 		"Series":      result.Series,
+		"TopProducts": result.TopProducts,
 		"LabelsJSON":  labels,
 		"OrdersJSON":  orders,
 		"RevenueJSON": revenue,
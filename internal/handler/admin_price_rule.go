@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminPriceRuleMenu     = "price_rules"
+	adminPriceRuleTitle    = "Khuyến mãi"
+	adminPriceRulePath     = "/admin/price-rules"
+	adminPriceRuleFlashKey = "flash_price_rule"
+)
+
+// priceRuleStatusLabels are the Vietnamese names of the rule states
+var priceRuleStatusLabels = map[string]string{
+	service.PriceRuleScheduled: "Sắp diễn ra",
+	service.PriceRuleRunning:   "Đang chạy",
+	service.PriceRuleEnded:     "Đã kết thúc",
+}
+
+type AdminPriceRuleHandler struct {
+	pricingService  *service.PricingService
+	productService  *service.ProductService
+	categoryService *service.CategoryService
+	listTmpl        *template.Template
+}
+
+func NewAdminPriceRuleHandler(pricingService *service.PricingService, productService *service.ProductService, categoryService *service.CategoryService, funcMap template.FuncMap) *AdminPriceRuleHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminPriceRuleHandler{
+		pricingService:  pricingService,
+		productService:  productService,
+		categoryService: categoryService,
+		listTmpl: template.Must(
+			template.New("price_rule_list").Funcs(funcMap).ParseFiles(layout, "templates/admin/price_rules/list.html"),
+		),
+	}
+}
+
+func (h *AdminPriceRuleHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminPriceRuleHandler) setFlash(c *gin.Context, t, msg string) {
+	c.SetCookie(adminPriceRuleFlashKey, t+"|"+msg, 0, "/", "", false, true)
+}
+
+func (h *AdminPriceRuleHandler) getFlash(c *gin.Context) *flash {
+	val, err := c.Cookie(adminPriceRuleFlashKey)
+	if err != nil || val == "" {
+		return nil
+	}
+	c.SetCookie(adminPriceRuleFlashKey, "", -1, "/", "", false, true)
+	parts := strings.SplitN(val, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &flash{Type: parts[0], Message: parts[1]}
+}
+
+// priceRuleRow is a rule on the list page
+type priceRuleRow struct {
+	dto.PriceRuleResponse
+	StatusLabel string
+	IsPercent   bool
+}
+
+// List handles GET /admin/price-rules: the form for a new rule and every
+// rule, those ending last first
+func (h *AdminPriceRuleHandler) List(c *gin.Context) {
+	data := gin.H{
+		"Title":      adminPriceRuleTitle,
+		"ActiveMenu": adminPriceRuleMenu,
+		"KindFixed":  models.PriceRuleFixed,
+		"KindPct":    models.PriceRulePercent,
+	}
+
+	products, err := h.productService.ListBundleCandidates(0)
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh sách sản phẩm: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+	data["Products"] = products
+
+	categories, err := h.categoryService.Tree()
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh mục: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+	data["Categories"] = buildCategoryTreeRows(categories)
+
+	rules, err := h.pricingService.List()
+	if err != nil {
+		data["Flash"] = &flash{Type: flashTypeErr, Message: "Lỗi khi tải khuyến mãi: " + err.Error()}
+		h.render(c, http.StatusInternalServerError, h.listTmpl, data)
+		return
+	}
+	rows := make([]priceRuleRow, len(rules))
+	for i, r := range rules {
+		rows[i] = priceRuleRow{
+			PriceRuleResponse: r,
+			StatusLabel:       priceRuleStatusLabels[r.Status],
+			IsPercent:         r.Kind == models.PriceRulePercent,
+		}
+	}
+
+	data["Flash"] = h.getFlash(c)
+	data["Rules"] = rows
+	h.render(c, http.StatusOK, h.listTmpl, data)
+}
+
+// Create handles POST /admin/price-rules
+func (h *AdminPriceRuleHandler) Create(c *gin.Context) {
+	var req dto.PriceRuleRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.setFlash(c, flashTypeErr, "Dữ liệu không hợp lệ: "+err.Error())
+		c.Redirect(http.StatusFound, adminPriceRulePath)
+		return
+	}
+
+	rule, err := h.pricingService.Create(&req)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, priceRuleErrMessage(err))
+		c.Redirect(http.StatusFound, adminPriceRulePath)
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, "Đã tạo khuyến mãi \""+rule.Name+"\".")
+	c.Redirect(http.StatusFound, adminPriceRulePath)
+}
+
+// Delete handles POST /admin/price-rules/:id/delete
+func (h *AdminPriceRuleHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "ID không hợp lệ.")
+		c.Redirect(http.StatusFound, adminPriceRulePath)
+		return
+	}
+
+	if err := h.pricingService.Delete(uint(id)); err != nil {
+		h.setFlash(c, flashTypeErr, priceRuleErrMessage(err))
+		c.Redirect(http.StatusFound, adminPriceRulePath)
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, "Đã xoá khuyến mãi.")
+	c.Redirect(http.StatusFound, adminPriceRulePath)
+}
+
+// priceRuleErrMessage returns the flash message for a failed rule change
+func priceRuleErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidPriceRule):
+		detail := strings.TrimPrefix(err.Error(), service.ErrInvalidPriceRule.Error()+": ")
+		return "Khuyến mãi không hợp lệ (" + detail + ")."
+	case errors.Is(err, service.ErrPriceRuleNotFound):
+		return "Không tìm thấy khuyến mãi."
+	case errors.Is(err, service.ErrProductNotFound):
+		return "Không tìm thấy sản phẩm."
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return "Không tìm thấy danh mục."
+	default:
+		return "Không thể lưu khuyến mãi: " + err.Error()
+	}
+}
@@ -2,38 +2,60 @@ package handler
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/service"
 )
 
 type AdminProductHandler struct {
-	productService  *service.ProductService
-	categoryService *service.CategoryService
-	listTmpl        *template.Template
-	formTmpl        *template.Template
+	productService      *service.ProductService
+	categoryService     *service.CategoryService
+	availabilityService *service.AvailabilityService
+	translationService  *service.TranslationService
+	listTmpl            *template.Template
+	formTmpl            *template.Template
+	historyTmpl         *template.Template
+	// priceConfirmPercent is the price change, in percent of the old price,
+	// above which an edit has to be confirmed
+	priceConfirmPercent float64
 }
 
 func NewAdminProductHandler(
 	productService *service.ProductService,
 	categoryService *service.CategoryService,
+	availabilityService *service.AvailabilityService,
+	translationService *service.TranslationService,
+	priceConfirmPercent float64,
 	funcMap template.FuncMap,
 ) *AdminProductHandler {
 	layout := "templates/admin/layout.html"
+	if priceConfirmPercent <= 0 {
+		priceConfirmPercent = service.DefaultPriceConfirmPercent
+	}
 	return &AdminProductHandler{
-		productService:  productService,
-		categoryService: categoryService,
+		productService:      productService,
+		categoryService:     categoryService,
+		availabilityService: availabilityService,
+		translationService:  translationService,
+		priceConfirmPercent: priceConfirmPercent,
 		listTmpl: template.Must(
 			template.New("list").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/list.html"),
 		),
 		formTmpl: template.Must(
-			template.New("form").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/form.html"),
+			template.New("form").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/form.html", "templates/admin/availability.html", "templates/admin/translations.html"),
+		),
+		historyTmpl: template.Must(
+			template.New("history").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/history.html"),
 		),
 	}
 }
@@ -75,24 +97,34 @@ func (h *AdminProductHandler) loadCategories() []dto.CategoryResponse {
 	return cats
 }
 
-func (h *AdminProductHandler) List(c *gin.Context) {
-	page := 1
-	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
-		page = p
-	}
-
+// adminProductFilters reads the filters of the product list, shared by the
+// list page and its export
+func adminProductFilters(c *gin.Context) *dto.ProductListRequest {
 	req := &dto.ProductListRequest{
-		Page:     page,
-		PageSize: 15,
 		Classify: c.Query("classify"),
 		Search:   c.Query("search"),
 		Status:   c.Query("status"),
-		SortBy:   c.DefaultQuery("sort_by", "created_at"),
+		SortBy:   c.Query("sort_by"),
 		SortDir:  c.DefaultQuery("sort_dir", "desc"),
 	}
+	if req.SortBy == "" && req.Search == "" {
+		req.SortBy = "created_at"
+	}
 	if cid, err := strconv.ParseUint(c.Query("category_id"), 10, 32); err == nil {
 		req.Category = uint(cid)
 	}
+	return req
+}
+
+func (h *AdminProductHandler) List(c *gin.Context) {
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	req := adminProductFilters(c)
+	req.Page = page
+	req.PageSize = 15
 
 	result, err := h.productService.List(req)
 	if err != nil {
@@ -107,11 +139,12 @@ func (h *AdminProductHandler) List(c *gin.Context) {
 	products, _ := result.Items.([]dto.ProductResponse)
 
 	h.render(c, http.StatusOK, h.listTmpl, gin.H{
-		"Title":      "Sản phẩm",
-		"ActiveMenu": "products",
-		"Flash":      h.getFlash(c),
-		"Products":   products,
-		"Categories": h.loadCategories(),
+		"Title":       "Sản phẩm",
+		"ActiveMenu":  "products",
+		"Flash":       h.getFlash(c),
+		"Products":    products,
+		"Categories":  h.loadCategories(),
+		"ExportLinks": productExportLinks(c),
 		"Query": map[string]interface{}{
 			"Search":     req.Search,
 			"Classify":   req.Classify,
@@ -130,20 +163,29 @@ func (h *AdminProductHandler) List(c *gin.Context) {
 
 func (h *AdminProductHandler) New(c *gin.Context) {
 	h.render(c, http.StatusOK, h.formTmpl, gin.H{
-		"Title":      "Thêm sản phẩm",
-		"ActiveMenu": "products",
-		"Categories": h.loadCategories(),
+		"Title":        "Thêm sản phẩm",
+		"ActiveMenu":   "products",
+		"Categories":   h.loadCategories(),
+		"Translations": h.translationEditor("", "", nil),
+		"Dietary":      newDietaryEditorData(nil, nil, nil),
 	})
 }
 
+// translationEditor returns the locale tabs of the form
+func (h *AdminProductHandler) translationEditor(name, description string, values map[string]dto.TranslationInput) *translationEditorData {
+	return newTranslationEditorData(h.translationService, "VD: Cà phê sữa đá...", "Mô tả chi tiết sản phẩm...", name, description, values)
+}
+
 func (h *AdminProductHandler) Create(c *gin.Context) {
 	categoryID, _ := strconv.ParseUint(c.PostForm("category_id"), 10, 32)
 	price, _ := strconv.ParseFloat(c.PostForm("price"), 64)
 	stock, _ := strconv.Atoi(c.PostForm("stock"))
+	threshold, _ := strconv.Atoi(c.PostForm("low_stock_threshold"))
 	status := c.PostForm("status")
 	if status == "" {
 		status = "active"
 	}
+	nutrition, allergens, tags := parseDietaryForm(c)
 
 	req := &dto.CreateProductRequest{
 		CategoryID:  uint(categoryID),
@@ -154,36 +196,54 @@ func (h *AdminProductHandler) Create(c *gin.Context) {
 		Price:       price,
 		Stock:       stock,
 		Status:      status,
+
+		LowStockThreshold: max(threshold, 0),
+		Translations:      parseTranslationsForm(c, h.translationService),
+		Nutrition:         nutrition,
+		Allergens:         allergens,
+		DietaryTags:       tags,
 	}
 
 	imageURLs := h.parseImageURLs(c)
 
 	if req.Name == "" || req.CategoryID == 0 || req.Classify == "" {
 		h.render(c, http.StatusUnprocessableEntity, h.formTmpl, gin.H{
-			"Title":      "Thêm sản phẩm",
-			"ActiveMenu": "products",
-			"Categories": h.loadCategories(),
-			"Errors":     []string{"Tên, danh mục và phân loại là bắt buộc."},
-			"Form":       req,
-			"ImageURLs":  imageURLs,
+			"Title":        "Thêm sản phẩm",
+			"ActiveMenu":   "products",
+			"Categories":   h.loadCategories(),
+			"Errors":       []string{"Tên, danh mục và phân loại là bắt buộc."},
+			"Form":         req,
+			"Translations": h.translationEditor(req.Name, req.Description, req.Translations),
+			"Dietary":      newDietaryEditorData(nutrition, allergens, tags),
+			"ImageURLs":    imageURLs,
 		})
 		return
 	}
 
-	_, err := h.productService.Create(req, imageURLs)
+	created, err := h.productService.Create(req, imageURLs)
 	if err != nil {
 		errs := h.serviceErrMessages(err)
 		h.render(c, http.StatusUnprocessableEntity, h.formTmpl, gin.H{
-			"Title":      "Thêm sản phẩm",
-			"ActiveMenu": "products",
-			"Categories": h.loadCategories(),
-			"Errors":     errs,
-			"Form":       req,
-			"ImageURLs":  imageURLs,
+			"Title":        "Thêm sản phẩm",
+			"ActiveMenu":   "products",
+			"Categories":   h.loadCategories(),
+			"Errors":       errs,
+			"Form":         req,
+			"Translations": h.translationEditor(req.Name, req.Description, req.Translations),
+			"Dietary":      newDietaryEditorData(nutrition, allergens, tags),
+			"ImageURLs":    imageURLs,
 		})
 		return
 	}
 
+	if files := h.parseImageFiles(c); len(files) > 0 {
+		if _, err := h.productService.UploadImages(created.ID, files); err != nil {
+			h.setFlash(c, flashTypeErr, "Đã tạo sản phẩm nhưng không thể tải ảnh lên: "+h.serviceErrMessages(err)[0])
+			c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", created.ID))
+			return
+		}
+	}
+
 	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã tạo sản phẩm \"%s\" thành công.", req.Name))
 	c.Redirect(http.StatusFound, "/admin/products")
 }
@@ -201,15 +261,90 @@ func (h *AdminProductHandler) Edit(c *gin.Context) {
 		c.Redirect(http.StatusFound, "/admin/products")
 		return
 	}
+	translations, err := h.translationService.Get(models.TranslationEntityProduct, id)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Lỗi khi tải bản dịch: "+err.Error())
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
 
 	h.render(c, http.StatusOK, h.formTmpl, gin.H{
-		"Title":      "Sửa sản phẩm",
-		"ActiveMenu": "products",
-		"Categories": h.loadCategories(),
-		"Product":    product,
+		"Title":            "Sửa sản phẩm",
+		"ActiveMenu":       "products",
+		"Flash":            h.getFlash(c),
+		"Categories":       h.loadCategories(),
+		"Product":          product,
+		"Translations":     h.translationEditor(product.Name, derefString(product.Description), translations),
+		"Dietary":          newDietaryEditorData(product.Nutrition, product.DeclaredAllergens, product.DietaryTags),
+		"Availability":     h.availabilityEditor(id),
+		"BundleCandidates": h.bundleCandidates(id),
 	})
 }
 
+// SaveBundle handles POST /admin/products/:id/bundle
+func (h *AdminProductHandler) SaveBundle(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	ids := c.PostFormArray("component_id")
+	quantities := c.PostFormArray("component_quantity")
+	items := make([]dto.BundleItemInput, 0, len(ids))
+	for i, raw := range ids {
+		productID, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			continue
+		}
+		quantity, _ := strconv.Atoi(formValueAt(quantities, i))
+		items = append(items, dto.BundleItemInput{ProductID: uint(productID), Quantity: quantity})
+	}
+
+	if _, err := h.productService.SaveBundle(id, items); err != nil {
+		h.setFlash(c, flashTypeErr, h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã lưu combo.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+func (h *AdminProductHandler) bundleCandidates(productID uint) []dto.ProductResponse {
+	candidates, err := h.productService.ListBundleCandidates(productID)
+	if err != nil {
+		return nil
+	}
+	return candidates
+}
+
+// SaveAvailability handles POST /admin/products/:id/availability
+func (h *AdminProductHandler) SaveAvailability(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	if err := h.availabilityService.SaveProductSchedule(id, parseAvailabilityForm(c)); err != nil {
+		h.setFlash(c, flashTypeErr, availabilityErrMessage(err))
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã lưu lịch phục vụ.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+func (h *AdminProductHandler) availabilityEditor(productID uint) *availabilityEditorData {
+	schedule, err := h.availabilityService.GetProductSchedule(productID)
+	if err != nil {
+		return nil
+	}
+	return newAvailabilityEditorData(
+		fmt.Sprintf("/admin/products/%d/availability", productID),
+		"Để trống để dùng lịch của danh mục; không có lịch nào thì sản phẩm phục vụ cả ngày.",
+		h.availabilityService, schedule,
+	)
+}
+
 func (h *AdminProductHandler) Update(c *gin.Context) {
 	id, ok := h.parseIDParam(c)
 	if !ok {
@@ -226,6 +361,7 @@ func (h *AdminProductHandler) Update(c *gin.Context) {
 	name := strings.TrimSpace(c.PostForm("name"))
 	slug := strings.TrimSpace(c.PostForm("slug"))
 	desc := strings.TrimSpace(c.PostForm("description"))
+	nutrition, allergens, tags := parseDietaryForm(c)
 
 	req := &dto.UpdateProductRequest{
 		CategoryID:  &catIDUint,
@@ -236,6 +372,16 @@ func (h *AdminProductHandler) Update(c *gin.Context) {
 		Price:       &price,
 		Stock:       &stock,
 		Status:      &status,
+
+		Translations: parseTranslationsForm(c, h.translationService),
+		Nutrition:    nutrition,
+		Allergens:    allergens,
+		DietaryTags:  tags,
+	}
+	// Bundles have the field disabled and keep their threshold
+	if v, err := strconv.Atoi(c.PostForm("low_stock_threshold")); err == nil {
+		threshold := max(v, 0)
+		req.LowStockThreshold = &threshold
 	}
 
 	imageURLs := h.parseImageURLs(c)
@@ -248,23 +394,191 @@ func (h *AdminProductHandler) Update(c *gin.Context) {
 		return
 	}
 
-	_, err = h.productService.Update(id, req, imageURLs, replaceImages)
+	// A large price change is often a typo, so it is saved only once the
+	// admin resubmits the form with the same price
+	confirmed, _ := strconv.ParseFloat(c.PostForm("confirm_price"), 64)
+	if confirm := pendingPriceChange(product.RegularPrice(), price, h.priceConfirmPercent); confirm != nil && confirmed != price {
+		pending := *product
+		pending.CategoryID = catIDUint
+		pending.Name = name
+		pending.Slug = slug
+		pending.Description = &desc
+		pending.Classify = classify
+		pending.Price = price
+		pending.OriginalPrice = 0
+		pending.SaleEndsAt = nil
+		pending.Stock = stock
+		pending.Status = status
+		if req.LowStockThreshold != nil {
+			pending.LowStockThreshold = *req.LowStockThreshold
+		}
+		h.render(c, http.StatusOK, h.formTmpl, gin.H{
+			"Title":        "Sửa sản phẩm",
+			"ActiveMenu":   "products",
+			"Categories":   h.loadCategories(),
+			"Product":      &pending,
+			"Translations": h.translationEditor(name, desc, req.Translations),
+			"Dietary":      newDietaryEditorData(nutrition, allergens, tags),
+			"PriceConfirm": confirm,
+		})
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	_, err = h.productService.Update(adminID, id, req, imageURLs, replaceImages)
 	if err != nil {
 		errs := h.serviceErrMessages(err)
 		h.render(c, http.StatusUnprocessableEntity, h.formTmpl, gin.H{
-			"Title":      "Sửa sản phẩm",
-			"ActiveMenu": "products",
-			"Categories": h.loadCategories(),
-			"Errors":     errs,
-			"Product":    product,
+			"Title":        "Sửa sản phẩm",
+			"ActiveMenu":   "products",
+			"Categories":   h.loadCategories(),
+			"Errors":       errs,
+			"Product":      product,
+			"Translations": h.translationEditor(name, desc, req.Translations),
+			"Dietary":      newDietaryEditorData(nutrition, allergens, tags),
 		})
 		return
 	}
 
+	if files := h.parseImageFiles(c); len(files) > 0 {
+		if _, err := h.productService.UploadImages(id, files); err != nil {
+			h.setFlash(c, flashTypeErr, "Đã cập nhật sản phẩm nhưng không thể tải ảnh lên: "+h.serviceErrMessages(err)[0])
+			c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+			return
+		}
+	}
+
 	h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã cập nhật sản phẩm \"%s\".", name))
 	c.Redirect(http.StatusFound, "/admin/products")
 }
 
+// priceConfirmation asks the admin to confirm a large price change
+type priceConfirmation struct {
+	Old       float64
+	New       float64
+	Percent   float64
+	Threshold float64
+}
+
+// pendingPriceChange returns the confirmation a change from oldPrice to
+// newPrice needs, nil when it stays within threshold percent. Setting the
+// first price of a free product never needs one.
+func pendingPriceChange(oldPrice, newPrice, threshold float64) *priceConfirmation {
+	percent, large := service.LargePriceChange(oldPrice, newPrice, threshold)
+	if !large {
+		return nil
+	}
+	return &priceConfirmation{Old: oldPrice, New: newPrice, Percent: percent, Threshold: threshold}
+}
+
+// productRevisionView is a revision on the history page
+type productRevisionView struct {
+	dto.ProductRevisionResponse
+	Changes []productChangeView
+}
+
+// productChangeView is one changed field; prices are also kept as numbers
+// so the page formats them like everywhere else
+type productChangeView struct {
+	Label       string
+	Before      string
+	After       string
+	IsPrice     bool
+	BeforePrice float64
+	AfterPrice  float64
+}
+
+var productFieldLabels = map[string]string{
+	service.ProductFieldCategory:          "Danh mục",
+	service.ProductFieldName:              "Tên",
+	service.ProductFieldSlug:              "Slug",
+	service.ProductFieldDescription:       "Mô tả",
+	service.ProductFieldClassify:          "Phân loại",
+	service.ProductFieldPrice:             "Giá",
+	service.ProductFieldStatus:            "Trạng thái",
+	service.ProductFieldLowStockThreshold: "Ngưỡng cảnh báo tồn kho",
+	service.ProductFieldCalories:          "Năng lượng (kcal)",
+	service.ProductFieldSugarGrams:        "Đường (g)",
+	service.ProductFieldCaffeineMg:        "Caffeine (mg)",
+	service.ProductFieldAllergens:         "Chất gây dị ứng",
+	service.ProductFieldDietaryTags:       "Nhãn chế độ ăn",
+}
+
+// History handles GET /admin/products/:id/history
+func (h *AdminProductHandler) History(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	product, err := h.productService.GetByID(id)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Không tìm thấy sản phẩm.")
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+	revisions, err := h.productService.History(id)
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, h.historyTmpl, gin.H{
+			"Title":      "Lịch sử thay đổi",
+			"ActiveMenu": "products",
+			"Product":    product,
+			"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi tải lịch sử: " + err.Error()},
+		})
+		return
+	}
+
+	views := make([]productRevisionView, len(revisions))
+	for i, rev := range revisions {
+		views[i] = productRevisionView{ProductRevisionResponse: rev, Changes: make([]productChangeView, len(rev.Changes))}
+		for j, change := range rev.Changes {
+			label, ok := productFieldLabels[change.Field]
+			if !ok {
+				label = change.Field
+			}
+			views[i].Changes[j] = productChangeView{Label: label, Before: change.Before, After: change.After}
+			if change.Field == service.ProductFieldPrice {
+				views[i].Changes[j].IsPrice = true
+				views[i].Changes[j].BeforePrice, _ = strconv.ParseFloat(change.Before, 64)
+				views[i].Changes[j].AfterPrice, _ = strconv.ParseFloat(change.After, 64)
+			}
+		}
+	}
+
+	h.render(c, http.StatusOK, h.historyTmpl, gin.H{
+		"Title":      "Lịch sử thay đổi",
+		"ActiveMenu": "products",
+		"Flash":      h.getFlash(c),
+		"Product":    product,
+		"Revisions":  views,
+	})
+}
+
+// Rollback handles POST /admin/products/:id/revisions/:revision_id/rollback
+func (h *AdminProductHandler) Rollback(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+	historyURL := fmt.Sprintf("/admin/products/%d/history", id)
+	revisionID, err := strconv.ParseUint(c.Param("revision_id"), 10, 64)
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Phiên bản không hợp lệ.")
+		c.Redirect(http.StatusFound, historyURL)
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	if _, err := h.productService.Rollback(adminID, id, uint(revisionID)); err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể khôi phục: "+h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã khôi phục sản phẩm về trước phiên bản #%d.", revisionID))
+	}
+	c.Redirect(http.StatusFound, historyURL)
+}
+
 func (h *AdminProductHandler) Delete(c *gin.Context) {
 	id, ok := h.parseIDParam(c)
 	if !ok {
@@ -280,6 +594,107 @@ func (h *AdminProductHandler) Delete(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/admin/products")
 }
 
+// Reindex handles POST /admin/products/reindex
+func (h *AdminProductHandler) Reindex(c *gin.Context) {
+	n, err := h.productService.RebuildSearchIndex()
+	if err != nil {
+		h.setFlash(c, flashTypeErr, "Không thể dựng lại chỉ mục tìm kiếm: "+err.Error())
+	} else {
+		h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã dựng lại chỉ mục tìm kiếm cho %d sản phẩm.", n))
+	}
+	c.Redirect(http.StatusFound, "/admin/products")
+}
+
+// UploadImages handles POST /admin/products/:id/images
+func (h *AdminProductHandler) UploadImages(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	files := h.parseImageFiles(c)
+	if len(files) == 0 {
+		h.setFlash(c, flashTypeErr, "Vui lòng chọn ít nhất một ảnh.")
+	} else if _, err := h.productService.UploadImages(id, files); err != nil {
+		h.setFlash(c, flashTypeErr, h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, fmt.Sprintf("Đã tải lên %d ảnh.", len(files)))
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+// ReorderImages handles POST /admin/products/:id/images/reorder
+func (h *AdminProductHandler) ReorderImages(c *gin.Context) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	var imageIDs []uint
+	for _, raw := range c.PostFormArray("image_ids") {
+		imageID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			h.setFlash(c, flashTypeErr, h.serviceErrMessages(service.ErrInvalidImageOrder)[0])
+			c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+			return
+		}
+		imageIDs = append(imageIDs, uint(imageID))
+	}
+
+	if err := h.productService.ReorderImages(id, imageIDs); err != nil {
+		h.setFlash(c, flashTypeErr, h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã cập nhật thứ tự ảnh.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+// SetPrimaryImage handles POST /admin/products/:id/images/:image_id/primary
+func (h *AdminProductHandler) SetPrimaryImage(c *gin.Context) {
+	id, imageID, ok := h.parseImageParams(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	if err := h.productService.SetPrimaryImage(id, imageID); err != nil {
+		h.setFlash(c, flashTypeErr, h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã đặt ảnh chính.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+// DeleteImage handles POST /admin/products/:id/images/:image_id/delete
+func (h *AdminProductHandler) DeleteImage(c *gin.Context) {
+	id, imageID, ok := h.parseImageParams(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/products")
+		return
+	}
+
+	if err := h.productService.DeleteImage(id, imageID); err != nil {
+		h.setFlash(c, flashTypeErr, h.serviceErrMessages(err)[0])
+	} else {
+		h.setFlash(c, flashTypeOK, "Đã xoá ảnh.")
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/admin/products/%d/edit", id))
+}
+
+func (h *AdminProductHandler) parseImageParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	imageID, err := strconv.ParseUint(c.Param("image_id"), 10, 32)
+	if err != nil || imageID == 0 {
+		return 0, 0, false
+	}
+	return id, uint(imageID), true
+}
+
 func (h *AdminProductHandler) parseIDParam(c *gin.Context) (uint, bool) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil || id == 0 {
@@ -300,6 +715,22 @@ func (h *AdminProductHandler) parseImageURLs(c *gin.Context) []string {
 	return urls
 }
 
+// parseImageFiles returns the files selected in the "image_files" input,
+// skipping the empty part browsers send when nothing was chosen.
+func (h *AdminProductHandler) parseImageFiles(c *gin.Context) []*multipart.FileHeader {
+	form, err := c.MultipartForm()
+	if err != nil || form == nil {
+		return nil
+	}
+	var files []*multipart.FileHeader
+	for _, f := range form.File["image_files"] {
+		if f.Filename != "" && f.Size > 0 {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
 func (h *AdminProductHandler) serviceErrMessages(err error) []string {
 	switch {
 	case err == service.ErrProductNotFound:
@@ -308,6 +739,28 @@ func (h *AdminProductHandler) serviceErrMessages(err error) []string {
 		return []string{"Slug này đã được sử dụng."}
 	case err == service.ErrProductEmptySlug:
 		return []string{"Tên sản phẩm phải chứa ít nhất một ký tự chữ hoặc số."}
+	case errors.Is(err, service.ErrProductImageNotFound):
+		return []string{"Không tìm thấy ảnh."}
+	case errors.Is(err, service.ErrInvalidImageOrder):
+		return []string{"Thứ tự ảnh không hợp lệ, vui lòng tải lại trang."}
+	case errors.Is(err, service.ErrFileTooLarge):
+		return []string{"Ảnh vượt quá dung lượng cho phép."}
+	case errors.Is(err, service.ErrInvalidFileType):
+		return []string{"Định dạng ảnh không được hỗ trợ."}
+	case errors.Is(err, service.ErrImageTooLarge):
+		return []string{"Kích thước ảnh quá lớn."}
+	case errors.Is(err, service.ErrUploadUnavailable):
+		return []string{"Chức năng tải ảnh chưa được cấu hình."}
+	case errors.Is(err, service.ErrProductRevisionNotFound):
+		return []string{"Không tìm thấy phiên bản."}
+	case errors.Is(err, service.ErrProductCategoryDeleted):
+		return []string{"Danh mục của phiên bản này đã bị xoá."}
+	case errors.Is(err, service.ErrInvalidTranslation):
+		return []string{translationErrMessage(err)}
+	case errors.Is(err, service.ErrInvalidDietaryInfo):
+		return []string{dietaryErrMessage(err)}
+	case errors.Is(err, service.ErrInvalidBundle):
+		return []string{"Combo không hợp lệ (" + strings.TrimPrefix(err.Error(), service.ErrInvalidBundle.Error()+": ") + ")."}
 	default:
 		return []string{"Đã có lỗi xảy ra: " + err.Error()}
 	}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/internal/spreadsheet"
+)
+
+const (
+	adminProductImportTitle = "Nhập sản phẩm từ file"
+	adminProductImportPath  = "/admin/products/import"
+	// maxProductImportFileSize bounds uploaded import files
+	maxProductImportFileSize = 10 << 20
+)
+
+type AdminProductImportHandler struct {
+	importService *service.ProductImportService
+	importTmpl    *template.Template
+	jobTmpl       *template.Template
+}
+
+func NewAdminProductImportHandler(importService *service.ProductImportService, funcMap template.FuncMap) *AdminProductImportHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminProductImportHandler{
+		importService: importService,
+		importTmpl: template.Must(
+			template.New("product_import").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/import.html"),
+		),
+		jobTmpl: template.Must(
+			template.New("product_import_job").Funcs(funcMap).ParseFiles(layout, "templates/admin/products/import_job.html"),
+		),
+	}
+}
+
+func (h *AdminProductImportHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminProductImportHandler) renderImport(c *gin.Context, status int, data gin.H) {
+	data["Title"] = adminProductImportTitle
+	data["ActiveMenu"] = "products"
+	data["Columns"] = service.ProductSheetColumns
+	h.render(c, status, h.importTmpl, data)
+}
+
+// productExportLinks builds the export URLs for the filters of the current
+// product list
+func productExportLinks(c *gin.Context) map[string]string {
+	query := c.Request.URL.Query()
+	query.Del("page")
+	links := make(map[string]string, 2)
+	for _, format := range []string{spreadsheet.FormatCSV, spreadsheet.FormatXLSX} {
+		query.Set("format", format)
+		links[format] = "/admin/products/export?" + query.Encode()
+	}
+	return links
+}
+
+// Export downloads the filtered product list as CSV or XLSX
+func (h *AdminProductImportHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.String(http.StatusBadRequest, "Định dạng không được hỗ trợ: %s", format)
+		return
+	}
+
+	rows, err := h.importService.Export(adminProductFilters(c))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Lỗi xuất file: %v", err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, format, rows); err != nil {
+		c.String(http.StatusInternalServerError, "Lỗi xuất file: %v", err)
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), buf.Bytes())
+}
+
+// Form shows the upload form
+func (h *AdminProductImportHandler) Form(c *gin.Context) {
+	h.renderImport(c, http.StatusOK, gin.H{})
+}
+
+// Preview validates the uploaded file without saving anything
+func (h *AdminProductImportHandler) Preview(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		h.renderImport(c, http.StatusBadRequest, gin.H{"Flash": &flash{Type: flashTypeErr, Message: "Vui lòng chọn file CSV hoặc XLSX"}})
+		return
+	}
+	if fileHeader.Size > maxProductImportFileSize {
+		h.renderImport(c, http.StatusBadRequest, gin.H{"Flash": &flash{Type: flashTypeErr, Message: fmt.Sprintf("File vượt quá %d MB", maxProductImportFileSize>>20)}})
+		return
+	}
+	format, err := spreadsheet.FormatFromFilename(fileHeader.Filename)
+	if err != nil {
+		h.renderImport(c, http.StatusBadRequest, gin.H{"Flash": &flash{Type: flashTypeErr, Message: "Chỉ hỗ trợ file .csv và .xlsx"}})
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		h.renderImport(c, http.StatusInternalServerError, gin.H{"Flash": &flash{Type: flashTypeErr, Message: "Không đọc được file: " + err.Error()}})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxProductImportFileSize))
+	if err != nil {
+		h.renderImport(c, http.StatusInternalServerError, gin.H{"Flash": &flash{Type: flashTypeErr, Message: "Không đọc được file: " + err.Error()}})
+		return
+	}
+
+	preview, err := h.importService.Preview(data, format)
+	if err != nil {
+		h.renderImport(c, importErrStatus(err), gin.H{"Flash": &flash{Type: flashTypeErr, Message: importErrMessage(err)}})
+		return
+	}
+	h.renderImport(c, http.StatusOK, gin.H{"Preview": preview, "Filename": fileHeader.Filename})
+}
+
+// Apply imports a previewed file and shows the job's progress. Large price
+// changes in the preview are confirmed with the confirm_prices checkbox.
+func (h *AdminProductImportHandler) Apply(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+	job, err := h.importService.Apply(adminID, c.PostForm("token"), c.PostForm("confirm_prices") != "")
+	if err != nil {
+		h.renderImport(c, importErrStatus(err), gin.H{"Flash": &flash{Type: flashTypeErr, Message: importErrMessage(err)}})
+		return
+	}
+	c.Redirect(http.StatusFound, adminProductImportPath+"/jobs/"+url.PathEscape(job.ID))
+}
+
+// Job shows the progress of an import; the page reloads while it runs
+func (h *AdminProductImportHandler) Job(c *gin.Context) {
+	job, err := h.importService.GetJob(c.Param("id"))
+	if err != nil {
+		h.renderImport(c, http.StatusNotFound, gin.H{"Flash": &flash{Type: flashTypeErr, Message: "Không tìm thấy lượt nhập này"}})
+		return
+	}
+	h.render(c, http.StatusOK, h.jobTmpl, gin.H{
+		"Title":      adminProductImportTitle,
+		"ActiveMenu": "products",
+		"Job":        job,
+		"Running":    job.Status == service.ProductImportRunning,
+		"Percent":    job.Processed * 100 / max(job.Total, 1),
+	})
+}
+
+func importErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrImportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrImportInvalidFile),
+		errors.Is(err, service.ErrImportEmpty),
+		errors.Is(err, service.ErrImportTooManyRows),
+		errors.Is(err, service.ErrImportHasErrors),
+		errors.Is(err, service.ErrImportPriceUnconfirmed):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func importErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrImportNotFound):
+		return "Bản xem trước đã hết hạn hoặc đã được áp dụng, vui lòng tải file lên lại"
+	case errors.Is(err, service.ErrImportInvalidFile):
+		return "File không hợp lệ: " + err.Error()
+	case errors.Is(err, service.ErrImportEmpty):
+		return "File không có dòng sản phẩm nào"
+	case errors.Is(err, service.ErrImportTooManyRows):
+		return "File có quá nhiều dòng: " + err.Error()
+	case errors.Is(err, service.ErrImportHasErrors):
+		return "Dữ liệu đã thay đổi từ lúc xem trước, vui lòng tải file lên lại: " + err.Error()
+	case errors.Is(err, service.ErrImportPriceUnconfirmed):
+		return "File có thay đổi giá lớn chưa được xác nhận, vui lòng quay lại bản xem trước và đánh dấu xác nhận: " + err.Error()
+	}
+	return "Lỗi nhập file: " + err.Error()
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminRatingsMenu     = "ratings"
+	adminRatingsTitle    = "Đánh giá"
+	adminRatingsPath     = "/admin/ratings"
+	adminRatingsFlashKey = "flash_rating"
+)
+
+// AdminRatingHandler serves the review moderation queue
+type AdminRatingHandler struct {
+	ratingService *service.RatingService
+	listTmpl      *template.Template
+}
+
+func NewAdminRatingHandler(ratingService *service.RatingService, funcMap template.FuncMap) *AdminRatingHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminRatingHandler{
+		ratingService: ratingService,
+		listTmpl: template.Must(
+			template.New("rating_list").Funcs(funcMap).ParseFiles(layout, "templates/admin/ratings/list.html"),
+		),
+	}
+}
+
+func (h *AdminRatingHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminRatingHandler) setFlash(c *gin.Context, t, msg string) {
+	c.SetCookie(adminRatingsFlashKey, t+"|"+msg, 0, "/", "", false, true)
+}
+
+func (h *AdminRatingHandler) getFlash(c *gin.Context) *flash {
+	val, err := c.Cookie(adminRatingsFlashKey)
+	if err != nil || val == "" {
+		return nil
+	}
+	c.SetCookie(adminRatingsFlashKey, "", -1, "/", "", false, true)
+	parts := strings.SplitN(val, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &flash{Type: parts[0], Message: parts[1]}
+}
+
+// List shows the flagged ratings unless another filter is picked
+func (h *AdminRatingHandler) List(c *gin.Context) {
+	q := dto.AdminRatingListRequest{
+		Page:     1,
+		PageSize: 15,
+		Status:   strings.TrimSpace(c.DefaultQuery("status", "flagged")),
+		Search:   strings.TrimSpace(c.Query("search")),
+	}
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		q.Page = p
+	}
+
+	result, err := h.ratingService.ListForAdmin(&q)
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, h.listTmpl, gin.H{
+			"Title":      adminRatingsTitle,
+			"ActiveMenu": adminRatingsMenu,
+			"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi khi tải danh sách đánh giá: " + err.Error()},
+		})
+		return
+	}
+
+	ratings, _ := result.Items.([]dto.AdminRatingResponse)
+	h.render(c, http.StatusOK, h.listTmpl, gin.H{
+		"Title":      adminRatingsTitle,
+		"ActiveMenu": adminRatingsMenu,
+		"Flash":      h.getFlash(c),
+		"Ratings":    ratings,
+		"Query":      q,
+		"Pagination": paginationData{
+			Page:       q.Page,
+			TotalPages: result.TotalPages,
+			Total:      result.Total,
+			Pages:      buildPages(q.Page, result.TotalPages),
+		},
+	})
+}
+
+func (h *AdminRatingHandler) Hide(c *gin.Context) {
+	h.moderate(c, h.ratingService.HideForAdmin, "Đã ẩn đánh giá #%d.")
+}
+
+func (h *AdminRatingHandler) Unhide(c *gin.Context) {
+	h.moderate(c, h.ratingService.UnhideForAdmin, "Đã hiển thị đánh giá #%d.")
+}
+
+func (h *AdminRatingHandler) Delete(c *gin.Context) {
+	h.moderate(c, h.ratingService.DeleteForAdmin, "Đã xoá đánh giá #%d.")
+}
+
+func (h *AdminRatingHandler) Reply(c *gin.Context) {
+	reply := c.PostForm("reply")
+	if len([]rune(reply)) > 2000 {
+		h.setFlash(c, flashTypeErr, "Phản hồi tối đa 2000 ký tự.")
+		c.Redirect(http.StatusFound, h.returnPath(c))
+		return
+	}
+	h.moderate(c, func(id uint) error {
+		return h.ratingService.ReplyForAdmin(id, reply)
+	}, "Đã lưu phản hồi cho đánh giá #%d.")
+}
+
+func (h *AdminRatingHandler) moderate(c *gin.Context, action func(id uint) error, done string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		h.setFlash(c, flashTypeErr, "ID đánh giá không hợp lệ.")
+		c.Redirect(http.StatusFound, adminRatingsPath)
+		return
+	}
+
+	if err := action(uint(id)); err != nil {
+		msg := "Không thể cập nhật đánh giá: " + err.Error()
+		if errors.Is(err, service.ErrRatingNotFound) {
+			msg = "Không tìm thấy đánh giá."
+		}
+		h.setFlash(c, flashTypeErr, msg)
+		c.Redirect(http.StatusFound, h.returnPath(c))
+		return
+	}
+
+	h.setFlash(c, flashTypeOK, fmt.Sprintf(done, id))
+	c.Redirect(http.StatusFound, h.returnPath(c))
+}
+
+// returnPath goes back to the filtered list the action was posted from
+func (h *AdminRatingHandler) returnPath(c *gin.Context) string {
+	if query := c.PostForm("return_query"); query != "" && !strings.ContainsAny(query, "/\\") {
+		return adminRatingsPath + "?" + query
+	}
+	return adminRatingsPath
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminSearchMenu  = "searches"
+	adminSearchTitle = "Từ khoá tìm kiếm"
+)
+
+type AdminSearchHandler struct {
+	searchService *service.SearchService
+	insightsTmpl  *template.Template
+}
+
+func NewAdminSearchHandler(searchService *service.SearchService, funcMap template.FuncMap) *AdminSearchHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminSearchHandler{
+		searchService: searchService,
+		insightsTmpl: template.Must(
+			template.New("search_insights").Funcs(funcMap).ParseFiles(layout, "templates/admin/searches/insights.html"),
+		),
+	}
+}
+
+func (h *AdminSearchHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// Insights handles GET /admin/searches: top searches and searches without results
+func (h *AdminSearchHandler) Insights(c *gin.Context) {
+	q := dto.AdminSearchInsightsRequest{Days: 30}
+	if d, err := strconv.Atoi(c.Query("days")); err == nil {
+		switch d {
+		case 1, 7, 30, 90:
+			q.Days = d
+		}
+	}
+
+	result, err := h.searchService.GetInsightsForAdmin(&q)
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, h.insightsTmpl, gin.H{
+			"Title":      adminSearchTitle,
+			"ActiveMenu": adminSearchMenu,
+			"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi khi tải thống kê tìm kiếm: " + err.Error()},
+			"Query":      q,
+			"Insights":   &dto.AdminSearchInsightsResponse{Days: q.Days},
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, h.insightsTmpl, gin.H{
+		"Title":      adminSearchTitle,
+		"ActiveMenu": adminSearchMenu,
+		"Query":      q,
+		"Insights":   result,
+	})
+}
@@ -62,6 +62,13 @@ func (h *AdminSuggestionHandler) getFlash(c *gin.Context) *flash {
 	return &flash{Type: parts[0], Message: parts[1]}
 }
 
+// suggestionRow is a suggestion on the list page
+type suggestionRow struct {
+	dto.SuggestionResponse
+	AllergenNames string
+	Tags          string
+}
+
 func (h *AdminSuggestionHandler) List(c *gin.Context) {
 	q := dto.AdminSuggestionListRequest{
 		Page:     1,
@@ -86,9 +93,14 @@ func (h *AdminSuggestionHandler) List(c *gin.Context) {
 		return
 	}
 
-	suggestions, ok := result.Items.([]dto.SuggestionResponse)
-	if !ok {
-		suggestions = []dto.SuggestionResponse{}
+	items, _ := result.Items.([]dto.SuggestionResponse)
+	suggestions := make([]suggestionRow, len(items))
+	for i, item := range items {
+		suggestions[i] = suggestionRow{
+			SuggestionResponse: item,
+			AllergenNames:      strings.Join(allergenNames(item.Allergens), ", "),
+			Tags:               strings.Join(item.DietaryTags, ", "),
+		}
 	}
 
 	h.render(c, http.StatusOK, h.listTmpl, gin.H{
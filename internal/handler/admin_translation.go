@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+// localeLabels are the tab titles of the locales on the admin forms
+var localeLabels = map[string]string{
+	"vi": "Tiếng Việt",
+	"en": "English",
+}
+
+// translationEditorData feeds the locale tabs of templates/admin/translations.html.
+// The default locale's tab edits the record's own name and description.
+type translationEditorData struct {
+	NamePlaceholder        string
+	DescriptionPlaceholder string
+	Tabs                   []translationTab
+}
+
+type translationTab struct {
+	Locale      string
+	Label       string
+	Default     bool
+	Name        string
+	Description string
+}
+
+// newTranslationEditorData builds one tab per supported locale, name and
+// description being the default locale's text
+func newTranslationEditorData(translations *service.TranslationService, namePlaceholder, descriptionPlaceholder, name, description string, values map[string]dto.TranslationInput) *translationEditorData {
+	locales := translations.Locales()
+	tabs := make([]translationTab, len(locales))
+	for i, locale := range locales {
+		label, ok := localeLabels[locale]
+		if !ok {
+			label = strings.ToUpper(locale)
+		}
+		tabs[i] = translationTab{Locale: locale, Label: label, Default: i == 0}
+		if i == 0 {
+			tabs[i].Name, tabs[i].Description = name, description
+		} else {
+			tabs[i].Name, tabs[i].Description = values[locale].Name, values[locale].Description
+		}
+	}
+	return &translationEditorData{
+		NamePlaceholder:        namePlaceholder,
+		DescriptionPlaceholder: descriptionPlaceholder,
+		Tabs:                   tabs,
+	}
+}
+
+// parseTranslationsForm reads the non-default locale tabs of a submitted form.
+// Every tab is returned, so clearing a field removes its translation.
+func parseTranslationsForm(c *gin.Context, translations *service.TranslationService) map[string]dto.TranslationInput {
+	locales := translations.Locales()[1:]
+	if len(locales) == 0 {
+		return nil
+	}
+	values := make(map[string]dto.TranslationInput, len(locales))
+	for _, locale := range locales {
+		values[locale] = dto.TranslationInput{
+			Name:        strings.TrimSpace(c.PostForm("translations[" + locale + "][name]")),
+			Description: strings.TrimSpace(c.PostForm("translations[" + locale + "][description]")),
+		}
+	}
+	return values
+}
+
+// translationErrMessage describes an invalid translation on the admin forms
+func translationErrMessage(err error) string {
+	return "Bản dịch không hợp lệ (" + strings.TrimPrefix(err.Error(), service.ErrInvalidTranslation.Error()+": ") + ")."
+}
+
+// derefString is the template deref helper for handlers
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
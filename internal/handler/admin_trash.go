@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	adminTrashMenu     = "trash"
+	adminTrashTitle    = "Thùng rác"
+	adminTrashPath     = "/admin/trash"
+	adminTrashFlashKey = "flash_trash"
+)
+
+// AdminTrashHandler lists soft-deleted categories and products, and restores
+// or permanently deletes them
+type AdminTrashHandler struct {
+	categoryService *service.CategoryService
+	productService  *service.ProductService
+	listTmpl        *template.Template
+}
+
+func NewAdminTrashHandler(categoryService *service.CategoryService, productService *service.ProductService, funcMap template.FuncMap) *AdminTrashHandler {
+	layout := "templates/admin/layout.html"
+	return &AdminTrashHandler{
+		categoryService: categoryService,
+		productService:  productService,
+		listTmpl: template.Must(
+			template.New("trash").Funcs(funcMap).ParseFiles(layout, "templates/admin/trash.html"),
+		),
+	}
+}
+
+func (h *AdminTrashHandler) render(c *gin.Context, status int, tmpl *template.Template, data gin.H) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		_ = c.Error(err)
+		c.String(http.StatusInternalServerError, "Template error: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *AdminTrashHandler) setFlash(c *gin.Context, t, msg string) {
+	c.SetCookie(adminTrashFlashKey, t+"|"+msg, 0, "/", "", false, true)
+}
+
+func (h *AdminTrashHandler) getFlash(c *gin.Context) *flash {
+	val, err := c.Cookie(adminTrashFlashKey)
+	if err != nil || val == "" {
+		return nil
+	}
+	c.SetCookie(adminTrashFlashKey, "", -1, "/", "", false, true)
+	parts := strings.SplitN(val, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &flash{Type: parts[0], Message: parts[1]}
+}
+
+// List handles GET /admin/trash
+func (h *AdminTrashHandler) List(c *gin.Context) {
+	categories, err := h.categoryService.ListDeleted()
+	var products []dto.TrashedProductResponse
+	if err == nil {
+		products, err = h.productService.ListDeleted()
+	}
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, h.listTmpl, gin.H{
+			"Title":      adminTrashTitle,
+			"ActiveMenu": adminTrashMenu,
+			"Flash":      &flash{Type: flashTypeErr, Message: "Lỗi khi tải thùng rác: " + err.Error()},
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, h.listTmpl, gin.H{
+		"Title":      adminTrashTitle,
+		"ActiveMenu": adminTrashMenu,
+		"Flash":      h.getFlash(c),
+		"Categories": categories,
+		"Products":   products,
+	})
+}
+
+// RestoreCategory handles POST /admin/trash/categories/:id/restore
+func (h *AdminTrashHandler) RestoreCategory(c *gin.Context) {
+	h.act(c, h.categoryService.Restore, "Đã khôi phục danh mục.")
+}
+
+// PurgeCategory handles POST /admin/trash/categories/:id/purge
+func (h *AdminTrashHandler) PurgeCategory(c *gin.Context) {
+	h.act(c, h.categoryService.Purge, "Đã xoá vĩnh viễn danh mục.")
+}
+
+// RestoreProduct handles POST /admin/trash/products/:id/restore
+func (h *AdminTrashHandler) RestoreProduct(c *gin.Context) {
+	h.act(c, h.productService.Restore, "Đã khôi phục sản phẩm.")
+}
+
+// PurgeProduct handles POST /admin/trash/products/:id/purge
+func (h *AdminTrashHandler) PurgeProduct(c *gin.Context) {
+	h.act(c, h.productService.Purge, "Đã xoá vĩnh viễn sản phẩm.")
+}
+
+// act runs action on the :id param and redirects back to the trash
+func (h *AdminTrashHandler) act(c *gin.Context, action func(uint) error, done string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.Redirect(http.StatusFound, adminTrashPath)
+		return
+	}
+	if err := action(uint(id)); err != nil {
+		h.setFlash(c, flashTypeErr, trashErrMessage(err))
+	} else {
+		h.setFlash(c, flashTypeOK, done)
+	}
+	c.Redirect(http.StatusFound, adminTrashPath)
+}
+
+func trashErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return "Không tìm thấy danh mục trong thùng rác."
+	case errors.Is(err, service.ErrProductNotFound):
+		return "Không tìm thấy sản phẩm trong thùng rác."
+	case errors.Is(err, service.ErrCategoryHasProducts):
+		return "Danh mục vẫn còn sản phẩm (kể cả trong thùng rác), hãy xoá vĩnh viễn hoặc chuyển chúng trước."
+	case errors.Is(err, service.ErrProductCategoryDeleted):
+		return "Danh mục của sản phẩm đang bị xoá, hãy khôi phục danh mục trước."
+	case errors.Is(err, service.ErrProductHasOrders):
+		return "Sản phẩm đã có trong đơn hàng nên không thể xoá vĩnh viễn."
+	default:
+		return "Đã có lỗi xảy ra: " + err.Error()
+	}
+}
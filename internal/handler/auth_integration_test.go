@@ -58,7 +58,7 @@ func newAuthHandlerRouter(t *testing.T, db *gorm.DB) (*gin.Engine, *service.Auth
 	userRepo := repository.NewUserRepository(db)
 	cartRepo := repository.NewCartRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	cartSvc := service.NewCartService(cartRepo, productRepo)
+	cartSvc := service.NewCartService(cartRepo, productRepo, nil, nil, nil)
 	authSvc := service.NewAuthService(userRepo, cartSvc, jwtCfg)
 	h := NewAuthHandler(authSvc)
 	authMW := middleware.NewAuthMiddleware(authSvc)
@@ -66,6 +66,7 @@ func (h *CartHandler) Get(c *gin.Context) {
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "product_unavailable: outside the product's serving hours"
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/cart/items [post]
 func (h *CartHandler) Add(c *gin.Context) {
@@ -188,6 +189,98 @@ func (h *CartHandler) Remove(c *gin.Context) {
 	c.JSON(http.StatusOK, cart)
 }
 
+// UpdateLine godoc
+// @Summary Update cart line quantity
+// @Description Update quantity for a single cart line, e.g. a product with a specific set of modifiers
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path int true "Cart item ID"
+// @Param request body dto.UpdateCartItemRequest true "Update cart item request"
+// @Success 200 {object} dto.CartResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/cart/lines/{item_id} [put]
+func (h *CartHandler) UpdateLine(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Authentication required",
+		})
+		return
+	}
+
+	itemID, ok := parsePositiveUintParam(c.Param("item_id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_item_id",
+			Message: "Invalid cart item ID",
+		})
+		return
+	}
+
+	var req dto.UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid request: quantity is required and must be at least 1",
+		})
+		return
+	}
+
+	cart, err := h.cartService.UpdateLine(userID, uint(itemID), req.Quantity)
+	if err != nil {
+		h.handleCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// RemoveLine godoc
+// @Summary Remove cart line
+// @Description Remove a single cart line from current user cart
+// @Tags cart
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path int true "Cart item ID"
+// @Success 200 {object} dto.CartResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/cart/lines/{item_id} [delete]
+func (h *CartHandler) RemoveLine(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Authentication required",
+		})
+		return
+	}
+
+	itemID, ok := parsePositiveUintParam(c.Param("item_id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_item_id",
+			Message: "Invalid cart item ID",
+		})
+		return
+	}
+
+	cart, err := h.cartService.RemoveLine(userID, uint(itemID))
+	if err != nil {
+		h.handleCartError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
 // Clear godoc
 // @Summary Clear current user cart
 // @Description Remove all items in current user cart
@@ -238,8 +331,12 @@ func (h *CartHandler) handleCartError(c *gin.Context, err error) {
 			message = err.Error()
 		}
 		respond(http.StatusBadRequest, "insufficient_stock", message)
+	case errors.Is(err, service.ErrProductUnavailable):
+		respond(http.StatusConflict, "product_unavailable", err.Error())
 	case errors.Is(err, service.ErrInvalidQuantity):
 		respond(http.StatusBadRequest, "invalid_quantity", "Quantity must be at least 1")
+	case errors.Is(err, service.ErrInvalidModifierSelection):
+		respond(http.StatusBadRequest, "invalid_modifiers", err.Error())
 	case errors.Is(err, service.ErrCartItemNotFound):
 		respond(http.StatusNotFound, "cart_item_not_found", "Item not found in cart")
 	case errors.Is(err, service.ErrCartNotFound):
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,6 +35,9 @@ func newCartHandlerTestDB(t *testing.T) *gorm.DB {
 		&models.ProductImage{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.ModifierGroup{},
+		&models.ModifierOption{},
+		&models.ModifierGroupAssignment{},
 	); err != nil {
 		t.Fatalf("cart handler migrate: %v", err)
 	}
@@ -47,7 +51,8 @@ func setupCartHandlerRouter(t *testing.T) (*gin.Engine, *gorm.DB, *service.AuthS
 	userRepo := repository.NewUserRepository(db)
 	productRepo := repository.NewProductRepository(db)
 	cartRepo := repository.NewCartRepository(db)
-	cartSvc := service.NewCartService(cartRepo, productRepo)
+	modifierRepo := repository.NewModifierRepository(db)
+	cartSvc := service.NewCartService(cartRepo, productRepo, modifierRepo, nil, nil)
 	authSvc := service.NewAuthService(userRepo, cartSvc, &config.JWTConfig{Secret: "cart-handler-secret", Expiration: time.Hour})
 	authMW := middleware.NewAuthMiddleware(authSvc)
 
@@ -60,6 +65,8 @@ func setupCartHandlerRouter(t *testing.T) (*gin.Engine, *gorm.DB, *service.AuthS
 	group.POST("/cart/items", cartHandler.Add)
 	group.PUT("/cart/items/:product_id", cartHandler.Update)
 	group.DELETE("/cart/items/:product_id", cartHandler.Remove)
+	group.PUT("/cart/lines/:item_id", cartHandler.UpdateLine)
+	group.DELETE("/cart/lines/:item_id", cartHandler.RemoveLine)
 	group.DELETE("/cart", cartHandler.Clear)
 	return r, db, authSvc
 }
@@ -190,6 +197,72 @@ func TestCartHandler_AddValidationAndInvalidPath(t *testing.T) {
 	}
 }
 
+func TestCartHandler_ModifierLines(t *testing.T) {
+	t.Parallel()
+	r, db, authSvc := setupCartHandlerRouter(t)
+	_, token := seedCartUserAndToken(t, db, authSvc, "cart-modifiers@example.com")
+	product := seedCartProduct(t, db, "cart-modifier-product", 10)
+	authHeader := "Bearer " + token
+
+	group := &models.ModifierGroup{Name: "Size", MinSelect: 1, MaxSelect: 1}
+	if err := db.Create(group).Error; err != nil {
+		t.Fatalf("create modifier group: %v", err)
+	}
+	option := &models.ModifierOption{GroupID: group.ID, Name: "L", Price: 5000}
+	if err := db.Create(option).Error; err != nil {
+		t.Fatalf("create modifier option: %v", err)
+	}
+	if err := db.Create(&models.ModifierGroupAssignment{GroupID: group.ID, ProductID: &product.ID}).Error; err != nil {
+		t.Fatalf("create modifier assignment: %v", err)
+	}
+
+	wMissing := httptest.NewRecorder()
+	reqMissing := httptest.NewRequest(http.MethodPost, "/cart/items", bytes.NewBufferString(fmt.Sprintf(`{"product_id":%d,"quantity":1}`, product.ID)))
+	reqMissing.Header.Set("Authorization", authHeader)
+	reqMissing.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(wMissing, reqMissing)
+	if wMissing.Code != http.StatusBadRequest || !strings.Contains(wMissing.Body.String(), "invalid_modifiers") {
+		t.Fatalf("missing modifier status = %d body = %s, want 400 invalid_modifiers", wMissing.Code, wMissing.Body)
+	}
+
+	addBody := fmt.Sprintf(`{"product_id":%d,"quantity":2,"modifier_option_ids":[%d]}`, product.ID, option.ID)
+	wAdd := httptest.NewRecorder()
+	reqAdd := httptest.NewRequest(http.MethodPost, "/cart/items", bytes.NewBufferString(addBody))
+	reqAdd.Header.Set("Authorization", authHeader)
+	reqAdd.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(wAdd, reqAdd)
+	if wAdd.Code != http.StatusOK {
+		t.Fatalf("add status = %d, want 200: %s", wAdd.Code, wAdd.Body)
+	}
+	var cart struct {
+		Items []struct {
+			ID        uint    `json:"id"`
+			UnitPrice float64 `json:"unit_price"`
+		} `json:"items"`
+	}
+	json.Unmarshal(wAdd.Body.Bytes(), &cart)
+	if len(cart.Items) != 1 || cart.Items[0].UnitPrice != 25000 {
+		t.Fatalf("unexpected cart items: %+v", cart.Items)
+	}
+
+	wUpdate := httptest.NewRecorder()
+	reqUpdate := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/cart/lines/%d", cart.Items[0].ID), bytes.NewBufferString(`{"quantity":3}`))
+	reqUpdate.Header.Set("Authorization", authHeader)
+	reqUpdate.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(wUpdate, reqUpdate)
+	if wUpdate.Code != http.StatusOK {
+		t.Fatalf("update line status = %d, want 200: %s", wUpdate.Code, wUpdate.Body)
+	}
+
+	wRemove := httptest.NewRecorder()
+	reqRemove := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/cart/lines/%d", cart.Items[0].ID), nil)
+	reqRemove.Header.Set("Authorization", authHeader)
+	r.ServeHTTP(wRemove, reqRemove)
+	if wRemove.Code != http.StatusOK {
+		t.Fatalf("remove line status = %d, want 200: %s", wRemove.Code, wRemove.Body)
+	}
+}
+
 func TestParsePositiveUintParam(t *testing.T) {
 	t.Parallel()
 
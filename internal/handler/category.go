@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/httpcache"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+type CategoryHandler struct {
+	categoryService    *service.CategoryService
+	productService     *service.ProductService
+	translationService *service.TranslationService
+	cache              *httpcache.Cache
+}
+
+// NewCategoryHandler creates a CategoryHandler. Responses are versioned by
+// the whole catalog since product counts follow product changes; cache may
+// be nil.
+func NewCategoryHandler(categoryService *service.CategoryService, productService *service.ProductService, translationService *service.TranslationService, cache *httpcache.Cache) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService, productService: productService, translationService: translationService, cache: cache}
+}
+
+// List godoc
+// @Summary List categories
+// @Description Public API list active categories ordered by sort order, with their count of active products
+// @Tags categories
+// @Produce json
+// @Param preview query int false "Number of top-rated products to include per category (0-12)" default(0)
+// @Param lang query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales; the default locale is used when none is supported"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} dto.CategoryBrowseListResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /api/v1/categories [get]
+func (h *CategoryHandler) List(c *gin.Context) {
+	var req dto.CategoryBrowseRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_params",
+			Message: "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	locale := responseLocale(c, h.translationService)
+	h.respond(c, fmt.Sprintf("categories/%s?preview=%d", locale, req.Preview), func() (interface{}, error) {
+		items, err := h.categoryService.ListActive(req.Preview, locale)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.CategoryBrowseListResponse{Items: items}, nil
+	})
+}
+
+// GetBySlug godoc
+// @Summary Get category detail
+// @Description Public API get an active category by slug, with its count of active products
+// @Tags categories
+// @Produce json
+// @Param slug    path  string true  "Category slug"
+// @Param preview query int    false "Number of top-rated products to include (0-12)" default(0)
+// @Param lang    query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales; the default locale is used when none is supported"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} dto.CategoryBrowseResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/v1/categories/{slug} [get]
+func (h *CategoryHandler) GetBySlug(c *gin.Context) {
+	slug := strings.TrimSpace(c.Param("slug"))
+	var req dto.CategoryBrowseRequest
+	if err := c.ShouldBindQuery(&req); err != nil || slug == "" {
+		message := "Slug is required"
+		if err != nil {
+			message = "Invalid query parameters: " + err.Error()
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_params",
+			Message: message,
+		})
+		return
+	}
+
+	locale := responseLocale(c, h.translationService)
+	h.respond(c, fmt.Sprintf("category/%s/%s?preview=%d", locale, slug, req.Preview), func() (interface{}, error) {
+		return h.categoryService.GetActiveBySlug(slug, req.Preview, locale)
+	})
+}
+
+// respond serves a cached response. The entry is keyed by the schedule epoch
+// as well because previewed products carry available_now and sale prices.
+func (h *CategoryHandler) respond(c *gin.Context, key string, load func() (interface{}, error)) {
+	epoch, err := h.productService.ScheduleEpoch()
+	if err != nil {
+		log.Printf("Category error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "internal_error",
+			Message: "An unexpected error occurred",
+		})
+		return
+	}
+	key = fmt.Sprintf("%s@%d", key, epoch.Unix())
+	entry, err := h.cache.Load(key, func() (*httpcache.Entry, error) {
+		// Read the version first so a concurrent change can only make it older
+		version, err := h.productService.ListVersion()
+		if err != nil {
+			return nil, err
+		}
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return &httpcache.Entry{
+			Value:        value,
+			ETag:         httpcache.WeakETag(key, version.Tag),
+			LastModified: version.LastModified,
+		}, nil
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error:   "category_not_found",
+				Message: "Category not found",
+			})
+			return
+		}
+		log.Printf("Category error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "internal_error",
+			Message: "An unexpected error occurred",
+		})
+		return
+	}
+
+	httpcache.Respond(c, entry, h.cache.MaxAge())
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/httpcache"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+func TestCategoryHandler_BrowseCountsPreviewAndCache(t *testing.T) {
+	t.Parallel()
+	db := newProductTestDB(t)
+
+	foods := &models.Category{Name: "Foods", Slug: "foods-browse", SortOrder: 1}
+	drinks := &models.Category{Name: "Drinks", Slug: "drinks-browse", SortOrder: 2}
+	hidden := &models.Category{Name: "Hidden", Slug: "hidden-browse", Status: models.CategoryStatusInactive}
+	for _, c := range []*models.Category{drinks, foods, hidden} {
+		db.Create(c)
+	}
+	db.Create(&models.Product{CategoryID: foods.ID, Name: "Pho", Slug: "pho-browse", Classify: "food", Price: 50000, Stock: 5, Status: "active", RatingAverage: 4.5})
+	db.Create(&models.Product{CategoryID: foods.ID, Name: "Bun", Slug: "bun-browse", Classify: "food", Price: 40000, Stock: 5, Status: "active", RatingAverage: 3})
+	db.Create(&models.Product{CategoryID: foods.ID, Name: "Com", Slug: "com-browse", Classify: "food", Price: 35000, Stock: 5, Status: "active", RatingAverage: 5})
+	db.Create(&models.Product{CategoryID: foods.ID, Name: "Xoi", Slug: "xoi-browse", Classify: "food", Price: 20000, Stock: 5, Status: "inactive", RatingAverage: 5})
+	db.Create(&models.Product{CategoryID: drinks.ID, Name: "Tra", Slug: "tra-browse", Classify: "drink", Price: 10000, Stock: 5, Status: "active"})
+
+	cache := httpcache.New(time.Minute, 10, 30*time.Second)
+	categoryRepo := repository.NewCategoryRepository(db)
+	products := service.NewProductService(repository.NewProductRepository(db), categoryRepo, nil, "http://test.local", "", service.NewCursorCodec("test-secret"), nil, nil, nil, nil, nil)
+	categories := service.NewCategoryService(categoryRepo, nil, products, nil, cache)
+	h := NewCategoryHandler(categories, products, nil, cache)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/categories", h.List)
+	r.GET("/categories/:slug", h.GetBySlug)
+	get := func(url, etag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/categories?preview=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d: %s", w.Code, w.Body)
+	}
+	var list dto.CategoryBrowseListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Slug != "foods-browse" || list.Items[1].Slug != "drinks-browse" {
+		t.Fatalf("categories = %+v, want foods then drinks", list.Items)
+	}
+	if got := list.Items[0]; got.ProductCount != 3 || len(got.Products) != 2 || got.Products[0].Slug != "com-browse" || got.Products[1].Slug != "pho-browse" {
+		t.Fatalf("foods = %+v, want 3 products previewing com and pho", got)
+	}
+	if got := list.Items[1]; got.ProductCount != 1 || len(got.Products) != 1 {
+		t.Fatalf("drinks = %+v", got)
+	}
+
+	w = get("/categories/foods-browse", "")
+	var detail map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if w.Code != http.StatusOK || detail["product_count"] != float64(3) || detail["products"] != nil {
+		t.Fatalf("detail = %d %s, want count 3 without preview", w.Code, w.Body)
+	}
+	etag := w.Header().Get("ETag")
+	if revalidated := get("/categories/foods-browse", etag); revalidated.Code != http.StatusNotModified {
+		t.Fatalf("revalidation = %d, want 304", revalidated.Code)
+	}
+
+	// Editing through the service drops cached responses
+	name := "Mon an"
+	if _, err := categories.Update(foods.ID, &dto.UpdateCategoryRequest{Name: &name}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	w = get("/categories/foods-browse", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("after edit = %d with ETag %q, want 200 with a new tag", w.Code, w.Header().Get("ETag"))
+	}
+
+	if w := get("/categories/hidden-browse", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("inactive category status = %d, want 404", w.Code)
+	}
+	if w := get("/categories?preview=13", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("oversized preview status = %d, want 400", w.Code)
+	}
+
+	pr := newProductHandlerRouter(db)
+	for url, want := range map[string]int{
+		"/products?category_slug=foods-browse":                                        3,
+		"/products?category_slug=hidden-browse":                                       0,
+		"/products?category_slug=nope":                                                0,
+		fmt.Sprintf("/products?category_slug=foods-browse&category_id=%d", drinks.ID): 0,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		pr.ServeHTTP(w, req)
+		var resp dto.PaginatedResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		items, _ := resp.Items.([]interface{})
+		if w.Code != http.StatusOK || len(items) != want {
+			t.Fatalf("%s = %d with %d items, want %d", url, w.Code, len(items), want)
+		}
+	}
+}
+
+func TestCategoryHandler_NestedCategories(t *testing.T) {
+	t.Parallel()
+	db := newProductTestDB(t)
+
+	drinks := &models.Category{Name: "Drinks", Slug: "drinks-nested"}
+	db.Create(drinks)
+	coffee := &models.Category{Name: "Coffee", Slug: "coffee-nested", ParentID: &drinks.ID}
+	db.Create(coffee)
+	coldBrew := &models.Category{Name: "Cold brew", Slug: "cold-brew-nested", ParentID: &coffee.ID}
+	db.Create(coldBrew)
+	db.Create(&models.Product{CategoryID: coldBrew.ID, Name: "Cold brew cam", Slug: "cold-brew-cam", Classify: "drink", Price: 45000, Stock: 5, Status: "active"})
+	db.Create(&models.Product{CategoryID: drinks.ID, Name: "Nuoc suoi", Slug: "nuoc-suoi", Classify: "drink", Price: 10000, Stock: 5, Status: "active"})
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	products := service.NewProductService(repository.NewProductRepository(db), categoryRepo, nil, "http://test.local", "", service.NewCursorCodec("test-secret"), nil, nil, nil, nil, nil)
+	h := NewCategoryHandler(service.NewCategoryService(categoryRepo, nil, products, nil), products, nil, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/categories/:slug", h.GetBySlug)
+
+	counts := map[string]int64{"drinks-nested": 2, "coffee-nested": 1, "cold-brew-nested": 1}
+	for slug, want := range counts {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/categories/"+slug, nil)
+		r.ServeHTTP(w, req)
+		var got dto.CategoryBrowseResponse
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if w.Code != http.StatusOK || got.ProductCount != want {
+			t.Fatalf("%s = %d with count %d, want %d", slug, w.Code, got.ProductCount, want)
+		}
+	}
+
+	resp, err := products.List(&dto.ProductListRequest{Page: 1, PageSize: 10, CategorySlug: "coffee-nested"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	items := resp.Items.([]dto.ProductResponse)
+	if len(items) != 1 || items[0].Slug != "cold-brew-cam" {
+		t.Fatalf("coffee products = %+v, want the cold brew from its subcategory", items)
+	}
+	var trail []string
+	for _, b := range items[0].Breadcrumbs {
+		trail = append(trail, b.Slug)
+	}
+	if fmt.Sprint(trail) != "[drinks-nested coffee-nested cold-brew-nested]" {
+		t.Fatalf("breadcrumbs = %v", trail)
+	}
+}
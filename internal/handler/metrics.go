@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/httpcache"
+)
+
+type MetricsHandler struct {
+	responseCache *httpcache.Cache
+}
+
+func NewMetricsHandler(responseCache *httpcache.Cache) *MetricsHandler {
+	return &MetricsHandler{responseCache: responseCache}
+}
+
+// Metrics godoc
+// @Summary Runtime metrics
+// @Description Counters of the in-process response cache for public catalog endpoints. Admin only.
+// @Tags health
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.MetricsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /metrics [get]
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	stats := h.responseCache.Stats()
+	c.JSON(http.StatusOK, dto.MetricsResponse{
+		ResponseCache: dto.ResponseCacheMetrics{
+			Enabled:       h.responseCache != nil,
+			Hits:          stats.Hits,
+			Misses:        stats.Misses,
+			HitRatio:      math.Round(stats.HitRatio*1000) / 1000,
+			Entries:       stats.Entries,
+			Invalidations: stats.Invalidations,
+			Evictions:     stats.Evictions,
+		},
+	})
+}
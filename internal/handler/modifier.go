@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+type ModifierHandler struct {
+	modifierService *service.ModifierService
+}
+
+func NewModifierHandler(modifierService *service.ModifierService) *ModifierHandler {
+	return &ModifierHandler{modifierService: modifierService}
+}
+
+// ListByProduct godoc
+// @Summary List product modifiers
+// @Description Public API list the add-on groups and options available for a product
+// @Tags products
+// @Produce json
+// @Param slug path string true "Product slug"
+// @Success 200 {array} dto.ModifierGroupResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/products/{slug}/modifiers [get]
+func (h *ModifierHandler) ListByProduct(c *gin.Context) {
+	groups, err := h.modifierService.ListForProductSlug(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product_not_found", Message: "Product not found"})
+			return
+		}
+		log.Printf("Modifier list error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "internal_error", Message: "An unexpected error occurred"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
@@ -37,6 +37,7 @@ func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "product_unavailable: a cart item is outside its serving hours"
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/orders [post]
 func (h *OrderHandler) Create(c *gin.Context) {
@@ -87,6 +88,9 @@ func (h *OrderHandler) Create(c *gin.Context) {
 // @Param status query string false "pending|confirmed|processing|shipping|delivered|cancelled"
 // @Param from_date query string false "From date (YYYY-MM-DD)"
 // @Param to_date query string false "To date (YYYY-MM-DD)"
+// @Param sort_by query string false "created_at|total_amount" default(created_at)
+// @Param sort_dir query string false "asc|desc" default(desc)
+// @Param cursor query string false "next_cursor from the previous response; skips the total count and ignores page"
 // @Success 200 {object} dto.PaginatedResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
@@ -163,6 +167,11 @@ func (h *OrderHandler) GetDetail(c *gin.Context) {
 
 func (h *OrderHandler) handleOrderError(c *gin.Context, err error) {
 	switch {
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_cursor",
+			Message: "Cursor is invalid or does not match the requested sort",
+		})
 	case errors.Is(err, service.ErrCartEmpty):
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Error:   "cart_empty",
@@ -182,6 +191,16 @@ func (h *OrderHandler) handleOrderError(c *gin.Context, err error) {
 			Error:   "insufficient_stock",
 			Message: message,
 		})
+	case errors.Is(err, service.ErrProductUnavailable):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "product_unavailable",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidModifierSelection):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_modifiers",
+			Message: err.Error(),
+		})
 	case errors.Is(err, service.ErrOrderNotFound):
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{
 			Error:   "order_not_found",
@@ -2,39 +2,67 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/httpcache"
+	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/service"
 )
 
 type ProductHandler struct {
-	productService *service.ProductService
+	productService     *service.ProductService
+	searchService      *service.SearchService
+	translationService *service.TranslationService
+	cache              *httpcache.Cache
 }
 
-func NewProductHandler(productService *service.ProductService) *ProductHandler {
-	return &ProductHandler{productService: productService}
+// NewProductHandler creates a ProductHandler. cache may be nil, in which case
+// every request is loaded from the database but still gets validators.
+func NewProductHandler(productService *service.ProductService, searchService *service.SearchService, translationService *service.TranslationService, cache *httpcache.Cache) *ProductHandler {
+	return &ProductHandler{productService: productService, searchService: searchService, translationService: translationService, cache: cache}
+}
+
+// responseLocale negotiates the language of a catalog response from ?lang=
+// and Accept-Language, and labels the response with it
+func responseLocale(c *gin.Context, translations *service.TranslationService) string {
+	locale := translations.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Header("Vary", "Accept-Language")
+	c.Header("Content-Language", locale)
+	return locale
 }
 
 // List godoc
 // @Summary List products
-// @Description Public API list products with filter, sort, search and pagination
+// @Description Public API list products with filter, sort, search and pagination, optionally with facet counts
 // @Tags products
 // @Produce json
 // @Param page       query int    false "Page"          default(1)
 // @Param page_size  query int    false "Page size"     default(20)
 // @Param classify   query string false "food or drink"
 // @Param category_id query uint  false "Category ID"
-// @Param min_price  query number false "Min price"
-// @Param max_price  query number false "Max price"
+// @Param category_slug query string false "Category slug"
+// @Param min_price  query number false "Min regular price (original_price when on sale, otherwise price)"
+// @Param max_price  query number false "Max regular price (original_price when on sale, otherwise price)"
 // @Param min_rating query number false "Min rating (0-5)"
-// @Param search     query string false "Full-text search"
-// @Param sort_by    query string false "price|rating_average|name|created_at" default(created_at)
+// @Param search     query string false "Accent-insensitive search on name and description"
+// @Param starts_with query string false "First letter of the name, accents ignored; # for names not starting with a-z"
+// @Param sort_by    query string false "relevance|price|rating_average|name|created_at (relevance when searching, otherwise created_at); price sorts by the regular price"
 // @Param sort_dir   query string false "asc|desc"                             default(desc)
-// @Success 200 {object} dto.PaginatedResponse
+// @Param exclude_allergens query string false "Comma-separated allergens to leave out (peanut, tree-nut, milk, egg, soy, gluten, fish, shellfish, sesame)"
+// @Param tags       query string false "Comma-separated dietary tags the products must all have, e.g. vegan"
+// @Param max_calories query int  false "Maximum kcal per serving; products without nutrition info are left out"
+// @Param facets     query bool   false "Include per-filter counts (each facet ignores its own filter)"
+// @Param cursor     query string false "next_cursor from the previous response; skips the total count and ignores page"
+// @Param lang       query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales; the default locale is used when none is supported"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} dto.ProductListResponse
+// @Success 304 "Not modified"
 // @Failure 400 {object} dto.ErrorResponse
 // @Router /api/v1/products [get]
 func (h *ProductHandler) List(c *gin.Context) {
@@ -55,14 +83,21 @@ func (h *ProductHandler) List(c *gin.Context) {
 	}
 	if req.SortBy == "" {
 		req.SortBy = "created_at"
+		if req.Search != "" {
+			req.SortBy = service.ProductSortRelevance
+		}
 	}
 	if req.SortDir == "" {
 		req.SortDir = "desc"
 	}
 	// Public list chỉ hiện active
 	req.Status = "active"
+	req.Locale = responseLocale(c, h.translationService)
 
-	result, err := h.productService.List(&req)
+	// The query string already holds every input, and keys are sorted by Encode.
+	// available_now and sale prices change with the clock, so entries expire at
+	// each opening or closing and whenever a sale starts or stops.
+	epoch, err := h.productService.ScheduleEpoch()
 	if err != nil {
 		log.Printf("Product list error: %v", err)
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
@@ -71,8 +106,55 @@ func (h *ProductHandler) List(c *gin.Context) {
 		})
 		return
 	}
+	key := fmt.Sprintf("products/%s?%s@%d", req.Locale, c.Request.URL.Query().Encode(), epoch.Unix())
+	entry, err := h.cache.Load(key, func() (*httpcache.Entry, error) {
+		// Read the version first so a concurrent change can only make it older
+		version, err := h.productService.ListVersion()
+		if err != nil {
+			return nil, err
+		}
+		result, err := h.productService.List(&req)
+		if err != nil {
+			return nil, err
+		}
+		return &httpcache.Entry{
+			Value:        result,
+			ETag:         httpcache.WeakETag(key, version.Tag),
+			LastModified: version.LastModified,
+		}, nil
+	})
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_cursor",
+			Message: "Cursor is invalid or does not match the requested sort",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidDietaryInfo) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_params",
+			Message: "Invalid query parameters: " + strings.TrimPrefix(err.Error(), service.ErrInvalidDietaryInfo.Error()+": "),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Product list error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "internal_error",
+			Message: "An unexpected error occurred",
+		})
+		return
+	}
+
+	// Log the search once, not again for every page the shopper flips through
+	if req.Search != "" && req.Page == 1 && req.Cursor == "" && h.searchService != nil {
+		result := entry.Value.(*dto.ProductListResponse)
+		if err := h.searchService.LogQuery(req.Search, result.Total, c.ClientIP()); err != nil {
+			log.Printf("Search log error: %v", err)
+		}
+	}
 
-	c.JSON(http.StatusOK, result)
+	httpcache.Respond(c, entry, h.cache.MaxAge())
 }
 
 // GetBySlug godoc
@@ -81,7 +163,11 @@ func (h *ProductHandler) List(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param slug path string true "Product slug"
+// @Param lang query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales; the default locale is used when none is supported"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} dto.ProductResponse
+// @Success 304 "Not modified"
 // @Failure 404 {object} dto.ErrorResponse
 // @Router /api/v1/products/{slug} [get]
 func (h *ProductHandler) GetBySlug(c *gin.Context) {
@@ -94,7 +180,32 @@ func (h *ProductHandler) GetBySlug(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.GetBySlug(slug)
+	epoch, err := h.productService.ScheduleEpoch()
+	if err != nil {
+		log.Printf("Product detail error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "internal_error",
+			Message: "An unexpected error occurred",
+		})
+		return
+	}
+	locale := responseLocale(c, h.translationService)
+	key := fmt.Sprintf("product/%s/%s@%d", locale, slug, epoch.Unix())
+	entry, err := h.cache.Load(key, func() (*httpcache.Entry, error) {
+		product, version, err := h.productService.GetBySlugWithVersion(slug, locale)
+		if err != nil {
+			return nil, err
+		}
+		// Hidden products are not cached so they show up as soon as they are activated
+		if !models.ProductVisible(product.Status) {
+			return nil, service.ErrProductNotFound
+		}
+		return &httpcache.Entry{
+			Value:        product,
+			ETag:         httpcache.WeakETag(locale, version.Tag),
+			LastModified: version.LastModified,
+		}, nil
+	})
 	if err != nil {
 		if errors.Is(err, service.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
@@ -111,13 +222,5 @@ func (h *ProductHandler) GetBySlug(c *gin.Context) {
 		return
 	}
 
-	if product.Status != "active" {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Error:   "product_not_found",
-			Message: "Product not found",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, product)
+	httpcache.Respond(c, entry, h.cache.MaxAge())
 }
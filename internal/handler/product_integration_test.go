@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/httpcache"
 	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/repository"
 	"github.com/kha/foods-drinks/internal/service"
@@ -34,10 +36,14 @@ func newProductTestDB(t *testing.T) *gorm.DB {
 }
 
 func newProductHandlerRouter(db *gorm.DB) *gin.Engine {
+	return newCachedProductHandlerRouter(db, nil)
+}
+
+func newCachedProductHandlerRouter(db *gorm.DB, cache *httpcache.Cache) *gin.Engine {
 	productRepo := repository.NewProductRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
-	svc := service.NewProductService(productRepo, categoryRepo, "http://test.local")
-	h := NewProductHandler(svc)
+	svc := service.NewProductService(productRepo, categoryRepo, nil, "http://test.local", "", service.NewCursorCodec("test-secret"), nil, nil, nil, nil, nil)
+	h := NewProductHandler(svc, nil, nil, cache)
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/products", h.List)
@@ -183,6 +189,208 @@ func TestProductHandler_List_SortByPrice(t *testing.T) {
 	}
 }
 
+func TestProductHandler_List_Cursor(t *testing.T) {
+	t.Parallel()
+	db := newProductTestDB(t)
+
+	cat := &models.Category{Name: "Food", Slug: "food-cursor-test"}
+	db.Create(cat)
+	// Prices repeat so pages must break ties on id
+	for i := 0; i < 7; i++ {
+		db.Create(&models.Product{
+			CategoryID: cat.ID,
+			Name:       fmt.Sprintf("Cursor Item %d", i),
+			Slug:       fmt.Sprintf("cursor-item-%d", i),
+			Classify:   "food",
+			Price:      float64(i/2+1) * 10000,
+			Stock:      10,
+			Status:     "active",
+		})
+	}
+
+	r := newProductHandlerRouter(db)
+	get := func(url string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := get("/products?page_size=3&sort_by=price&sort_dir=asc")
+	if code != http.StatusOK {
+		t.Fatalf("first page status = %d", code)
+	}
+	if resp["total"].(float64) != 7 {
+		t.Fatalf("first page should still be counted, total = %v", resp["total"])
+	}
+
+	var slugs []string
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("cursor never ended")
+		}
+		for _, it := range resp["items"].([]interface{}) {
+			slugs = append(slugs, it.(map[string]interface{})["slug"].(string))
+		}
+		next, _ := resp["next_cursor"].(string)
+		if next == "" {
+			break
+		}
+		code, resp = get("/products?page_size=3&sort_by=price&sort_dir=asc&cursor=" + next)
+		if code != http.StatusOK {
+			t.Fatalf("cursor page status = %d: %v", code, resp)
+		}
+		if resp["total"].(float64) != 0 {
+			t.Errorf("cursor page should skip the count, total = %v", resp["total"])
+		}
+	}
+	if len(slugs) != 7 {
+		t.Fatalf("got %d products across pages, want 7: %v", len(slugs), slugs)
+	}
+	for i, slug := range slugs {
+		if want := fmt.Sprintf("cursor-item-%d", i); slug != want {
+			t.Errorf("position %d = %s, want %s", i, slug, want)
+		}
+	}
+
+	_, first := get("/products?page_size=3&sort_by=price&sort_dir=asc")
+	cursor := first["next_cursor"].(string)
+	if code, _ := get("/products?page_size=3&sort_by=price&sort_dir=desc&cursor=" + cursor); code != http.StatusBadRequest {
+		t.Errorf("cursor reused with another sort: status = %d, want 400", code)
+	}
+	if code, _ := get("/products?page_size=3&sort_by=price&sort_dir=asc&cursor=x" + cursor); code != http.StatusBadRequest {
+		t.Errorf("tampered cursor: status = %d, want 400", code)
+	}
+}
+
+func TestProductHandler_List_StartsWithAndFacets(t *testing.T) {
+	t.Parallel()
+	db := newProductTestDB(t)
+
+	cat := &models.Category{Name: "Facets", Slug: "facets-handler-test"}
+	empty := &models.Category{Name: "Empty", Slug: "empty-facets-handler-test"}
+	db.Create(cat)
+	db.Create(empty)
+	db.Create(&models.Product{CategoryID: cat.ID, Name: "Đậu hũ", Slug: "dau-hu-facet-test", Classify: "food", Price: 15000, Stock: 5, Status: "active"})
+	db.Create(&models.Product{CategoryID: cat.ID, Name: "Dừa tươi", Slug: "dua-facet-test", Classify: "drink", Price: 25000, Stock: 5, Status: "active"})
+	db.Create(&models.Product{CategoryID: cat.ID, Name: "Bánh flan", Slug: "flan-facet-test", Classify: "food", Price: 20000, Stock: 5, Status: "active"})
+
+	r := newProductHandlerRouter(db)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/products?starts_with=D&classify=food&facets=true", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Items  []map[string]interface{} `json:"items"`
+		Facets struct {
+			Classify []struct {
+				Value string `json:"value"`
+				Count int64  `json:"count"`
+			} `json:"classify"`
+			Category []struct {
+				Slug  string `json:"slug"`
+				Count int64  `json:"count"`
+			} `json:"category"`
+			StartsWith []struct {
+				Value string `json:"value"`
+				Count int64  `json:"count"`
+			} `json:"starts_with"`
+		} `json:"facets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0]["slug"] != "dau-hu-facet-test" {
+		t.Fatalf("items = %v", resp.Items)
+	}
+	// Classify counts ignore classify=food but keep starts_with=d
+	if len(resp.Facets.Classify) != 2 || resp.Facets.Classify[0].Count != 1 || resp.Facets.Classify[1].Count != 1 {
+		t.Errorf("classify facet = %+v", resp.Facets.Classify)
+	}
+	if len(resp.Facets.Category) != 2 || resp.Facets.Category[1].Slug != "empty-facets-handler-test" || resp.Facets.Category[1].Count != 0 {
+		t.Errorf("category facet = %+v", resp.Facets.Category)
+	}
+	// starts_with counts ignore starts_with=d but keep classify=food
+	letters := map[string]int64{}
+	for _, f := range resp.Facets.StartsWith {
+		letters[f.Value] = f.Count
+	}
+	if len(resp.Facets.StartsWith) != 27 || letters["b"] != 1 || letters["d"] != 1 {
+		t.Errorf("starts_with facet = %v", letters)
+	}
+}
+
+func TestProductHandler_ConditionalAndCached(t *testing.T) {
+	t.Parallel()
+	db := newProductTestDB(t)
+
+	cat := &models.Category{Name: "Drinks", Slug: "drinks-etag-test"}
+	db.Create(cat)
+	product := &models.Product{CategoryID: cat.ID, Name: "Lemon Tea", Slug: "lemon-tea-etag", Classify: "drink", Price: 20000, Stock: 5, Status: "active"}
+	db.Create(product)
+
+	cache := httpcache.New(time.Minute, 10, 30*time.Second)
+	r := newCachedProductHandlerRouter(db, cache)
+	get := func(url, etag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, url := range []string{"/products?page_size=5", "/products/lemon-tea-etag"} {
+		first := get(url, "")
+		if first.Code != http.StatusOK {
+			t.Fatalf("%s status = %d", url, first.Code)
+		}
+		etag := first.Header().Get("ETag")
+		if len(etag) < 4 || etag[:3] != `W/"` {
+			t.Fatalf("%s ETag = %q, want a weak tag", url, etag)
+		}
+		if cc := first.Header().Get("Cache-Control"); cc != "public, max-age=30" {
+			t.Errorf("%s Cache-Control = %q", url, cc)
+		}
+		if first.Header().Get("Last-Modified") == "" {
+			t.Errorf("%s has no Last-Modified", url)
+		}
+
+		revalidated := get(url, etag)
+		if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+			t.Fatalf("%s revalidation = %d with %d bytes, want empty 304", url, revalidated.Code, revalidated.Body.Len())
+		}
+
+		// Direct database edits are only seen once the cache is invalidated
+		db.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+			"price":      float64(21000 + len(url)),
+			"updated_at": time.Now().Add(time.Second),
+		})
+		if w := get(url, etag); w.Code != http.StatusNotModified {
+			t.Fatalf("%s before invalidation = %d, want cached 304", url, w.Code)
+		}
+		cache.CatalogChanged()
+		changed := get(url, etag)
+		if changed.Code != http.StatusOK {
+			t.Fatalf("%s after change = %d, want 200", url, changed.Code)
+		}
+		if changed.Header().Get("ETag") == etag {
+			t.Errorf("%s ETag did not change after the product was updated", url)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 4 || stats.Misses != 4 || stats.Invalidations != 2 {
+		t.Errorf("stats = %+v, want 4 hits, 4 misses, 2 invalidations", stats)
+	}
+}
+
 func TestProductHandler_GetBySlug_NotFound(t *testing.T) {
 	t.Parallel()
 	db := newProductTestDB(t)
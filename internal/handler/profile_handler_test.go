@@ -35,7 +35,7 @@ func TestProfileHandler_UploadAvatarValidation(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	cfg := &config.UploadConfig{Path: t.TempDir(), MaxSize: 10, AllowedTypes: []string{"jpg", "png"}}
-	h := NewProfileHandler(service.NewProfileService(nil, cfg, "/uploads"))
+	h := NewProfileHandler(service.NewProfileService(nil, service.NewUploadService(cfg, nil, "/uploads")))
 
 	r := gin.New()
 	r.POST("/profile/avatar", h.UploadAvatar)
@@ -89,7 +89,7 @@ func TestProfileHandler_DeleteAvatarUnauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	cfg := &config.UploadConfig{Path: t.TempDir(), MaxSize: 10, AllowedTypes: []string{"jpg"}}
-	h := NewProfileHandler(service.NewProfileService(nil, cfg, "/uploads"))
+	h := NewProfileHandler(service.NewProfileService(nil, service.NewUploadService(cfg, nil, "/uploads")))
 
 	r := gin.New()
 	r.DELETE("/profile/avatar", h.DeleteAvatar)
@@ -3,7 +3,9 @@ package handler
 import (
 	"errors"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +30,9 @@ func NewRatingHandler(ratingService *service.RatingService) *RatingHandler {
 // @Param slug path string true "Product slug"
 // @Param page query int false "Page" default(1)
 // @Param page_size query int false "Page size" default(20)
-// @Success 200 {object} dto.PaginatedResponse
+// @Param cursor query string false "next_cursor from the previous response; skips the total count and ignores page"
+// @Param sort query string false "newest (default), helpful or with_photos"
+// @Success 200 {object} dto.RatingListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -142,6 +146,188 @@ func (h *RatingHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// UploadPhotos godoc
+// @Summary Add photos to own rating
+// @Description Attach images to the authenticated user's rating of a product, up to the configured number per rating
+// @Tags ratings
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Product slug"
+// @Param photos formData file true "Image files"
+// @Success 200 {object} dto.RatingResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/products/{slug}/ratings/photos [post]
+func (h *RatingHandler) UploadPhotos(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "unauthorized", Message: "Authentication required"})
+		return
+	}
+
+	slug := strings.TrimSpace(c.Param("slug"))
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_slug", Message: "Invalid product slug"})
+		return
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil && form != nil {
+		files = form.File["photos"]
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "validation_error", Message: "At least one photo is required"})
+		return
+	}
+
+	resp, err := h.ratingService.AddPhotos(userID, slug, files)
+	if err != nil {
+		h.handleRatingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeletePhoto godoc
+// @Summary Remove a photo from own rating
+// @Description Delete one photo of the authenticated user's rating of a product
+// @Tags ratings
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Product slug"
+// @Param photoId path int true "Photo ID"
+// @Success 200 {object} dto.RatingResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/products/{slug}/ratings/photos/{photoId} [delete]
+func (h *RatingHandler) DeletePhoto(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "unauthorized", Message: "Authentication required"})
+		return
+	}
+
+	photoID, err := strconv.ParseUint(c.Param("photoId"), 10, 64)
+	if err != nil || photoID == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_id", Message: "Invalid photo ID"})
+		return
+	}
+
+	resp, err := h.ratingService.DeletePhoto(userID, strings.TrimSpace(c.Param("slug")), uint(photoID))
+	if err != nil {
+		h.handleRatingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Vote godoc
+// @Summary Vote a rating helpful
+// @Description Mark another user's rating as helpful. Each user has one vote per rating; voting again changes nothing.
+// @Tags ratings
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Rating ID"
+// @Success 200 {object} dto.RatingVoteResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/ratings/{id}/helpful [post]
+func (h *RatingHandler) Vote(c *gin.Context) {
+	h.changeVote(c, h.ratingService.Vote)
+}
+
+// Unvote godoc
+// @Summary Withdraw a helpful vote
+// @Description Remove the authenticated user's helpful vote from a rating
+// @Tags ratings
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Rating ID"
+// @Success 200 {object} dto.RatingVoteResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/ratings/{id}/helpful [delete]
+func (h *RatingHandler) Unvote(c *gin.Context) {
+	h.changeVote(c, h.ratingService.Unvote)
+}
+
+// Report godoc
+// @Summary Report a rating
+// @Description Report another user's rating for moderation. Each user can report a rating once.
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Rating ID"
+// @Param request body dto.ReportRatingRequest true "Report reason"
+// @Success 201 {object} dto.RatingReportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/ratings/{id}/report [post]
+func (h *RatingHandler) Report(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "unauthorized", Message: "Authentication required"})
+		return
+	}
+
+	ratingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || ratingID == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_id", Message: "Invalid rating ID"})
+		return
+	}
+
+	var req dto.ReportRatingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "validation_error", Message: "Invalid request: " + err.Error()})
+		return
+	}
+
+	resp, err := h.ratingService.Report(userID, uint(ratingID), &req)
+	if err != nil {
+		h.handleRatingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *RatingHandler) changeVote(c *gin.Context, change func(userID, ratingID uint) (*dto.RatingVoteResponse, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "unauthorized", Message: "Authentication required"})
+		return
+	}
+
+	ratingID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || ratingID == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_id", Message: "Invalid rating ID"})
+		return
+	}
+
+	resp, err := change(userID, uint(ratingID))
+	if err != nil {
+		h.handleRatingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *RatingHandler) handleRatingError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrProductNotFound):
@@ -152,6 +338,26 @@ func (h *RatingHandler) handleRatingError(c *gin.Context, err error) {
 		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "rating_exists", Message: "You have already rated this product"})
 	case errors.Is(err, service.ErrRatingNotFound):
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "rating_not_found", Message: "Rating not found"})
+	case errors.Is(err, service.ErrRatingPhotoNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "photo_not_found", Message: "Photo not found"})
+	case errors.Is(err, service.ErrRatingPhotoLimit):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "too_many_photos", Message: "A rating can have at most " + strconv.Itoa(h.ratingService.MaxPhotos()) + " photos"})
+	case errors.Is(err, service.ErrRatingOwnVote):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "own_rating", Message: "You cannot vote on your own rating"})
+	case errors.Is(err, service.ErrRatingOwnReport):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "own_rating", Message: "You cannot report your own rating"})
+	case errors.Is(err, service.ErrRatingReported):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "already_reported", Message: "You have already reported this rating"})
+	case errors.Is(err, service.ErrFileTooLarge):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "file_too_large", Message: "File size exceeds the maximum allowed size"})
+	case errors.Is(err, service.ErrImageTooLarge):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "image_too_large", Message: "Image dimensions are too large"})
+	case errors.Is(err, service.ErrInvalidFileType):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_file_type", Message: "Only image files are allowed"})
+	case errors.Is(err, service.ErrUploadUnavailable):
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "upload_unavailable", Message: "Uploads are not available"})
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_cursor", Message: "Cursor is invalid or does not match the requested sort"})
 	default:
 		log.Printf("Rating error: %v", err)
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "internal_error", Message: "An unexpected error occurred"})
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
 	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/dto"
 	"github.com/kha/foods-drinks/internal/middleware"
 	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/repository"
@@ -35,6 +37,9 @@ func newRatingSuggestionTestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Rating{},
+		&models.RatingPhoto{},
+		&models.RatingVote{},
+		&models.RatingReport{},
 		&models.Suggestion{},
 	); err != nil {
 		t.Fatalf("migrate rating/suggestion test db: %v", err)
@@ -52,7 +57,7 @@ func setupRatingSuggestionRouter(t *testing.T) (*gin.Engine, *gorm.DB, string) {
 
 	ratingRepo := repository.NewRatingRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	ratingSvc := service.NewRatingService(ratingRepo, productRepo)
+	ratingSvc := service.NewRatingService(ratingRepo, productRepo, nil, nil, 0, nil)
 	ratingHandler := NewRatingHandler(ratingSvc)
 
 	suggestionRepo := repository.NewSuggestionRepository(db)
@@ -66,6 +71,9 @@ func setupRatingSuggestionRouter(t *testing.T) (*gin.Engine, *gorm.DB, string) {
 	protected.Use(authMW.RequireAuth())
 	protected.POST("/products/:slug/ratings", ratingHandler.Create)
 	protected.PUT("/products/:slug/ratings", ratingHandler.Update)
+	protected.POST("/products/:slug/ratings/photos", ratingHandler.UploadPhotos)
+	protected.POST("/ratings/:id/helpful", ratingHandler.Vote)
+	protected.POST("/ratings/:id/report", ratingHandler.Report)
 	protected.POST("/suggestions", suggestionHandler.Create)
 
 	user := &models.User{ID: 1001, Email: "rs-user@example.com", FullName: "RS User", Role: models.RoleUser, Status: models.UserStatusActive}
@@ -140,6 +148,90 @@ func TestRatingHandler_CreateUpdateAndList(t *testing.T) {
 	}
 }
 
+func TestRatingHandler_SummaryVotesAndPhotos(t *testing.T) {
+	t.Parallel()
+	r, db, token := setupRatingSuggestionRouter(t)
+	product := seedPurchasableProduct(t, db, 1001, "rating-handler-votes")
+	rating := &models.Rating{UserID: 1001, ProductID: product.ID, Rating: 4}
+	if err := db.Create(rating).Error; err != nil {
+		t.Fatalf("create rating: %v", err)
+	}
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodGet, "/products/"+product.Slug+"/ratings?sort=helpful")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Items   []dto.RatingResponse `json:"items"`
+		Summary dto.RatingSummary    `json:"summary"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Summary.Count != 1 || len(resp.Summary.Distribution) != 5 || resp.Summary.Distribution[1].Count != 1 {
+		t.Fatalf("list = %+v", resp)
+	}
+	if w := send(http.MethodGet, "/products/"+product.Slug+"/ratings?sort=oldest"); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown sort status = %d, want 400", w.Code)
+	}
+
+	if w := send(http.MethodPost, fmt.Sprintf("/ratings/%d/helpful", rating.ID)); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "own_rating") {
+		t.Fatalf("own vote = %d: %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodPost, "/ratings/abc/helpful"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid rating id status = %d, want 400", w.Code)
+	}
+	if w := send(http.MethodPost, "/products/"+product.Slug+"/ratings/photos"); w.Code != http.StatusBadRequest {
+		t.Fatalf("upload without photos status = %d, want 400", w.Code)
+	}
+}
+
+func TestRatingHandler_Report(t *testing.T) {
+	t.Parallel()
+	r, db, token := setupRatingSuggestionRouter(t)
+	product := seedPurchasableProduct(t, db, 1001, "rating-handler-report")
+	author := &models.User{ID: 1002, Email: "rs-author@example.com", FullName: "RS Author", Role: models.RoleUser, Status: models.UserStatusActive}
+	if err := db.Create(author).Error; err != nil {
+		t.Fatalf("create author: %v", err)
+	}
+	rating := &models.Rating{UserID: author.ID, ProductID: product.ID, Rating: 1}
+	if err := db.Create(rating).Error; err != nil {
+		t.Fatalf("create rating: %v", err)
+	}
+
+	report := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/ratings/%d/report", rating.ID), bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := report(`{"reason":"rude"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown reason status = %d, want 400", w.Code)
+	}
+	if w := report(`{"reason":"offensive","note":"insults the staff"}`); w.Code != http.StatusCreated {
+		t.Fatalf("report status = %d, want 201: %s", w.Code, w.Body)
+	}
+	if w := report(`{"reason":"spam"}`); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "already_reported") {
+		t.Fatalf("second report = %d: %s", w.Code, w.Body)
+	}
+	var flagged models.Rating
+	db.First(&flagged, rating.ID)
+	if !flagged.Flagged || flagged.ReportCount != 1 {
+		t.Fatalf("reported rating flagged=%v reports=%d", flagged.Flagged, flagged.ReportCount)
+	}
+}
+
 func TestRatingHandler_UnauthorizedAndValidation(t *testing.T) {
 	t.Parallel()
 	r, db, _ := setupRatingSuggestionRouter(t)
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+type RecommendationHandler struct {
+	recommendationService *service.RecommendationService
+	translationService    *service.TranslationService
+}
+
+func NewRecommendationHandler(recommendationService *service.RecommendationService, translationService *service.TranslationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService, translationService: translationService}
+}
+
+// Related godoc
+// @Summary Related products
+// @Description Products frequently bought together with this one (from delivered orders, refreshed nightly), topped up with the best rated products of the same category and then the same classify
+// @Tags recommendations
+// @Produce json
+// @Param slug  path  string true  "Product slug"
+// @Param limit query int    false "Max products (1-24)" default(8)
+// @Param lang  query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Success 200 {object} dto.RecommendationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/products/{slug}/related [get]
+func (h *RecommendationHandler) Related(c *gin.Context) {
+	slug := strings.TrimSpace(c.Param("slug"))
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_slug", Message: "Invalid product slug"})
+		return
+	}
+
+	var req dto.RecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_params", Message: "Invalid query parameters: " + err.Error()})
+		return
+	}
+	req.Locale = responseLocale(c, h.translationService)
+
+	resp, err := h.recommendationService.Related(slug, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ForYou godoc
+// @Summary Personal recommendations
+// @Description Products bought together with what the current user ordered before, then the best rated of their favourite categories, then recent best sellers. Products already ordered are left out; users without orders get best sellers.
+// @Tags recommendations
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int    false "Max products (1-24)" default(8)
+// @Param lang  query string false "Locale of names and descriptions (vi, en); overrides Accept-Language"
+// @Success 200 {object} dto.RecommendationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/recommendations/for-you [get]
+func (h *RecommendationHandler) ForYou(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "unauthorized", Message: "Authentication required"})
+		return
+	}
+
+	var req dto.RecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_params", Message: "Invalid query parameters: " + err.Error()})
+		return
+	}
+	req.Locale = responseLocale(c, h.translationService)
+
+	resp, err := h.recommendationService.ForUser(userID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *RecommendationHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrProductNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product_not_found", Message: "Product not found"})
+		return
+	}
+	log.Printf("Recommendation error: %v", err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "internal_error", Message: "An unexpected error occurred"})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Suggest godoc
+// @Summary Search suggestions
+// @Description Search-as-you-type suggestions mixing product names, categories and popular searches. Accent-insensitive; every word of q must be the start of a word in the suggestion. Rate limited per IP.
+// @Tags search
+// @Produce json
+// @Param q     query string true  "Text typed so far"
+// @Param limit query int    false "Max suggestions (1-20)" default(8)
+// @Success 200 {object} dto.SearchSuggestResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /api/v1/search/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	var req dto.SearchSuggestRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_params",
+			Message: "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.searchService.Suggest(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptySearchQuery) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "invalid_params",
+				Message: "Query must contain letters or digits",
+			})
+			return
+		}
+		log.Printf("Search suggest error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "internal_error",
+			Message: "An unexpected error occurred",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
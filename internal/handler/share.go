@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/httpcache"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+const (
+	shareSiteName             = "Foods & Drinks"
+	shareDescriptionMaxLength = 200
+	sitemapNamespace          = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// shareDescriptions describe a product without a description of its own, by
+// content locale, from its name and the site name
+var shareDescriptions = map[string]string{
+	"vi": "Khám phá %s tại %s",
+	"en": "Discover %s at %s",
+}
+
+// ogLocales are the Open Graph names of the content locales
+var ogLocales = map[string]string{
+	"vi": "vi_VN",
+	"en": "en_US",
+}
+
+// SharePageHandler serves the pages meant for crawlers: product pages with
+// Open Graph and Twitter card tags, sitemap.xml and robots.txt. All of them
+// go through the response cache, so catalog changes invalidate them.
+type SharePageHandler struct {
+	productService     *service.ProductService
+	translationService *service.TranslationService
+	cache              *httpcache.Cache
+	apiURL             string
+	productTmpl        *template.Template
+}
+
+// NewSharePageHandler creates a SharePageHandler. apiURL is the public address
+// of this server, which absolute URLs are built from; the routes are not
+// served without it, so a request's Host header never ends up in a page.
+func NewSharePageHandler(productService *service.ProductService, translationService *service.TranslationService, cache *httpcache.Cache, apiURL string, funcMap template.FuncMap) *SharePageHandler {
+	return &SharePageHandler{
+		productService:     productService,
+		translationService: translationService,
+		cache:              cache,
+		apiURL:             strings.TrimRight(strings.TrimSpace(apiURL), "/"),
+		productTmpl: template.Must(
+			template.New("share_product").Funcs(funcMap).ParseFiles("templates/share/product.html"),
+		),
+	}
+}
+
+// Product godoc
+// @Summary Product share page
+// @Description HTML page with Open Graph and Twitter card tags for link previews. Browsers are sent on to the frontend product page.
+// @Tags share
+// @Produce html
+// @Param slug path string true "Product slug"
+// @Param lang query string false "Content locale, e.g. vi or en"
+// @Success 200 {string} string "HTML page"
+// @Success 302 {string} string "Redirect to the frontend when the product is not found"
+// @Router /p/{slug} [get]
+func (h *SharePageHandler) Product(c *gin.Context) {
+	slug := strings.TrimSpace(c.Param("slug"))
+	epoch, err := h.productService.ScheduleEpoch()
+	if err != nil {
+		log.Printf("Share page error: %v", err)
+		c.String(http.StatusInternalServerError, "An unexpected error occurred")
+		return
+	}
+	locale := responseLocale(c, h.translationService)
+	origin := h.apiURL
+	key := fmt.Sprintf("share/%s/%s@%d", locale, slug, epoch.Unix())
+	entry, err := h.cache.Load(key, func() (*httpcache.Entry, error) {
+		product, version, err := h.productService.GetBySlugWithVersion(slug, locale)
+		if err != nil {
+			return nil, err
+		}
+		if !models.ProductVisible(product.Status) {
+			return nil, service.ErrProductNotFound
+		}
+		var buf bytes.Buffer
+		if err := h.productTmpl.ExecuteTemplate(&buf, "share_product", h.productPage(product, locale, origin)); err != nil {
+			return nil, err
+		}
+		return &httpcache.Entry{
+			Value:        buf.Bytes(),
+			ETag:         httpcache.WeakETag(origin, locale, version.Tag),
+			LastModified: version.LastModified,
+		}, nil
+	})
+	if err != nil {
+		// The frontend shows its own page for products that are gone
+		if errors.Is(err, service.ErrProductNotFound) {
+			c.Redirect(http.StatusFound, h.productService.ProductURL(slug))
+			return
+		}
+		log.Printf("Share page error: %v", err)
+		c.String(http.StatusInternalServerError, "An unexpected error occurred")
+		return
+	}
+
+	httpcache.RespondData(c, entry, h.cache.MaxAge(), "text/html; charset=utf-8")
+}
+
+type sharePage struct {
+	Locale      string
+	OGLocale    string
+	SiteName    string
+	Title       string
+	Description string
+	PageURL     string
+	ProductURL  string
+	ImageURL    string
+	ImageAlt    string
+	Price       float64
+	PriceAmount string
+}
+
+func (h *SharePageHandler) productPage(p *dto.ProductResponse, locale, origin string) *sharePage {
+	page := &sharePage{
+		Locale:      locale,
+		OGLocale:    ogLocales[locale],
+		SiteName:    shareSiteName,
+		Title:       p.Name,
+		Description: fmt.Sprintf(shareDescription(locale), p.Name, shareSiteName),
+		PageURL:     origin + "/p/" + url.PathEscape(p.Slug),
+		ProductURL:  h.productService.ProductURL(p.Slug),
+		ImageAlt:    p.Name,
+		Price:       p.Price,
+		PriceAmount: strconv.FormatFloat(p.Price, 'f', -1, 64),
+	}
+	if p.Description != nil {
+		if d := strings.Join(strings.Fields(*p.Description), " "); d != "" {
+			page.Description = truncateRunes(d, shareDescriptionMaxLength)
+		}
+	}
+	if img := p.PrimaryImage; img != nil {
+		page.ImageURL = absoluteURL(origin, img.ImageURL)
+		if img.AltText != nil && *img.AltText != "" {
+			page.ImageAlt = *img.AltText
+		}
+	}
+	return page
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap godoc
+// @Summary Sitemap
+// @Description Frontend pages of the products that are not inactive, for search engines
+// @Tags share
+// @Produce xml
+// @Success 200 {string} string "sitemap.xml"
+// @Router /sitemap.xml [get]
+func (h *SharePageHandler) Sitemap(c *gin.Context) {
+	version, err := h.productService.ListVersion()
+	if err != nil {
+		log.Printf("Sitemap error: %v", err)
+		c.String(http.StatusInternalServerError, "An unexpected error occurred")
+		return
+	}
+	entry, err := h.cache.Load("sitemap.xml@"+version.Tag, func() (*httpcache.Entry, error) {
+		entries, err := h.productService.SitemapEntries()
+		if err != nil {
+			return nil, err
+		}
+		set := sitemapURLSet{Xmlns: sitemapNamespace, URLs: []sitemapURL{{Loc: h.productService.ProductURL("")}}}
+		for _, e := range entries {
+			set.URLs = append(set.URLs, sitemapURL{Loc: e.URL, LastMod: e.LastModified.UTC().Format(time.RFC3339)})
+		}
+		body, err := xml.MarshalIndent(set, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &httpcache.Entry{
+			Value:        append([]byte(xml.Header), body...),
+			ETag:         httpcache.WeakETag("sitemap", version.Tag),
+			LastModified: version.LastModified,
+		}, nil
+	})
+	if err != nil {
+		log.Printf("Sitemap error: %v", err)
+		c.String(http.StatusInternalServerError, "An unexpected error occurred")
+		return
+	}
+
+	httpcache.RespondData(c, entry, h.cache.MaxAge(), "application/xml; charset=utf-8")
+}
+
+// Robots godoc
+// @Summary robots.txt
+// @Description Lets crawlers read the share pages but not the API or admin, and points them at the sitemap
+// @Tags share
+// @Produce plain
+// @Success 200 {string} string "robots.txt"
+// @Router /robots.txt [get]
+func (h *SharePageHandler) Robots(c *gin.Context) {
+	origin := h.apiURL
+	entry, _ := h.cache.Load("robots.txt", func() (*httpcache.Entry, error) {
+		body := strings.Join([]string{
+			"User-agent: *",
+			"Allow: /p/",
+			"Disallow: /api/",
+			"Disallow: /admin",
+			"",
+			"Sitemap: " + origin + "/sitemap.xml",
+			"",
+		}, "\n")
+		return &httpcache.Entry{Value: []byte(body), ETag: httpcache.WeakETag(body)}, nil
+	})
+
+	httpcache.RespondData(c, entry, h.cache.MaxAge(), "text/plain; charset=utf-8")
+}
+
+// absoluteURL resolves paths served by this server, such as local uploads,
+// against origin. Crawlers ignore relative og:image URLs.
+func absoluteURL(origin, u string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return origin + u
+	}
+	return u
+}
+
+// shareDescription returns the default description format of the locale,
+// falling back to the one of the default locale
+func shareDescription(locale string) string {
+	if format, ok := shareDescriptions[locale]; ok {
+		return format
+	}
+	return shareDescriptions[service.DefaultLocale]
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
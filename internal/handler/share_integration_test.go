@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/httpcache"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/service"
+)
+
+func TestSharePageHandler(t *testing.T) {
+	db := newProductTestDB(t)
+	cat := &models.Category{Name: "Drinks", Slug: "drinks-share-test"}
+	db.Create(cat)
+	description := "Trà sữa\nvới trân châu đen"
+	active := &models.Product{CategoryID: cat.ID, Name: "Trà sữa", Slug: "tra-sua", Description: &description, Classify: "drink", Price: 30000, Stock: 10, Status: "active"}
+	db.Create(active)
+	db.Create(&models.ProductImage{ProductID: active.ID, ImageURL: "/uploads/products/tra-sua.jpg", IsPrimary: true})
+	db.Create(&models.Product{CategoryID: cat.ID, Name: "Cà phê", Slug: "ca-phe", Classify: "drink", Price: 20000, Stock: 10, Status: "inactive"})
+	bread := &models.Product{CategoryID: cat.ID, Name: "Bánh mì", Slug: "banh-mi", Classify: "food", Price: 15000, Stock: 10, Status: "active"}
+	db.Create(bread)
+	if err := db.AutoMigrate(&models.Translation{}); err != nil {
+		t.Fatalf("migrate translations: %v", err)
+	}
+	db.Create(&models.Translation{EntityType: models.TranslationEntityProduct, EntityID: bread.ID, Field: models.TranslationFieldName, Locale: "en", Value: "Baguette"})
+	translations := service.NewTranslationService(repository.NewTranslationRepository(db), "vi", []string{"vi", "en"})
+
+	products := service.NewProductService(repository.NewProductRepository(db), repository.NewCategoryRepository(db), nil, "http://shop.test", "", nil, nil, nil, nil, nil, translations)
+	// The template is read relative to the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("chdir repo root: %v", err)
+	}
+	h := NewSharePageHandler(products, translations, httpcache.New(time.Minute, 10, time.Minute), "http://api.test", template.FuncMap{
+		"formatVND": func(amount float64) string { return "30.000đ" },
+	})
+	if err := os.Chdir(wd); err != nil {
+		t.Fatalf("chdir back: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/p/:slug", h.Product)
+	r.GET("/sitemap.xml", h.Sitemap)
+	r.GET("/robots.txt", h.Robots)
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		// Absolute URLs come from the configured address, not the Host header
+		req.Host = "evil.test"
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/p/tra-sua", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("share page status = %d: %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Trà sữa" />`,
+		`<meta property="og:description" content="Trà sữa với trân châu đen" />`,
+		`<meta property="og:url" content="http://api.test/p/tra-sua" />`,
+		`<meta property="og:image" content="http://api.test/uploads/products/tra-sua.jpg" />`,
+		`<meta property="product:price:amount" content="30000" />`,
+		`<meta name="twitter:card" content="summary_large_image" />`,
+		`<link rel="canonical" href="http://shop.test/products/tra-sua" />`,
+		`window.location.replace("http://shop.test/products/tra-sua")`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("share page lacks %s", want)
+		}
+	}
+	if w := get("/p/tra-sua", map[string]string{"If-None-Match": w.Header().Get("ETag")}); w.Code != http.StatusNotModified {
+		t.Errorf("revalidated share page status = %d, want 304", w.Code)
+	}
+
+	// Without a description of its own the text is in the negotiated locale
+	for lang, want := range map[string]string{
+		"vi": `<meta property="og:description" content="Khám phá Bánh mì tại Foods &amp; Drinks" />`,
+		"en": `<meta property="og:description" content="Discover Baguette at Foods &amp; Drinks" />`,
+	} {
+		if body := get("/p/banh-mi?lang="+lang, nil).Body.String(); !strings.Contains(body, want) {
+			t.Errorf("%s share page lacks %s:\n%s", lang, want, body)
+		}
+	}
+
+	// Hidden and unknown products are left to the frontend
+	for _, slug := range []string{"ca-phe", "khong-co"} {
+		w := get("/p/"+slug, nil)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "http://shop.test/products/"+slug {
+			t.Errorf("GET /p/%s = %d to %q, want a redirect to the frontend", slug, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	w = get("/sitemap.xml", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("sitemap = %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	sitemap := w.Body.String()
+	if !strings.Contains(sitemap, "<loc>http://shop.test/products/tra-sua</loc>") || strings.Contains(sitemap, "ca-phe") {
+		t.Fatalf("sitemap should list only active products:\n%s", sitemap)
+	}
+
+	// A product change shows up without waiting for the cache to expire
+	db.Model(&models.Product{}).Where("slug = ?", "ca-phe").Updates(map[string]interface{}{"status": "active", "updated_at": time.Now().Add(time.Second)})
+	if sitemap := get("/sitemap.xml", nil).Body.String(); !strings.Contains(sitemap, "<loc>http://shop.test/products/ca-phe</loc>") {
+		t.Fatalf("sitemap after activating a product:\n%s", sitemap)
+	}
+
+	w = get("/robots.txt", map[string]string{"X-Forwarded-Proto": "https"})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Sitemap: http://api.test/sitemap.xml") {
+		t.Fatalf("robots.txt = %d:\n%s", w.Code, w.Body)
+	}
+}
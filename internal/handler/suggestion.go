@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kha/foods-drinks/internal/dto"
@@ -58,6 +59,8 @@ func (h *SuggestionHandler) handleSuggestionError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrCategoryNotFound):
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "category_not_found", Message: "Category not found"})
+	case errors.Is(err, service.ErrInvalidDietaryInfo):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "validation_error", Message: strings.TrimPrefix(err.Error(), service.ErrInvalidDietaryInfo.Error()+": ")})
 	case errors.Is(err, service.ErrInvalidSuggestionState):
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid_suggestion_state", Message: "Invalid suggestion state"})
 	default:
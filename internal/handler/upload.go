@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/storage"
+)
+
+// PrivateUploadAccess guards /uploads/*filepath: keys under
+// storage.PrivatePrefix are only served to their owner and to admins, and
+// answer 404 to anyone else so their existence is not revealed. It runs
+// after OptionalAuth, in front of both the static files and UploadHandler.
+func PrivateUploadAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := storage.CleanKey(strings.TrimPrefix(c.Param("filepath"), "/"))
+		if err != nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		owner, private := storage.PrivateOwner(key)
+		if !private {
+			c.Next()
+			return
+		}
+		userID, ok := middleware.GetUserID(c)
+		if !ok || (userID != owner && !middleware.IsAdmin(c)) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		// A private file must not be kept by shared caches
+		c.Header("Cache-Control", "private, no-store")
+		c.Next()
+	}
+}
+
+// UploadHandler serves stored upload URLs when files live in a remote
+// backend, by redirecting to the backend (or a freshly signed) URL. Private
+// keys are always signed; PrivateUploadAccess decides who may get them.
+type UploadHandler struct {
+	store storage.Storage
+}
+
+func NewUploadHandler(store storage.Storage) *UploadHandler {
+	return &UploadHandler{store: store}
+}
+
+// Serve handles GET /uploads/*filepath
+func (h *UploadHandler) Serve(c *gin.Context) {
+	key, err := storage.CleanKey(strings.TrimPrefix(c.Param("filepath"), "/"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	target, err := h.store.URL(c.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrInvalidKey) {
+			log.Printf("Upload URL error: %v", err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	// Signed URLs expire, so the redirect itself must not be cached for long
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "private, max-age=60")
+	}
+	c.Redirect(http.StatusFound, target)
+}
@@ -0,0 +1,168 @@
+// Package httpcache holds rendered public API responses in memory and answers
+// conditional requests against their validators.
+package httpcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// Defaults apply when the configured values are not positive
+	DefaultTTL        = time.Minute
+	DefaultMaxEntries = 1000
+	DefaultMaxAge     = 30 * time.Second
+)
+
+// Entry is one cached response
+type Entry struct {
+	// Value is the response body before encoding
+	Value        interface{}
+	ETag         string
+	LastModified time.Time
+}
+
+type item struct {
+	key     string
+	entry   *Entry
+	expires time.Time
+}
+
+// Stats are the cache counters since start
+type Stats struct {
+	Hits          uint64
+	Misses        uint64
+	HitRatio      float64
+	Entries       int
+	Invalidations uint64
+	Evictions     uint64
+}
+
+// Cache is a size-bounded LRU of responses with a TTL. Entries are dropped
+// all at once when the catalog changes; the TTL covers changes made outside
+// the services, such as stock updates from orders or edits in the database.
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxAge     time.Duration
+	maxEntries int
+	items      map[string]*list.Element
+	lru        *list.List // front is most recently used
+	generation uint64
+	stats      Stats
+	now        func() time.Time
+}
+
+// New creates a Cache. maxAge is what clients are told to cache for in
+// Cache-Control, independent of how long the server keeps entries.
+func New(ttl time.Duration, maxEntries int, maxAge time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// Load returns the cached entry for key, or calls load and caches its result.
+// A result is not cached if the cache was purged while load ran, since it
+// may have read data from before the change.
+func (c *Cache) Load(key string, load func() (*Entry, error)) (*Entry, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		it := el.Value.(*item)
+		if c.now().Before(it.expires) {
+			c.lru.MoveToFront(el)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return it.entry, nil
+		}
+		c.removeLocked(el)
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	entry, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return entry, nil
+	}
+	if el, ok := c.items[key]; ok {
+		c.removeLocked(el)
+	}
+	c.items[key] = c.lru.PushFront(&item{key: key, entry: entry, expires: c.now().Add(c.ttl)})
+	for c.lru.Len() > c.maxEntries {
+		c.removeLocked(c.lru.Back())
+		c.stats.Evictions++
+	}
+	return entry, nil
+}
+
+// Purge drops every entry
+func (c *Cache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.generation++
+	c.stats.Invalidations++
+}
+
+// CatalogChanged implements service.CatalogListener
+func (c *Cache) CatalogChanged() {
+	c.Purge()
+}
+
+// MaxAge is the max-age sent to clients
+func (c *Cache) MaxAge() time.Duration {
+	if c == nil {
+		return DefaultMaxAge
+	}
+	return c.maxAge
+}
+
+// Stats returns a snapshot of the counters
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRatio = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+func (c *Cache) removeLocked(el *list.Element) {
+	delete(c.items, el.Value.(*item).key)
+	c.lru.Remove(el)
+}
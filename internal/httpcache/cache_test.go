@@ -0,0 +1,135 @@
+package httpcache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCache_LoadHitMissAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := New(time.Minute, 10, 0)
+	c.now = func() time.Time { return now }
+
+	calls := 0
+	load := func() (*Entry, error) {
+		calls++
+		return &Entry{Value: calls, ETag: `W/"x"`}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		e, err := c.Load("k", load)
+		if err != nil || e.Value != 1 {
+			t.Fatalf("load %d = %v, %v", i, e, err)
+		}
+	}
+	now = now.Add(time.Minute)
+	if e, _ := c.Load("k", load); e.Value != 2 {
+		t.Fatalf("expired entry served: %v", e.Value)
+	}
+
+	if _, err := c.Load("err", func() (*Entry, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("load error swallowed")
+	}
+
+	s := c.Stats()
+	if s.Hits != 2 || s.Misses != 3 || s.Entries != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if s.HitRatio != 0.4 {
+		t.Fatalf("hit ratio = %v, want 0.4", s.HitRatio)
+	}
+}
+
+func TestCache_PurgeDuringLoadIsNotCached(t *testing.T) {
+	t.Parallel()
+
+	c := New(time.Minute, 10, 0)
+	if _, err := c.Load("k", func() (*Entry, error) {
+		c.CatalogChanged()
+		return &Entry{Value: "stale"}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := c.Load("k", func() (*Entry, error) { return &Entry{Value: "fresh"}, nil }); e.Value != "fresh" {
+		t.Fatalf("entry loaded across a purge was cached: %v", e.Value)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c := New(time.Minute, 2, 0)
+	put := func(key string) {
+		c.Load(key, func() (*Entry, error) { return &Entry{Value: key}, nil })
+	}
+	put("a")
+	put("b")
+	put("a") // hit, a becomes most recent
+	put("c") // evicts b
+
+	missed := false
+	c.Load("b", func() (*Entry, error) { missed = true; return &Entry{}, nil })
+	if !missed {
+		t.Fatal("b should have been evicted")
+	}
+	if s := c.Stats(); s.Evictions != 2 || s.Entries != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestCache_NilIsPassThrough(t *testing.T) {
+	t.Parallel()
+
+	var c *Cache
+	e, err := c.Load("k", func() (*Entry, error) { return &Entry{Value: 1}, nil })
+	if err != nil || e.Value != 1 {
+		t.Fatalf("nil cache load = %v, %v", e, err)
+	}
+	c.CatalogChanged()
+	if c.MaxAge() != DefaultMaxAge || c.Stats() != (Stats{}) {
+		t.Fatal("nil cache should report defaults")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 500, time.UTC)
+	entry := &Entry{ETag: WeakETag("v1"), LastModified: modified}
+	strong := entry.ETag[2:]
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no validators", nil, false},
+		{"matching tag", map[string]string{"If-None-Match": entry.ETag}, true},
+		{"strong form of weak tag", map[string]string{"If-None-Match": strong}, true},
+		{"tag in list", map[string]string{"If-None-Match": `"other", ` + entry.ETag}, true},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, true},
+		{"other tag", map[string]string{"If-None-Match": WeakETag("v2")}, false},
+		{"tag wins over date", map[string]string{"If-None-Match": WeakETag("v2"), "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, false},
+		{"same second", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"older date", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"bad date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest(http.MethodGet, "/products", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := NotModified(r, entry); got != tt.want {
+				t.Errorf("NotModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
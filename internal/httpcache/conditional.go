@@ -0,0 +1,89 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WeakETag builds a weak validator from the given parts. Weak because the
+// same data may be encoded with different bytes, e.g. by another server version.
+func WeakETag(parts ...interface{}) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// Respond writes entry as JSON with its validators and Cache-Control, or an
+// empty 304 when the request's validators still match
+func Respond(c *gin.Context, entry *Entry, maxAge time.Duration) {
+	if respondNotModified(c, entry, maxAge) {
+		return
+	}
+	c.JSON(http.StatusOK, entry.Value)
+}
+
+// RespondData is Respond for an entry whose value is an already encoded
+// []byte body of the given content type
+func RespondData(c *gin.Context, entry *Entry, maxAge time.Duration, contentType string) {
+	if respondNotModified(c, entry, maxAge) {
+		return
+	}
+	body, _ := entry.Value.([]byte)
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// respondNotModified sets the caching headers and writes a 304 when the
+// client's copy is current
+func respondNotModified(c *gin.Context, entry *Entry, maxAge time.Duration) bool {
+	h := c.Writer.Header()
+	h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(math.Ceil(maxAge.Seconds()))))
+	h.Set("ETag", entry.ETag)
+	if !entry.LastModified.IsZero() {
+		h.Set("Last-Modified", entry.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if NotModified(c.Request, entry) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// NotModified reports whether the client's copy is current. If-None-Match
+// takes precedence over If-Modified-Since, as RFC 9110 requires.
+func NotModified(r *http.Request, entry *Entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, entry.ETag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !entry.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have whole-second precision
+		return !entry.LastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// etagMatches applies the weak comparison used for If-None-Match
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -14,8 +14,8 @@ func CORSConfig() gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowAllOrigins:  true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "If-None-Match", "If-Modified-Since"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag", "Last-Modified"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	})
@@ -26,8 +26,8 @@ func CORSConfigWithOrigins(origins []string) gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "If-None-Match", "If-Modified-Since"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag", "Last-Modified"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kha/foods-drinks/internal/dto"
+)
+
+const (
+	// DefaultRateLimit and DefaultRateWindow apply when the configured values are not positive
+	DefaultRateLimit  = 30
+	DefaultRateWindow = 10 * time.Second
+)
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a per-client-IP token bucket: each IP may burst up to limit
+// requests, refilled evenly over window. State is kept in memory, so each
+// server instance enforces its own budget.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     float64
+	window    time.Duration
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing limit requests per window per IP
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	if limit <= 0 {
+		limit = DefaultRateLimit
+	}
+	if window <= 0 {
+		window = DefaultRateWindow
+	}
+	return &RateLimiter{
+		limit:   float64(limit),
+		window:  window,
+		buckets: make(map[string]*rateBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token for key and reports whether the request may proceed.
+// When it may not, the returned duration is how long until a token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	rate := l.limit / l.window.Seconds() // tokens per second
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: l.limit, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware rejects requests over the limit with 429 Too Many Requests
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, wait := l.Allow(c.ClientIP())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, dto.ErrorResponse{
+				Error:   "rate_limited",
+				Message: "Too many requests, please slow down",
+			})
+			return
+		}
+		c.Next()
+	}
+}
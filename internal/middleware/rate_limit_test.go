@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiter_PerIPBucket(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(2, time.Second)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	r := gin.New()
+	r.GET("/suggest", limiter.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	call := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/suggest", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := call("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i+1, w.Code)
+		}
+	}
+	w := call("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want 1", got)
+	}
+
+	// Other clients have their own budget
+	if w := call("10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("other ip status = %d, want 200", w.Code)
+	}
+
+	// Half the window refills one token
+	now = now.Add(500 * time.Millisecond)
+	if w := call("10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("after refill status = %d, want 200", w.Code)
+	}
+	if w := call("10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("after refill second status = %d, want 429", w.Code)
+	}
+
+	// Idle buckets are swept
+	now = now.Add(2 * time.Second)
+	call("10.0.0.3")
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("buckets = %d, want 1 after sweep", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// AvailabilityWindow is a weekly time range in which a product is served,
+// attached to exactly one of a product or a category. A product with windows
+// of its own ignores those of its category; a product without any window,
+// own or inherited, is served all day.
+type AvailabilityWindow struct {
+	ID         uint  `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID  *uint `gorm:"index" json:"product_id,omitempty"`
+	CategoryID *uint `gorm:"index" json:"category_id,omitempty"`
+	// Weekday follows time.Weekday: 0 is Sunday
+	Weekday int `gorm:"not null" json:"weekday"`
+	// StartTime and EndTime are "HH:MM" in the shop timezone. An end at or
+	// before the start runs past midnight into the next day.
+	StartTime string    `gorm:"type:varchar(5);not null" json:"start_time"`
+	EndTime   string    `gorm:"type:varchar(5);not null" json:"end_time"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (AvailabilityWindow) TableName() string {
+	return "availability_windows"
+}
+
+// AvailabilityException replaces the weekly windows on one date, e.g. closed
+// on a holiday or open for a shorter time. Rows without times close the whole
+// day; several rows on the same date give several open ranges.
+type AvailabilityException struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID  *uint     `gorm:"index" json:"product_id,omitempty"`
+	CategoryID *uint     `gorm:"index" json:"category_id,omitempty"`
+	Date       string    `gorm:"type:varchar(10);not null;index" json:"date"` // YYYY-MM-DD
+	StartTime  string    `gorm:"type:varchar(5);not null;default:''" json:"start_time"`
+	EndTime    string    `gorm:"type:varchar(5);not null;default:''" json:"end_time"`
+	Note       string    `gorm:"type:varchar(255);not null;default:''" json:"note"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (AvailabilityException) TableName() string {
+	return "availability_exceptions"
+}
+
+// Closed reports whether the exception closes the whole day
+func (e *AvailabilityException) Closed() bool {
+	return e.StartTime == "" || e.EndTime == ""
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// BundleItem is one component of a bundle product, e.g. the fries in a
+// "Burger + Fries + Coke" combo. A bundle's stock is derived from its
+// components and ordering it consumes their stock.
+type BundleItem struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	BundleID    uint      `gorm:"not null;index" json:"bundle_id"`
+	ComponentID uint      `gorm:"not null;index" json:"component_id"`
+	Quantity    int       `gorm:"not null;default:1" json:"quantity"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Component *Product `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+}
+
+func (BundleItem) TableName() string {
+	return "bundle_items"
+}
+
+// OrderItemComponent snapshots a bundle component at the time of ordering.
+// Quantity is the number of units consumed by the whole order line and
+// Subtotal is the share of the line's subtotal attributed to the component.
+type OrderItemComponent struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderItemID uint      `gorm:"not null;index" json:"order_item_id"`
+	ProductID   uint      `gorm:"not null;index" json:"product_id"`
+	ProductName string    `gorm:"type:varchar(255);not null" json:"product_name"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	Subtotal    float64   `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OrderItemComponent) TableName() string {
+	return "order_item_components"
+}
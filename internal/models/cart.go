@@ -20,12 +20,13 @@ func (Cart) TableName() string {
 }
 
 type CartItem struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	CartID    uint      `gorm:"not null;index;uniqueIndex:uk_cart_product" json:"cart_id"`
-	ProductID uint      `gorm:"not null;index;uniqueIndex:uk_cart_product" json:"product_id"`
-	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CartID      uint      `gorm:"not null;index;uniqueIndex:uk_cart_product_modifier" json:"cart_id"`
+	ProductID   uint      `gorm:"not null;index;uniqueIndex:uk_cart_product_modifier" json:"product_id"`
+	Quantity    int       `gorm:"not null;default:1" json:"quantity"`
+	ModifierKey string    `gorm:"type:varchar(255);not null;default:'';uniqueIndex:uk_cart_product_modifier" json:"modifier_key"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// Relationships
 	Cart    *Cart    `gorm:"foreignKey:CartID" json:"cart,omitempty"`
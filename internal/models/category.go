@@ -13,16 +13,18 @@ const (
 )
 
 type Category struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name        string         `gorm:"type:varchar(255);not null" json:"name"`
-	Slug        string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
-	Description *string        `gorm:"type:text" json:"description,omitempty"`
-	ImageURL    *string        `gorm:"type:varchar(500)" json:"image_url,omitempty"`
-	SortOrder   int            `gorm:"not null;default:0;index" json:"sort_order"`
-	Status      string         `gorm:"type:varchar(50);not null;default:active;index" json:"status"` // Default uses CategoryStatusActive value
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	ParentID     *uint          `gorm:"index" json:"parent_id,omitempty"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Slug         string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Description  *string        `gorm:"type:text" json:"description,omitempty"`
+	ImageURL     *string        `gorm:"type:varchar(500)" json:"image_url,omitempty"`
+	ThumbnailURL *string        `gorm:"type:varchar(500)" json:"thumbnail_url,omitempty"`
+	SortOrder    int            `gorm:"not null;default:0;index" json:"sort_order"`
+	Status       string         `gorm:"type:varchar(50);not null;default:active;index" json:"status"` // Default uses CategoryStatusActive value
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships - using pointers to avoid large response when not preloaded
 	Products    *[]Product    `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
@@ -0,0 +1,27 @@
+package models
+
+// Allergen constants, the fixed list products and suggestions declare
+const (
+	AllergenPeanut    = "peanut"
+	AllergenTreeNut   = "tree-nut"
+	AllergenMilk      = "milk"
+	AllergenEgg       = "egg"
+	AllergenSoy       = "soy"
+	AllergenGluten    = "gluten"
+	AllergenFish      = "fish"
+	AllergenShellfish = "shellfish"
+	AllergenSesame    = "sesame"
+)
+
+// Allergens lists every allergen in display order
+var Allergens = []string{
+	AllergenPeanut,
+	AllergenTreeNut,
+	AllergenMilk,
+	AllergenEgg,
+	AllergenSoy,
+	AllergenGluten,
+	AllergenFish,
+	AllergenShellfish,
+	AllergenSesame,
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status constants
+const (
+	ModifierStatusActive   = "active"
+	ModifierStatusInactive = "inactive"
+)
+
+// ModifierGroup is an admin-managed set of add-ons (e.g. "Topping") with
+// selection rules. A group applies to a product when it is assigned either to
+// the product itself or to the product's category.
+type ModifierGroup struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
+	MinSelect int            `gorm:"not null;default:0" json:"min_select"`
+	MaxSelect int            `gorm:"not null;default:1" json:"max_select"`
+	SortOrder int            `gorm:"not null;default:0;index" json:"sort_order"`
+	Status    string         `gorm:"type:varchar(50);not null;default:active;index" json:"status"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Options     []ModifierOption          `gorm:"foreignKey:GroupID" json:"options,omitempty"`
+	Assignments []ModifierGroupAssignment `gorm:"foreignKey:GroupID" json:"assignments,omitempty"`
+}
+
+func (ModifierGroup) TableName() string {
+	return "modifier_groups"
+}
+
+// BeforeCreate hook to set default status using constant
+func (g *ModifierGroup) BeforeCreate(_ *gorm.DB) error {
+	if g.Status == "" {
+		g.Status = ModifierStatusActive
+	}
+	return nil
+}
+
+type ModifierOption struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	GroupID   uint      `gorm:"not null;index" json:"group_id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	Price     float64   `gorm:"type:decimal(10,2);not null;default:0.00" json:"price"`
+	SortOrder int       `gorm:"not null;default:0" json:"sort_order"`
+	Status    string    `gorm:"type:varchar(50);not null;default:active" json:"status"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Group *ModifierGroup `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+}
+
+func (ModifierOption) TableName() string {
+	return "modifier_options"
+}
+
+// BeforeCreate hook to set default status using constant
+func (o *ModifierOption) BeforeCreate(_ *gorm.DB) error {
+	if o.Status == "" {
+		o.Status = ModifierStatusActive
+	}
+	return nil
+}
+
+// ModifierGroupAssignment attaches a group to exactly one of a product or a category.
+type ModifierGroupAssignment struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	GroupID    uint      `gorm:"not null;index" json:"group_id"`
+	ProductID  *uint     `gorm:"index" json:"product_id,omitempty"`
+	CategoryID *uint     `gorm:"index" json:"category_id,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ModifierGroupAssignment) TableName() string {
+	return "modifier_group_assignments"
+}
@@ -48,10 +48,27 @@ type OrderItem struct {
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 
 	// Relationships
-	Order   *Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
-	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Order      *Order               `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Product    *Product             `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Modifiers  []OrderItemModifier  `gorm:"foreignKey:OrderItemID" json:"modifiers,omitempty"`
+	Components []OrderItemComponent `gorm:"foreignKey:OrderItemID" json:"components,omitempty"`
 }
 
 func (OrderItem) TableName() string {
 	return "order_items"
 }
+
+// OrderItemModifier snapshots a selected modifier option at the time of ordering.
+type OrderItemModifier struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderItemID      uint      `gorm:"not null;index" json:"order_item_id"`
+	ModifierOptionID uint      `gorm:"not null" json:"modifier_option_id"`
+	GroupName        string    `gorm:"type:varchar(255);not null" json:"group_name"`
+	OptionName       string    `gorm:"type:varchar(255);not null" json:"option_name"`
+	Price            float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OrderItemModifier) TableName() string {
+	return "order_item_modifiers"
+}
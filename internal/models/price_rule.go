@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// PriceRule lowers the price of a product, or of every product in a category
+// and its subcategories, between StartsAt and EndsAt. When several rules
+// apply at once the lowest resulting price wins.
+type PriceRule struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID  *uint  `gorm:"index" json:"product_id,omitempty"`
+	CategoryID *uint  `gorm:"index" json:"category_id,omitempty"`
+	Name       string `gorm:"type:varchar(100);not null" json:"name"`
+	// Kind says whether Value is the sale price or a discount in percent
+	Kind     string    `gorm:"type:varchar(20);not null" json:"kind"`
+	Value    float64   `gorm:"type:decimal(10,2);not null" json:"value"`
+	StartsAt time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt   time.Time `gorm:"not null;index" json:"ends_at"`
+	// DailyStart and DailyEnd are "HH:MM" in the shop timezone and limit the
+	// rule to that part of each day; both empty means all day. An end at or
+	// before the start runs past midnight.
+	DailyStart string    `gorm:"type:varchar(5);not null;default:''" json:"daily_start"`
+	DailyEnd   string    `gorm:"type:varchar(5);not null;default:''" json:"daily_end"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Product  *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Category *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+}
+
+func (PriceRule) TableName() string {
+	return "price_rules"
+}
+
+// Kind constants
+const (
+	PriceRuleFixed   = "fixed"
+	PriceRulePercent = "percent"
+)
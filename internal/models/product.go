@@ -3,37 +3,57 @@ package models
 import (
 	"time"
 
+	"github.com/kha/foods-drinks/internal/search"
 	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	CategoryID    uint           `gorm:"not null;index" json:"category_id"`
-	Name          string         `gorm:"type:varchar(255);not null" json:"name"`
-	Slug          string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
-	Description   *string        `gorm:"type:text" json:"description,omitempty"`
-	Classify      string         `gorm:"type:varchar(50);not null;index" json:"classify"`
-	Price         float64        `gorm:"type:decimal(10,2);not null;index" json:"price"`
-	Stock         int            `gorm:"not null;default:0" json:"stock"`
-	RatingAverage float64        `gorm:"type:decimal(3,2);not null;default:0.00;index" json:"rating_average"`
-	RatingCount   int            `gorm:"not null;default:0" json:"rating_count"`
-	Status        string         `gorm:"type:varchar(50);not null;default:active;index" json:"status"`
-	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	CategoryID        uint           `gorm:"not null;index" json:"category_id"`
+	Name              string         `gorm:"type:varchar(255);not null" json:"name"`
+	NameInitial       string         `gorm:"type:varchar(1);not null;default:'#';index" json:"-"` // folded first letter for the alphabet filter
+	Slug              string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Description       *string        `gorm:"type:text" json:"description,omitempty"`
+	Classify          string         `gorm:"type:varchar(50);not null;index" json:"classify"`
+	Price             float64        `gorm:"type:decimal(10,2);not null;index" json:"price"`
+	Stock             int            `gorm:"not null;default:0" json:"stock"`
+	RatingAverage     float64        `gorm:"type:decimal(3,2);not null;default:0.00;index" json:"rating_average"`
+	RatingCount       int            `gorm:"not null;default:0" json:"rating_count"`
+	Status            string         `gorm:"type:varchar(50);not null;default:active;index" json:"status"`
+	IsBundle          bool           `gorm:"not null;default:false;index" json:"is_bundle"` // stock is derived from BundleItems
+	LowStockThreshold int            `gorm:"not null;default:0" json:"low_stock_threshold"` // alert once stock falls to this or below
+	LowStockAlertedAt *time.Time     `gorm:"type:timestamp" json:"-"`                       // set while an alert is outstanding, cleared when stock recovers
+	Calories          *int           `json:"calories,omitempty"`                            // kcal per serving, nil when unknown
+	SugarGrams        *float64       `gorm:"type:decimal(6,2)" json:"sugar_grams,omitempty"`
+	CaffeineMg        *int           `json:"caffeine_mg,omitempty"`
+	Allergens         []string       `gorm:"type:varchar(500);serializer:json" json:"allergens,omitempty"`    // keys from the Allergens list
+	DietaryTags       []string       `gorm:"type:varchar(500);serializer:json" json:"dietary_tags,omitempty"` // free-form, e.g. vegan, spicy-2
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
-	Category   *Category      `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	Images     []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
-	CartItems  []CartItem     `gorm:"foreignKey:ProductID" json:"cart_items,omitempty"`
-	OrderItems []OrderItem    `gorm:"foreignKey:ProductID" json:"order_items,omitempty"`
-	Ratings    []Rating       `gorm:"foreignKey:ProductID" json:"ratings,omitempty"`
+	Category    *Category      `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Images      []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	CartItems   []CartItem     `gorm:"foreignKey:ProductID" json:"cart_items,omitempty"`
+	OrderItems  []OrderItem    `gorm:"foreignKey:ProductID" json:"order_items,omitempty"`
+	Ratings     []Rating       `gorm:"foreignKey:ProductID" json:"ratings,omitempty"`
+	BundleItems []BundleItem   `gorm:"foreignKey:BundleID" json:"bundle_items,omitempty"`
 }
 
 func (Product) TableName() string {
 	return "products"
 }
 
+// BeforeSave keeps NameInitial in step with Name. Column updates such as
+// stock changes run it on an empty model, which must not touch the field.
+func (p *Product) BeforeSave(_ *gorm.DB) error {
+	if p.Name != "" {
+		p.NameInitial = search.Initial(p.Name)
+	}
+	return nil
+}
+
 // Classify constants
 const (
 	ClassifyFood  = "food"
@@ -46,3 +66,10 @@ const (
 	ProductStatusInactive   = "inactive"
 	ProductStatusOutOfStock = "out_of_stock"
 )
+
+// ProductVisible reports whether a product with status can be shown publicly.
+// Sold-out products stay visible; only inactive ones are hidden. Selling is
+// still limited to active products.
+func ProductVisible(status string) bool {
+	return status != ProductStatusInactive
+}
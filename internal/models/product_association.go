@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductAssociation is a precomputed "frequently bought together" score
+// from ProductID to RelatedProductID, rebuilt by the nightly job
+type ProductAssociation struct {
+	ProductID        uint `gorm:"primaryKey;autoIncrement:false" json:"product_id"`
+	RelatedProductID uint `gorm:"primaryKey;autoIncrement:false;index" json:"related_product_id"`
+	// Score is the cosine similarity of the two products' order sets, in (0, 1]
+	Score float64 `gorm:"not null" json:"score"`
+	// Support is the number of delivered orders containing both products
+	Support    int       `gorm:"not null" json:"support"`
+	ComputedAt time.Time `gorm:"not null" json:"computed_at"`
+
+	// Relationships
+	RelatedProduct *Product `gorm:"foreignKey:RelatedProductID" json:"related_product,omitempty"`
+}
+
+func (ProductAssociation) TableName() string {
+	return "product_associations"
+}
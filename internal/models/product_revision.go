@@ -0,0 +1,77 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// ProductSnapshot holds the catalog fields of a product at one point in time.
+// Stock is left out since the stock ledger already tracks it.
+type ProductSnapshot struct {
+	CategoryID        uint    `json:"category_id"`
+	Name              string  `json:"name"`
+	Slug              string  `json:"slug"`
+	Description       *string `json:"description,omitempty"`
+	Classify          string  `json:"classify"`
+	Price             float64 `json:"price"`
+	Status            string  `json:"status"`
+	LowStockThreshold int     `json:"low_stock_threshold"`
+	// Dietary info; revisions recorded before it existed leave it empty
+	Calories    *int     `json:"calories,omitempty"`
+	SugarGrams  *float64 `json:"sugar_grams,omitempty"`
+	CaffeineMg  *int     `json:"caffeine_mg,omitempty"`
+	Allergens   []string `json:"allergens,omitempty"`
+	DietaryTags []string `json:"dietary_tags,omitempty"`
+}
+
+// Snapshot returns the fields of p a revision records
+func (p *Product) Snapshot() ProductSnapshot {
+	s := ProductSnapshot{
+		CategoryID:        p.CategoryID,
+		Name:              p.Name,
+		Slug:              p.Slug,
+		Classify:          p.Classify,
+		Price:             p.Price,
+		Status:            p.Status,
+		LowStockThreshold: p.LowStockThreshold,
+		Allergens:         slices.Clone(p.Allergens),
+		DietaryTags:       slices.Clone(p.DietaryTags),
+	}
+	if p.Description != nil {
+		d := *p.Description
+		s.Description = &d
+	}
+	if p.Calories != nil {
+		v := *p.Calories
+		s.Calories = &v
+	}
+	if p.SugarGrams != nil {
+		v := *p.SugarGrams
+		s.SugarGrams = &v
+	}
+	if p.CaffeineMg != nil {
+		v := *p.CaffeineMg
+		s.CaffeineMg = &v
+	}
+	return s
+}
+
+// ProductRevision is one append-only entry of a product's edit history.
+// Rolling back adds a new revision rather than changing an old one.
+type ProductRevision struct {
+	ID        uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID uint            `gorm:"not null;index" json:"product_id"`
+	Before    ProductSnapshot `gorm:"column:before_data;type:text;serializer:json;not null" json:"before"`
+	After     ProductSnapshot `gorm:"column:after_data;type:text;serializer:json;not null" json:"after"`
+	// RollbackOf is the revision whose Before state this one restored
+	RollbackOf *uint     `json:"rollback_of,omitempty"`
+	CreatedBy  *uint     `json:"created_by,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relationships
+	Admin *User `gorm:"foreignKey:CreatedBy" json:"admin,omitempty"`
+}
+
+func (ProductRevision) TableName() string {
+	return "product_revisions"
+}
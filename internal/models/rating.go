@@ -4,22 +4,89 @@ import (
 	"time"
 )
 
+// Rating visibility. Hidden ratings stay with their author but are left out
+// of listings and product stats.
+const (
+	RatingStatusVisible = "visible"
+	RatingStatusHidden  = "hidden"
+)
+
+// Reasons a rating can be reported for
+const (
+	RatingReportSpam      = "spam"
+	RatingReportOffensive = "offensive"
+	RatingReportOffTopic  = "off_topic"
+	RatingReportOther     = "other"
+)
+
+// Rating is a customer review of a purchased product. Flagged ratings wait in
+// the admin moderation queue, FlagReason says why they were flagged.
 type Rating struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    uint      `gorm:"not null;uniqueIndex:uk_user_product" json:"user_id"`
-	ProductID uint      `gorm:"not null;index;uniqueIndex:uk_user_product" json:"product_id"`
-	OrderID   *uint     `gorm:"index" json:"order_id,omitempty"`
-	Rating    uint8     `gorm:"not null" json:"rating"`
-	Comment   *string   `gorm:"type:text" json:"comment,omitempty"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:uk_user_product" json:"user_id"`
+	ProductID    uint       `gorm:"not null;index;uniqueIndex:uk_user_product" json:"product_id"`
+	OrderID      *uint      `gorm:"index" json:"order_id,omitempty"`
+	Rating       uint8      `gorm:"not null" json:"rating"`
+	Comment      *string    `gorm:"type:text" json:"comment,omitempty"`
+	HelpfulCount int        `gorm:"not null;default:0;index" json:"helpful_count"`
+	PhotoCount   int        `gorm:"not null;default:0" json:"photo_count"`
+	Status       string     `gorm:"type:varchar(20);not null;default:visible;index" json:"status"`
+	Flagged      bool       `gorm:"not null;default:false;index" json:"flagged"`
+	FlagReason   *string    `gorm:"type:varchar(255)" json:"flag_reason,omitempty"`
+	ReportCount  int        `gorm:"not null;default:0" json:"report_count"`
+	ShopReply    *string    `gorm:"type:text" json:"shop_reply,omitempty"`
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// Relationships
-	User    *User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	Order   *Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	User    *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Product *Product      `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Order   *Order        `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Photos  []RatingPhoto `gorm:"foreignKey:RatingID" json:"photos,omitempty"`
 }
 
 func (Rating) TableName() string {
 	return "ratings"
 }
+
+// RatingPhoto is an image a customer attached to their rating
+type RatingPhoto struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RatingID     uint      `gorm:"not null;index" json:"rating_id"`
+	ImageURL     string    `gorm:"type:varchar(500);not null" json:"image_url"`
+	MediumURL    *string   `gorm:"type:varchar(500)" json:"medium_url,omitempty"`
+	ThumbnailURL *string   `gorm:"type:varchar(500)" json:"thumbnail_url,omitempty"`
+	SortOrder    int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RatingPhoto) TableName() string {
+	return "rating_photos"
+}
+
+// RatingVote marks a rating as helpful, at most once per user
+type RatingVote struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RatingID  uint      `gorm:"not null;uniqueIndex:uk_rating_user" json:"rating_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:uk_rating_user;index" json:"user_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RatingVote) TableName() string {
+	return "rating_votes"
+}
+
+// RatingReport is a user's complaint about a rating, at most one per user
+type RatingReport struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RatingID  uint      `gorm:"not null;uniqueIndex:uk_report_rating_user" json:"rating_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:uk_report_rating_user;index" json:"user_id"`
+	Reason    string    `gorm:"type:varchar(20);not null" json:"reason"`
+	Note      *string   `gorm:"type:varchar(500)" json:"note,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RatingReport) TableName() string {
+	return "rating_reports"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchQuery records one product search submitted by a shopper
+type SearchQuery struct {
+	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	// Query is the text as typed; Normalized is folded (lowercase, no diacritics)
+	// so that "Trà sữa" and "tra sua" are counted together
+	Query       string    `gorm:"type:varchar(255);not null" json:"query"`
+	Normalized  string    `gorm:"type:varchar(255);not null;index:idx_normalized_created,priority:1" json:"normalized"`
+	ResultCount int64     `gorm:"not null;default:0" json:"result_count"`
+	IPAddress   string    `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index;index:idx_normalized_created,priority:2" json:"created_at"`
+}
+
+func (SearchQuery) TableName() string {
+	return "search_queries"
+}
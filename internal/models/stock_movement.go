@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// StockMovement is one append-only entry of the inventory ledger. The sum of
+// a product's deltas equals its stock; bundles hold no stock of their own and
+// have no entries.
+type StockMovement struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID uint   `gorm:"not null;index" json:"product_id"`
+	Delta     int    `gorm:"not null" json:"delta"`
+	Reason    string `gorm:"type:varchar(30);not null;index" json:"reason"`
+	// ReferenceID is the order for order and cancellation entries
+	ReferenceID *uint     `gorm:"index" json:"reference_id,omitempty"`
+	Note        *string   `gorm:"type:varchar(255)" json:"note,omitempty"`
+	CreatedBy   *uint     `json:"created_by,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relationships
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// Reason constants
+const (
+	StockReasonOrder        = "order"
+	StockReasonCancellation = "cancellation"
+	StockReasonRestock      = "restock"
+	StockReasonAdjustment   = "adjustment"
+	StockReasonWaste        = "waste"
+)
@@ -11,6 +11,8 @@ type Suggestion struct {
 	Description *string   `gorm:"type:text" json:"description,omitempty"`
 	Classify    string    `gorm:"type:varchar(50);not null" json:"classify"`
 	CategoryID  *uint     `gorm:"index" json:"category_id,omitempty"`
+	Allergens   []string  `gorm:"type:varchar(500);serializer:json" json:"allergens,omitempty"`
+	DietaryTags []string  `gorm:"type:varchar(500);serializer:json" json:"dietary_tags,omitempty"`
 	Status      string    `gorm:"type:varchar(50);not null;default:pending;index" json:"status"`
 	AdminNote   *string   `gorm:"type:text" json:"admin_note,omitempty"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Translation is the text of one field of a product or category in a locale
+// other than the default one. The default locale lives on the record itself.
+type Translation struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EntityType string    `gorm:"type:varchar(20);not null;uniqueIndex:uk_translation_entry,priority:1" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;uniqueIndex:uk_translation_entry,priority:2" json:"entity_id"`
+	Field      string    `gorm:"type:varchar(30);not null;uniqueIndex:uk_translation_entry,priority:3" json:"field"`
+	Locale     string    `gorm:"type:varchar(10);not null;uniqueIndex:uk_translation_entry,priority:4" json:"locale"`
+	Value      string    `gorm:"type:text;not null" json:"value"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Translation) TableName() string {
+	return "translations"
+}
+
+// Translated entities
+const (
+	TranslationEntityProduct  = "product"
+	TranslationEntityCategory = "category"
+)
+
+// Translated fields
+const (
+	TranslationFieldName        = "name"
+	TranslationFieldDescription = "description"
+)
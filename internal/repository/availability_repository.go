@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// AvailabilityRepository handles the serving schedules of products and categories
+type AvailabilityRepository struct {
+	db *gorm.DB
+}
+
+// NewAvailabilityRepository creates a new AvailabilityRepository
+func NewAvailabilityRepository(db *gorm.DB) *AvailabilityRepository {
+	return &AvailabilityRepository{db: db}
+}
+
+// ListWindows returns the windows of any of productIDs or categoryIDs
+func (r *AvailabilityRepository) ListWindows(productIDs, categoryIDs []uint) ([]models.AvailabilityWindow, error) {
+	var windows []models.AvailabilityWindow
+	if len(productIDs) == 0 && len(categoryIDs) == 0 {
+		return windows, nil
+	}
+	err := scheduleOwnedBy(r.db, productIDs, categoryIDs).
+		Order("weekday ASC, start_time ASC, id ASC").
+		Find(&windows).Error
+	return windows, err
+}
+
+// ListExceptions returns the exceptions of any of productIDs or categoryIDs.
+// When dates is not empty only those dates are returned.
+func (r *AvailabilityRepository) ListExceptions(productIDs, categoryIDs []uint, dates []string) ([]models.AvailabilityException, error) {
+	var exceptions []models.AvailabilityException
+	if len(productIDs) == 0 && len(categoryIDs) == 0 {
+		return exceptions, nil
+	}
+	query := scheduleOwnedBy(r.db, productIDs, categoryIDs)
+	if len(dates) > 0 {
+		query = query.Where("date IN ?", dates)
+	}
+	err := query.Order("date ASC, start_time ASC, id ASC").Find(&exceptions).Error
+	return exceptions, err
+}
+
+// TimesOfDay returns every distinct start and end time used by any window or
+// exception, and whether any exception exists
+func (r *AvailabilityRepository) TimesOfDay() ([]string, bool, error) {
+	var times []string
+	for _, model := range []interface{}{&models.AvailabilityWindow{}, &models.AvailabilityException{}} {
+		for _, column := range []string{"start_time", "end_time"} {
+			var found []string
+			if err := r.db.Model(model).Distinct(column).Where(column+" <> ''").Pluck(column, &found).Error; err != nil {
+				return nil, false, err
+			}
+			times = append(times, found...)
+		}
+	}
+	var exceptions int64
+	if err := r.db.Model(&models.AvailabilityException{}).Count(&exceptions).Error; err != nil {
+		return nil, false, err
+	}
+	return times, exceptions > 0, nil
+}
+
+// ReplaceProductSchedule swaps the schedule of a product and bumps its
+// updated_at so catalog validators change with it
+func (r *AvailabilityRepository) ReplaceProductSchedule(productID uint, windows []models.AvailabilityWindow, exceptions []models.AvailabilityException) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range windows {
+			windows[i].ProductID, windows[i].CategoryID = &productID, nil
+		}
+		for i := range exceptions {
+			exceptions[i].ProductID, exceptions[i].CategoryID = &productID, nil
+		}
+		if err := replaceSchedule(tx, "product_id", productID, windows, exceptions); err != nil {
+			return err
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("updated_at", time.Now()).Error
+	})
+}
+
+// ReplaceCategorySchedule swaps the schedule of a category and bumps its updated_at
+func (r *AvailabilityRepository) ReplaceCategorySchedule(categoryID uint, windows []models.AvailabilityWindow, exceptions []models.AvailabilityException) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range windows {
+			windows[i].ProductID, windows[i].CategoryID = nil, &categoryID
+		}
+		for i := range exceptions {
+			exceptions[i].ProductID, exceptions[i].CategoryID = nil, &categoryID
+		}
+		if err := replaceSchedule(tx, "category_id", categoryID, windows, exceptions); err != nil {
+			return err
+		}
+		return tx.Model(&models.Category{}).Where("id = ?", categoryID).UpdateColumn("updated_at", time.Now()).Error
+	})
+}
+
+func replaceSchedule(tx *gorm.DB, column string, id uint, windows []models.AvailabilityWindow, exceptions []models.AvailabilityException) error {
+	if err := tx.Where(column+" = ?", id).Delete(&models.AvailabilityWindow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(column+" = ?", id).Delete(&models.AvailabilityException{}).Error; err != nil {
+		return err
+	}
+	if len(windows) > 0 {
+		if err := tx.Create(&windows).Error; err != nil {
+			return err
+		}
+	}
+	if len(exceptions) > 0 {
+		if err := tx.Create(&exceptions).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scheduleOwnedBy(db *gorm.DB, productIDs, categoryIDs []uint) *gorm.DB {
+	switch {
+	case len(productIDs) == 0:
+		return db.Where("category_id IN ?", categoryIDs)
+	case len(categoryIDs) == 0:
+		return db.Where("product_id IN ?", productIDs)
+	default:
+		return db.Where("product_id IN ? OR category_id IN ?", productIDs, categoryIDs)
+	}
+}
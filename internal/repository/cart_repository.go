@@ -70,15 +70,44 @@ func (r *CartRepository) ExistsByUserID(userID uint) (bool, error) {
 	return count > 0, err
 }
 
+// FindCartItem finds the cart line of a product that has no modifiers selected
 func (r *CartRepository) FindCartItem(cartID, productID uint) (*models.CartItem, error) {
+	return r.FindCartItemByModifierKey(cartID, productID, "")
+}
+
+// FindCartItemByModifierKey finds the cart line of a product with exactly the given modifier selection
+func (r *CartRepository) FindCartItemByModifierKey(cartID, productID uint, modifierKey string) (*models.CartItem, error) {
 	var item models.CartItem
-	err := r.db.Where("cart_id = ? AND product_id = ?", cartID, productID).First(&item).Error
+	err := r.db.Where("cart_id = ? AND product_id = ? AND modifier_key = ?", cartID, productID, modifierKey).First(&item).Error
 	if err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
 
+func (r *CartRepository) FindCartItemByID(cartID, itemID uint) (*models.CartItem, error) {
+	var item models.CartItem
+	err := r.db.Where("cart_id = ? AND id = ?", cartID, itemID).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// SumQuantityByProduct returns the total quantity of a product across all cart
+// lines, optionally excluding one line (e.g. the line being updated).
+func (r *CartRepository) SumQuantityByProduct(cartID, productID uint, excludeItemID uint) (int, error) {
+	var total int
+	query := r.db.Model(&models.CartItem{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("cart_id = ? AND product_id = ?", cartID, productID)
+	if excludeItemID > 0 {
+		query = query.Where("id <> ?", excludeItemID)
+	}
+	err := query.Scan(&total).Error
+	return total, err
+}
+
 func (r *CartRepository) CreateCartItem(item *models.CartItem) error {
 	return r.db.Create(item).Error
 }
@@ -92,6 +121,11 @@ func (r *CartRepository) DeleteCartItem(cartID, productID uint) error {
 		Delete(&models.CartItem{}).Error
 }
 
+func (r *CartRepository) DeleteCartItemByID(cartID, itemID uint) error {
+	return r.db.Where("cart_id = ? AND id = ?", cartID, itemID).
+		Delete(&models.CartItem{}).Error
+}
+
 func (r *CartRepository) ClearCartItems(cartID uint) error {
 	return r.db.Where("cart_id = ?", cartID).Delete(&models.CartItem{}).Error
 }
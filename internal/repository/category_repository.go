@@ -15,6 +15,16 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
+// GetDB returns the underlying database handle
+func (r *CategoryRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+// WithTx returns a repository bound to the transaction tx
+func (r *CategoryRepository) WithTx(tx *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: tx}
+}
+
 // Create creates a new category
 func (r *CategoryRepository) Create(category *models.Category) error {
 	return r.db.Create(category).Error
@@ -111,3 +121,124 @@ func (r *CategoryRepository) List(params CategoryListParams) ([]models.Category,
 
 	return categories, total, nil
 }
+
+// ListActive returns the active categories in display order
+func (r *CategoryRepository) ListActive() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Where("status = ?", models.CategoryStatusActive).
+		Order("sort_order ASC, id ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// ListAll returns every category in display order, for building the tree
+func (r *CategoryRepository) ListAll() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("sort_order ASC, id ASC").Find(&categories).Error
+	return categories, err
+}
+
+// UpdateSortOrders sets the sort order of several categories at once
+func (r *CategoryRepository) UpdateSortOrders(orders map[uint]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, order := range orders {
+			if err := tx.Model(&models.Category{}).Where("id = ?", id).Update("sort_order", order).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteReparenting soft deletes a category and moves its children under
+// parentID, or to the top level when parentID is nil
+func (r *CategoryRepository) DeleteReparenting(id uint, parentID *uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Category{}, id).Error
+	})
+}
+
+// DeleteMany soft deletes the categories
+func (r *CategoryRepository) DeleteMany(ids []uint) error {
+	return r.db.Delete(&models.Category{}, ids).Error
+}
+
+// CountProducts counts the products in the categories. Trashed products are
+// counted too when withDeleted is set, as they still reference the category.
+func (r *CategoryRepository) CountProducts(categoryIDs []uint, withDeleted bool) (int64, error) {
+	var count int64
+	query := r.db.Model(&models.Product{})
+	if withDeleted {
+		query = query.Unscoped()
+	}
+	err := query.Where("category_id IN ?", categoryIDs).Count(&count).Error
+	return count, err
+}
+
+// CountProductsByCategory counts the live products of every category
+func (r *CategoryRepository) CountProductsByCategory() (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	err := r.db.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Group("category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
+// MoveProducts moves the live products of the categories to categoryID
+func (r *CategoryRepository) MoveProducts(fromIDs []uint, categoryID uint) error {
+	return r.db.Model(&models.Product{}).
+		Where("category_id IN ?", fromIDs).
+		Update("category_id", categoryID).Error
+}
+
+// ListDeleted returns the soft-deleted categories, most recently deleted first
+func (r *CategoryRepository) ListDeleted() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id DESC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// FindDeletedByID finds a soft-deleted category
+func (r *CategoryRepository) FindDeletedByID(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Restore brings a soft-deleted category back under parentID, or to the top
+// level when parentID is nil
+func (r *CategoryRepository) Restore(id uint, parentID *uint) error {
+	return r.db.Unscoped().Model(&models.Category{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "parent_id": parentID}).Error
+}
+
+// Purge permanently deletes a category. Subcategories still pointing at it,
+// trashed ones included, are detached first.
+func (r *CategoryRepository) Purge(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Category{}, id).Error
+	})
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Keyset marks the last row of the previous page in cursor pagination
+type Keyset struct {
+	// Value is the sort column value of that row
+	Value interface{}
+	ID    uint
+}
+
+// orderByKeyset sorts query by column and then id in dir, so rows with equal
+// sort values keep a stable order. When after is set only rows that come after
+// it are kept. column and dir must come from a fixed allow-list.
+func orderByKeyset(query *gorm.DB, column, dir string, after *Keyset) *gorm.DB {
+	if dir != "asc" {
+		dir = "desc"
+	}
+	if after != nil {
+		op := "<"
+		if dir == "asc" {
+			op = ">"
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op),
+			after.Value, after.Value, after.ID,
+		)
+	}
+	return query.Order(column + " " + dir).Order("id " + dir)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// ModifierRepository handles modifier group/option database operations
+type ModifierRepository struct {
+	db *gorm.DB
+}
+
+// NewModifierRepository creates a new ModifierRepository
+func NewModifierRepository(db *gorm.DB) *ModifierRepository {
+	return &ModifierRepository{db: db}
+}
+
+func (r *ModifierRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *ModifierRepository) WithTx(tx *gorm.DB) *ModifierRepository {
+	return &ModifierRepository{db: tx}
+}
+
+// CreateGroup creates a group together with its options and assignments
+func (r *ModifierRepository) CreateGroup(group *models.ModifierGroup) error {
+	return r.db.Create(group).Error
+}
+
+// FindGroupByID finds a group with its options (ordered) and assignments
+func (r *ModifierRepository) FindGroupByID(id uint) (*models.ModifierGroup, error) {
+	var group models.ModifierGroup
+	err := r.db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).Preload("Assignments").First(&group, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroup saves the group columns only; options and assignments are
+// replaced separately through SaveOptions and ReplaceAssignments.
+func (r *ModifierRepository) UpdateGroup(group *models.ModifierGroup) error {
+	return r.db.Omit("Options", "Assignments").Save(group).Error
+}
+
+// DeleteGroup soft deletes a group and removes its assignments so it no longer
+// applies to any product.
+func (r *ModifierRepository) DeleteGroup(id uint) error {
+	if err := r.db.Where("group_id = ?", id).Delete(&models.ModifierGroupAssignment{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&models.ModifierGroup{}, id).Error
+}
+
+// ListGroups returns all groups ordered for display, with options and assignments
+func (r *ModifierRepository) ListGroups() ([]models.ModifierGroup, error) {
+	var groups []models.ModifierGroup
+	err := r.db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).Preload("Assignments").
+		Order("sort_order ASC, id ASC").
+		Find(&groups).Error
+	return groups, err
+}
+
+// SaveOptions upserts the given options for a group and deactivates any
+// existing option that is not in the list. Options are never hard-deleted so
+// that cart lines referencing them can still be resolved and rejected cleanly.
+func (r *ModifierRepository) SaveOptions(groupID uint, options []models.ModifierOption) error {
+	keepIDs := make([]uint, 0, len(options))
+	for i := range options {
+		options[i].GroupID = groupID
+		if options[i].ID == 0 {
+			if err := r.db.Create(&options[i]).Error; err != nil {
+				return err
+			}
+		} else {
+			err := r.db.Model(&models.ModifierOption{}).
+				Where("id = ? AND group_id = ?", options[i].ID, groupID).
+				Updates(map[string]interface{}{
+					"name":       options[i].Name,
+					"price":      options[i].Price,
+					"sort_order": options[i].SortOrder,
+					"status":     options[i].Status,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		keepIDs = append(keepIDs, options[i].ID)
+	}
+
+	query := r.db.Model(&models.ModifierOption{}).Where("group_id = ?", groupID)
+	if len(keepIDs) > 0 {
+		query = query.Where("id NOT IN ?", keepIDs)
+	}
+	return query.Update("status", models.ModifierStatusInactive).Error
+}
+
+// ReplaceAssignments replaces all product/category assignments of a group
+func (r *ModifierRepository) ReplaceAssignments(groupID uint, assignments []models.ModifierGroupAssignment) error {
+	if err := r.db.Where("group_id = ?", groupID).Delete(&models.ModifierGroupAssignment{}).Error; err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+	for i := range assignments {
+		assignments[i].ID = 0
+		assignments[i].GroupID = groupID
+	}
+	return r.db.Create(&assignments).Error
+}
+
+// FindActiveGroupsForProduct returns the active groups assigned to the product
+// or to its category, each with its active options.
+func (r *ModifierRepository) FindActiveGroupsForProduct(productID, categoryID uint) ([]models.ModifierGroup, error) {
+	var groups []models.ModifierGroup
+	sub := r.db.Model(&models.ModifierGroupAssignment{}).
+		Select("group_id").
+		Where("product_id = ? OR category_id = ?", productID, categoryID)
+
+	err := r.db.Where("id IN (?) AND status = ?", sub, models.ModifierStatusActive).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", models.ModifierStatusActive).Order("sort_order ASC, id ASC")
+		}).
+		Order("sort_order ASC, id ASC").
+		Find(&groups).Error
+	return groups, err
+}
+
+// FindOptionsByIDs returns options (with their group) regardless of status
+func (r *ModifierRepository) FindOptionsByIDs(ids []uint) ([]models.ModifierOption, error) {
+	options := []models.ModifierOption{}
+	if len(ids) == 0 {
+		return options, nil
+	}
+	err := r.db.Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("id IN ?", ids).Find(&options).Error
+	return options, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/kha/foods-drinks/internal/models"
@@ -44,6 +45,8 @@ func (r *OrderRepository) FindByIDAndUserID(orderID uint, userID uint) (*models.
 		Preload("Items", func(db *gorm.DB) *gorm.DB {
 			return db.Order("order_items.id ASC")
 		}).
+		Preload("Items.Modifiers").
+		Preload("Items.Components").
 		First(&order).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -61,6 +64,8 @@ type OrderListParams struct {
 	Status   string
 	FromDate *time.Time
 	ToDate   *time.Time
+	SortBy   string
+	SortDir  string
 }
 
 type AdminOrderListParams struct {
@@ -88,6 +93,15 @@ type OrderStatisticsSummaryRow struct {
 	CancelledCount int64
 }
 
+// ProductSalesStatRow is the quantity and revenue of one product. Bundle
+// lines count towards their components.
+type ProductSalesStatRow struct {
+	ProductID     uint
+	ProductName   string
+	Quantity      int64
+	RevenueAmount float64
+}
+
 type OrderStatisticsSeriesRow struct {
 	PeriodLabel   string
 	OrdersCount   int64
@@ -98,23 +112,12 @@ func (r *OrderRepository) ListByUserID(params OrderListParams) ([]models.Order,
 	var orders []models.Order
 	var total int64
 
-	query := r.db.Model(&models.Order{}).Where("user_id = ?", params.UserID)
-	if params.Status != "" {
-		query = query.Where("status = ?", params.Status)
-	}
-	if params.FromDate != nil {
-		query = query.Where("created_at >= ?", *params.FromDate)
-	}
-	if params.ToDate != nil {
-		query = query.Where("created_at <= ?", *params.ToDate)
-	}
-
+	query := r.userOrdersQuery(params)
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := query.
-		Order("created_at DESC").
+	err := orderByKeyset(query, userOrderSortColumn(params.SortBy), params.SortDir, nil).
 		Offset(params.Offset).
 		Limit(params.Limit).
 		Find(&orders).Error
@@ -125,6 +128,40 @@ func (r *OrderRepository) ListByUserID(params OrderListParams) ([]models.Order,
 	return orders, total, nil
 }
 
+// ListByUserIDAfter returns up to params.Limit orders of the user that sort
+// after the given keyset, without counting the total
+func (r *OrderRepository) ListByUserIDAfter(params OrderListParams, after *Keyset) ([]models.Order, error) {
+	var orders []models.Order
+	err := orderByKeyset(r.userOrdersQuery(params), userOrderSortColumn(params.SortBy), params.SortDir, after).
+		Limit(params.Limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *OrderRepository) userOrdersQuery(params OrderListParams) *gorm.DB {
+	query := r.db.Model(&models.Order{}).Where("user_id = ?", params.UserID)
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	if params.FromDate != nil {
+		query = query.Where("created_at >= ?", *params.FromDate)
+	}
+	if params.ToDate != nil {
+		query = query.Where("created_at <= ?", *params.ToDate)
+	}
+	return query
+}
+
+func userOrderSortColumn(sortBy string) string {
+	if sortBy == "total_amount" {
+		return sortBy
+	}
+	return "created_at"
+}
+
 func (r *OrderRepository) CountItemsByOrderIDs(orderIDs []uint) (map[uint]int, error) {
 	counts := make(map[uint]int)
 	if len(orderIDs) == 0 {
@@ -186,6 +223,8 @@ func (r *OrderRepository) ListForAdmin(params AdminOrderListParams) ([]models.Or
 		Preload("Items", func(db *gorm.DB) *gorm.DB {
 			return db.Order("order_items.id ASC")
 		}).
+		Preload("Items.Modifiers").
+		Preload("Items.Components").
 		Order(sortBy + " " + sortDir).
 		Offset(params.Offset).
 		Limit(params.Limit).
@@ -204,6 +243,8 @@ func (r *OrderRepository) FindByID(id uint) (*models.Order, error) {
 		Preload("Items", func(db *gorm.DB) *gorm.DB {
 			return db.Order("order_items.id ASC")
 		}).
+		Preload("Items.Modifiers").
+		Preload("Items.Components").
 		First(&order).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -292,3 +333,67 @@ func (r *OrderRepository) GetStatisticsSeries(params OrderStatisticsParams) ([]O
 
 	return rows, err
 }
+
+// GetProductSales returns the best-selling products, up to limit, by
+// quantity. Regular lines count for their product; bundle lines count for
+// each component with the share of the line subtotal attributed to it.
+func (r *OrderRepository) GetProductSales(params OrderStatisticsParams, limit int) ([]ProductSalesStatRow, error) {
+	var lines, parts []ProductSalesStatRow
+
+	err := filterStatisticsOrders(r.db.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id"), params).
+		Where("NOT EXISTS (SELECT 1 FROM order_item_components WHERE order_item_components.order_item_id = order_items.id)").
+		Select("order_items.product_id AS product_id, MAX(order_items.product_name) AS product_name, " +
+			"SUM(order_items.quantity) AS quantity, COALESCE(SUM(order_items.subtotal), 0) AS revenue_amount").
+		Group("order_items.product_id").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = filterStatisticsOrders(r.db.Table("order_item_components").
+		Joins("JOIN order_items ON order_items.id = order_item_components.order_item_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id"), params).
+		Select("order_item_components.product_id AS product_id, MAX(order_item_components.product_name) AS product_name, " +
+			"SUM(order_item_components.quantity) AS quantity, COALESCE(SUM(order_item_components.subtotal), 0) AS revenue_amount").
+		Group("order_item_components.product_id").
+		Scan(&parts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[uint]int, len(lines)+len(parts))
+	rows := make([]ProductSalesStatRow, 0, len(lines)+len(parts))
+	for _, row := range append(lines, parts...) {
+		if i, ok := index[row.ProductID]; ok {
+			rows[i].Quantity += row.Quantity
+			rows[i].RevenueAmount += row.RevenueAmount
+			continue
+		}
+		index[row.ProductID] = len(rows)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Quantity != rows[j].Quantity {
+			return rows[i].Quantity > rows[j].Quantity
+		}
+		return rows[i].ProductID < rows[j].ProductID
+	})
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func filterStatisticsOrders(query *gorm.DB, params OrderStatisticsParams) *gorm.DB {
+	if params.Status != "" {
+		query = query.Where("orders.status = ?", params.Status)
+	}
+	if params.FromDate != nil {
+		query = query.Where("orders.created_at >= ?", *params.FromDate)
+	}
+	if params.ToDate != nil {
+		query = query.Where("orders.created_at <= ?", *params.ToDate)
+	}
+	return query
+}
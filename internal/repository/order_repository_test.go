@@ -22,7 +22,7 @@ func setupOrderRepositoryTest(t *testing.T) (*OrderRepository, *gorm.DB, uint, u
 		t.Fatalf("open sqlite db: %v", err)
 	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.Order{}, &models.OrderItem{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Order{}, &models.OrderItem{}, &models.OrderItemModifier{}, &models.OrderItemComponent{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
 
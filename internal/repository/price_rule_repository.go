@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// PriceRuleRepository handles the scheduled sale prices
+type PriceRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceRuleRepository creates a new PriceRuleRepository
+func NewPriceRuleRepository(db *gorm.DB) *PriceRuleRepository {
+	return &PriceRuleRepository{db: db}
+}
+
+// ListEndingAfter returns the rules that have not ended at t
+func (r *PriceRuleRepository) ListEndingAfter(t time.Time) ([]models.PriceRule, error) {
+	var rules []models.PriceRule
+	err := r.db.Where("ends_at > ?", t).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// List returns every rule with its product or category, those ending last first
+func (r *PriceRuleRepository) List() ([]models.PriceRule, error) {
+	var rules []models.PriceRule
+	err := r.db.
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("ends_at DESC, id DESC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *PriceRuleRepository) FindByID(id uint) (*models.PriceRule, error) {
+	var rule models.PriceRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Create saves a rule and bumps the updated_at of its product or category so
+// catalog validators change with it
+func (r *PriceRuleRepository) Create(rule *models.PriceRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rule).Error; err != nil {
+			return err
+		}
+		return touchPriceRuleOwner(tx, rule)
+	})
+}
+
+// Delete removes a rule and bumps the updated_at of its product or category
+func (r *PriceRuleRepository) Delete(rule *models.PriceRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.PriceRule{}, rule.ID).Error; err != nil {
+			return err
+		}
+		return touchPriceRuleOwner(tx, rule)
+	})
+}
+
+func touchPriceRuleOwner(tx *gorm.DB, rule *models.PriceRule) error {
+	if rule.ProductID != nil {
+		return tx.Model(&models.Product{}).Where("id = ?", *rule.ProductID).UpdateColumn("updated_at", time.Now()).Error
+	}
+	if rule.CategoryID != nil {
+		return tx.Model(&models.Category{}).Where("id = ?", *rule.CategoryID).UpdateColumn("updated_at", time.Now()).Error
+	}
+	return nil
+}
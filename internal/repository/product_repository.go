@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/kha/foods-drinks/internal/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -14,6 +17,10 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
+func (r *ProductRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
 func (r *ProductRepository) WithTx(tx *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: tx}
 }
@@ -30,6 +37,11 @@ func (r *ProductRepository) FindByID(id uint) (*models.Product, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.IsBundle {
+		if p.BundleItems, err = r.FindBundleItems([]uint{p.ID}); err != nil {
+			return nil, err
+		}
+	}
 	return &p, nil
 }
 
@@ -42,14 +54,63 @@ func (r *ProductRepository) FindByIDForUpdate(id uint) (*models.Product, error)
 	return &p, nil
 }
 
-func (r *ProductRepository) DecreaseStock(id uint, quantity int) (bool, error) {
-	result := r.db.Model(&models.Product{}).
-		Where("id = ? AND stock >= ?", id, quantity).
-		Update("stock", gorm.Expr("stock - ?", quantity))
-	if result.Error != nil {
-		return false, result.Error
+// FindByIDsForUpdate loads the products and locks their rows in ascending id
+// order, the order checkout locks them in, so the two cannot deadlock
+func (r *ProductRepository) FindByIDsForUpdate(ids []uint) ([]models.Product, error) {
+	var products []models.Product
+	if len(ids) == 0 {
+		return products, nil
 	}
-	return result.RowsAffected > 0, nil
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// ApplyStockMovement changes the product's stock by m.Delta and appends m to
+// the ledger. It reports false, recording nothing, when the product is
+// missing or the stock would go negative.
+func (r *ProductRepository) ApplyStockMovement(m *models.StockMovement) (bool, error) {
+	if m.Delta == 0 {
+		return true, nil
+	}
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND stock + ? >= 0", m.ProductID, m.Delta).
+			Update("stock", gorm.Expr("stock + ?", m.Delta))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		applied = true
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return syncStockStatus(tx, []uint{m.ProductID})
+	})
+	return applied, err
+}
+
+// SyncStockStatus marks sold-out active products out_of_stock and puts them
+// back on sale once restocked. Inactive products are left alone.
+func (r *ProductRepository) SyncStockStatus(productIDs []uint) error {
+	return syncStockStatus(r.db, productIDs)
+}
+
+func syncStockStatus(db *gorm.DB, productIDs []uint) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	err := db.Model(&models.Product{}).
+		Where("id IN ? AND status = ? AND stock <= 0", productIDs, models.ProductStatusActive).
+		Update("status", models.ProductStatusOutOfStock).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.Product{}).
+		Where("id IN ? AND status = ? AND stock > 0", productIDs, models.ProductStatusOutOfStock).
+		Update("status", models.ProductStatusActive).Error
 }
 
 func (r *ProductRepository) FindBySlug(slug string) (*models.Product, error) {
@@ -60,9 +121,25 @@ func (r *ProductRepository) FindBySlug(slug string) (*models.Product, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.IsBundle {
+		if p.BundleItems, err = r.FindBundleItems([]uint{p.ID}); err != nil {
+			return nil, err
+		}
+	}
 	return &p, nil
 }
 
+// FindBySlugs loads the products with the given slugs, soft-deleted ones
+// included because their slugs stay taken
+func (r *ProductRepository) FindBySlugs(slugs []string) ([]models.Product, error) {
+	var products []models.Product
+	if len(slugs) == 0 {
+		return products, nil
+	}
+	err := r.db.Unscoped().Where("slug IN ?", slugs).Find(&products).Error
+	return products, err
+}
+
 func (r *ProductRepository) ExistsBySlug(slug string, excludeID ...uint) (bool, error) {
 	var count int64
 	query := r.db.Model(&models.Product{}).Where("slug = ?", slug)
@@ -74,13 +151,55 @@ func (r *ProductRepository) ExistsBySlug(slug string, excludeID ...uint) (bool,
 }
 
 func (r *ProductRepository) Update(product *models.Product) error {
-	return r.db.Save(product).Error
+	// Stock goes through ApplyStockMovement, components through
+	// ReplaceBundleItems and the alert marker through MarkLowStockAlerts,
+	// so none is overwritten with a stale value
+	return r.db.Omit("Stock", "BundleItems", "LowStockAlertedAt").Save(product).Error
 }
 
 func (r *ProductRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Product{}, id).Error
 }
 
+// ListDeleted returns the soft-deleted products with their category, trashed
+// or not, most recently deleted first
+func (r *ProductRepository) ListDeleted() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Unscoped().
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id DESC").
+		Find(&products).Error
+	return products, err
+}
+
+// FindDeletedByID finds a soft-deleted product
+func (r *ProductRepository) FindDeletedByID(id uint) (*models.Product, error) {
+	var p models.Product
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Restore brings a soft-deleted product back
+func (r *ProductRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.Product{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// CountOrderItems counts the order lines of a product
+func (r *ProductRepository) CountOrderItems(productID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrderItem{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// Purge permanently deletes a product; its images, cart lines and other
+// dependent rows go with it through the foreign keys
+func (r *ProductRepository) Purge(id uint) error {
+	return r.db.Unscoped().Delete(&models.Product{}, id).Error
+}
+
 func (r *ProductRepository) AddImage(img *models.ProductImage) error {
 	return r.db.Create(img).Error
 }
@@ -89,6 +208,42 @@ func (r *ProductRepository) DeleteImagesByProductID(productID uint) error {
 	return r.db.Where("product_id = ?", productID).Delete(&models.ProductImage{}).Error
 }
 
+func (r *ProductRepository) FindImageByID(productID, imageID uint) (*models.ProductImage, error) {
+	var img models.ProductImage
+	err := r.db.Where("id = ? AND product_id = ?", imageID, productID).First(&img).Error
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (r *ProductRepository) FindImagesByProductID(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.Where("product_id = ?", productID).Order("sort_order ASC, id ASC").Find(&images).Error
+	return images, err
+}
+
+// FindImagesByProductIDs loads the images of several products, each
+// product's images in display order
+func (r *ProductRepository) FindImagesByProductIDs(productIDs []uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+	err := r.db.Where("product_id IN ?", productIDs).Order("product_id ASC, sort_order ASC, id ASC").Find(&images).Error
+	return images, err
+}
+
+func (r *ProductRepository) UpdateImageSortOrder(productID, imageID uint, sortOrder int) error {
+	return r.db.Model(&models.ProductImage{}).
+		Where("id = ? AND product_id = ?", imageID, productID).
+		Update("sort_order", sortOrder).Error
+}
+
+func (r *ProductRepository) DeleteImage(productID, imageID uint) error {
+	return r.db.Where("id = ? AND product_id = ?", imageID, productID).Delete(&models.ProductImage{}).Error
+}
+
 func (r *ProductRepository) SetPrimaryImage(productID, imageID uint) error {
 	if err := r.db.Model(&models.ProductImage{}).
 		Where("product_id = ?", productID).
@@ -100,31 +255,421 @@ func (r *ProductRepository) SetPrimaryImage(productID, imageID uint) error {
 		Update("is_primary", true).Error
 }
 
-type ProductListParams struct {
+// FindBundleItems returns the components of the given bundles with the
+// component products, ordered by bundle and insertion
+func (r *ProductRepository) FindBundleItems(bundleIDs []uint) ([]models.BundleItem, error) {
+	var items []models.BundleItem
+	if len(bundleIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.Preload("Component").
+		Where("bundle_id IN ?", bundleIDs).
+		Order("bundle_id ASC, id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// CountBundlesContaining counts the bundles that use the product as a component
+func (r *ProductRepository) CountBundlesContaining(productID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.BundleItem{}).Where("component_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// ReplaceBundleItems swaps the components of a product and marks it as a
+// bundle when any are left. A bundle turned back into a regular product is
+// left with no stock, matching its empty ledger.
+func (r *ProductRepository) ReplaceBundleItems(bundleID uint, items []models.BundleItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundleID).Delete(&models.BundleItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ID = 0
+			items[i].BundleID = bundleID
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		if len(items) == 0 {
+			return tx.Model(&models.Product{}).
+				Where("id = ? AND is_bundle = ?", bundleID, true).
+				Updates(map[string]interface{}{"is_bundle": false, "stock": 0}).Error
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", bundleID).Update("is_bundle", true).Error
+	})
+}
+
+// SyncBundleStock recomputes the stock of every bundle that is one of the
+// given products or contains one of them: the number of complete bundles the
+// components can make. Inactive or deleted components count as empty.
+func (r *ProductRepository) SyncBundleStock(productIDs []uint) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	var bundleIDs []uint
+	err := r.db.Model(&models.BundleItem{}).
+		Where("bundle_id IN ? OR component_id IN ?", productIDs, productIDs).
+		Distinct().
+		Pluck("bundle_id", &bundleIDs).Error
+	if err != nil || len(bundleIDs) == 0 {
+		return err
+	}
+	items, err := r.FindBundleItems(bundleIDs)
+	if err != nil {
+		return err
+	}
+
+	stock := make(map[uint]int, len(bundleIDs))
+	for _, item := range items {
+		available := 0
+		if item.Component != nil && item.Component.Status == models.ProductStatusActive && item.Quantity > 0 {
+			available = item.Component.Stock / item.Quantity
+		}
+		if current, ok := stock[item.BundleID]; !ok || available < current {
+			stock[item.BundleID] = available
+		}
+	}
+	for _, id := range bundleIDs {
+		err := r.db.Model(&models.Product{}).
+			Where("id = ? AND is_bundle = ? AND stock <> ?", id, true, stock[id]).
+			Update("stock", stock[id]).Error
+		if err != nil {
+			return err
+		}
+	}
+	return syncStockStatus(r.db, bundleIDs)
+}
+
+// MarkLowStockAlerts sets the alert marker on the given regular products
+// that are at or below their threshold and not yet alerted, and returns
+// them. The conditional update lets only one caller claim each alert.
+func (r *ProductRepository) MarkLowStockAlerts(productIDs []uint, now time.Time) ([]models.Product, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+	var candidates []models.Product
+	err := r.db.
+		Where("id IN ? AND is_bundle = ? AND low_stock_alerted_at IS NULL AND stock <= low_stock_threshold", productIDs, false).
+		Order("id ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	claimed := make([]models.Product, 0, len(candidates))
+	for _, p := range candidates {
+		result := r.db.Model(&models.Product{}).
+			Where("id = ? AND low_stock_alerted_at IS NULL", p.ID).
+			Update("low_stock_alerted_at", now)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			p.LowStockAlertedAt = &now
+			claimed = append(claimed, p)
+		}
+	}
+	return claimed, nil
+}
+
+// ClearLowStockAlerts removes the alert marker from the given products whose
+// stock is back above their threshold, so the next dip alerts again
+func (r *ProductRepository) ClearLowStockAlerts(productIDs []uint) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Product{}).
+		Where("id IN ? AND low_stock_alerted_at IS NOT NULL AND stock > low_stock_threshold", productIDs).
+		Update("low_stock_alerted_at", nil).Error
+}
+
+// ListLowStock returns the regular products on sale or sold out whose stock
+// is at or below their threshold, lowest stock first
+func (r *ProductRepository) ListLowStock() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.
+		Where("is_bundle = ? AND status <> ? AND stock <= low_stock_threshold", false, models.ProductStatusInactive).
+		Order("stock ASC, name ASC").
+		Find(&products).Error
+	return products, err
+}
+
+type StockMovementListParams struct {
 	Offset    int
 	Limit     int
-	Classify  string
-	Category  uint
+	ProductID uint
+	Reason    string
+}
+
+// ListStockMovements returns ledger entries, newest first, with their
+// products including deleted ones
+func (r *ProductRepository) ListStockMovements(params StockMovementListParams) ([]models.StockMovement, int64, error) {
+	var movements []models.StockMovement
+	var total int64
+
+	query := r.db.Model(&models.StockMovement{})
+	if params.ProductID > 0 {
+		query = query.Where("product_id = ?", params.ProductID)
+	}
+	if params.Reason != "" {
+		query = query.Where("reason = ?", params.Reason)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Product", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Order("created_at DESC, id DESC").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Find(&movements).Error
+	return movements, total, err
+}
+
+// CreateRevision appends an entry to a product's edit history
+func (r *ProductRepository) CreateRevision(rev *models.ProductRevision) error {
+	return r.db.Create(rev).Error
+}
+
+// ListRevisions returns a product's edit history, newest first, with the
+// admins who made the edits
+func (r *ProductRepository) ListRevisions(productID uint) ([]models.ProductRevision, error) {
+	var revisions []models.ProductRevision
+	err := r.db.Preload("Admin").
+		Where("product_id = ?", productID).
+		Order("created_at DESC, id DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// FindRevision finds one revision of a product
+func (r *ProductRepository) FindRevision(productID, revisionID uint) (*models.ProductRevision, error) {
+	var rev models.ProductRevision
+	if err := r.db.Where("product_id = ?", productID).First(&rev, revisionID).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// StockLedgerRow compares a product's stock with the sum of its ledger
+type StockLedgerRow struct {
+	ProductID uint
+	Name      string
+	Stock     int
+	Ledger    int
+}
+
+// StockLedgerMismatches returns the regular products whose stock differs
+// from the sum of their stock movements
+func (r *ProductRepository) StockLedgerMismatches() ([]StockLedgerRow, error) {
+	rows := []StockLedgerRow{}
+	err := r.db.Table("products").
+		Select("products.id AS product_id, products.name AS name, products.stock AS stock, COALESCE(SUM(stock_movements.delta), 0) AS ledger").
+		Joins("LEFT JOIN stock_movements ON stock_movements.product_id = products.id").
+		Where("products.deleted_at IS NULL AND products.is_bundle = ?", false).
+		Group("products.id, products.name, products.stock").
+		Having("products.stock <> COALESCE(SUM(stock_movements.delta), 0)").
+		Order("products.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+type ProductListParams struct {
+	Offset   int
+	Limit    int
+	Classify string
+	Category uint
+	// Subcategories are the descendants of Category, matched along with it
+	Subcategories []uint
+	// MinPrice and MaxPrice match the stored regular price; sale prices
+	// are not known to the database
 	MinPrice  float64
 	MaxPrice  float64
 	MinRating float64
 	Status    string
 	Search    string
-	SortBy    string
-	SortDir   string
+	// StartsWith is a folded initial a-z, or "#" for names starting with anything else
+	StartsWith string
+	// ExcludeAllergens drops products declaring any of them, and bundles
+	// with a component declaring one; Tags keeps products having all of
+	// them. Both hold normalized keys.
+	ExcludeAllergens []string
+	Tags             []string
+	// MaxCalories keeps products known to have at most this many kcal
+	MaxCalories int
+	// IDs restricts the result to these products when non-nil; an empty
+	// slice matches nothing. Used with the search index.
+	IDs     []uint
+	SortBy  string
+	SortDir string
 }
 
 func (r *ProductRepository) List(params ProductListParams) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
+	query := r.filteredQuery(params)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := withListPreloads(orderByKeyset(query, ProductSortColumn(params.SortBy), params.SortDir, nil)).
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Find(&products).Error
+	if err == nil {
+		err = r.attachBundleItems(products)
+	}
+
+	return products, total, err
+}
+
+// ListAfter returns up to params.Limit products that sort after the given
+// keyset, without counting the total. A nil after starts from the first row.
+func (r *ProductRepository) ListAfter(params ProductListParams, after *Keyset) ([]models.Product, error) {
+	var products []models.Product
+	query := orderByKeyset(r.filteredQuery(params), ProductSortColumn(params.SortBy), params.SortDir, after)
+	err := withListPreloads(query).Limit(params.Limit).Find(&products).Error
+	if err == nil {
+		err = r.attachBundleItems(products)
+	}
+	return products, err
+}
+
+// ProductSortColumn maps a requested sort to a column, defaulting to created_at
+func ProductSortColumn(sortBy string) string {
+	switch sortBy {
+	case "price", "rating_average", "name", "created_at":
+		return sortBy
+	}
+	return "created_at"
+}
+
+// ListIDs returns the ids of every product matching the filters, ignoring
+// pagination and sorting
+func (r *ProductRepository) ListIDs(params ProductListParams) ([]uint, error) {
+	var ids []uint
+	err := r.filteredQuery(params).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindByIDs loads products with the same associations as List. The result is
+// in no particular order.
+func (r *ProductRepository) FindByIDs(ids []uint) ([]models.Product, error) {
+	var products []models.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+	err := withListPreloads(r.db.Model(&models.Product{})).Where("id IN ?", ids).Find(&products).Error
+	if err == nil {
+		err = r.attachBundleItems(products)
+	}
+	return products, err
+}
+
+// ListAll returns every product for building the search index
+func (r *ProductRepository) ListAll() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Order("id ASC").Find(&products).Error
+	return products, err
+}
+
+// ListVisibleSlugs returns the slug and update time of every product that is
+// not inactive
+func (r *ProductRepository) ListVisibleSlugs() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Select("id", "slug", "updated_at").
+		Where("status <> ?", models.ProductStatusInactive).
+		Order("id ASC").Find(&products).Error
+	return products, err
+}
+
+// CountByCategory counts the products with the given status in each of the
+// categories. Categories without any are left out of the map.
+func (r *ProductRepository) CountByCategory(categoryIDs []uint, status string) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(categoryIDs))
+	if len(categoryIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	err := r.db.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IN ? AND status = ?", categoryIDs, status).
+		Group("category_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, err
+}
+
+// CatalogStamp summarises products and categories for HTTP validators. An
+// edited row moves the latest update time; an added or deleted one changes
+// the count or the highest id.
+type CatalogStamp struct {
+	ProductCount      int64
+	ProductMaxID      uint
+	ProductUpdatedAt  time.Time
+	CategoryCount     int64
+	CategoryMaxID     uint
+	CategoryUpdatedAt time.Time
+}
+
+func (r *ProductRepository) CatalogStamp() (*CatalogStamp, error) {
+	var stamp CatalogStamp
+	var err error
+	if stamp.ProductCount, stamp.ProductMaxID, stamp.ProductUpdatedAt, err = r.tableStamp(&models.Product{}); err != nil {
+		return nil, err
+	}
+	if stamp.CategoryCount, stamp.CategoryMaxID, stamp.CategoryUpdatedAt, err = r.tableStamp(&models.Category{}); err != nil {
+		return nil, err
+	}
+	return &stamp, nil
+}
+
+func (r *ProductRepository) tableStamp(model interface{}) (int64, uint, time.Time, error) {
+	var agg struct {
+		Count int64
+		MaxID uint
+	}
+	if err := r.db.Model(model).Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS max_id").Scan(&agg).Error; err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	// Read the newest row rather than MAX(updated_at) so the driver keeps the column type
+	var latest struct{ UpdatedAt time.Time }
+	if err := r.db.Model(model).Select("updated_at").Order("updated_at DESC").Limit(1).Scan(&latest).Error; err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return agg.Count, agg.MaxID, latest.UpdatedAt, nil
+}
+
+func (r *ProductRepository) filteredQuery(params ProductListParams) *gorm.DB {
 	query := r.db.Model(&models.Product{})
 
+	if params.IDs != nil {
+		if len(params.IDs) == 0 {
+			query = query.Where("1 = 0")
+		} else {
+			query = query.Where("id IN ?", params.IDs)
+		}
+	}
 	if params.Classify != "" {
 		query = query.Where("classify = ?", params.Classify)
 	}
 	if params.Category > 0 {
-		query = query.Where("category_id = ?", params.Category)
+		if len(params.Subcategories) > 0 {
+			query = query.Where("category_id IN ?", append([]uint{params.Category}, params.Subcategories...))
+		} else {
+			query = query.Where("category_id = ?", params.Category)
+		}
 	}
 	if params.MinPrice > 0 {
 		query = query.Where("price >= ?", params.MinPrice)
@@ -138,33 +683,173 @@ func (r *ProductRepository) List(params ProductListParams) ([]models.Product, in
 	if params.Status != "" {
 		query = query.Where("status = ?", params.Status)
 	}
+	if params.StartsWith != "" {
+		query = query.Where("name_initial = ?", params.StartsWith)
+	}
 	if params.Search != "" {
-		query = query.Where("MATCH(name, description) AGAINST(? IN BOOLEAN MODE)", params.Search+"*")
+		like := "%" + params.Search + "%"
+		query = query.Where("name LIKE ? OR description LIKE ?", like, like)
 	}
-
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	// Allergens and tags are stored as JSON arrays, so a quoted key matches
+	// one element. A bundle also contains the allergens of its components.
+	for _, allergen := range params.ExcludeAllergens {
+		pattern := `%"` + allergen + `"%`
+		query = query.Where("(allergens IS NULL OR allergens NOT LIKE ?)", pattern).
+			Where("NOT EXISTS (SELECT 1 FROM bundle_items bi JOIN products c ON c.id = bi.component_id WHERE bi.bundle_id = products.id AND c.allergens LIKE ?)", pattern)
 	}
-
-	sortBy := "created_at"
-	allowed := map[string]bool{"price": true, "rating_average": true, "name": true, "created_at": true, "sort_order": true}
-	if allowed[params.SortBy] {
-		sortBy = params.SortBy
+	for _, tag := range params.Tags {
+		query = query.Where("dietary_tags LIKE ?", `%"`+tag+`"%`)
 	}
-	sortDir := "desc"
-	if params.SortDir == "asc" {
-		sortDir = "asc"
+	if params.MaxCalories > 0 {
+		query = query.Where("calories <= ?", params.MaxCalories)
 	}
+	return query
+}
 
-	err := query.
+func withListPreloads(query *gorm.DB) *gorm.DB {
+	return query.
 		Preload("Images", func(db *gorm.DB) *gorm.DB {
 			return db.Where("is_primary = ?", true).Limit(1)
 		}).
-		Preload("Category").
-		Order(sortBy + " " + sortDir).
-		Offset(params.Offset).
-		Limit(params.Limit).
-		Find(&products).Error
+		Preload("Category")
+}
 
-	return products, total, err
+// attachBundleItems loads the components of the bundles among products. It
+// runs instead of a preload so catalogs without bundles pay no extra query.
+func (r *ProductRepository) attachBundleItems(products []models.Product) error {
+	var bundleIDs []uint
+	for _, p := range products {
+		if p.IsBundle {
+			bundleIDs = append(bundleIDs, p.ID)
+		}
+	}
+	if len(bundleIDs) == 0 {
+		return nil
+	}
+	items, err := r.FindBundleItems(bundleIDs)
+	if err != nil {
+		return err
+	}
+	byBundle := make(map[uint][]models.BundleItem, len(bundleIDs))
+	for _, item := range items {
+		byBundle[item.BundleID] = append(byBundle[item.BundleID], item)
+	}
+	for i := range products {
+		products[i].BundleItems = byBundle[products[i].ID]
+	}
+	return nil
+}
+
+// FacetCountRow is the number of products sharing one facet value
+type FacetCountRow struct {
+	Value string
+	Count int64
+}
+
+// ProductFacetRows holds the per-facet counts. Each facet is counted with
+// every filter in the params applied except its own, so the counts show what
+// choosing another value would return.
+type ProductFacetRows struct {
+	Classify []FacetCountRow
+	Category []FacetCountRow
+	Initial  []FacetCountRow
+	// PriceBuckets[i] counts products in the i-th range of the bounds passed to Facets
+	PriceBuckets []int64
+	// RatingAtLeast[i] counts products rated i+1 stars or more
+	RatingAtLeast [4]int64
+}
+
+type ratingFacetRow struct {
+	AtLeast1 int64
+	AtLeast2 int64
+	AtLeast3 int64
+	AtLeast4 int64
+}
+
+// Facets counts the products matching params per classify, category, name
+// initial, price range and minimum rating. priceBounds are the ascending upper
+// bounds of all but the last price range.
+func (r *ProductRepository) Facets(params ProductListParams, priceBounds []float64) (*ProductFacetRows, error) {
+	var facets ProductFacetRows
+	var err error
+
+	p := params
+	p.Classify = ""
+	if facets.Classify, err = r.countBy(p, "classify"); err != nil {
+		return nil, err
+	}
+
+	p = params
+	p.Category = 0
+	if facets.Category, err = r.countBy(p, "category_id"); err != nil {
+		return nil, err
+	}
+
+	p = params
+	p.StartsWith = ""
+	if facets.Initial, err = r.countBy(p, "name_initial"); err != nil {
+		return nil, err
+	}
+
+	p = params
+	p.MinPrice, p.MaxPrice = 0, 0
+	if facets.PriceBuckets, err = r.countPriceBuckets(p, priceBounds); err != nil {
+		return nil, err
+	}
+
+	p = params
+	p.MinRating = 0
+	var rating ratingFacetRow
+	err = r.filteredQuery(p).Select(
+		"COALESCE(SUM(CASE WHEN rating_average >= 1 THEN 1 ELSE 0 END), 0) AS at_least1, " +
+			"COALESCE(SUM(CASE WHEN rating_average >= 2 THEN 1 ELSE 0 END), 0) AS at_least2, " +
+			"COALESCE(SUM(CASE WHEN rating_average >= 3 THEN 1 ELSE 0 END), 0) AS at_least3, " +
+			"COALESCE(SUM(CASE WHEN rating_average >= 4 THEN 1 ELSE 0 END), 0) AS at_least4",
+	).Scan(&rating).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.RatingAtLeast = [4]int64{rating.AtLeast1, rating.AtLeast2, rating.AtLeast3, rating.AtLeast4}
+
+	return &facets, nil
+}
+
+// countBy groups the filtered products by one of a fixed set of columns
+func (r *ProductRepository) countBy(params ProductListParams, column string) ([]FacetCountRow, error) {
+	rows := []FacetCountRow{}
+	err := r.filteredQuery(params).
+		Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *ProductRepository) countPriceBuckets(params ProductListParams, bounds []float64) ([]int64, error) {
+	counts := make([]int64, len(bounds)+1)
+
+	expr := "CASE"
+	args := make([]interface{}, 0, len(bounds))
+	for i, bound := range bounds {
+		expr += fmt.Sprintf(" WHEN price < ? THEN %d", i)
+		args = append(args, bound)
+	}
+	expr += fmt.Sprintf(" ELSE %d END", len(bounds))
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err := r.filteredQuery(params).
+		Select(expr+" AS bucket, COUNT(*) AS count", args...).
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Bucket >= 0 && row.Bucket < len(counts) {
+			counts[row.Bucket] = row.Count
+		}
+	}
+	return counts, nil
 }
@@ -17,7 +17,7 @@ func newProductRepoTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open product repo test db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}); err != nil {
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}, &models.StockMovement{}); err != nil {
 		t.Fatalf("migrate product repo models: %v", err)
 	}
 	return db
@@ -94,7 +94,7 @@ func TestProductRepositoryExistsBySlug(t *testing.T) {
 	}
 }
 
-func TestProductRepositoryDecreaseStock(t *testing.T) {
+func TestProductRepositoryApplyStockMovement(t *testing.T) {
 	t.Parallel()
 	db := newProductRepoTestDB(t)
 	repo := NewProductRepository(db)
@@ -103,9 +103,9 @@ func TestProductRepositoryDecreaseStock(t *testing.T) {
 	p := &models.Product{CategoryID: cat.ID, Name: "Soda", Slug: "soda-stock-test", Classify: models.ClassifyDrink, Price: 10000, Stock: 5, Status: models.ProductStatusActive}
 	repo.Create(p)
 
-	ok, err := repo.DecreaseStock(p.ID, 3)
+	ok, err := repo.ApplyStockMovement(&models.StockMovement{ProductID: p.ID, Delta: -3, Reason: models.StockReasonOrder})
 	if err != nil {
-		t.Fatalf("DecreaseStock(3): %v", err)
+		t.Fatalf("ApplyStockMovement(-3): %v", err)
 	}
 	if !ok {
 		t.Fatal("expected decrease stock to succeed")
@@ -115,13 +115,19 @@ func TestProductRepositoryDecreaseStock(t *testing.T) {
 		t.Errorf("stock = %d, want 2", got.Stock)
 	}
 
-	ok, err = repo.DecreaseStock(p.ID, 5)
+	ok, err = repo.ApplyStockMovement(&models.StockMovement{ProductID: p.ID, Delta: -5, Reason: models.StockReasonOrder})
 	if err != nil {
-		t.Fatalf("DecreaseStock(5): %v", err)
+		t.Fatalf("ApplyStockMovement(-5): %v", err)
 	}
 	if ok {
 		t.Fatal("expected decrease stock to fail when insufficient")
 	}
+
+	var movements int64
+	db.Model(&models.StockMovement{}).Where("product_id = ?", p.ID).Count(&movements)
+	if movements != 1 {
+		t.Errorf("ledger entries = %d, want 1", movements)
+	}
 }
 
 func TestProductRepositoryListFiltersAndSort(t *testing.T) {
@@ -156,6 +162,69 @@ func TestProductRepositoryListFiltersAndSort(t *testing.T) {
 	}
 }
 
+func TestProductRepositoryFacetsIgnoreOwnFilter(t *testing.T) {
+	t.Parallel()
+	db := newProductRepoTestDB(t)
+	repo := NewProductRepository(db)
+	tea := seedCategoryForProductRepo(t, db, "product-repo-facets-tea")
+	cake := seedCategoryForProductRepo(t, db, "product-repo-facets-cake")
+
+	seed := []models.Product{
+		{CategoryID: tea.ID, Name: "Trà đào", Slug: "facet-1", Classify: models.ClassifyDrink, Price: 25000, RatingAverage: 4.5, Status: models.ProductStatusActive},
+		{CategoryID: tea.ID, Name: "Trà sữa", Slug: "facet-2", Classify: models.ClassifyDrink, Price: 35000, RatingAverage: 3.2, Status: models.ProductStatusActive},
+		{CategoryID: tea.ID, Name: "Đậu hũ nước đường", Slug: "facet-3", Classify: models.ClassifyFood, Price: 15000, RatingAverage: 0, Status: models.ProductStatusActive},
+		{CategoryID: cake.ID, Name: "7Up", Slug: "facet-4", Classify: models.ClassifyDrink, Price: 12000, RatingAverage: 2, Status: models.ProductStatusActive},
+		{CategoryID: cake.ID, Name: "Tiramisu", Slug: "facet-5", Classify: models.ClassifyFood, Price: 120000, RatingAverage: 5, Status: models.ProductStatusInactive},
+	}
+	for i := range seed {
+		if err := repo.Create(&seed[i]); err != nil {
+			t.Fatalf("seed product: %v", err)
+		}
+	}
+
+	items, total, err := repo.List(ProductListParams{Limit: 10, StartsWith: "d"})
+	if err != nil || total != 1 || items[0].Slug != "facet-3" {
+		t.Fatalf("starts_with d = %v, %d, %v", items, total, err)
+	}
+	if _, total, _ := repo.List(ProductListParams{Limit: 10, StartsWith: "#"}); total != 1 {
+		t.Fatalf("starts_with # total = %d, want 1", total)
+	}
+
+	facets, err := repo.Facets(ProductListParams{
+		Status:   models.ProductStatusActive,
+		Classify: models.ClassifyDrink,
+		Category: tea.ID,
+	}, []float64{20000, 30000})
+	if err != nil {
+		t.Fatalf("Facets: %v", err)
+	}
+
+	counts := func(rows []FacetCountRow) map[string]int64 {
+		m := map[string]int64{}
+		for _, r := range rows {
+			m[r.Value] = r.Count
+		}
+		return m
+	}
+	// Classify ignores the classify filter but keeps category and status
+	if got := counts(facets.Classify); got["drink"] != 2 || got["food"] != 1 {
+		t.Errorf("classify facet = %v", got)
+	}
+	// Category ignores the category filter but keeps classify and status
+	if got := counts(facets.Category); got[fmt.Sprint(tea.ID)] != 2 || got[fmt.Sprint(cake.ID)] != 1 {
+		t.Errorf("category facet = %v", got)
+	}
+	if got := counts(facets.Initial); got["t"] != 2 || len(got) != 1 {
+		t.Errorf("initial facet = %v", got)
+	}
+	if got := facets.PriceBuckets; len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 1 {
+		t.Errorf("price buckets = %v", got)
+	}
+	if got := facets.RatingAtLeast; got != [4]int64{2, 2, 2, 1} {
+		t.Errorf("rating facet = %v", got)
+	}
+}
+
 func TestProductRepositoryUpdateDelete(t *testing.T) {
 	t.Parallel()
 	db := newProductRepoTestDB(t)
@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"github.com/kha/foods-drinks/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RatingRepository struct {
@@ -25,31 +29,63 @@ func (r *RatingRepository) Create(rating *models.Rating) error {
 	return r.db.Create(rating).Error
 }
 
+// Update writes the star value, comment and moderation flag only, so the
+// counters kept by votes, photos and reports are never overwritten with stale
+// values
 func (r *RatingRepository) Update(rating *models.Rating) error {
-	return r.db.Save(rating).Error
+	return r.db.Model(rating).Select("rating", "comment", "flagged", "flag_reason", "updated_at").Updates(rating).Error
+}
+
+// Delete removes a rating together with its photos, votes and reports
+func (r *RatingRepository) Delete(id uint) error {
+	for _, child := range []interface{}{&models.RatingPhoto{}, &models.RatingVote{}, &models.RatingReport{}} {
+		if err := r.db.Where("rating_id = ?", id).Delete(child).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Delete(&models.Rating{}, id).Error
 }
 
 func (r *RatingRepository) FindByUserAndProduct(userID, productID uint) (*models.Rating, error) {
 	var rating models.Rating
-	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&rating).Error
+	err := withRatingPhotos(r.db).Where("user_id = ? AND product_id = ?", userID, productID).First(&rating).Error
 	if err != nil {
 		return nil, err
 	}
 	return &rating, nil
 }
 
-func (r *RatingRepository) ListByProductID(productID uint, offset, limit int) ([]models.Rating, int64, error) {
+// FindByID loads a rating with its photos
+func (r *RatingRepository) FindByID(id uint) (*models.Rating, error) {
+	var rating models.Rating
+	if err := withRatingPhotos(r.db).First(&rating, id).Error; err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// FindByIDForUpdate loads a rating with its photos and locks the rating row
+// until the transaction ends
+func (r *RatingRepository) FindByIDForUpdate(id uint) (*models.Rating, error) {
+	var rating models.Rating
+	if err := withRatingPhotos(r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&rating, id).Error; err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// ListByProductID returns a page of the product's visible ratings ordered by
+// column, descending, with the newest first among equal values
+func (r *RatingRepository) ListByProductID(productID uint, column string, offset, limit int) ([]models.Rating, int64, error) {
 	var ratings []models.Rating
 	var total int64
 
-	query := r.db.Model(&models.Rating{}).Where("product_id = ?", productID)
+	query := r.db.Model(&models.Rating{}).Where("product_id = ? AND status = ?", productID, models.RatingStatusVisible)
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := query.
-		Preload("User").
-		Order("created_at DESC").
+	err := orderByKeyset(withRatingPhotos(query.Preload("User")), column, "desc", nil).
 		Offset(offset).
 		Limit(limit).
 		Find(&ratings).Error
@@ -60,6 +96,178 @@ func (r *RatingRepository) ListByProductID(productID uint, offset, limit int) ([
 	return ratings, total, nil
 }
 
+// ListByProductIDAfter returns up to limit ratings of the product ordered
+// like ListByProductID that come after the given keyset, without counting
+// the total
+func (r *RatingRepository) ListByProductIDAfter(productID uint, column string, after *Keyset, limit int) ([]models.Rating, error) {
+	var ratings []models.Rating
+	query := r.db.Model(&models.Rating{}).Where("product_id = ? AND status = ?", productID, models.RatingStatusVisible).Preload("User")
+	err := orderByKeyset(withRatingPhotos(query), column, "desc", after).
+		Limit(limit).
+		Find(&ratings).Error
+	if err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// CountByStar counts the product's visible ratings per star value
+func (r *RatingRepository) CountByStar(productID uint) (map[uint8]int64, error) {
+	var rows []struct {
+		Rating uint8
+		Count  int64
+	}
+	err := r.db.Model(&models.Rating{}).
+		Select("rating, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, models.RatingStatusVisible).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint8]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Rating] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *RatingRepository) AddPhoto(photo *models.RatingPhoto) error {
+	return r.db.Create(photo).Error
+}
+
+func (r *RatingRepository) FindPhoto(ratingID, photoID uint) (*models.RatingPhoto, error) {
+	var photo models.RatingPhoto
+	if err := r.db.Where("id = ? AND rating_id = ?", photoID, ratingID).First(&photo).Error; err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *RatingRepository) DeletePhoto(ratingID, photoID uint) error {
+	return r.db.Where("id = ? AND rating_id = ?", photoID, ratingID).Delete(&models.RatingPhoto{}).Error
+}
+
+// SyncPhotoCount recounts the photos of a rating into photo_count
+func (r *RatingRepository) SyncPhotoCount(ratingID uint) error {
+	return r.db.Model(&models.Rating{}).Where("id = ?", ratingID).
+		UpdateColumn("photo_count", r.db.Model(&models.RatingPhoto{}).Select("COUNT(*)").Where("rating_id = ?", ratingID)).Error
+}
+
+// AddVote records a helpful vote. It reports false when the user had
+// already voted.
+func (r *RatingRepository) AddVote(ratingID, userID uint) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RatingVote{RatingID: ratingID, UserID: userID})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteVote removes a helpful vote, reporting whether there was one
+func (r *RatingRepository) DeleteVote(ratingID, userID uint) (bool, error) {
+	result := r.db.Where("rating_id = ? AND user_id = ?", ratingID, userID).Delete(&models.RatingVote{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SyncHelpfulCount recounts the votes of a rating into helpful_count and
+// returns the new count
+func (r *RatingRepository) SyncHelpfulCount(ratingID uint) (int, error) {
+	var count int64
+	if err := r.db.Model(&models.RatingVote{}).Where("rating_id = ?", ratingID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	err := r.db.Model(&models.Rating{}).Where("id = ?", ratingID).UpdateColumn("helpful_count", count).Error
+	return int(count), err
+}
+
+// AddReport records a user's report of a rating. It reports false when the
+// user had already reported it.
+func (r *RatingRepository) AddReport(report *models.RatingReport) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SyncReportCount recounts the reports of a rating into report_count
+func (r *RatingRepository) SyncReportCount(ratingID uint) error {
+	return r.db.Model(&models.Rating{}).Where("id = ?", ratingID).
+		UpdateColumn("report_count", r.db.Model(&models.RatingReport{}).Select("COUNT(*)").Where("rating_id = ?", ratingID)).Error
+}
+
+// Flag puts a rating in the moderation queue. The reason of an earlier,
+// undecided flag is kept.
+func (r *RatingRepository) Flag(ratingID uint, reason string) error {
+	return r.db.Model(&models.Rating{}).Where("id = ?", ratingID).
+		UpdateColumns(map[string]interface{}{"flagged": true, "flag_reason": gorm.Expr("COALESCE(flag_reason, ?)", reason)}).Error
+}
+
+// SetStatus shows or hides a rating and takes it out of the moderation queue
+func (r *RatingRepository) SetStatus(ratingID uint, status string) error {
+	return r.db.Model(&models.Rating{}).Where("id = ?", ratingID).
+		UpdateColumns(map[string]interface{}{"status": status, "flagged": false, "flag_reason": nil}).Error
+}
+
+// SetReply stores the shop's reply to a rating; a nil reply removes it
+func (r *RatingRepository) SetReply(ratingID uint, reply *string, repliedAt *time.Time) error {
+	return r.db.Model(&models.Rating{}).Where("id = ?", ratingID).
+		UpdateColumns(map[string]interface{}{"shop_reply": reply, "replied_at": repliedAt}).Error
+}
+
+// Moderation filters of the admin rating list
+const (
+	RatingFilterFlagged = "flagged"
+	RatingFilterHidden  = "hidden"
+	RatingFilterVisible = "visible"
+)
+
+type RatingListParams struct {
+	Offset int
+	Limit  int
+	Filter string
+	Search string
+}
+
+// ListForAdmin returns a page of ratings of all products, newest first
+func (r *RatingRepository) ListForAdmin(params RatingListParams) ([]models.Rating, int64, error) {
+	var ratings []models.Rating
+	var total int64
+
+	query := r.db.Model(&models.Rating{})
+	switch params.Filter {
+	case RatingFilterFlagged:
+		query = query.Where("flagged = ?", true)
+	case RatingFilterHidden:
+		query = query.Where("status = ?", models.RatingStatusHidden)
+	case RatingFilterVisible:
+		query = query.Where("status = ?", models.RatingStatusVisible)
+	}
+	if params.Search != "" {
+		like := "%" + strings.TrimSpace(params.Search) + "%"
+		query = query.Where("comment LIKE ?", like)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := withRatingPhotos(query).
+		Preload("User").
+		Preload("Product").
+		Order("created_at DESC, id DESC").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Find(&ratings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ratings, total, nil
+}
+
+// withRatingPhotos preloads the photos of ratings in upload order
+func withRatingPhotos(query *gorm.DB) *gorm.DB {
+	return query.Preload("Photos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	})
+}
+
 func (r *RatingRepository) FindPurchasedOrderID(userID, productID uint) (*uint, error) {
 	var orderID uint
 	err := r.db.Table("orders").
@@ -78,6 +286,7 @@ func (r *RatingRepository) FindPurchasedOrderID(userID, productID uint) (*uint,
 	return &orderID, nil
 }
 
+// CalcProductRatingStats averages and counts the product's visible ratings
 func (r *RatingRepository) CalcProductRatingStats(productID uint) (float64, int64, error) {
 	type stats struct {
 		Average float64
@@ -87,7 +296,7 @@ func (r *RatingRepository) CalcProductRatingStats(productID uint) (float64, int6
 	var s stats
 	err := r.db.Model(&models.Rating{}).
 		Select("COALESCE(AVG(rating), 0) as average, COUNT(*) as count").
-		Where("product_id = ?", productID).
+		Where("product_id = ? AND status = ?", productID, models.RatingStatusVisible).
 		Scan(&s).Error
 	if err != nil {
 		return 0, 0, err
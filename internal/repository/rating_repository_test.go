@@ -17,7 +17,7 @@ func newRatingRepoTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open rating repo test db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.Category{}, &models.Product{}, &models.Order{}, &models.OrderItem{}, &models.Rating{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Category{}, &models.Product{}, &models.Order{}, &models.OrderItem{}, &models.Rating{}, &models.RatingPhoto{}, &models.RatingVote{}, &models.RatingReport{}); err != nil {
 		t.Fatalf("migrate rating repo test db: %v", err)
 	}
 	return db
@@ -56,7 +56,7 @@ func TestRatingRepository_CRUDAndStats(t *testing.T) {
 		t.Fatalf("Update: %v", err)
 	}
 
-	list, total, err := repo.ListByProductID(p.ID, 0, 10)
+	list, total, err := repo.ListByProductID(p.ID, "created_at", 0, 10)
 	if err != nil {
 		t.Fatalf("ListByProductID: %v", err)
 	}
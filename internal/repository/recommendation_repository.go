@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// RecommendationRepository reads order history for recommendations and
+// stores the precomputed product associations
+type RecommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) *RecommendationRepository {
+	return &RecommendationRepository{db: db}
+}
+
+// CoPurchaseRow counts the delivered orders containing both products
+type CoPurchaseRow struct {
+	ProductID        uint
+	RelatedProductID uint
+	Support          int
+}
+
+// ProductOrderCountRow counts the delivered orders containing a product
+type ProductOrderCountRow struct {
+	ProductID  uint
+	OrderCount int
+}
+
+// RelatedScoreRow is an aggregated association score for one product
+type RelatedScoreRow struct {
+	ProductID uint
+	Score     float64
+}
+
+// ProductSalesRow is the quantity of a product sold
+type ProductSalesRow struct {
+	ProductID uint
+	Quantity  int
+}
+
+// CoPurchases returns every ordered pair of distinct products that appear
+// together in at least minSupport delivered orders placed since since
+func (r *RecommendationRepository) CoPurchases(since time.Time, minSupport int) ([]CoPurchaseRow, error) {
+	rows := []CoPurchaseRow{}
+	err := r.db.Table("order_items AS a").
+		Select("a.product_id AS product_id, b.product_id AS related_product_id, COUNT(DISTINCT a.order_id) AS support").
+		Joins("JOIN order_items AS b ON b.order_id = a.order_id AND b.product_id <> a.product_id").
+		Joins("JOIN orders ON orders.id = a.order_id").
+		Where("orders.status = ? AND orders.created_at >= ?", models.OrderStatusDelivered, since).
+		Group("a.product_id, b.product_id").
+		Having("COUNT(DISTINCT a.order_id) >= ?", minSupport).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ProductOrderCounts returns how many delivered orders placed since since
+// contain each product
+func (r *RecommendationRepository) ProductOrderCounts(since time.Time) ([]ProductOrderCountRow, error) {
+	rows := []ProductOrderCountRow{}
+	err := r.db.Table("order_items").
+		Select("order_items.product_id AS product_id, COUNT(DISTINCT order_items.order_id) AS order_count").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status = ? AND orders.created_at >= ?", models.OrderStatusDelivered, since).
+		Group("order_items.product_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ReplaceAll swaps the whole association table in one transaction, so
+// readers never see a half-built set
+func (r *RecommendationRepository) ReplaceAll(associations []models.ProductAssociation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductAssociation{}).Error; err != nil {
+			return err
+		}
+		if len(associations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(associations, 500).Error
+	})
+}
+
+// ListByProductID returns the strongest associations of a product
+func (r *RecommendationRepository) ListByProductID(productID uint, limit int) ([]models.ProductAssociation, error) {
+	var associations []models.ProductAssociation
+	err := r.db.Where("product_id = ?", productID).
+		Order("score DESC, related_product_id ASC").
+		Limit(limit).
+		Find(&associations).Error
+	return associations, err
+}
+
+// ScoreRelated sums the association scores from any of productIDs to every
+// product outside them, best first
+func (r *RecommendationRepository) ScoreRelated(productIDs []uint, limit int) ([]RelatedScoreRow, error) {
+	rows := []RelatedScoreRow{}
+	if len(productIDs) == 0 {
+		return rows, nil
+	}
+	err := r.db.Model(&models.ProductAssociation{}).
+		Select("related_product_id AS product_id, SUM(score) AS score").
+		Where("product_id IN ? AND related_product_id NOT IN ?", productIDs, productIDs).
+		Group("related_product_id").
+		Order("score DESC, related_product_id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// PurchasedProductIDs returns the distinct products of the user's orders that
+// were not cancelled, most recently ordered first
+func (r *RecommendationRepository) PurchasedProductIDs(userID uint, limit int) ([]uint, error) {
+	var rows []struct {
+		ProductID uint
+	}
+	err := r.db.Table("order_items").
+		Select("order_items.product_id AS product_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status <> ?", userID, models.OrderStatusCancelled).
+		Group("order_items.product_id").
+		Order("MAX(orders.id) DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ProductID
+	}
+	return ids, nil
+}
+
+// BestSellers returns the products with the most units in delivered orders
+// placed since since
+func (r *RecommendationRepository) BestSellers(since time.Time, limit int) ([]ProductSalesRow, error) {
+	rows := []ProductSalesRow{}
+	err := r.db.Table("order_items").
+		Select("order_items.product_id AS product_id, SUM(order_items.quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status = ? AND orders.created_at >= ?", models.OrderStatusDelivered, since).
+		Group("order_items.product_id").
+		Order("quantity DESC, order_items.product_id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+type SearchQueryRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchQueryRepository(db *gorm.DB) *SearchQueryRepository {
+	return &SearchQueryRepository{db: db}
+}
+
+func (r *SearchQueryRepository) Create(q *models.SearchQuery) error {
+	return r.db.Create(q).Error
+}
+
+// SearchQueryStatsParams filters the aggregated search log
+type SearchQueryStatsParams struct {
+	Since time.Time
+	Limit int
+	// ZeroResults keeps only searches that found nothing;
+	// WithResults keeps only searches that found something
+	ZeroResults bool
+	WithResults bool
+}
+
+// SearchQueryStatRow aggregates searches sharing the same normalized text
+type SearchQueryStatRow struct {
+	Normalized     string
+	Query          string // one of the spellings actually typed
+	SearchCount    int64
+	AvgResultCount float64
+}
+
+// TopQueries returns the most frequent searches since params.Since
+func (r *SearchQueryRepository) TopQueries(params SearchQueryStatsParams) ([]SearchQueryStatRow, error) {
+	rows := []SearchQueryStatRow{}
+
+	query := r.db.Model(&models.SearchQuery{}).Where("created_at >= ?", params.Since)
+	if params.ZeroResults {
+		query = query.Where("result_count = 0")
+	}
+	if params.WithResults {
+		query = query.Where("result_count > 0")
+	}
+
+	err := query.
+		Select("normalized, MAX(query) AS query, COUNT(*) AS search_count, COALESCE(AVG(result_count), 0) AS avg_result_count").
+		Group("normalized").
+		Order("search_count DESC, normalized ASC").
+		Limit(params.Limit).
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// CountSince returns the number of searches logged since the given time,
+// optionally only those that found nothing
+func (r *SearchQueryRepository) CountSince(since time.Time, zeroResults bool) (int64, error) {
+	var count int64
+	query := r.db.Model(&models.SearchQuery{}).Where("created_at >= ?", since)
+	if zeroResults {
+		query = query.Where("result_count = 0")
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// TranslationRepository handles the translated text of products and categories
+type TranslationRepository struct {
+	db *gorm.DB
+}
+
+// NewTranslationRepository creates a new TranslationRepository
+func NewTranslationRepository(db *gorm.DB) *TranslationRepository {
+	return &TranslationRepository{db: db}
+}
+
+// WithTx returns a repository bound to the transaction tx
+func (r *TranslationRepository) WithTx(tx *gorm.DB) *TranslationRepository {
+	return &TranslationRepository{db: tx}
+}
+
+// ListByEntity returns every translation of one record, all locales
+func (r *TranslationRepository) ListByEntity(entityType string, id uint) ([]models.Translation, error) {
+	var rows []models.Translation
+	err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, id).Order("locale ASC, field ASC").Find(&rows).Error
+	return rows, err
+}
+
+// ListByEntities returns the translations of the given records. An empty
+// locale returns all locales.
+func (r *TranslationRepository) ListByEntities(entityType string, ids []uint, locale string) ([]models.Translation, error) {
+	var rows []models.Translation
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	q := r.db.Where("entity_type = ? AND entity_id IN ?", entityType, ids)
+	if locale != "" {
+		q = q.Where("locale = ?", locale)
+	}
+	err := q.Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// ListByType returns every translation of one kind of record
+func (r *TranslationRepository) ListByType(entityType string) ([]models.Translation, error) {
+	var rows []models.Translation
+	err := r.db.Where("entity_type = ?", entityType).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// Replace sets the fields of one record in one locale, dropping the fields
+// not in values, and bumps the record's updated_at so catalog validators
+// change with it
+func (r *TranslationRepository) Replace(entityType string, id uint, locale string, values map[string]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity_type = ? AND entity_id = ? AND locale = ?", entityType, id, locale).
+			Delete(&models.Translation{}).Error; err != nil {
+			return err
+		}
+		for field, value := range values {
+			row := &models.Translation{EntityType: entityType, EntityID: id, Field: field, Locale: locale, Value: value}
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		return touchTranslationOwner(tx, entityType, id)
+	})
+}
+
+// DeleteByEntity removes every translation of a purged record
+func (r *TranslationRepository) DeleteByEntity(entityType string, id uint) error {
+	return r.db.Where("entity_type = ? AND entity_id = ?", entityType, id).Delete(&models.Translation{}).Error
+}
+
+func touchTranslationOwner(tx *gorm.DB, entityType string, id uint) error {
+	switch entityType {
+	case models.TranslationEntityProduct:
+		return tx.Model(&models.Product{}).Where("id = ?", id).UpdateColumn("updated_at", time.Now()).Error
+	case models.TranslationEntityCategory:
+		return tx.Model(&models.Category{}).Where("id = ?", id).UpdateColumn("updated_at", time.Now()).Error
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/kha/foods-drinks/internal/models"
+	"gorm.io/gorm"
+)
+
+// UploadRepository answers which uploaded files are still referenced by records
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadRepository creates a new UploadRepository
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// ListReferencedURLs returns every upload URL stored on users, product images,
+// categories and rating photos. Soft-deleted rows are included because they can still be restored.
+func (r *UploadRepository) ListReferencedURLs() ([]string, error) {
+	sources := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.User{}, "avatar_url"},
+		{&models.ProductImage{}, "image_url"},
+		{&models.ProductImage{}, "medium_url"},
+		{&models.ProductImage{}, "thumbnail_url"},
+		{&models.Category{}, "image_url"},
+		{&models.Category{}, "thumbnail_url"},
+		{&models.RatingPhoto{}, "image_url"},
+		{&models.RatingPhoto{}, "medium_url"},
+		{&models.RatingPhoto{}, "thumbnail_url"},
+	}
+
+	var urls []string
+	for _, src := range sources {
+		var values []string
+		err := r.db.Unscoped().Model(src.model).
+			Where(src.column+" IS NOT NULL AND "+src.column+" <> ''").
+			Distinct().
+			Pluck(src.column, &values).Error
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, values...)
+	}
+	return urls, nil
+}
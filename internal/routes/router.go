@@ -11,34 +11,57 @@ import (
 	"github.com/kha/foods-drinks/internal/middleware"
 )
 
-// UploadURLPrefix is the public route prefix under which uploaded files are served.
-// It must match the first argument passed to router.Static below.
+// UploadURLPrefix is the public route prefix under which uploaded files are served,
+// either by router.Static (local storage) or by UploadHandler (remote storage).
 const UploadURLPrefix = "/uploads"
 
 // RouterDependencies holds all dependencies for router setup
 type RouterDependencies struct {
-	HealthHandler          *handler.HealthHandler
-	AuthHandler            *handler.AuthHandler
-	OAuthHandler           *handler.OAuthHandler
-	ProfileHandler         *handler.ProfileHandler
-	AdminCategoryHandler   *handler.AdminCategoryHandler
-	ProductHandler         *handler.ProductHandler
-	AdminProductHandler    *handler.AdminProductHandler
-	AdminOrderHandler      *handler.AdminOrderHandler
-	AdminOrderStatsHandler *handler.AdminOrderStatisticsHandler
-	AdminSuggestionHandler *handler.AdminSuggestionHandler
-	AdminUserHandler       *handler.AdminUserHandler
-	CartHandler            *handler.CartHandler
-	OrderHandler           *handler.OrderHandler
-	RatingHandler          *handler.RatingHandler
-	SuggestionHandler      *handler.SuggestionHandler
-	CorsMiddleware         gin.HandlerFunc
-	AuthMiddleware         *middleware.AuthMiddleware
-	UploadPath             string
+	HealthHandler             *handler.HealthHandler
+	MetricsHandler            *handler.MetricsHandler
+	AuthHandler               *handler.AuthHandler
+	OAuthHandler              *handler.OAuthHandler
+	ProfileHandler            *handler.ProfileHandler
+	AdminCategoryHandler      *handler.AdminCategoryHandler
+	ProductHandler            *handler.ProductHandler
+	CategoryHandler           *handler.CategoryHandler
+	AdminProductHandler       *handler.AdminProductHandler
+	AdminOrderHandler         *handler.AdminOrderHandler
+	AdminOrderStatsHandler    *handler.AdminOrderStatisticsHandler
+	AdminSuggestionHandler    *handler.AdminSuggestionHandler
+	AdminRatingHandler        *handler.AdminRatingHandler
+	AdminSearchHandler        *handler.AdminSearchHandler
+	AdminUserHandler          *handler.AdminUserHandler
+	AdminTrashHandler         *handler.AdminTrashHandler
+	AdminModifierHandler      *handler.AdminModifierHandler
+	AdminInventoryHandler     *handler.AdminInventoryHandler
+	AdminPriceRuleHandler     *handler.AdminPriceRuleHandler
+	AdminProductImportHandler *handler.AdminProductImportHandler
+	CartHandler               *handler.CartHandler
+	ModifierHandler           *handler.ModifierHandler
+	OrderHandler              *handler.OrderHandler
+	RatingHandler             *handler.RatingHandler
+	RecommendationHandler     *handler.RecommendationHandler
+	SuggestionHandler         *handler.SuggestionHandler
+	SearchHandler             *handler.SearchHandler
+	SharePageHandler          *handler.SharePageHandler // set when app.api_url is configured
+	UploadHandler             *handler.UploadHandler    // set when uploads live in remote storage
+	CorsMiddleware            gin.HandlerFunc
+	AuthMiddleware            *middleware.AuthMiddleware
+	SuggestRateLimiter        *middleware.RateLimiter
+	UploadPath                string
+	// TrustedProxies may set the client IP through X-Forwarded-For; nil
+	// trusts no proxy
+	TrustedProxies []string
 }
 
 func SetupRouter(deps *RouterDependencies) *gin.Engine {
 	router := gin.New()
+	// The client IP keys rate limits, so it only comes from headers sent by
+	// a known proxy
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies %q: %v", deps.TrustedProxies, err)
+	}
 
 	// Global middleware - order matters!
 	router.Use(deps.CorsMiddleware) // CORS first
@@ -49,9 +72,14 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 	// configured AND resolves to a path within the current working directory.
 	// This prevents a misconfigured UploadPath (e.g. "." or "/") from
 	// accidentally exposing source code or system files.
-	if deps.UploadPath != "" {
+	// Private files (storage.PrivatePrefix) are checked against the caller
+	// before either serves them.
+	uploads := router.Group(UploadURLPrefix, deps.AuthMiddleware.OptionalAuth(), handler.PrivateUploadAccess())
+	if deps.UploadHandler != nil {
+		uploads.GET("/*filepath", deps.UploadHandler.Serve)
+	} else if deps.UploadPath != "" {
 		if safe, absPath := isSafeUploadPath(deps.UploadPath); safe {
-			router.Static(UploadURLPrefix, absPath)
+			uploads.Static("/", absPath)
 		} else {
 			log.Fatalf("Unsafe upload path configured (%q): must be a subdirectory of the working directory", deps.UploadPath)
 		}
@@ -61,6 +89,16 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 	router.GET("/health", deps.HealthHandler.HealthCheck)
 	router.GET("/", deps.HealthHandler.Welcome)
 
+	// Metrics (admin only)
+	router.GET("/metrics", deps.AuthMiddleware.RequireAuth(), deps.AuthMiddleware.RequireAdmin(), deps.MetricsHandler.Metrics)
+
+	// Pages for crawlers (public), only when app.api_url gives their address
+	if deps.SharePageHandler != nil {
+		router.GET("/p/:slug", deps.SharePageHandler.Product)
+		router.GET("/sitemap.xml", deps.SharePageHandler.Sitemap)
+		router.GET("/robots.txt", deps.SharePageHandler.Robots)
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -86,8 +124,24 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			{
 				products.GET("", deps.ProductHandler.List)
 				products.GET("/:slug/ratings", deps.RatingHandler.ListByProduct)
+				products.GET("/:slug/modifiers", deps.ModifierHandler.ListByProduct)
+				products.GET("/:slug/related", deps.RecommendationHandler.Related)
 				products.GET("/:slug", deps.ProductHandler.GetBySlug)
 			}
+
+			categories := public.Group("/categories")
+			{
+				categories.GET("", deps.CategoryHandler.List)
+				categories.GET("/:slug", deps.CategoryHandler.GetBySlug)
+			}
+
+			searchGroup := public.Group("/search")
+			if deps.SuggestRateLimiter != nil {
+				searchGroup.Use(deps.SuggestRateLimiter.Middleware())
+			}
+			{
+				searchGroup.GET("/suggest", deps.SearchHandler.Suggest)
+			}
 		}
 
 		// Protected routes (require authentication)
@@ -107,6 +161,8 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			protected.POST("/cart/items", deps.CartHandler.Add)
 			protected.PUT("/cart/items/:product_id", deps.CartHandler.Update)
 			protected.DELETE("/cart/items/:product_id", deps.CartHandler.Remove)
+			protected.PUT("/cart/lines/:item_id", deps.CartHandler.UpdateLine)
+			protected.DELETE("/cart/lines/:item_id", deps.CartHandler.RemoveLine)
 			protected.DELETE("/cart", deps.CartHandler.Clear)
 
 			// Order routes
@@ -117,9 +173,17 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			// Rating routes
 			protected.POST("/products/:slug/ratings", deps.RatingHandler.Create)
 			protected.PUT("/products/:slug/ratings", deps.RatingHandler.Update)
+			protected.POST("/products/:slug/ratings/photos", deps.RatingHandler.UploadPhotos)
+			protected.DELETE("/products/:slug/ratings/photos/:photoId", deps.RatingHandler.DeletePhoto)
+			protected.POST("/ratings/:id/helpful", deps.RatingHandler.Vote)
+			protected.DELETE("/ratings/:id/helpful", deps.RatingHandler.Unvote)
+			protected.POST("/ratings/:id/report", deps.RatingHandler.Report)
 
 			// Suggestion routes
 			protected.POST("/suggestions", deps.SuggestionHandler.Create)
+
+			// Recommendation routes
+			protected.GET("/recommendations/for-you", deps.RecommendationHandler.ForYou)
 		}
 
 	}
@@ -136,6 +200,9 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			categories.GET("/:id/edit", deps.AdminCategoryHandler.Edit)
 			categories.POST("/:id/update", deps.AdminCategoryHandler.Update)
 			categories.POST("/:id/delete", deps.AdminCategoryHandler.Delete)
+			categories.POST("/:id/move", deps.AdminCategoryHandler.Move)
+			categories.POST("/:id/reorder", deps.AdminCategoryHandler.Reorder)
+			categories.POST("/:id/availability", deps.AdminCategoryHandler.SaveAvailability)
 		}
 
 		products := adminSSR.Group("/products")
@@ -143,9 +210,47 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			products.GET("", deps.AdminProductHandler.List)
 			products.GET("/new", deps.AdminProductHandler.New)
 			products.POST("", deps.AdminProductHandler.Create)
+			products.POST("/reindex", deps.AdminProductHandler.Reindex)
+			products.GET("/export", deps.AdminProductImportHandler.Export)
+			products.GET("/import", deps.AdminProductImportHandler.Form)
+			products.POST("/import", deps.AdminProductImportHandler.Preview)
+			products.POST("/import/apply", deps.AdminProductImportHandler.Apply)
+			products.GET("/import/jobs/:id", deps.AdminProductImportHandler.Job)
 			products.GET("/:id/edit", deps.AdminProductHandler.Edit)
 			products.POST("/:id/update", deps.AdminProductHandler.Update)
 			products.POST("/:id/delete", deps.AdminProductHandler.Delete)
+			products.GET("/:id/history", deps.AdminProductHandler.History)
+			products.POST("/:id/revisions/:revision_id/rollback", deps.AdminProductHandler.Rollback)
+			products.POST("/:id/availability", deps.AdminProductHandler.SaveAvailability)
+			products.POST("/:id/bundle", deps.AdminProductHandler.SaveBundle)
+			products.POST("/:id/images", deps.AdminProductHandler.UploadImages)
+			products.POST("/:id/images/reorder", deps.AdminProductHandler.ReorderImages)
+			products.POST("/:id/images/:image_id/primary", deps.AdminProductHandler.SetPrimaryImage)
+			products.POST("/:id/images/:image_id/delete", deps.AdminProductHandler.DeleteImage)
+		}
+
+		modifiers := adminSSR.Group("/modifiers")
+		{
+			modifiers.GET("", deps.AdminModifierHandler.List)
+			modifiers.GET("/new", deps.AdminModifierHandler.New)
+			modifiers.POST("", deps.AdminModifierHandler.Create)
+			modifiers.GET("/:id/edit", deps.AdminModifierHandler.Edit)
+			modifiers.POST("/:id/update", deps.AdminModifierHandler.Update)
+			modifiers.POST("/:id/delete", deps.AdminModifierHandler.Delete)
+		}
+
+		inventory := adminSSR.Group("/inventory")
+		{
+			inventory.GET("", deps.AdminInventoryHandler.List)
+			inventory.POST("/receive", deps.AdminInventoryHandler.Receive)
+			inventory.POST("/waste", deps.AdminInventoryHandler.Waste)
+		}
+
+		priceRules := adminSSR.Group("/price-rules")
+		{
+			priceRules.GET("", deps.AdminPriceRuleHandler.List)
+			priceRules.POST("", deps.AdminPriceRuleHandler.Create)
+			priceRules.POST("/:id/delete", deps.AdminPriceRuleHandler.Delete)
 		}
 
 		orders := adminSSR.Group("/orders")
@@ -156,12 +261,23 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			orders.POST("/:id/status", deps.AdminOrderHandler.UpdateStatus)
 		}
 
+		adminSSR.GET("/searches", deps.AdminSearchHandler.Insights)
+
 		suggestions := adminSSR.Group("/suggestions")
 		{
 			suggestions.GET("", deps.AdminSuggestionHandler.List)
 			suggestions.POST("/:id/status", deps.AdminSuggestionHandler.UpdateStatus)
 		}
 
+		ratings := adminSSR.Group("/ratings")
+		{
+			ratings.GET("", deps.AdminRatingHandler.List)
+			ratings.POST("/:id/hide", deps.AdminRatingHandler.Hide)
+			ratings.POST("/:id/unhide", deps.AdminRatingHandler.Unhide)
+			ratings.POST("/:id/delete", deps.AdminRatingHandler.Delete)
+			ratings.POST("/:id/reply", deps.AdminRatingHandler.Reply)
+		}
+
 		users := adminSSR.Group("/users")
 		{
 			users.GET("", deps.AdminUserHandler.List)
@@ -169,6 +285,15 @@ func SetupRouter(deps *RouterDependencies) *gin.Engine {
 			users.POST("/:id/status", deps.AdminUserHandler.UpdateStatus)
 			users.POST("/:id/role", deps.AdminUserHandler.UpdateRole)
 		}
+
+		trash := adminSSR.Group("/trash")
+		{
+			trash.GET("", deps.AdminTrashHandler.List)
+			trash.POST("/categories/:id/restore", deps.AdminTrashHandler.RestoreCategory)
+			trash.POST("/categories/:id/purge", deps.AdminTrashHandler.PurgeCategory)
+			trash.POST("/products/:id/restore", deps.AdminTrashHandler.RestoreProduct)
+			trash.POST("/products/:id/purge", deps.AdminTrashHandler.PurgeProduct)
+		}
 	}
 
 	return router
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 	"github.com/kha/foods-drinks/internal/config"
 	"github.com/kha/foods-drinks/internal/handler"
 	"github.com/kha/foods-drinks/internal/middleware"
+	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/service"
+	"github.com/kha/foods-drinks/internal/storage"
 )
 
 func chdirRepoRoot(t *testing.T) {
@@ -82,24 +85,37 @@ func TestSetupRouter_BasicPublicEndpoints(t *testing.T) {
 	authMW := middleware.NewAuthMiddleware(authSvc)
 
 	deps := &RouterDependencies{
-		HealthHandler:          handler.NewHealthHandler(),
-		AuthHandler:            handler.NewAuthHandler(nil),
-		OAuthHandler:           handler.NewOAuthHandler(nil),
-		ProfileHandler:         handler.NewProfileHandler(nil),
-		AdminCategoryHandler:   handler.NewAdminCategoryHandler(nil, funcMap),
-		ProductHandler:         handler.NewProductHandler(nil),
-		AdminProductHandler:    handler.NewAdminProductHandler(nil, nil, funcMap),
-		AdminOrderHandler:      handler.NewAdminOrderHandler(nil, funcMap),
-		AdminOrderStatsHandler: handler.NewAdminOrderStatisticsHandler(nil, funcMap),
-		AdminSuggestionHandler: handler.NewAdminSuggestionHandler(nil, funcMap),
-		AdminUserHandler:       handler.NewAdminUserHandler(nil, funcMap),
-		CartHandler:            handler.NewCartHandler(nil),
-		OrderHandler:           handler.NewOrderHandler(nil),
-		RatingHandler:          handler.NewRatingHandler(nil),
-		SuggestionHandler:      handler.NewSuggestionHandler(nil),
-		CorsMiddleware:         middleware.CORSConfig(),
-		AuthMiddleware:         authMW,
-		UploadPath:             "",
+		HealthHandler:             handler.NewHealthHandler(),
+		MetricsHandler:            handler.NewMetricsHandler(nil),
+		AuthHandler:               handler.NewAuthHandler(nil),
+		OAuthHandler:              handler.NewOAuthHandler(nil),
+		ProfileHandler:            handler.NewProfileHandler(nil),
+		AdminCategoryHandler:      handler.NewAdminCategoryHandler(nil, nil, nil, funcMap),
+		ProductHandler:            handler.NewProductHandler(nil, nil, nil, nil),
+		CategoryHandler:           handler.NewCategoryHandler(nil, nil, nil, nil),
+		AdminProductHandler:       handler.NewAdminProductHandler(nil, nil, nil, nil, 0, funcMap),
+		AdminOrderHandler:         handler.NewAdminOrderHandler(nil, funcMap),
+		AdminOrderStatsHandler:    handler.NewAdminOrderStatisticsHandler(nil, funcMap),
+		AdminSuggestionHandler:    handler.NewAdminSuggestionHandler(nil, funcMap),
+		AdminRatingHandler:        handler.NewAdminRatingHandler(nil, funcMap),
+		AdminSearchHandler:        handler.NewAdminSearchHandler(nil, funcMap),
+		AdminUserHandler:          handler.NewAdminUserHandler(nil, funcMap),
+		AdminTrashHandler:         handler.NewAdminTrashHandler(nil, nil, funcMap),
+		AdminModifierHandler:      handler.NewAdminModifierHandler(nil, nil, nil, funcMap),
+		AdminInventoryHandler:     handler.NewAdminInventoryHandler(nil, nil, funcMap),
+		AdminPriceRuleHandler:     handler.NewAdminPriceRuleHandler(nil, nil, nil, funcMap),
+		AdminProductImportHandler: handler.NewAdminProductImportHandler(nil, funcMap),
+		CartHandler:               handler.NewCartHandler(nil),
+		ModifierHandler:           handler.NewModifierHandler(nil),
+		OrderHandler:              handler.NewOrderHandler(nil),
+		RatingHandler:             handler.NewRatingHandler(nil),
+		RecommendationHandler:     handler.NewRecommendationHandler(nil, nil),
+		SuggestionHandler:         handler.NewSuggestionHandler(nil),
+		SearchHandler:             handler.NewSearchHandler(nil),
+		SharePageHandler:          handler.NewSharePageHandler(nil, nil, nil, "", funcMap),
+		CorsMiddleware:            middleware.CORSConfig(),
+		AuthMiddleware:            authMW,
+		UploadPath:                "",
 	}
 
 	r := SetupRouter(deps)
@@ -117,6 +133,30 @@ func TestSetupRouter_BasicPublicEndpoints(t *testing.T) {
 	if wRoot.Code != http.StatusOK {
 		t.Fatalf("GET / status = %d, want 200", wRoot.Code)
 	}
+
+	// Metrics are for admins only
+	metrics := func(user *models.User) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if user != nil {
+			token, _, err := authSvc.GenerateToken(user)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := metrics(nil); code != http.StatusUnauthorized {
+		t.Fatalf("GET /metrics without token status = %d, want 401", code)
+	}
+	if code := metrics(&models.User{ID: 1, Email: "user@example.com", Role: models.RoleUser}); code != http.StatusForbidden {
+		t.Fatalf("GET /metrics as user status = %d, want 403", code)
+	}
+	if code := metrics(&models.User{ID: 2, Email: "admin@example.com", Role: models.RoleAdmin}); code != http.StatusOK {
+		t.Fatalf("GET /metrics as admin status = %d, want 200", code)
+	}
 }
 
 func TestSetupRouter_UploadPathSafe(t *testing.T) {
@@ -128,24 +168,37 @@ func TestSetupRouter_UploadPathSafe(t *testing.T) {
 	authMW := middleware.NewAuthMiddleware(authSvc)
 
 	deps := &RouterDependencies{
-		HealthHandler:          handler.NewHealthHandler(),
-		AuthHandler:            handler.NewAuthHandler(nil),
-		OAuthHandler:           handler.NewOAuthHandler(nil),
-		ProfileHandler:         handler.NewProfileHandler(nil),
-		AdminCategoryHandler:   handler.NewAdminCategoryHandler(nil, funcMap),
-		ProductHandler:         handler.NewProductHandler(nil),
-		AdminProductHandler:    handler.NewAdminProductHandler(nil, nil, funcMap),
-		AdminOrderHandler:      handler.NewAdminOrderHandler(nil, funcMap),
-		AdminOrderStatsHandler: handler.NewAdminOrderStatisticsHandler(nil, funcMap),
-		AdminSuggestionHandler: handler.NewAdminSuggestionHandler(nil, funcMap),
-		AdminUserHandler:       handler.NewAdminUserHandler(nil, funcMap),
-		CartHandler:            handler.NewCartHandler(nil),
-		OrderHandler:           handler.NewOrderHandler(nil),
-		RatingHandler:          handler.NewRatingHandler(nil),
-		SuggestionHandler:      handler.NewSuggestionHandler(nil),
-		CorsMiddleware:         middleware.CORSConfig(),
-		AuthMiddleware:         authMW,
-		UploadPath:             "uploads",
+		HealthHandler:             handler.NewHealthHandler(),
+		MetricsHandler:            handler.NewMetricsHandler(nil),
+		AuthHandler:               handler.NewAuthHandler(nil),
+		OAuthHandler:              handler.NewOAuthHandler(nil),
+		ProfileHandler:            handler.NewProfileHandler(nil),
+		AdminCategoryHandler:      handler.NewAdminCategoryHandler(nil, nil, nil, funcMap),
+		ProductHandler:            handler.NewProductHandler(nil, nil, nil, nil),
+		CategoryHandler:           handler.NewCategoryHandler(nil, nil, nil, nil),
+		AdminProductHandler:       handler.NewAdminProductHandler(nil, nil, nil, nil, 0, funcMap),
+		AdminOrderHandler:         handler.NewAdminOrderHandler(nil, funcMap),
+		AdminOrderStatsHandler:    handler.NewAdminOrderStatisticsHandler(nil, funcMap),
+		AdminSuggestionHandler:    handler.NewAdminSuggestionHandler(nil, funcMap),
+		AdminRatingHandler:        handler.NewAdminRatingHandler(nil, funcMap),
+		AdminSearchHandler:        handler.NewAdminSearchHandler(nil, funcMap),
+		AdminUserHandler:          handler.NewAdminUserHandler(nil, funcMap),
+		AdminTrashHandler:         handler.NewAdminTrashHandler(nil, nil, funcMap),
+		AdminModifierHandler:      handler.NewAdminModifierHandler(nil, nil, nil, funcMap),
+		AdminInventoryHandler:     handler.NewAdminInventoryHandler(nil, nil, funcMap),
+		AdminPriceRuleHandler:     handler.NewAdminPriceRuleHandler(nil, nil, nil, funcMap),
+		AdminProductImportHandler: handler.NewAdminProductImportHandler(nil, funcMap),
+		CartHandler:               handler.NewCartHandler(nil),
+		ModifierHandler:           handler.NewModifierHandler(nil),
+		OrderHandler:              handler.NewOrderHandler(nil),
+		RatingHandler:             handler.NewRatingHandler(nil),
+		RecommendationHandler:     handler.NewRecommendationHandler(nil, nil),
+		SuggestionHandler:         handler.NewSuggestionHandler(nil),
+		SearchHandler:             handler.NewSearchHandler(nil),
+		SharePageHandler:          handler.NewSharePageHandler(nil, nil, nil, "", funcMap),
+		CorsMiddleware:            middleware.CORSConfig(),
+		AuthMiddleware:            authMW,
+		UploadPath:                "uploads",
 	}
 
 	r := SetupRouter(deps)
@@ -153,3 +206,175 @@ func TestSetupRouter_UploadPathSafe(t *testing.T) {
 		t.Fatal("expected router to be non-nil")
 	}
 }
+
+func TestSetupRouter_RemoteUploadsRedirect(t *testing.T) {
+	chdirRepoRoot(t)
+
+	gin.SetMode(gin.TestMode)
+	funcMap := testTemplateFuncMap()
+	authSvc := service.NewAuthServiceWithConfig(&config.JWTConfig{Secret: "router-test-secret", Expiration: time.Hour})
+	authMW := middleware.NewAuthMiddleware(authSvc)
+
+	store, err := storage.NewS3Storage(&config.S3Config{
+		Endpoint:      "http://minio.local:9000",
+		Bucket:        "foods",
+		UsePathStyle:  true,
+		PublicBaseURL: "https://cdn.example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	deps := &RouterDependencies{
+		HealthHandler:             handler.NewHealthHandler(),
+		MetricsHandler:            handler.NewMetricsHandler(nil),
+		AuthHandler:               handler.NewAuthHandler(nil),
+		OAuthHandler:              handler.NewOAuthHandler(nil),
+		ProfileHandler:            handler.NewProfileHandler(nil),
+		AdminCategoryHandler:      handler.NewAdminCategoryHandler(nil, nil, nil, funcMap),
+		ProductHandler:            handler.NewProductHandler(nil, nil, nil, nil),
+		CategoryHandler:           handler.NewCategoryHandler(nil, nil, nil, nil),
+		AdminProductHandler:       handler.NewAdminProductHandler(nil, nil, nil, nil, 0, funcMap),
+		AdminOrderHandler:         handler.NewAdminOrderHandler(nil, funcMap),
+		AdminOrderStatsHandler:    handler.NewAdminOrderStatisticsHandler(nil, funcMap),
+		AdminSuggestionHandler:    handler.NewAdminSuggestionHandler(nil, funcMap),
+		AdminRatingHandler:        handler.NewAdminRatingHandler(nil, funcMap),
+		AdminSearchHandler:        handler.NewAdminSearchHandler(nil, funcMap),
+		AdminUserHandler:          handler.NewAdminUserHandler(nil, funcMap),
+		AdminTrashHandler:         handler.NewAdminTrashHandler(nil, nil, funcMap),
+		AdminModifierHandler:      handler.NewAdminModifierHandler(nil, nil, nil, funcMap),
+		AdminInventoryHandler:     handler.NewAdminInventoryHandler(nil, nil, funcMap),
+		AdminPriceRuleHandler:     handler.NewAdminPriceRuleHandler(nil, nil, nil, funcMap),
+		AdminProductImportHandler: handler.NewAdminProductImportHandler(nil, funcMap),
+		CartHandler:               handler.NewCartHandler(nil),
+		ModifierHandler:           handler.NewModifierHandler(nil),
+		OrderHandler:              handler.NewOrderHandler(nil),
+		RatingHandler:             handler.NewRatingHandler(nil),
+		RecommendationHandler:     handler.NewRecommendationHandler(nil, nil),
+		SuggestionHandler:         handler.NewSuggestionHandler(nil),
+		SearchHandler:             handler.NewSearchHandler(nil),
+		SharePageHandler:          handler.NewSharePageHandler(nil, nil, nil, "", funcMap),
+		UploadHandler:             handler.NewUploadHandler(store),
+		CorsMiddleware:            middleware.CORSConfig(),
+		AuthMiddleware:            authMW,
+		UploadPath:                "uploads",
+	}
+
+	r := SetupRouter(deps)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/uploads/products/a_thumb.jpg", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("GET /uploads status = %d, want 302", w.Code)
+	}
+	if got := w.Header().Get("Location"); got != "https://cdn.example.com/products/a_thumb.jpg" {
+		t.Fatalf("redirect location = %q", got)
+	}
+
+	// Private files are signed for their owner and admins only
+	private := func(user *models.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/uploads/"+storage.PrivateKey(7, "receipt.pdf"), nil)
+		if user != nil {
+			token, _, err := authSvc.GenerateToken(user)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := private(nil); w.Code != http.StatusNotFound {
+		t.Fatalf("anonymous private upload status = %d, want 404", w.Code)
+	}
+	if w := private(&models.User{ID: 8, Email: "other@example.com", Role: models.RoleUser}); w.Code != http.StatusNotFound {
+		t.Fatalf("other user's private upload status = %d, want 404", w.Code)
+	}
+	for _, user := range []*models.User{
+		{ID: 7, Email: "owner@example.com", Role: models.RoleUser},
+		{ID: 1, Email: "admin@example.com", Role: models.RoleAdmin},
+	} {
+		w := private(user)
+		if w.Code != http.StatusFound || !strings.Contains(w.Header().Get("Location"), "X-Amz-Signature=") {
+			t.Fatalf("private upload for %s = %d %q, want a signed redirect", user.Email, w.Code, w.Header().Get("Location"))
+		}
+		if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+			t.Fatalf("private upload Cache-Control = %q", got)
+		}
+	}
+}
+
+func TestSetupRouter_RateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	chdirRepoRoot(t)
+
+	gin.SetMode(gin.TestMode)
+	funcMap := testTemplateFuncMap()
+	authSvc := service.NewAuthServiceWithConfig(&config.JWTConfig{Secret: "router-test-secret", Expiration: time.Hour})
+
+	newRouter := func(trustedProxies []string) *gin.Engine {
+		return SetupRouter(&RouterDependencies{
+			HealthHandler:             handler.NewHealthHandler(),
+			MetricsHandler:            handler.NewMetricsHandler(nil),
+			AuthHandler:               handler.NewAuthHandler(nil),
+			OAuthHandler:              handler.NewOAuthHandler(nil),
+			ProfileHandler:            handler.NewProfileHandler(nil),
+			AdminCategoryHandler:      handler.NewAdminCategoryHandler(nil, nil, nil, funcMap),
+			ProductHandler:            handler.NewProductHandler(nil, nil, nil, nil),
+			CategoryHandler:           handler.NewCategoryHandler(nil, nil, nil, nil),
+			AdminProductHandler:       handler.NewAdminProductHandler(nil, nil, nil, nil, 0, funcMap),
+			AdminOrderHandler:         handler.NewAdminOrderHandler(nil, funcMap),
+			AdminOrderStatsHandler:    handler.NewAdminOrderStatisticsHandler(nil, funcMap),
+			AdminSuggestionHandler:    handler.NewAdminSuggestionHandler(nil, funcMap),
+			AdminRatingHandler:        handler.NewAdminRatingHandler(nil, funcMap),
+			AdminSearchHandler:        handler.NewAdminSearchHandler(nil, funcMap),
+			AdminUserHandler:          handler.NewAdminUserHandler(nil, funcMap),
+			AdminTrashHandler:         handler.NewAdminTrashHandler(nil, nil, funcMap),
+			AdminModifierHandler:      handler.NewAdminModifierHandler(nil, nil, nil, funcMap),
+			AdminInventoryHandler:     handler.NewAdminInventoryHandler(nil, nil, funcMap),
+			AdminPriceRuleHandler:     handler.NewAdminPriceRuleHandler(nil, nil, nil, funcMap),
+			AdminProductImportHandler: handler.NewAdminProductImportHandler(nil, funcMap),
+			CartHandler:               handler.NewCartHandler(nil),
+			ModifierHandler:           handler.NewModifierHandler(nil),
+			OrderHandler:              handler.NewOrderHandler(nil),
+			RatingHandler:             handler.NewRatingHandler(nil),
+			RecommendationHandler:     handler.NewRecommendationHandler(nil, nil),
+			SuggestionHandler:         handler.NewSuggestionHandler(nil),
+			SearchHandler:             handler.NewSearchHandler(nil),
+			SharePageHandler:          handler.NewSharePageHandler(nil, nil, nil, "", funcMap),
+			CorsMiddleware:            middleware.CORSConfig(),
+			AuthMiddleware:            middleware.NewAuthMiddleware(authSvc),
+			SuggestRateLimiter:        middleware.NewRateLimiter(1, time.Minute),
+			TrustedProxies:            trustedProxies,
+		})
+	}
+	// The missing query is rejected after the limiter has counted the request
+	suggest := func(r *gin.Engine, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/search/suggest", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	r := newRouter(nil)
+	if code := suggest(r, "203.0.113.1"); code != http.StatusBadRequest {
+		t.Fatalf("first request status = %d, want 400", code)
+	}
+	if code := suggest(r, "203.0.113.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("request with a new forwarded IP status = %d, want 429", code)
+	}
+
+	// Behind a trusted proxy each forwarded client has its own budget
+	r = newRouter([]string{"10.0.0.0/8"})
+	if code := suggest(r, "203.0.113.1"); code != http.StatusBadRequest {
+		t.Fatalf("first client via proxy status = %d, want 400", code)
+	}
+	if code := suggest(r, "203.0.113.2"); code != http.StatusBadRequest {
+		t.Fatalf("second client via proxy status = %d, want 400", code)
+	}
+	if code := suggest(r, "203.0.113.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("first client again via proxy status = %d, want 429", code)
+	}
+}
@@ -0,0 +1,195 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Suggestion kinds
+const (
+	KindProduct  = "product"
+	KindCategory = "category"
+	KindQuery    = "query"
+)
+
+// kindBoost breaks ties between equally good matches of different kinds:
+// a category is a broader hit than a single product, and popular queries
+// come last because they only repeat what the user is already typing.
+var kindBoost = map[string]float64{
+	KindCategory: 0.3,
+	KindProduct:  0.2,
+	KindQuery:    0,
+}
+
+// Entry is one completion candidate
+type Entry struct {
+	Kind string
+	ID   uint
+	Text string
+	Slug string
+	// Weight in [0, 1] ranks candidates that match equally well,
+	// e.g. product rating or query popularity
+	Weight float64
+}
+
+type tokenRef struct {
+	token string
+	entry int
+}
+
+// Autocomplete answers search-as-you-type lookups from an in-memory sorted
+// token list. Each query token must be a prefix of some word of the entry.
+// It is safe for concurrent use.
+type Autocomplete struct {
+	mu      sync.RWMutex
+	entries []Entry
+	folded  []string
+	words   [][]string
+	tokens  []tokenRef // sorted by token
+}
+
+// NewAutocomplete creates an empty Autocomplete
+func NewAutocomplete() *Autocomplete {
+	return &Autocomplete{}
+}
+
+// Replace swaps the candidate set. Query entries whose text folds to the same
+// words as a product or category are dropped as duplicates.
+func (a *Autocomplete) Replace(entries []Entry) {
+	named := make(map[string]bool)
+	for _, e := range entries {
+		if e.Kind != KindQuery {
+			named[strings.Join(Tokenize(e.Text), " ")] = true
+		}
+	}
+
+	next := &Autocomplete{}
+	seenQuery := make(map[string]bool)
+	for _, e := range entries {
+		words := Tokenize(e.Text)
+		if len(words) == 0 {
+			continue
+		}
+		key := strings.Join(words, " ")
+		if e.Kind == KindQuery {
+			if named[key] || seenQuery[key] {
+				continue
+			}
+			seenQuery[key] = true
+		}
+
+		idx := len(next.entries)
+		next.entries = append(next.entries, e)
+		next.folded = append(next.folded, key)
+		next.words = append(next.words, words)
+		seen := make(map[string]bool, len(words))
+		for _, w := range words {
+			if !seen[w] {
+				seen[w] = true
+				next.tokens = append(next.tokens, tokenRef{token: w, entry: idx})
+			}
+		}
+	}
+	sort.Slice(next.tokens, func(i, j int) bool {
+		if next.tokens[i].token != next.tokens[j].token {
+			return next.tokens[i].token < next.tokens[j].token
+		}
+		return next.tokens[i].entry < next.tokens[j].entry
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries, a.folded, a.words, a.tokens = next.entries, next.folded, next.words, next.tokens
+}
+
+// Len returns the number of candidates
+func (a *Autocomplete) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.entries)
+}
+
+// Suggest returns up to limit entries matching query, best first. An exact
+// match ranks first, then entries whose text starts with the whole query,
+// then those that only contain its words.
+func (a *Autocomplete) Suggest(query string, limit int) []Entry {
+	queryTokens := Tokenize(query)
+	if len(queryTokens) == 0 || limit <= 0 {
+		return nil
+	}
+	phrase := strings.Join(queryTokens, " ")
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Candidates come from the longest query token, which has the fewest prefix hits
+	pivot := queryTokens[0]
+	for _, t := range queryTokens[1:] {
+		if len(t) > len(pivot) {
+			pivot = t
+		}
+	}
+
+	type scored struct {
+		entry int
+		score float64
+	}
+	var hits []scored
+	seen := make(map[int]bool)
+	start := sort.Search(len(a.tokens), func(i int) bool { return a.tokens[i].token >= pivot })
+	for i := start; i < len(a.tokens) && strings.HasPrefix(a.tokens[i].token, pivot); i++ {
+		idx := a.tokens[i].entry
+		if seen[idx] || !a.matchesAll(idx, queryTokens) {
+			continue
+		}
+		seen[idx] = true
+
+		e := a.entries[idx]
+		score := 1 + kindBoost[e.Kind] + e.Weight*0.5
+		if a.folded[idx] == phrase {
+			score += 3
+		} else if strings.HasPrefix(a.folded[idx], phrase) {
+			score += 2
+		}
+		hits = append(hits, scored{entry: idx, score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		// Shorter completions first, then alphabetical for a stable order
+		fi, fj := a.folded[hits[i].entry], a.folded[hits[j].entry]
+		if len(fi) != len(fj) {
+			return len(fi) < len(fj)
+		}
+		return fi < fj
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	out := make([]Entry, len(hits))
+	for i, h := range hits {
+		out[i] = a.entries[h.entry]
+	}
+	return out
+}
+
+// matchesAll reports whether every query token is a prefix of a word of the entry
+func (a *Autocomplete) matchesAll(idx int, queryTokens []string) bool {
+	for _, qt := range queryTokens {
+		found := false
+		for _, w := range a.words[idx] {
+			if strings.HasPrefix(w, qt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
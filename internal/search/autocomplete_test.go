@@ -0,0 +1,53 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestAutocomplete_Suggest(t *testing.T) {
+	t.Parallel()
+
+	ac := NewAutocomplete()
+	ac.Replace([]Entry{
+		{Kind: KindProduct, ID: 1, Text: "Trà sữa trân châu", Slug: "tra-sua-tran-chau", Weight: 0.9},
+		{Kind: KindProduct, ID: 2, Text: "Trà đào cam sả", Slug: "tra-dao-cam-sa", Weight: 0.2},
+		{Kind: KindProduct, ID: 3, Text: "Cà phê sữa đá", Slug: "ca-phe-sua-da"},
+		{Kind: KindCategory, ID: 10, Text: "Trà", Slug: "tra"},
+		{Kind: KindQuery, Text: "trà sữa", Weight: 1},
+		{Kind: KindQuery, Text: "TRA SUA"},
+		{Kind: KindQuery, Text: "trà đào cam sả"},
+		{Kind: KindQuery, Text: "  "},
+	})
+
+	// Duplicate and blank queries are dropped, as is the query that repeats a product name
+	if ac.Len() != 5 {
+		t.Fatalf("Len = %d, want 5", ac.Len())
+	}
+
+	got := ac.Suggest("tra", 10)
+	if len(got) != 4 {
+		t.Fatalf("Suggest(tra) = %+v", got)
+	}
+	// Exact category match first, then by weight
+	if got[0].Kind != KindCategory || got[1].ID != 1 || got[2].Kind != KindQuery || got[3].ID != 2 {
+		t.Fatalf("unexpected ranking %+v", got)
+	}
+
+	// Words may match in any order, but the phrase prefix wins
+	got = ac.Suggest("ca", 10)
+	if len(got) != 2 || got[0].ID != 3 || got[1].ID != 2 {
+		t.Fatalf("Suggest(ca) = %+v", got)
+	}
+
+	got = ac.Suggest("tra s", 1)
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("Suggest(tra s) = %+v", got)
+	}
+
+	if got := ac.Suggest("tra xyz", 10); len(got) != 0 {
+		t.Fatalf("all tokens must match, got %+v", got)
+	}
+	if got := ac.Suggest("", 10); got != nil {
+		t.Fatalf("empty query should return nil, got %+v", got)
+	}
+}
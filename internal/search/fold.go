@@ -0,0 +1,45 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lowercases s and strips diacritics so that "Trà Sữa Đá" and
+// "tra sua da" compare equal.
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	// đ/Đ is a distinct letter, not d + combining mark, so NFD leaves it alone
+	folded = strings.NewReplacer("đ", "d", "Đ", "d").Replace(folded)
+	return strings.ToLower(folded)
+}
+
+// Tokenize folds s and splits it into words made of letters and digits
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// OtherInitial groups names that do not start with a letter a-z
+const OtherInitial = "#"
+
+// Initial returns the folded first letter of s ("Đậu hũ" -> "d"), or
+// OtherInitial when s starts with anything else
+func Initial(s string) string {
+	for _, r := range Fold(strings.TrimSpace(s)) {
+		if r >= 'a' && r <= 'z' {
+			return string(r)
+		}
+		return OtherInitial
+	}
+	return OtherInitial
+}
@@ -0,0 +1,263 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Field weights: a hit in the product name counts more than one in the description
+const (
+	WeightName        = 3.0
+	WeightDescription = 1.0
+)
+
+// Match quality multipliers applied to the field weight
+const (
+	scoreExact  = 1.0
+	scorePrefix = 0.7
+	scoreTypo   = 0.4
+
+	// minPrefixLen avoids a single letter matching half the catalogue
+	minPrefixLen = 2
+	// minTypoLen is the shortest query token typo tolerance applies to
+	minTypoLen = 4
+	// longTypoLen and longer tokens accept two edits instead of one
+	longTypoLen = 8
+)
+
+// Field is a piece of text indexed with a weight
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Document is the indexed representation of a record
+type Document struct {
+	ID     uint
+	Fields []Field
+}
+
+// InvertedIndex is an in-memory inverted index with diacritic folding,
+// prefix matching and typo tolerance (one edit, two for tokens of longTypoLen
+// letters or more). It is safe for concurrent use.
+type InvertedIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[uint]float64 // term -> doc id -> weight
+	docTerms map[uint][]string
+	terms    []string // sorted, for prefix scans
+}
+
+// NewInvertedIndex creates an empty index
+func NewInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		postings: make(map[string]map[uint]float64),
+		docTerms: make(map[uint][]string),
+	}
+}
+
+// Upsert indexes doc, replacing any previous version
+func (idx *InvertedIndex) Upsert(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.ID)
+	idx.add(doc)
+}
+
+// Remove drops the document from the index
+func (idx *InvertedIndex) Remove(id uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+// Replace swaps the whole index content for docs
+func (idx *InvertedIndex) Replace(docs []Document) {
+	fresh := NewInvertedIndex()
+	for _, doc := range docs {
+		fresh.add(doc)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.postings, idx.docTerms, idx.terms = fresh.postings, fresh.docTerms, fresh.terms
+}
+
+// Len returns the number of indexed documents
+func (idx *InvertedIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docTerms)
+}
+
+// Search returns the ids of documents matching every token of query, best
+// match first. Each token matches a term exactly, as a prefix, or within a
+// small edit distance when nothing closer exists.
+func (idx *InvertedIndex) Search(query string) []uint {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var scores map[uint]float64
+	for _, token := range tokens {
+		tokenScores := idx.scoreToken(token)
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		// AND semantics: keep documents that matched every token so far
+		for id, score := range scores {
+			if extra, ok := tokenScores[id]; ok {
+				scores[id] = score + extra
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] > ids[j]
+	})
+	return ids
+}
+
+// scoreToken returns the best score of each document for one query token
+func (idx *InvertedIndex) scoreToken(token string) map[uint]float64 {
+	scores := make(map[uint]float64)
+	collect := func(term string, quality float64) {
+		for id, weight := range idx.postings[term] {
+			if s := weight * quality; s > scores[id] {
+				scores[id] = s
+			}
+		}
+	}
+
+	collect(token, scoreExact)
+	if len(token) >= minPrefixLen {
+		start := sort.SearchStrings(idx.terms, token)
+		for i := start; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], token); i++ {
+			if idx.terms[i] != token {
+				collect(idx.terms[i], scorePrefix)
+			}
+		}
+	}
+	if len(scores) > 0 || len(token) < minTypoLen {
+		return scores
+	}
+
+	maxEdits := 1
+	if len(token) >= longTypoLen {
+		maxEdits = 2
+	}
+	for _, term := range idx.terms {
+		if abs(len(term)-len(token)) > maxEdits {
+			continue
+		}
+		if editDistance(token, term, maxEdits) <= maxEdits {
+			collect(term, scoreTypo)
+		}
+	}
+	return scores
+}
+
+func (idx *InvertedIndex) add(doc Document) {
+	weights := make(map[string]float64)
+	for _, field := range doc.Fields {
+		for _, term := range Tokenize(field.Text) {
+			if field.Weight > weights[term] {
+				weights[term] = field.Weight
+			}
+		}
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		postings, ok := idx.postings[term]
+		if !ok {
+			postings = make(map[uint]float64)
+			idx.postings[term] = postings
+			idx.insertTerm(term)
+		}
+		postings[doc.ID] = weight
+		terms = append(terms, term)
+	}
+	idx.docTerms[doc.ID] = terms
+}
+
+func (idx *InvertedIndex) remove(id uint) {
+	for _, term := range idx.docTerms[id] {
+		postings := idx.postings[term]
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(idx.postings, term)
+			idx.deleteTerm(term)
+		}
+	}
+	delete(idx.docTerms, id)
+}
+
+func (idx *InvertedIndex) insertTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	idx.terms = append(idx.terms, "")
+	copy(idx.terms[i+1:], idx.terms[i:])
+	idx.terms[i] = term
+}
+
+func (idx *InvertedIndex) deleteTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	if i < len(idx.terms) && idx.terms[i] == term {
+		idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+	}
+}
+
+// editDistance is the optimal string alignment distance between a and b
+// (insertions, deletions, substitutions and adjacent transpositions). It stops
+// early and returns max+1 once the distance is known to exceed max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
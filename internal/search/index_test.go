@@ -0,0 +1,98 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"Trà Sữa Trân Châu": "tra sua tran chau",
+		"ĐẬU HŨ":            "dau hu",
+		"Bánh mì đặc biệt":  "banh mi dac biet",
+		"Coca-Cola 330ml":   "coca-cola 330ml",
+	}
+	for in, want := range cases {
+		if got := Fold(in); got != want {
+			t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	for in, want := range map[string]string{"Đậu hũ": "d", " ăn vặt": "a", "7Up": "#", "": "#"} {
+		if got := Initial(in); got != want {
+			t.Fatalf("Initial(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := Tokenize("Cà phê, sữa-đá!"); !reflect.DeepEqual(got, []string{"ca", "phe", "sua", "da"}) {
+		t.Fatalf("Tokenize = %v", got)
+	}
+}
+
+func newTestIndex() *InvertedIndex {
+	idx := NewInvertedIndex()
+	idx.Replace([]Document{
+		{ID: 1, Fields: []Field{{Text: "Trà sữa trân châu", Weight: WeightName}}},
+		{ID: 2, Fields: []Field{{Text: "Cà phê sữa đá", Weight: WeightName}}},
+		{ID: 3, Fields: []Field{{Text: "Bánh mì", Weight: WeightName}, {Text: "Ăn kèm trà đá", Weight: WeightDescription}}},
+		{ID: 4, Fields: []Field{{Text: "Phở bò tái", Weight: WeightName}}},
+	})
+	return idx
+}
+
+func TestInvertedIndex_Search(t *testing.T) {
+	t.Parallel()
+
+	idx := newTestIndex()
+	cases := []struct {
+		name  string
+		query string
+		want  []uint
+	}{
+		{name: "accent insensitive", query: "tra sua", want: []uint{1}},
+		{name: "accented query", query: "SỮA", want: []uint{2, 1}},
+		{name: "name outranks description", query: "trà", want: []uint{1, 3}},
+		{name: "prefix", query: "tran ch", want: []uint{1}},
+		{name: "typo", query: "banhh", want: []uint{3}},
+		{name: "transposition", query: "bnah mi", want: []uint{3}},
+		{name: "short tokens need exact or prefix", query: "pho bo tia", want: []uint{}},
+		{name: "all tokens required", query: "sua pho", want: []uint{}},
+		{name: "no match", query: "pizza", want: []uint{}},
+	}
+	for _, tc := range cases {
+		got := idx.Search(tc.query)
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: Search(%q) = %v, want %v", tc.name, tc.query, got, tc.want)
+		}
+	}
+
+	if got := idx.Search("  ,. "); got != nil {
+		t.Fatalf("empty query should return nil, got %v", got)
+	}
+}
+
+func TestInvertedIndex_UpsertRemove(t *testing.T) {
+	t.Parallel()
+
+	idx := newTestIndex()
+	idx.Upsert(Document{ID: 4, Fields: []Field{{Text: "Bún bò Huế", Weight: WeightName}}})
+	if got := idx.Search("pho"); len(got) != 0 {
+		t.Fatalf("old terms should be gone after upsert, got %v", got)
+	}
+	if got := idx.Search("bun bo hue"); !reflect.DeepEqual(got, []uint{4}) {
+		t.Fatalf("Search after upsert = %v", got)
+	}
+
+	idx.Remove(4)
+	if got := idx.Search("hue"); len(got) != 0 {
+		t.Fatalf("Search after remove = %v", got)
+	}
+	if idx.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", idx.Len())
+	}
+}
@@ -32,7 +32,7 @@ func newAuthServiceForFlowTest(db *gorm.DB) *AuthService {
 	userRepo := repository.NewUserRepository(db)
 	cartRepo := repository.NewCartRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	cartSvc := NewCartService(cartRepo, productRepo)
+	cartSvc := NewCartService(cartRepo, productRepo, nil, nil, nil)
 	jwtCfg := &config.JWTConfig{Secret: "auth-service-flow-secret", Expiration: 2 * time.Hour}
 	return NewAuthService(userRepo, cartSvc, jwtCfg)
 }
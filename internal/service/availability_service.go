@@ -0,0 +1,494 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductUnavailable  = errors.New("product is not available at this time")
+	ErrInvalidAvailability = errors.New("invalid availability schedule")
+)
+
+const (
+	// DefaultAvailabilityTimezone applies when availability.timezone is empty
+	DefaultAvailabilityTimezone = "Asia/Ho_Chi_Minh"
+
+	availabilityDateLayout = "2006-01-02"
+	// availabilityTimesTTL bounds how long schedule edits made directly in
+	// the database take to move the catalog version
+	availabilityTimesTTL = 5 * time.Minute
+)
+
+// AvailabilityService decides whether products are served at the current
+// time from the weekly windows and date exceptions of the product, or of the
+// nearest category on its path that has some when the product has none. A
+// nil *AvailabilityService treats every product as always available.
+type AvailabilityService struct {
+	repo         *repository.AvailabilityRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	loc          *time.Location
+	listeners    []CatalogListener
+	now          func() time.Time
+
+	mu       sync.Mutex
+	times    []int // minutes of the day at which some product opens or closes
+	loadedAt time.Time
+	// tree is the category tree schedules are inherited along, reloaded
+	// after the same time as times
+	tree         *categoryTree
+	treeLoadedAt time.Time
+}
+
+// NewAvailabilityService creates an AvailabilityService whose windows are read
+// in timezone. An empty timezone uses DefaultAvailabilityTimezone.
+func NewAvailabilityService(repo *repository.AvailabilityRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, timezone string, listeners ...CatalogListener) (*AvailabilityService, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		timezone = DefaultAvailabilityTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid availability timezone %q: %w", timezone, err)
+	}
+	return &AvailabilityService{
+		repo:         repo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		loc:          loc,
+		listeners:    listeners,
+		now:          time.Now,
+	}, nil
+}
+
+// Location is the timezone windows are read in
+func (s *AvailabilityService) Location() *time.Location {
+	if s == nil {
+		return time.Local
+	}
+	return s.loc
+}
+
+// Check returns ErrProductUnavailable when product is outside its serving hours
+func (s *AvailabilityService) Check(product *models.Product) error {
+	if s == nil {
+		return nil
+	}
+	available, err := s.AvailableNow([]models.Product{*product})
+	if err != nil {
+		return err
+	}
+	if !available[product.ID] {
+		return fmt.Errorf("%w: %q is not served at this time", ErrProductUnavailable, product.Name)
+	}
+	return nil
+}
+
+// AvailableNow reports for each product whether it is served right now
+func (s *AvailabilityService) AvailableNow(products []models.Product) (map[uint]bool, error) {
+	result := make(map[uint]bool, len(products))
+	if s == nil {
+		for _, p := range products {
+			result[p.ID] = true
+		}
+		return result, nil
+	}
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	tree, err := s.categoryTree()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().In(s.loc)
+	productIDs := make([]uint, 0, len(products))
+	categoryIDs := make([]uint, 0, len(products))
+	chains := make(map[uint][]uint)
+	seenCategory := make(map[uint]bool)
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+		if _, ok := chains[p.CategoryID]; ok {
+			continue
+		}
+		chain := categoryChain(tree, p.CategoryID)
+		chains[p.CategoryID] = chain
+		for _, id := range chain {
+			if !seenCategory[id] {
+				seenCategory[id] = true
+				categoryIDs = append(categoryIDs, id)
+			}
+		}
+	}
+
+	windows, err := s.repo.ListWindows(productIDs, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability windows: %w", err)
+	}
+	// Yesterday's exceptions decide whether its overnight windows run into today
+	dates := []string{now.Format(availabilityDateLayout), now.AddDate(0, 0, -1).Format(availabilityDateLayout)}
+	exceptions, err := s.repo.ListExceptions(productIDs, categoryIDs, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability exceptions: %w", err)
+	}
+
+	sc := newSchedules(windows, exceptions, chains)
+	for i := range products {
+		result[products[i].ID] = sc.availableAt(&products[i], now)
+	}
+	return result, nil
+}
+
+// categoryTree returns the category tree, reloading it when it is older
+// than availabilityTimesTTL
+func (s *AvailabilityService) categoryTree() (*categoryTree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.tree == nil || now.Sub(s.treeLoadedAt) >= availabilityTimesTTL {
+		categories, err := s.categoryRepo.ListAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load categories: %w", err)
+		}
+		s.tree, s.treeLoadedAt = newCategoryTree(categories), now
+	}
+	return s.tree, nil
+}
+
+// categoryChain lists a category and its ancestors, nearest first
+func categoryChain(tree *categoryTree, categoryID uint) []uint {
+	path := tree.path(categoryID)
+	if len(path) == 0 {
+		return []uint{categoryID}
+	}
+	chain := make([]uint, len(path))
+	for i, c := range path {
+		chain[len(path)-1-i] = c.ID
+	}
+	return chain
+}
+
+// LastTransition returns the latest time, not after now, at which any
+// product may have opened or closed. Responses that show availability are
+// still valid while it does not change. It is zero when there is no schedule.
+func (s *AvailabilityService) LastTransition() (time.Time, error) {
+	if s == nil {
+		return time.Time{}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now().In(s.loc)
+	if s.times == nil || now.Sub(s.loadedAt) >= availabilityTimesTTL {
+		raw, hasExceptions, err := s.repo.TimesOfDay()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to load availability times: %w", err)
+		}
+		times := make([]int, 0, len(raw)+1)
+		if hasExceptions {
+			// Exceptions start and end with their date
+			times = append(times, 0)
+		}
+		for _, t := range raw {
+			if m, ok := parseMinuteOfDay(t); ok {
+				times = append(times, m)
+			}
+		}
+		s.times, s.loadedAt = times, now
+	}
+
+	var last time.Time
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	for _, m := range s.times {
+		at := midnight.Add(time.Duration(m) * time.Minute)
+		if at.After(now) {
+			at = at.AddDate(0, 0, -1)
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	return last, nil
+}
+
+// GetProductSchedule returns the schedule set on a product itself
+func (s *AvailabilityService) GetProductSchedule(productID uint) (*dto.AvailabilitySchedule, error) {
+	return s.getSchedule([]uint{productID}, nil)
+}
+
+// GetCategorySchedule returns the schedule of a category
+func (s *AvailabilityService) GetCategorySchedule(categoryID uint) (*dto.AvailabilitySchedule, error) {
+	return s.getSchedule(nil, []uint{categoryID})
+}
+
+// SaveProductSchedule replaces the schedule of a product. An empty schedule
+// makes the product follow its category again.
+func (s *AvailabilityService) SaveProductSchedule(productID uint, req *dto.AvailabilitySchedule) error {
+	windows, exceptions, err := buildAvailability(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to find product: %w", err)
+	}
+	if err := s.repo.ReplaceProductSchedule(productID, windows, exceptions); err != nil {
+		return fmt.Errorf("failed to save availability: %w", err)
+	}
+	s.changed()
+	return nil
+}
+
+// SaveCategorySchedule replaces the schedule of a category
+func (s *AvailabilityService) SaveCategorySchedule(categoryID uint, req *dto.AvailabilitySchedule) error {
+	windows, exceptions, err := buildAvailability(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.categoryRepo.FindByID(categoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to find category: %w", err)
+	}
+	if err := s.repo.ReplaceCategorySchedule(categoryID, windows, exceptions); err != nil {
+		return fmt.Errorf("failed to save availability: %w", err)
+	}
+	s.changed()
+	return nil
+}
+
+func (s *AvailabilityService) getSchedule(productIDs, categoryIDs []uint) (*dto.AvailabilitySchedule, error) {
+	if s == nil {
+		return &dto.AvailabilitySchedule{}, nil
+	}
+	windows, err := s.repo.ListWindows(productIDs, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability windows: %w", err)
+	}
+	exceptions, err := s.repo.ListExceptions(productIDs, categoryIDs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability exceptions: %w", err)
+	}
+
+	schedule := &dto.AvailabilitySchedule{
+		Windows:    make([]dto.AvailabilityWindowInput, len(windows)),
+		Exceptions: make([]dto.AvailabilityExceptionInput, len(exceptions)),
+	}
+	for i, w := range windows {
+		schedule.Windows[i] = dto.AvailabilityWindowInput{Weekday: w.Weekday, StartTime: w.StartTime, EndTime: w.EndTime}
+	}
+	for i, e := range exceptions {
+		schedule.Exceptions[i] = dto.AvailabilityExceptionInput{Date: e.Date, StartTime: e.StartTime, EndTime: e.EndTime, Note: e.Note}
+	}
+	return schedule, nil
+}
+
+// changed drops the cached transition times and tells listeners, since
+// available_now is part of catalog responses
+func (s *AvailabilityService) changed() {
+	s.mu.Lock()
+	s.times = nil
+	s.tree = nil
+	s.mu.Unlock()
+	for _, l := range s.listeners {
+		l.CatalogChanged()
+	}
+}
+
+// buildAvailability validates the editor rows, skipping blank ones
+func buildAvailability(req *dto.AvailabilitySchedule) ([]models.AvailabilityWindow, []models.AvailabilityException, error) {
+	windows := make([]models.AvailabilityWindow, 0, len(req.Windows))
+	for _, in := range req.Windows {
+		start, end := strings.TrimSpace(in.StartTime), strings.TrimSpace(in.EndTime)
+		if start == "" && end == "" {
+			continue
+		}
+		if in.Weekday < 0 || in.Weekday > 6 {
+			return nil, nil, fmt.Errorf("%w: weekday must be between 0 and 6", ErrInvalidAvailability)
+		}
+		startMin, okStart := parseMinuteOfDay(start)
+		endMin, okEnd := parseMinuteOfDay(end)
+		if !okStart || !okEnd {
+			return nil, nil, fmt.Errorf("%w: times must be HH:MM", ErrInvalidAvailability)
+		}
+		if startMin == endMin {
+			return nil, nil, fmt.Errorf("%w: a window cannot start and end at %s", ErrInvalidAvailability, start)
+		}
+		windows = append(windows, models.AvailabilityWindow{Weekday: in.Weekday, StartTime: start, EndTime: end})
+	}
+
+	exceptions := make([]models.AvailabilityException, 0, len(req.Exceptions))
+	for _, in := range req.Exceptions {
+		date := strings.TrimSpace(in.Date)
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(availabilityDateLayout, date); err != nil {
+			return nil, nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidAvailability)
+		}
+		start, end := strings.TrimSpace(in.StartTime), strings.TrimSpace(in.EndTime)
+		if start != "" || end != "" {
+			startMin, okStart := parseMinuteOfDay(start)
+			endMin, okEnd := parseMinuteOfDay(end)
+			if !okStart || !okEnd {
+				return nil, nil, fmt.Errorf("%w: times must be HH:MM, or both empty to close the day", ErrInvalidAvailability)
+			}
+			if startMin >= endMin {
+				return nil, nil, fmt.Errorf("%w: on %s the end must be after the start", ErrInvalidAvailability, date)
+			}
+		}
+		exceptions = append(exceptions, models.AvailabilityException{
+			Date:      date,
+			StartTime: start,
+			EndTime:   end,
+			Note:      strings.TrimSpace(in.Note),
+		})
+	}
+	return windows, exceptions, nil
+}
+
+// parseMinuteOfDay parses "HH:MM" into minutes since midnight
+func parseMinuteOfDay(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil || len(value) != 5 {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// schedules indexes loaded windows and exceptions by owner
+type schedules struct {
+	productWindows     map[uint][]models.AvailabilityWindow
+	categoryWindows    map[uint][]models.AvailabilityWindow
+	productExceptions  map[uint][]models.AvailabilityException
+	categoryExceptions map[uint][]models.AvailabilityException
+	// chains holds the categories a product category inherits from, from
+	// categoryChain
+	chains map[uint][]uint
+}
+
+func newSchedules(windows []models.AvailabilityWindow, exceptions []models.AvailabilityException, chains map[uint][]uint) *schedules {
+	sc := &schedules{
+		productWindows:     make(map[uint][]models.AvailabilityWindow),
+		categoryWindows:    make(map[uint][]models.AvailabilityWindow),
+		productExceptions:  make(map[uint][]models.AvailabilityException),
+		categoryExceptions: make(map[uint][]models.AvailabilityException),
+		chains:             chains,
+	}
+	for _, w := range windows {
+		if w.ProductID != nil {
+			sc.productWindows[*w.ProductID] = append(sc.productWindows[*w.ProductID], w)
+		} else if w.CategoryID != nil {
+			sc.categoryWindows[*w.CategoryID] = append(sc.categoryWindows[*w.CategoryID], w)
+		}
+	}
+	for _, e := range exceptions {
+		if e.ProductID != nil {
+			sc.productExceptions[*e.ProductID] = append(sc.productExceptions[*e.ProductID], e)
+		} else if e.CategoryID != nil {
+			sc.categoryExceptions[*e.CategoryID] = append(sc.categoryExceptions[*e.CategoryID], e)
+		}
+	}
+	return sc
+}
+
+// categories returns the categories p inherits a schedule from, nearest first
+func (sc *schedules) categories(p *models.Product) []uint {
+	if chain, ok := sc.chains[p.CategoryID]; ok {
+		return chain
+	}
+	return []uint{p.CategoryID}
+}
+
+// exceptionsOn returns the exceptions deciding the product's hours on the
+// date of day: its own if it has any that day, otherwise those of the
+// nearest category having some
+func (sc *schedules) exceptionsOn(p *models.Product, day time.Time) []models.AvailabilityException {
+	date := day.Format(availabilityDateLayout)
+	lists := [][]models.AvailabilityException{sc.productExceptions[p.ID]}
+	for _, id := range sc.categories(p) {
+		lists = append(lists, sc.categoryExceptions[id])
+	}
+	for _, list := range lists {
+		var onDate []models.AvailabilityException
+		for _, e := range list {
+			if e.Date == date {
+				onDate = append(onDate, e)
+			}
+		}
+		if len(onDate) > 0 {
+			return onDate
+		}
+	}
+	return nil
+}
+
+// availableAt reports whether p is served at t, given in the shop timezone
+func (sc *schedules) availableAt(p *models.Product, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	// An exception replaces the weekly windows for the whole date
+	if exceptions := sc.exceptionsOn(p, t); exceptions != nil {
+		for _, e := range exceptions {
+			if e.Closed() {
+				return false
+			}
+		}
+		for _, e := range exceptions {
+			start, _ := parseMinuteOfDay(e.StartTime)
+			end, _ := parseMinuteOfDay(e.EndTime)
+			if minute >= start && minute < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	windows := sc.productWindows[p.ID]
+	for _, id := range sc.categories(p) {
+		if len(windows) > 0 {
+			break
+		}
+		windows = sc.categoryWindows[id]
+	}
+	if len(windows) == 0 {
+		return true
+	}
+
+	today := int(t.Weekday())
+	yesterday := t.AddDate(0, 0, -1)
+	spillsOver := sc.exceptionsOn(p, yesterday) == nil
+	for _, w := range windows {
+		start, okStart := parseMinuteOfDay(w.StartTime)
+		end, okEnd := parseMinuteOfDay(w.EndTime)
+		if !okStart || !okEnd {
+			continue
+		}
+		overnight := end <= start
+		if w.Weekday == today {
+			if overnight && minute >= start {
+				return true
+			}
+			if !overnight && minute >= start && minute < end {
+				return true
+			}
+		}
+		// The part of yesterday's overnight window after midnight
+		if spillsOver && overnight && w.Weekday == int(yesterday.Weekday()) && minute < end {
+			return true
+		}
+	}
+	return false
+}
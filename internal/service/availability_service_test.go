@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newAvailabilityServiceTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open availability test db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductImage{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.AvailabilityWindow{},
+		&models.AvailabilityException{},
+	); err != nil {
+		t.Fatalf("migrate availability test db: %v", err)
+	}
+	return db
+}
+
+func TestSchedules_AvailableAt(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation(DefaultAvailabilityTimezone)
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	uintPtr := func(v uint) *uint { return &v }
+
+	breakfast := &models.Product{ID: 1, CategoryID: 10} // own windows
+	lunch := &models.Product{ID: 2, CategoryID: 10}     // category windows
+	drink := &models.Product{ID: 3, CategoryID: 20}     // no schedule
+	brunch := &models.Product{ID: 4, CategoryID: 11}    // subcategory of 10
+
+	sc := newSchedules(
+		[]models.AvailabilityWindow{
+			{ProductID: uintPtr(1), Weekday: int(time.Monday), StartTime: "06:00", EndTime: "10:00"},
+			{ProductID: uintPtr(1), Weekday: int(time.Monday), StartTime: "22:00", EndTime: "02:00"},
+			{CategoryID: uintPtr(10), Weekday: int(time.Sunday), StartTime: "11:00", EndTime: "14:00"},
+		},
+		[]models.AvailabilityException{
+			{ProductID: uintPtr(1), Date: "2026-05-05"},
+			{CategoryID: uintPtr(10), Date: "2026-05-10", StartTime: "12:00", EndTime: "13:00"},
+		},
+		map[uint][]uint{10: {10}, 11: {11, 10}, 20: {20}},
+	)
+
+	// 2026-05-04 is a Monday
+	at := func(date, clock string) time.Time {
+		t.Helper()
+		v, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name    string
+		product *models.Product
+		at      time.Time
+		want    bool
+	}{
+		{"inside morning window", breakfast, at("2026-05-04", "07:00"), true},
+		{"end is exclusive", breakfast, at("2026-05-04", "10:00"), false},
+		{"overnight before midnight", breakfast, at("2026-05-04", "23:00"), true},
+		{"overnight after midnight", breakfast, at("2026-05-12", "01:30"), true},
+		{"after overnight window", breakfast, at("2026-05-12", "02:00"), false},
+		{"closed date cuts the overnight part", breakfast, at("2026-05-05", "01:00"), false},
+		{"own windows hide the category's", breakfast, at("2026-05-03", "12:00"), false},
+		{"inherits category window", lunch, at("2026-05-03", "12:00"), true},
+		{"outside category window", lunch, at("2026-05-04", "12:00"), false},
+		{"category exception replaces the window", lunch, at("2026-05-10", "11:30"), false},
+		{"inside category exception", lunch, at("2026-05-10", "12:30"), true},
+		{"no schedule is always available", drink, at("2026-05-05", "03:00"), true},
+		{"inherits parent category window", brunch, at("2026-05-03", "12:00"), true},
+		{"outside parent category window", brunch, at("2026-05-04", "12:00"), false},
+		{"parent category exception", brunch, at("2026-05-10", "11:30"), false},
+	}
+	for _, tt := range tests {
+		if got := sc.availableAt(tt.product, tt.at); got != tt.want {
+			t.Errorf("%s: availableAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAvailability_Validation(t *testing.T) {
+	t.Parallel()
+
+	windows, exceptions, err := buildAvailability(&dto.AvailabilitySchedule{
+		Windows: []dto.AvailabilityWindowInput{
+			{Weekday: 1, StartTime: "06:00", EndTime: "10:00"},
+			{Weekday: 1}, // blank row from the editor
+		},
+		Exceptions: []dto.AvailabilityExceptionInput{
+			{Date: "2026-02-17", Note: " Tết "},
+			{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+	if len(windows) != 1 || len(exceptions) != 1 || !exceptions[0].Closed() || exceptions[0].Note != "Tết" {
+		t.Fatalf("windows = %+v, exceptions = %+v", windows, exceptions)
+	}
+
+	invalid := []*dto.AvailabilitySchedule{
+		{Windows: []dto.AvailabilityWindowInput{{Weekday: 7, StartTime: "06:00", EndTime: "10:00"}}},
+		{Windows: []dto.AvailabilityWindowInput{{Weekday: 1, StartTime: "6:00", EndTime: "10:00"}}},
+		{Windows: []dto.AvailabilityWindowInput{{Weekday: 1, StartTime: "08:00", EndTime: "08:00"}}},
+		{Exceptions: []dto.AvailabilityExceptionInput{{Date: "17/02/2026"}}},
+		{Exceptions: []dto.AvailabilityExceptionInput{{Date: "2026-02-17", StartTime: "10:00"}}},
+		{Exceptions: []dto.AvailabilityExceptionInput{{Date: "2026-02-17", StartTime: "22:00", EndTime: "02:00"}}},
+	}
+	for i, req := range invalid {
+		if _, _, err := buildAvailability(req); !errors.Is(err, ErrInvalidAvailability) {
+			t.Errorf("case %d: error = %v, want ErrInvalidAvailability", i, err)
+		}
+	}
+}
+
+type countingListener struct{ calls int }
+
+func (l *countingListener) CatalogChanged() { l.calls++ }
+
+func TestAvailabilityService_SaveCheckAndCart(t *testing.T) {
+	t.Parallel()
+
+	db := newAvailabilityServiceTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	listener := &countingListener{}
+	svc, err := NewAvailabilityService(repository.NewAvailabilityRepository(db), productRepo, repository.NewCategoryRepository(db), "", listener)
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Monday 2026-05-04 15:20 in the shop timezone
+	now := time.Date(2026, 5, 4, 15, 20, 0, 0, svc.Location())
+	svc.now = func() time.Time { return now }
+
+	cat := &models.Category{Name: "Breakfast", Slug: "availability-breakfast"}
+	if err := db.Create(cat).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	pho := &models.Product{CategoryID: cat.ID, Name: "Phở sáng", Slug: "pho-sang", Classify: models.ClassifyFood, Price: 40000, Stock: 10, Status: models.ProductStatusActive}
+	if err := db.Create(pho).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	user := &models.User{Email: "availability@example.com", FullName: "Early Bird", Role: models.RoleUser, Status: models.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if epoch, err := svc.LastTransition(); err != nil || !epoch.IsZero() {
+		t.Fatalf("LastTransition without schedule = %v, %v", epoch, err)
+	}
+
+	if err := svc.SaveCategorySchedule(cat.ID, &dto.AvailabilitySchedule{
+		Windows: []dto.AvailabilityWindowInput{{Weekday: int(time.Monday), StartTime: "06:00", EndTime: "10:00"}},
+	}); err != nil {
+		t.Fatalf("save category schedule: %v", err)
+	}
+	if listener.calls != 1 {
+		t.Fatalf("listener calls = %d, want 1", listener.calls)
+	}
+	if err := svc.SaveProductSchedule(9999, &dto.AvailabilitySchedule{}); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("save for missing product error = %v", err)
+	}
+
+	if err := svc.Check(pho); !errors.Is(err, ErrProductUnavailable) {
+		t.Fatalf("Check at 15:20 = %v, want ErrProductUnavailable", err)
+	}
+	epoch, err := svc.LastTransition()
+	if err != nil {
+		t.Fatalf("LastTransition: %v", err)
+	}
+	if want := time.Date(2026, 5, 4, 10, 0, 0, 0, svc.Location()); !epoch.Equal(want) {
+		t.Fatalf("LastTransition = %v, want %v", epoch, want)
+	}
+
+	cartSvc := NewCartService(repository.NewCartRepository(db), productRepo, nil, svc, nil)
+	if _, err := cartSvc.AddItem(user.ID, &dto.AddCartItemRequest{ProductID: pho.ID, Quantity: 1}); !errors.Is(err, ErrProductUnavailable) {
+		t.Fatalf("AddItem outside hours error = %v, want ErrProductUnavailable", err)
+	}
+
+	// A product schedule overrides the category's
+	if err := svc.SaveProductSchedule(pho.ID, &dto.AvailabilitySchedule{
+		Windows: []dto.AvailabilityWindowInput{{Weekday: int(time.Monday), StartTime: "15:00", EndTime: "16:00"}},
+	}); err != nil {
+		t.Fatalf("save product schedule: %v", err)
+	}
+	if _, err := cartSvc.AddItem(user.ID, &dto.AddCartItemRequest{ProductID: pho.ID, Quantity: 1}); err != nil {
+		t.Fatalf("AddItem inside hours: %v", err)
+	}
+	schedule, err := svc.GetProductSchedule(pho.ID)
+	if err != nil || len(schedule.Windows) != 1 || schedule.Windows[0].StartTime != "15:00" {
+		t.Fatalf("product schedule = %+v, %v", schedule, err)
+	}
+}
@@ -18,14 +18,20 @@ var (
 )
 
 type CartService struct {
-	cartRepo    *repository.CartRepository
-	productRepo *repository.ProductRepository
+	cartRepo     *repository.CartRepository
+	productRepo  *repository.ProductRepository
+	modifierRepo *repository.ModifierRepository
+	availability *AvailabilityService
+	pricing      *PricingService
 }
 
-func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *CartService {
+func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, modifierRepo *repository.ModifierRepository, availability *AvailabilityService, pricing *PricingService) *CartService {
 	return &CartService{
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		modifierRepo: modifierRepo,
+		availability: availability,
+		pricing:      pricing,
 	}
 }
 
@@ -45,11 +51,11 @@ func (s *CartService) GetCart(userID uint) (*dto.CartResponse, error) {
 			if err := s.cartRepo.Create(cart); err != nil {
 				return nil, fmt.Errorf("failed to create cart: %w", err)
 			}
-			return s.toCartResponse(cart), nil
+			return s.toCartResponse(cart)
 		}
 		return nil, fmt.Errorf("failed to get cart: %w", err)
 	}
-	return s.toCartResponse(cart), nil
+	return s.toCartResponse(cart)
 }
 
 func (s *CartService) AddItem(userID uint, req *dto.AddCartItemRequest) (*dto.CartResponse, error) {
@@ -57,46 +63,50 @@ func (s *CartService) AddItem(userID uint, req *dto.AddCartItemRequest) (*dto.Ca
 		return nil, ErrInvalidQuantity
 	}
 
-	product, err := s.productRepo.FindByID(req.ProductID)
+	product, err := s.findActiveProduct(req.ProductID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrProductNotFound
-		}
-		return nil, fmt.Errorf("failed to find product: %w", err)
+		return nil, err
 	}
-
-	if product.Status != models.ProductStatusActive {
-		return nil, ErrProductNotFound
+	if err := s.availability.Check(product); err != nil {
+		return nil, err
 	}
 
-	if product.Stock < req.Quantity {
-		return nil, fmt.Errorf("%w: available %d, requested %d", ErrInsufficientStock, product.Stock, req.Quantity)
+	selected, err := resolveProductModifiers(s.modifierRepo, product, req.ModifierOptionIDs)
+	if err != nil {
+		return nil, err
 	}
+	key := modifierKey(selected)
 
 	cart, err := s.getOrCreateCart(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	item, err := s.cartRepo.FindCartItem(cart.ID, req.ProductID)
+	// Stock is shared by every line of the same product, whatever its modifiers.
+	inCart, err := s.cartRepo.SumQuantityByProduct(cart.ID, product.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cart quantity: %w", err)
+	}
+	if product.Stock < inCart+req.Quantity {
+		return nil, fmt.Errorf("%w: available %d, requested total %d", ErrInsufficientStock, product.Stock, inCart+req.Quantity)
+	}
+
+	item, err := s.cartRepo.FindCartItemByModifierKey(cart.ID, req.ProductID, key)
 	if err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to find cart item: %w", err)
 		}
 		item = &models.CartItem{
-			CartID:    cart.ID,
-			ProductID: req.ProductID,
-			Quantity:  req.Quantity,
+			CartID:      cart.ID,
+			ProductID:   req.ProductID,
+			Quantity:    req.Quantity,
+			ModifierKey: key,
 		}
 		if err := s.cartRepo.CreateCartItem(item); err != nil {
 			return nil, fmt.Errorf("failed to add cart item: %w", err)
 		}
 	} else {
-		newQty := item.Quantity + req.Quantity
-		if product.Stock < newQty {
-			return nil, fmt.Errorf("%w: available %d, requested total %d", ErrInsufficientStock, product.Stock, newQty)
-		}
-		item.Quantity = newQty
+		item.Quantity += req.Quantity
 		if err := s.cartRepo.UpdateCartItem(item); err != nil {
 			return nil, fmt.Errorf("failed to update cart item: %w", err)
 		}
@@ -105,25 +115,38 @@ func (s *CartService) AddItem(userID uint, req *dto.AddCartItemRequest) (*dto.Ca
 	return s.GetCart(userID)
 }
 
+// UpdateItem sets the quantity of the product's cart line without modifiers.
+// Lines with modifiers are addressed by ID through UpdateLine.
 func (s *CartService) UpdateItem(userID uint, productID uint, quantity int) (*dto.CartResponse, error) {
 	if quantity < 1 {
 		return nil, ErrInvalidQuantity
 	}
 
-	product, err := s.productRepo.FindByID(productID)
+	product, err := s.findActiveProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.getOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.cartRepo.FindCartItem(cart.ID, productID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrProductNotFound
+			return nil, ErrCartItemNotFound
 		}
-		return nil, fmt.Errorf("failed to find product: %w", err)
+		return nil, fmt.Errorf("failed to find cart item: %w", err)
 	}
 
-	if product.Status != models.ProductStatusActive {
-		return nil, ErrProductNotFound
-	}
+	return s.updateLineQuantity(userID, cart, item, product, quantity)
+}
 
-	if product.Stock < quantity {
-		return nil, fmt.Errorf("%w: available %d, requested %d", ErrInsufficientStock, product.Stock, quantity)
+// UpdateLine sets the quantity of a single cart line identified by its ID
+func (s *CartService) UpdateLine(userID uint, itemID uint, quantity int) (*dto.CartResponse, error) {
+	if quantity < 1 {
+		return nil, ErrInvalidQuantity
 	}
 
 	cart, err := s.getOrCreateCart(userID)
@@ -131,7 +154,7 @@ func (s *CartService) UpdateItem(userID uint, productID uint, quantity int) (*dt
 		return nil, err
 	}
 
-	item, err := s.cartRepo.FindCartItem(cart.ID, productID)
+	item, err := s.cartRepo.FindCartItemByID(cart.ID, itemID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrCartItemNotFound
@@ -139,6 +162,23 @@ func (s *CartService) UpdateItem(userID uint, productID uint, quantity int) (*dt
 		return nil, fmt.Errorf("failed to find cart item: %w", err)
 	}
 
+	product, err := s.findActiveProduct(item.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateLineQuantity(userID, cart, item, product, quantity)
+}
+
+func (s *CartService) updateLineQuantity(userID uint, cart *models.Cart, item *models.CartItem, product *models.Product, quantity int) (*dto.CartResponse, error) {
+	otherLines, err := s.cartRepo.SumQuantityByProduct(cart.ID, product.ID, item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cart quantity: %w", err)
+	}
+	if product.Stock < otherLines+quantity {
+		return nil, fmt.Errorf("%w: available %d, requested %d", ErrInsufficientStock, product.Stock, otherLines+quantity)
+	}
+
 	item.Quantity = quantity
 	if err := s.cartRepo.UpdateCartItem(item); err != nil {
 		return nil, fmt.Errorf("failed to update cart item: %w", err)
@@ -160,6 +200,20 @@ func (s *CartService) RemoveItem(userID uint, productID uint) (*dto.CartResponse
 	return s.GetCart(userID)
 }
 
+// RemoveLine removes a single cart line identified by its ID
+func (s *CartService) RemoveLine(userID uint, itemID uint) (*dto.CartResponse, error) {
+	cart, err := s.getOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.DeleteCartItemByID(cart.ID, itemID); err != nil {
+		return nil, fmt.Errorf("failed to remove cart item: %w", err)
+	}
+
+	return s.GetCart(userID)
+}
+
 func (s *CartService) ClearCart(userID uint) (*dto.CartResponse, error) {
 	cart, err := s.getOrCreateCart(userID)
 	if err != nil {
@@ -181,7 +235,47 @@ func (s *CartService) getOrCreateCart(userID uint) (*models.Cart, error) {
 	return cart, nil
 }
 
-func (s *CartService) toCartResponse(cart *models.Cart) *dto.CartResponse {
+func (s *CartService) findActiveProduct(productID uint) (*models.Product, error) {
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	if product.Status != models.ProductStatusActive {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// loadCartModifiers fetches every option referenced by the cart lines in one query
+func (s *CartService) loadCartModifiers(items []models.CartItem) (map[uint]models.ModifierOption, error) {
+	options := make(map[uint]models.ModifierOption)
+	if s.modifierRepo == nil {
+		return options, nil
+	}
+
+	ids := []uint{}
+	for _, item := range items {
+		ids = append(ids, parseModifierKey(item.ModifierKey)...)
+	}
+	if len(ids) == 0 {
+		return options, nil
+	}
+
+	found, err := s.modifierRepo.FindOptionsByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cart modifiers: %w", err)
+	}
+	for _, opt := range found {
+		options[opt.ID] = opt
+	}
+	return options, nil
+}
+
+func (s *CartService) toCartResponse(cart *models.Cart) (*dto.CartResponse, error) {
 	resp := &dto.CartResponse{
 		ID:          cart.ID,
 		Items:       make([]dto.CartItemResponse, 0, len(cart.Items)),
@@ -189,16 +283,47 @@ func (s *CartService) toCartResponse(cart *models.Cart) *dto.CartResponse {
 		TotalAmount: 0,
 	}
 
+	options, err := s.loadCartModifiers(cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, item := range cart.Items {
 		subtotal := 0.0
 		name := ""
 		price := 0.0
+		originalPrice := 0.0
 		imageURL := ""
 
+		modifiers := []dto.CartItemModifierResponse{}
+		modifiersPrice := 0.0
+		for _, id := range parseModifierKey(item.ModifierKey) {
+			opt, ok := options[id]
+			if !ok {
+				continue
+			}
+			groupName := ""
+			if opt.Group != nil {
+				groupName = opt.Group.Name
+			}
+			modifiers = append(modifiers, dto.CartItemModifierResponse{
+				OptionID:   opt.ID,
+				GroupName:  groupName,
+				OptionName: opt.Name,
+				Price:      opt.Price,
+			})
+			modifiersPrice += opt.Price
+		}
+
 		if item.Product != nil {
-			price = item.Product.Price
+			// Priced the way checkout will snapshot it
+			quote, err := s.pricing.Quote(item.Product)
+			if err != nil {
+				return nil, err
+			}
+			price, originalPrice = quote.Price, quote.OriginalPrice
 			name = item.Product.Name
-			subtotal = price * float64(item.Quantity)
+			subtotal = (price + modifiersPrice) * float64(item.Quantity)
 			if len(item.Product.Images) > 0 {
 				for _, img := range item.Product.Images {
 					if img.IsPrimary {
@@ -212,18 +337,25 @@ func (s *CartService) toCartResponse(cart *models.Cart) *dto.CartResponse {
 			}
 		}
 
-		resp.Items = append(resp.Items, dto.CartItemResponse{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Name:      name,
-			Price:     price,
-			Quantity:  item.Quantity,
-			Subtotal:  subtotal,
-			ImageURL:  imageURL,
-		})
+		line := dto.CartItemResponse{
+			ID:             item.ID,
+			ProductID:      item.ProductID,
+			Name:           name,
+			Price:          price,
+			OriginalPrice:  originalPrice,
+			ModifiersPrice: modifiersPrice,
+			UnitPrice:      price + modifiersPrice,
+			Quantity:       item.Quantity,
+			Subtotal:       subtotal,
+			ImageURL:       imageURL,
+		}
+		if len(modifiers) > 0 {
+			line.Modifiers = modifiers
+		}
+		resp.Items = append(resp.Items, line)
 		resp.TotalItems += item.Quantity
 		resp.TotalAmount += subtotal
 	}
 
-	return resp
+	return resp, nil
 }
@@ -27,6 +27,9 @@ func newCartServiceTestDB(t *testing.T) *gorm.DB {
 		&models.ProductImage{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.ModifierGroup{},
+		&models.ModifierOption{},
+		&models.ModifierGroupAssignment{},
 	); err != nil {
 		t.Fatalf("cart service migrate: %v", err)
 	}
@@ -36,7 +39,8 @@ func newCartServiceTestDB(t *testing.T) *gorm.DB {
 func newCartServiceForTest(db *gorm.DB) *CartService {
 	cartRepo := repository.NewCartRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	return NewCartService(cartRepo, productRepo)
+	modifierRepo := repository.NewModifierRepository(db)
+	return NewCartService(cartRepo, productRepo, modifierRepo, nil, nil)
 }
 
 func seedUserForCartTest(t *testing.T, db *gorm.DB, email string) *models.User {
@@ -221,3 +225,81 @@ func TestCartService_UpdateAndRemove_NotFound(t *testing.T) {
 		t.Fatalf("RemoveItem should ignore missing item, got error: %v", err)
 	}
 }
+
+func TestCartService_AddItem_SplitsLinesByModifiers(t *testing.T) {
+	t.Parallel()
+	db := newCartServiceTestDB(t)
+	svc := newCartServiceForTest(db)
+	u := seedUserForCartTest(t, db, "modifiers@example.com")
+	p := seedProductForCartTest(t, db, "modifiers-slug", 5)
+	group := seedModifierGroupForTest(t, db, p, 0, 2,
+		dto.ModifierOptionInput{Name: "Pearl", Price: 7000},
+		dto.ModifierOptionInput{Name: "Jelly", Price: 6000},
+	)
+	pearl, jelly := group.Options[0].ID, group.Options[1].ID
+
+	if _, err := svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 1}); err != nil {
+		t.Fatalf("AddItem plain: %v", err)
+	}
+	if _, err := svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 1, ModifierOptionIDs: []uint{jelly, pearl}}); err != nil {
+		t.Fatalf("AddItem with modifiers: %v", err)
+	}
+	resp, err := svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 2, ModifierOptionIDs: []uint{pearl, jelly}})
+	if err != nil {
+		t.Fatalf("AddItem same modifiers: %v", err)
+	}
+
+	if len(resp.Items) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(resp.Items))
+	}
+	var modLine *dto.CartItemResponse
+	for i := range resp.Items {
+		if len(resp.Items[i].Modifiers) > 0 {
+			modLine = &resp.Items[i]
+		}
+	}
+	if modLine == nil || modLine.Quantity != 3 || modLine.UnitPrice != 23000 || modLine.Subtotal != 69000 {
+		t.Fatalf("unexpected modifier line: %+v", modLine)
+	}
+	if resp.TotalAmount != 79000 {
+		t.Fatalf("total = %v, want 79000", resp.TotalAmount)
+	}
+
+	// Stock is shared across lines of the same product
+	_, err = svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 2})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("error = %v, want %v", err, ErrInsufficientStock)
+	}
+
+	if _, err := svc.UpdateLine(u.ID, modLine.ID, 4); err != nil {
+		t.Fatalf("UpdateLine: %v", err)
+	}
+	resp, err = svc.RemoveLine(u.ID, modLine.ID)
+	if err != nil {
+		t.Fatalf("RemoveLine: %v", err)
+	}
+	if len(resp.Items) != 1 || len(resp.Items[0].Modifiers) != 0 {
+		t.Fatalf("expected only the plain line to remain, got %+v", resp.Items)
+	}
+}
+
+func TestCartService_AddItem_InvalidModifiers(t *testing.T) {
+	t.Parallel()
+	db := newCartServiceTestDB(t)
+	svc := newCartServiceForTest(db)
+	u := seedUserForCartTest(t, db, "bad-modifiers@example.com")
+	p := seedProductForCartTest(t, db, "bad-modifiers-slug", 5)
+	other := seedProductForCartTest(t, db, "other-modifiers-slug", 5)
+	seedModifierGroupForTest(t, db, p, 1, 1, dto.ModifierOptionInput{Name: "M"}, dto.ModifierOptionInput{Name: "L", Price: 5000})
+	foreign := seedModifierGroupForTest(t, db, other, 0, 1, dto.ModifierOptionInput{Name: "Extra", Price: 1000})
+
+	_, err := svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 1})
+	if !errors.Is(err, ErrInvalidModifierSelection) {
+		t.Fatalf("missing required: error = %v, want %v", err, ErrInvalidModifierSelection)
+	}
+
+	_, err = svc.AddItem(u.ID, &dto.AddCartItemRequest{ProductID: p.ID, Quantity: 1, ModifierOptionIDs: []uint{foreign.Options[0].ID}})
+	if !errors.Is(err, ErrInvalidModifierSelection) {
+		t.Fatalf("foreign option: error = %v, want %v", err, ErrInvalidModifierSelection)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"mime/multipart"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/kha/foods-drinks/internal/dto"
@@ -17,6 +19,20 @@ var (
 	ErrCategoryNotFound  = errors.New("category not found")
 	ErrSlugAlreadyExists = errors.New("slug already exists")
 	ErrEmptySlug         = errors.New("slug cannot be empty after generation; use alphanumeric characters")
+
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its subcategories")
+	ErrCategoryHasChildren    = errors.New("category has subcategories; choose whether to re-parent or delete them")
+	ErrCategoryHasProducts    = errors.New("category still has products; choose a category to move them to")
+	ErrInvalidProductTarget   = errors.New("products cannot be moved to a missing or deleted category")
+)
+
+// What Delete does with the subcategories of a deleted category
+const (
+	// CategoryChildrenReparent moves them up to the deleted category's parent
+	CategoryChildrenReparent = "reparent"
+	// CategoryChildrenDelete deletes them along with it
+	CategoryChildrenDelete = "delete"
 )
 
 // maxSlugSuffixAttempts is the maximum number of numeric suffixes tried when
@@ -35,12 +51,22 @@ var (
 // CategoryService handles category business logic
 type CategoryService struct {
 	categoryRepo *repository.CategoryRepository
+	uploads      *UploadService
+	products     *ProductService
+	translations *TranslationService
+	listeners    []CatalogListener
 }
 
-// NewCategoryService creates a new CategoryService
-func NewCategoryService(categoryRepo *repository.CategoryRepository) *CategoryService {
+// NewCategoryService creates a new CategoryService. products may be nil, in
+// which case browsed categories carry no product count or preview, and
+// translations may be nil to serve the default locale only.
+func NewCategoryService(categoryRepo *repository.CategoryRepository, uploads *UploadService, products *ProductService, translations *TranslationService, listeners ...CatalogListener) *CategoryService {
 	return &CategoryService{
 		categoryRepo: categoryRepo,
+		uploads:      uploads,
+		products:     products,
+		translations: translations,
+		listeners:    listeners,
 	}
 }
 
@@ -60,6 +86,10 @@ func (s *CategoryService) Create(req *dto.CreateCategoryRequest) (*dto.CategoryR
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate unique slug: %w", err)
 	}
+	translations, err := s.translations.validate(models.TranslationEntityCategory, req.Translations)
+	if err != nil {
+		return nil, err
+	}
 
 	category := &models.Category{
 		Name:   strings.TrimSpace(req.Name),
@@ -67,6 +97,16 @@ func (s *CategoryService) Create(req *dto.CreateCategoryRequest) (*dto.CategoryR
 		Status: models.CategoryStatusActive,
 	}
 
+	if req.ParentID != nil && *req.ParentID != 0 {
+		if _, err := s.categoryRepo.FindByID(*req.ParentID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrParentCategoryNotFound
+			}
+			return nil, fmt.Errorf("failed to find parent category: %w", err)
+		}
+		category.ParentID = req.ParentID
+	}
+
 	if req.Description != nil {
 		trimmed := strings.TrimSpace(*req.Description)
 		if trimmed != "" {
@@ -89,6 +129,10 @@ func (s *CategoryService) Create(req *dto.CreateCategoryRequest) (*dto.CategoryR
 	if err := s.categoryRepo.Create(category); err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
 	}
+	if err := s.translations.save(models.TranslationEntityCategory, category.ID, translations); err != nil {
+		return nil, err
+	}
+	s.notifyChanged()
 
 	return s.toCategoryResponse(category), nil
 }
@@ -114,6 +158,17 @@ func (s *CategoryService) Update(id uint, req *dto.UpdateCategoryRequest) (*dto.
 		}
 		return nil, fmt.Errorf("failed to find category: %w", err)
 	}
+	translations, err := s.translations.validate(models.TranslationEntityCategory, req.Translations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update parent
+	if req.ParentID != nil {
+		if err := s.setParent(category, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Update name
 	if req.Name != nil {
@@ -149,9 +204,12 @@ func (s *CategoryService) Update(id uint, req *dto.UpdateCategoryRequest) (*dto.
 		}
 	}
 
-	// Update image URL
+	// Update image URL; a changed URL no longer matches the uploaded thumbnail
 	if req.ImageURL != nil {
 		trimmed := strings.TrimSpace(*req.ImageURL)
+		if category.ImageURL == nil || *category.ImageURL != trimmed {
+			category.ThumbnailURL = nil
+		}
 		if trimmed == "" {
 			category.ImageURL = nil
 		} else {
@@ -172,28 +230,264 @@ func (s *CategoryService) Update(id uint, req *dto.UpdateCategoryRequest) (*dto.
 	if err := s.categoryRepo.Update(category); err != nil {
 		return nil, fmt.Errorf("failed to update category: %w", err)
 	}
+	if err := s.translations.save(models.TranslationEntityCategory, id, translations); err != nil {
+		return nil, err
+	}
+	s.notifyChanged()
 
 	return s.toCategoryResponse(category), nil
 }
 
-// Delete soft deletes a category
-func (s *CategoryService) Delete(id uint) error {
-	// Check if category exists
-	_, err := s.categoryRepo.FindByID(id)
+// Move places a category under parentID, or at the top level when parentID
+// is 0
+func (s *CategoryService) Move(id, parentID uint) error {
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to find category: %w", err)
+	}
+	if err := s.setParent(category, parentID); err != nil {
+		return err
+	}
+	if err := s.categoryRepo.Update(category); err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	s.notifyChanged()
+	return nil
+}
+
+// setParent points category at parentID after checking that the parent
+// exists and is not the category itself or one of its subcategories
+func (s *CategoryService) setParent(category *models.Category, parentID uint) error {
+	if parentID == 0 {
+		category.ParentID = nil
+		return nil
+	}
+	if parentID == category.ID {
+		return ErrCategoryCycle
+	}
+	tree, err := s.tree()
+	if err != nil {
+		return err
+	}
+	if tree.byID[parentID] == nil {
+		return ErrParentCategoryNotFound
+	}
+	for _, id := range tree.descendants(category.ID) {
+		if id == parentID {
+			return ErrCategoryCycle
+		}
+	}
+	category.ParentID = &parentID
+	return nil
+}
+
+// Reorder moves a category one place up or down among its siblings. The
+// siblings are renumbered from 0 so equal sort orders cannot pin it in place.
+func (s *CategoryService) Reorder(id uint, up bool) error {
+	tree, err := s.tree()
+	if err != nil {
+		return err
+	}
+	siblings := tree.siblings(id)
+	if siblings == nil {
+		return ErrCategoryNotFound
+	}
+
+	ordered := append([]*models.Category{}, siblings...)
+	for i, c := range ordered {
+		if c.ID != id {
+			continue
+		}
+		j := i + 1
+		if up {
+			j = i - 1
+		}
+		if j < 0 || j >= len(ordered) {
+			return nil
+		}
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+		break
+	}
+
+	orders := make(map[uint]int)
+	for i, c := range ordered {
+		if c.SortOrder != i {
+			orders[c.ID] = i
+		}
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+	if err := s.categoryRepo.UpdateSortOrders(orders); err != nil {
+		return fmt.Errorf("failed to reorder categories: %w", err)
+	}
+	s.notifyChanged()
+	return nil
+}
+
+// Tree lists every category depth first, each followed by its subcategories
+// in display order
+func (s *CategoryService) Tree() ([]dto.CategoryTreeItem, error) {
+	tree, err := s.tree()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.categoryRepo.CountProductsByCategory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+	items := make([]dto.CategoryTreeItem, 0, len(tree.byID))
+	tree.walk(func(c *models.Category, depth int) {
+		items = append(items, dto.CategoryTreeItem{
+			CategoryResponse: *s.toCategoryResponse(c),
+			Depth:            depth,
+			ChildCount:       len(tree.children[c.ID]),
+			ProductCount:     counts[c.ID],
+		})
+	})
+	return items, nil
+}
+
+// Delete soft deletes a category. A category with subcategories needs
+// children set to CategoryChildrenReparent or CategoryChildrenDelete, and one
+// whose deletion would orphan products needs moveProductsTo, the category
+// taking them over. Products move and categories go in one transaction.
+func (s *CategoryService) Delete(id uint, children string, moveProductsTo uint) error {
+	category, err := s.categoryRepo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrCategoryNotFound
 		}
 		return fmt.Errorf("failed to find category: %w", err)
 	}
+	tree, err := s.tree()
+	if err != nil {
+		return err
+	}
 
-	if err := s.categoryRepo.Delete(id); err != nil {
+	descendants := tree.descendants(id)
+	removed := []uint{id}
+	switch {
+	case len(descendants) == 0, children == CategoryChildrenReparent:
+	case children == CategoryChildrenDelete:
+		removed = append(removed, descendants...)
+	default:
+		return ErrCategoryHasChildren
+	}
+
+	validTarget := tree.byID[moveProductsTo] != nil && !slices.Contains(removed, moveProductsTo)
+
+	err = s.categoryRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.categoryRepo.WithTx(tx)
+		// products are checked in the transaction so that one created
+		// meanwhile is not left in a deleted category
+		if validTarget {
+			if err := repo.MoveProducts(removed, moveProductsTo); err != nil {
+				return err
+			}
+		} else {
+			products, err := repo.CountProducts(removed, false)
+			if err != nil {
+				return fmt.Errorf("failed to count products: %w", err)
+			}
+			if products > 0 && moveProductsTo == 0 {
+				return ErrCategoryHasProducts
+			}
+			if products > 0 {
+				return ErrInvalidProductTarget
+			}
+		}
+		if len(removed) == 1 && len(descendants) > 0 {
+			return repo.DeleteReparenting(id, category.ParentID)
+		}
+		return repo.DeleteMany(removed)
+	})
+	if errors.Is(err, ErrCategoryHasProducts) || errors.Is(err, ErrInvalidProductTarget) {
+		return err
+	}
+	if err != nil {
 		return fmt.Errorf("failed to delete category: %w", err)
 	}
+	s.notifyChanged()
 
 	return nil
 }
 
+// ListDeleted returns the categories in the trash
+func (s *CategoryService) ListDeleted() ([]dto.TrashedCategoryResponse, error) {
+	categories, err := s.categoryRepo.ListDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted categories: %w", err)
+	}
+	items := make([]dto.TrashedCategoryResponse, len(categories))
+	for i := range categories {
+		items[i] = dto.TrashedCategoryResponse{
+			CategoryResponse: *s.toCategoryResponse(&categories[i]),
+			DeletedAt:        categories[i].DeletedAt.Time,
+		}
+	}
+	return items, nil
+}
+
+// Restore takes a category out of the trash. It goes back under its parent,
+// or to the top level if the parent is gone.
+func (s *CategoryService) Restore(id uint) error {
+	category, err := s.categoryRepo.FindDeletedByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to find category: %w", err)
+	}
+	parentID := category.ParentID
+	if parentID != nil {
+		if _, err := s.categoryRepo.FindByID(*parentID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to find parent category: %w", err)
+			}
+			parentID = nil
+		}
+	}
+	if err := s.categoryRepo.Restore(id, parentID); err != nil {
+		return fmt.Errorf("failed to restore category: %w", err)
+	}
+	s.notifyChanged()
+	return nil
+}
+
+// Purge permanently deletes a category from the trash. It is refused while
+// any product, trashed ones included, still belongs to it.
+func (s *CategoryService) Purge(id uint) error {
+	if _, err := s.categoryRepo.FindDeletedByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to find category: %w", err)
+	}
+	products, err := s.categoryRepo.CountProducts([]uint{id}, true)
+	if err != nil {
+		return fmt.Errorf("failed to count products: %w", err)
+	}
+	if products > 0 {
+		return ErrCategoryHasProducts
+	}
+	if err := s.categoryRepo.Purge(id); err != nil {
+		return fmt.Errorf("failed to purge category: %w", err)
+	}
+	return s.translations.deleteAll(models.TranslationEntityCategory, id)
+}
+
+func (s *CategoryService) tree() (*categoryTree, error) {
+	categories, err := s.categoryRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	return newCategoryTree(categories), nil
+}
+
 // List returns a paginated list of categories
 func (s *CategoryService) List(req *dto.CategoryListRequest) (*dto.PaginatedResponse, error) {
 	offset := (req.Page - 1) * req.PageSize
@@ -228,6 +522,90 @@ func (s *CategoryService) List(req *dto.CategoryListRequest) (*dto.PaginatedResp
 	}, nil
 }
 
+// ListActive returns the active categories in display order, each with its
+// count of active products and up to preview of its top-rated products, with
+// names and descriptions in locale
+func (s *CategoryService) ListActive(preview int, locale string) ([]dto.CategoryBrowseResponse, error) {
+	categories, err := s.categoryRepo.ListActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return s.toBrowseResponses(categories, preview, locale)
+}
+
+// GetActiveBySlug is ListActive for a single category. Inactive categories
+// are not found.
+func (s *CategoryService) GetActiveBySlug(slug string, preview int, locale string) (*dto.CategoryBrowseResponse, error) {
+	category, err := s.categoryRepo.FindBySlug(slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to find category: %w", err)
+	}
+	if category.Status != models.CategoryStatusActive {
+		return nil, ErrCategoryNotFound
+	}
+	items, err := s.toBrowseResponses([]models.Category{*category}, preview, locale)
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *CategoryService) toBrowseResponses(categories []models.Category, preview int, locale string) ([]dto.CategoryBrowseResponse, error) {
+	items := make([]dto.CategoryBrowseResponse, len(categories))
+	localized := make([]*dto.CategoryResponse, len(categories))
+	for i := range categories {
+		items[i].CategoryResponse = *s.toCategoryResponse(&categories[i])
+		localized[i] = &items[i].CategoryResponse
+	}
+	if err := s.translations.localizeCategories(locale, localized); err != nil {
+		return nil, err
+	}
+	if s.products == nil || len(categories) == 0 {
+		return items, nil
+	}
+
+	// A category's products include those of its subcategories
+	tree, err := s.tree()
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+		ids = append(ids, tree.descendants(c.ID)...)
+	}
+	counts, err := s.products.productRepo.CountByCategory(ids, models.ProductStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+	for i := range items {
+		items[i].ProductCount = counts[items[i].ID]
+		for _, id := range tree.descendants(items[i].ID) {
+			items[i].ProductCount += counts[id]
+		}
+		if preview <= 0 || items[i].ProductCount == 0 {
+			continue
+		}
+		top, err := s.products.List(&dto.ProductListRequest{
+			Page:     1,
+			PageSize: preview,
+			Category: items[i].ID,
+			Status:   models.ProductStatusActive,
+			SortBy:   "rating_average",
+			SortDir:  "desc",
+			Locale:   locale,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list products of category %d: %w", items[i].ID, err)
+		}
+		items[i].Products, _ = top.Items.([]dto.ProductResponse)
+	}
+	return items, nil
+}
+
 // generateSlug converts a string to a URL-friendly slug.
 // Returns an empty string when the input contains no usable alphanumeric characters.
 func (s *CategoryService) generateSlug(input string) string {
@@ -275,17 +653,68 @@ func (s *CategoryService) ensureUniqueSlug(slug string, excludeID uint) (string,
 	return "", errors.New("could not generate unique slug after maximum attempts")
 }
 
+// UploadImage stores an uploaded category image. ImageURL points at the medium
+// rendition and ThumbnailURL at the thumbnail; previously uploaded files are
+// removed once the category has been updated.
+func (s *CategoryService) UploadImage(id uint, file *multipart.FileHeader) (*dto.CategoryResponse, error) {
+	if s.uploads == nil {
+		return nil, ErrUploadUnavailable
+	}
+
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to find category: %w", err)
+	}
+
+	stored, err := s.uploads.SaveImage(file, "categories")
+	if err != nil {
+		return nil, err
+	}
+
+	oldImage, oldThumb := category.ImageURL, category.ThumbnailURL
+	category.ImageURL = &stored.MediumURL
+	category.ThumbnailURL = &stored.ThumbnailURL
+	if err := s.categoryRepo.Update(category); err != nil {
+		s.uploads.RemoveImage(stored)
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	// The category only references the renditions, so drop an unused original
+	if stored.URL != stored.MediumURL && stored.URL != stored.ThumbnailURL {
+		s.uploads.Remove(stored.URL)
+	}
+	for _, old := range []*string{oldImage, oldThumb} {
+		if old != nil && s.uploads.IsStoredURL(*old) {
+			s.uploads.Remove(*old)
+		}
+	}
+	s.notifyChanged()
+
+	return s.toCategoryResponse(category), nil
+}
+
+func (s *CategoryService) notifyChanged() {
+	for _, l := range s.listeners {
+		l.CatalogChanged()
+	}
+}
+
 // toCategoryResponse converts a Category model to CategoryResponse DTO
 func (s *CategoryService) toCategoryResponse(category *models.Category) *dto.CategoryResponse {
 	return &dto.CategoryResponse{
-		ID:          category.ID,
-		Name:        category.Name,
-		Slug:        category.Slug,
-		Description: category.Description,
-		ImageURL:    category.ImageURL,
-		SortOrder:   category.SortOrder,
-		Status:      category.Status,
-		CreatedAt:   category.CreatedAt,
-		UpdatedAt:   category.UpdatedAt,
+		ID:           category.ID,
+		ParentID:     category.ParentID,
+		Name:         category.Name,
+		Slug:         category.Slug,
+		Description:  category.Description,
+		ImageURL:     category.ImageURL,
+		ThumbnailURL: category.ThumbnailURL,
+		SortOrder:    category.SortOrder,
+		Status:       category.Status,
+		CreatedAt:    category.CreatedAt,
+		UpdatedAt:    category.UpdatedAt,
 	}
 }
@@ -21,12 +21,12 @@ func setupCategoryServiceTest(t *testing.T) (*CategoryService, *gorm.DB) {
 	if err != nil {
 		t.Fatalf("open sqlite: %v", err)
 	}
-	if err := db.AutoMigrate(&models.Category{}); err != nil {
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
 
 	repo := repository.NewCategoryRepository(db)
-	return NewCategoryService(repo), db
+	return NewCategoryService(repo, nil, nil, nil), db
 }
 
 func TestCategoryService_Create(t *testing.T) {
@@ -100,7 +100,7 @@ func TestCategoryService_Delete(t *testing.T) {
 
 	created, _ := svc.Create(&dto.CreateCategoryRequest{Name: "ToDelete"})
 
-	if err := svc.Delete(created.ID); err != nil {
+	if err := svc.Delete(created.ID, "", 0); err != nil {
 		t.Fatalf("Delete() error: %v", err)
 	}
 
@@ -149,3 +149,184 @@ func TestCategoryService_Create_EmptyName(t *testing.T) {
 		t.Fatal("expected error for slug that becomes empty")
 	}
 }
+
+func TestCategoryService_ParentPreventsCycles(t *testing.T) {
+	svc, _ := setupCategoryServiceTest(t)
+
+	drinks, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Drinks"})
+	coffee, err := svc.Create(&dto.CreateCategoryRequest{Name: "Coffee", ParentID: &drinks.ID})
+	if err != nil {
+		t.Fatalf("Create child: %v", err)
+	}
+	coldBrew, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Cold brew", ParentID: &coffee.ID})
+
+	missing := uint(999)
+	if _, err := svc.Create(&dto.CreateCategoryRequest{Name: "Orphan", ParentID: &missing}); err != ErrParentCategoryNotFound {
+		t.Fatalf("unknown parent err = %v, want ErrParentCategoryNotFound", err)
+	}
+	if err := svc.Move(drinks.ID, drinks.ID); err != ErrCategoryCycle {
+		t.Fatalf("move under itself err = %v, want ErrCategoryCycle", err)
+	}
+	if _, err := svc.Update(drinks.ID, &dto.UpdateCategoryRequest{ParentID: &coldBrew.ID}); err != ErrCategoryCycle {
+		t.Fatalf("move under grandchild err = %v, want ErrCategoryCycle", err)
+	}
+
+	// Moving to the top level and back is fine
+	if err := svc.Move(coldBrew.ID, 0); err != nil {
+		t.Fatalf("Move to top level: %v", err)
+	}
+	if got, _ := svc.GetByID(coldBrew.ID); got.ParentID != nil {
+		t.Fatalf("parent = %v, want top level", *got.ParentID)
+	}
+	if err := svc.Move(coldBrew.ID, drinks.ID); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	tree, err := svc.Tree()
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	var got []string
+	for _, item := range tree {
+		got = append(got, fmt.Sprintf("%s/%d/%d", item.Slug, item.Depth, item.ChildCount))
+	}
+	if want := "[drinks/0/2 coffee/1/0 cold-brew/1/0]"; fmt.Sprint(got) != want {
+		t.Fatalf("tree = %v, want %s", got, want)
+	}
+}
+
+func TestCategoryService_Reorder(t *testing.T) {
+	svc, _ := setupCategoryServiceTest(t)
+
+	parent, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Drinks"})
+	var ids []uint
+	for _, name := range []string{"Tea", "Coffee", "Juice"} {
+		c, _ := svc.Create(&dto.CreateCategoryRequest{Name: name, ParentID: &parent.ID})
+		ids = append(ids, c.ID)
+	}
+
+	if err := svc.Reorder(ids[2], true); err != nil {
+		t.Fatalf("Reorder: %v", err)
+	}
+	// Already first: nothing to do
+	if err := svc.Reorder(ids[0], true); err != nil {
+		t.Fatalf("Reorder first: %v", err)
+	}
+
+	tree, _ := svc.Tree()
+	var got []string
+	for _, item := range tree[1:] {
+		got = append(got, fmt.Sprintf("%s=%d", item.Slug, item.SortOrder))
+	}
+	if want := "[tea=0 juice=1 coffee=2]"; fmt.Sprint(got) != want {
+		t.Fatalf("children = %v, want %s", got, want)
+	}
+}
+
+func TestCategoryService_DeleteWithChildren(t *testing.T) {
+	svc, _ := setupCategoryServiceTest(t)
+
+	drinks, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Drinks"})
+	coffee, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Coffee", ParentID: &drinks.ID})
+	coldBrew, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Cold brew", ParentID: &coffee.ID})
+	tea, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Tea", ParentID: &drinks.ID})
+
+	if err := svc.Delete(coffee.ID, "", 0); err != ErrCategoryHasChildren {
+		t.Fatalf("Delete without choice err = %v, want ErrCategoryHasChildren", err)
+	}
+
+	// Re-parenting hands the children to the grandparent
+	if err := svc.Delete(coffee.ID, CategoryChildrenReparent, 0); err != nil {
+		t.Fatalf("Delete reparent: %v", err)
+	}
+	got, err := svc.GetByID(coldBrew.ID)
+	if err != nil || got.ParentID == nil || *got.ParentID != drinks.ID {
+		t.Fatalf("cold brew = %+v, %v; want it under drinks", got, err)
+	}
+
+	if err := svc.Delete(drinks.ID, CategoryChildrenDelete, 0); err != nil {
+		t.Fatalf("Delete cascade: %v", err)
+	}
+	for _, id := range []uint{drinks.ID, coldBrew.ID, tea.ID} {
+		if _, err := svc.GetByID(id); err != ErrCategoryNotFound {
+			t.Fatalf("category %d err = %v, want ErrCategoryNotFound", id, err)
+		}
+	}
+}
+
+func TestCategoryService_DeleteMovesProducts(t *testing.T) {
+	svc, db := setupCategoryServiceTest(t)
+
+	drinks, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Drinks"})
+	coffee, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Coffee", ParentID: &drinks.ID})
+	foods, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Foods"})
+	db.Create(&models.Product{CategoryID: coffee.ID, Name: "Latte", Slug: "latte", Classify: "drink", Price: 30000, Status: "active"})
+	db.Create(&models.Product{CategoryID: drinks.ID, Name: "Tra", Slug: "tra", Classify: "drink", Price: 10000, Status: "inactive"})
+
+	if err := svc.Delete(coffee.ID, "", 0); err != ErrCategoryHasProducts {
+		t.Fatalf("Delete with products err = %v, want ErrCategoryHasProducts", err)
+	}
+	// The target cannot be among the deleted categories
+	if err := svc.Delete(drinks.ID, CategoryChildrenDelete, coffee.ID); err != ErrInvalidProductTarget {
+		t.Fatalf("Delete into subcategory err = %v, want ErrInvalidProductTarget", err)
+	}
+	if _, err := svc.GetByID(coffee.ID); err != nil {
+		t.Fatalf("refused delete removed the category: %v", err)
+	}
+
+	if err := svc.Delete(drinks.ID, CategoryChildrenDelete, foods.ID); err != nil {
+		t.Fatalf("Delete moving products: %v", err)
+	}
+	var moved int64
+	db.Model(&models.Product{}).Where("category_id = ?", foods.ID).Count(&moved)
+	if moved != 2 {
+		t.Fatalf("products in foods = %d, want 2", moved)
+	}
+}
+
+func TestCategoryService_RestoreAndPurge(t *testing.T) {
+	svc, db := setupCategoryServiceTest(t)
+
+	drinks, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Drinks"})
+	coffee, _ := svc.Create(&dto.CreateCategoryRequest{Name: "Coffee", ParentID: &drinks.ID})
+	if err := svc.Delete(drinks.ID, CategoryChildrenDelete, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	trash, err := svc.ListDeleted()
+	if err != nil || len(trash) != 2 {
+		t.Fatalf("ListDeleted = %d items, %v; want 2", len(trash), err)
+	}
+	if err := svc.Restore(drinks.ID + 100); err != ErrCategoryNotFound {
+		t.Fatalf("Restore unknown err = %v, want ErrCategoryNotFound", err)
+	}
+
+	// The parent is still in the trash, so the child comes back at the top level
+	if err := svc.Restore(coffee.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got, err := svc.GetByID(coffee.ID)
+	if err != nil || got.ParentID != nil {
+		t.Fatalf("restored = %+v, %v; want a top-level category", got, err)
+	}
+
+	// Trashed products still hold on to their category
+	product := &models.Product{CategoryID: drinks.ID, Name: "Tra", Slug: "tra", Classify: "drink", Price: 10000}
+	db.Create(product)
+	db.Delete(product)
+	if err := svc.Purge(drinks.ID); err != ErrCategoryHasProducts {
+		t.Fatalf("Purge with products err = %v, want ErrCategoryHasProducts", err)
+	}
+	db.Unscoped().Delete(product)
+	if err := svc.Purge(drinks.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	var left int64
+	db.Unscoped().Model(&models.Category{}).Where("id = ?", drinks.ID).Count(&left)
+	if left != 0 {
+		t.Fatal("purged category is still stored")
+	}
+	if err := svc.Purge(coffee.ID); err != ErrCategoryNotFound {
+		t.Fatalf("Purge of a live category err = %v, want ErrCategoryNotFound", err)
+	}
+}
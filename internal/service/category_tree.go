@@ -0,0 +1,94 @@
+package service
+
+import "github.com/kha/foods-drinks/internal/models"
+
+// categoryTree indexes categories by parent. A category whose parent is
+// missing (e.g. deleted) is treated as a top-level one.
+type categoryTree struct {
+	byID     map[uint]*models.Category
+	roots    []*models.Category
+	children map[uint][]*models.Category
+}
+
+// newCategoryTree builds the tree, keeping the order of categories among
+// siblings
+func newCategoryTree(categories []models.Category) *categoryTree {
+	t := &categoryTree{
+		byID:     make(map[uint]*models.Category, len(categories)),
+		children: make(map[uint][]*models.Category),
+	}
+	for i := range categories {
+		t.byID[categories[i].ID] = &categories[i]
+	}
+	for i := range categories {
+		c := &categories[i]
+		if c.ParentID != nil && t.byID[*c.ParentID] != nil {
+			t.children[*c.ParentID] = append(t.children[*c.ParentID], c)
+		} else {
+			t.roots = append(t.roots, c)
+		}
+	}
+	return t
+}
+
+// siblings returns the categories sharing the parent of id, id included
+func (t *categoryTree) siblings(id uint) []*models.Category {
+	c := t.byID[id]
+	if c == nil {
+		return nil
+	}
+	if c.ParentID != nil && t.byID[*c.ParentID] != nil {
+		return t.children[*c.ParentID]
+	}
+	return t.roots
+}
+
+// path returns the ancestry of id, root first and id last. It is empty for
+// an unknown id.
+func (t *categoryTree) path(id uint) []*models.Category {
+	var path []*models.Category
+	seen := make(map[uint]bool)
+	for c := t.byID[id]; c != nil && !seen[c.ID]; {
+		seen[c.ID] = true
+		path = append(path, c)
+		if c.ParentID == nil {
+			break
+		}
+		c = t.byID[*c.ParentID]
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// descendants returns the IDs of every category below id, depth first
+func (t *categoryTree) descendants(id uint) []uint {
+	var ids []uint
+	seen := map[uint]bool{id: true}
+	var walk func(uint)
+	walk = func(parent uint) {
+		for _, c := range t.children[parent] {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			ids = append(ids, c.ID)
+			walk(c.ID)
+		}
+	}
+	walk(id)
+	return ids
+}
+
+// walk visits every category depth first in display order
+func (t *categoryTree) walk(visit func(c *models.Category, depth int)) {
+	var walk func([]*models.Category, int)
+	walk = func(level []*models.Category, depth int) {
+		for _, c := range level {
+			visit(c, depth)
+			walk(t.children[c.ID], depth+1)
+		}
+	}
+	walk(t.roots, 0)
+}
@@ -34,9 +34,11 @@ type ChatworkNotificationService struct {
 	jobs             chan chatworkNotificationJob
 }
 
+// chatworkNotificationJob carries either an order or a low-stock alert
 type chatworkNotificationJob struct {
 	notificationID uint
 	order          *dto.OrderResponse
+	lowStock       *dto.LowStockAlert
 }
 
 func NewChatworkNotificationService(cfg *config.ChatworkConfig, notificationRepo repository.OrderNotificationRepositoryInterface) *ChatworkNotificationService {
@@ -122,12 +124,48 @@ func (s *ChatworkNotificationService) NotifyNewOrderAsync(order *dto.OrderRespon
 	}
 }
 
+// NotifyLowStockAsync posts the alert to the configured room. Low-stock
+// alerts are not tied to an order, so failures are only logged.
+func (s *ChatworkNotificationService) NotifyLowStockAsync(alert *dto.LowStockAlert) {
+	if s == nil || alert == nil || len(alert.Products) == 0 || !s.cfg.Enabled {
+		return
+	}
+	if strings.TrimSpace(s.cfg.RoomID) == "" || strings.TrimSpace(s.cfg.APIToken) == "" {
+		log.Printf("[notification] low-stock chatwork message disabled because room_id or api_token is empty")
+		return
+	}
+
+	select {
+	case s.jobs <- chatworkNotificationJob{lowStock: cloneLowStockAlert(alert)}:
+	default:
+		log.Printf("[notification] queue full, dropping low-stock chatwork message for %d products", len(alert.Products))
+	}
+}
+
 func (s *ChatworkNotificationService) worker() {
 	for job := range s.jobs {
+		if job.lowStock != nil {
+			s.sendLowStockWithRetry(job.lowStock)
+			continue
+		}
 		s.sendWithRetry(job.notificationID, job.order)
 	}
 }
 
+func (s *ChatworkNotificationService) sendLowStockWithRetry(alert *dto.LowStockAlert) {
+	message := s.formatLowStockMessage(alert)
+	var lastErr error
+	for attempt := 1; attempt <= s.maxRetries; attempt++ {
+		if lastErr = s.sendChatworkMessage(message); lastErr == nil {
+			return
+		}
+		if attempt < s.maxRetries {
+			time.Sleep(time.Duration(attempt) * s.retryDelay)
+		}
+	}
+	log.Printf("[notification] failed to send low-stock chatwork message: %v", lastErr)
+}
+
 func (s *ChatworkNotificationService) sendWithRetry(notificationID uint, order *dto.OrderResponse) {
 	message := s.formatMessage(order)
 	var lastErr error
@@ -229,8 +267,23 @@ func (s *ChatworkNotificationService) formatMessage(order *dto.OrderResponse) st
 	if len(order.Items) > 0 {
 		lines = append(lines, "", "Items:")
 		for idx, item := range order.Items {
+			name := sanitizeChatworkText(item.ProductName)
+			if len(item.Modifiers) > 0 {
+				parts := make([]string, 0, len(item.Modifiers))
+				for _, m := range item.Modifiers {
+					parts = append(parts, sanitizeChatworkText(m.GroupName+": "+m.OptionName))
+				}
+				name += " (" + strings.Join(parts, ", ") + ")"
+			}
+			if len(item.Components) > 0 {
+				parts := make([]string, 0, len(item.Components))
+				for _, c := range item.Components {
+					parts = append(parts, fmt.Sprintf("%s x%d", sanitizeChatworkText(c.ProductName), c.Quantity))
+				}
+				name += " [" + strings.Join(parts, ", ") + "]"
+			}
 			lines = append(lines,
-				fmt.Sprintf("%d. %s x%d - %.2f", idx+1, sanitizeChatworkText(item.ProductName), item.Quantity, item.Subtotal),
+				fmt.Sprintf("%d. %s x%d - %.2f", idx+1, name, item.Quantity, item.Subtotal),
 			)
 		}
 	}
@@ -239,6 +292,28 @@ func (s *ChatworkNotificationService) formatMessage(order *dto.OrderResponse) st
 	return strings.Join(lines, "\n")
 }
 
+func (s *ChatworkNotificationService) formatLowStockMessage(alert *dto.LowStockAlert) string {
+	lines := []string{}
+	if prefix := sanitizeChatworkText(s.cfg.MessagePrefix); prefix != "" {
+		lines = append(lines, prefix)
+	}
+
+	title := "Low Stock"
+	if alert.Digest {
+		title = "Daily Low-Stock Digest"
+	}
+	lines = append(lines, "[info][title]"+title+"[/title]")
+	for idx, p := range alert.Products {
+		line := fmt.Sprintf("%d. %s: %d left (threshold %d)", idx+1, sanitizeChatworkText(p.Name), p.Stock, p.Threshold)
+		if p.Status == models.ProductStatusOutOfStock {
+			line += " - out of stock"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "[/info]")
+	return strings.Join(lines, "\n")
+}
+
 func sanitizeChatworkText(value string) string {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -155,3 +155,27 @@ func TestFormatMessage_EscapesBracketsInOrderFields(t *testing.T) {
 		t.Errorf("raw brackets in address not sanitized: %q", msg)
 	}
 }
+
+func TestFormatLowStockMessage(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestChatworkService()
+	msg := svc.formatLowStockMessage(&dto.LowStockAlert{
+		Digest: true,
+		Products: []dto.LowStockProduct{
+			{ProductID: 1, Name: "Trà [sữa]", Stock: 0, Threshold: 5, Status: "out_of_stock"},
+			{ProductID: 2, Name: "Cà phê", Stock: 3, Threshold: 5, Status: "active"},
+		},
+	})
+
+	for _, want := range []string{
+		"🔔 Test",
+		"[title]Daily Low-Stock Digest[/title]",
+		"1. Trà &#91;sữa&#93;: 0 left (threshold 5) - out of stock",
+		"2. Cà phê: 3 left (threshold 5)\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
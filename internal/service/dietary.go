@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+)
+
+var ErrInvalidDietaryInfo = errors.New("invalid dietary info")
+
+const (
+	maxDietaryTags      = 10
+	maxDietaryTagLength = 30
+)
+
+// nutritionLimits bound the nutrition values per serving
+var nutritionLimits = struct{ calories, sugarGrams, caffeineMg float64 }{
+	calories:   10000,
+	sugarGrams: 1000,
+	caffeineMg: 2000,
+}
+
+// normalizeAllergens lowercases allergens, drops duplicates and puts them in
+// the order of models.Allergens. Unknown allergens are rejected.
+func normalizeAllergens(values []string) ([]string, error) {
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		if !slices.Contains(models.Allergens, v) {
+			return nil, fmt.Errorf("%w: unknown allergen %q", ErrInvalidDietaryInfo, v)
+		}
+		seen[v] = true
+	}
+	var allergens []string
+	for _, a := range models.Allergens {
+		if seen[a] {
+			allergens = append(allergens, a)
+		}
+	}
+	return allergens, nil
+}
+
+// normalizeDietaryTags turns tags into lowercase keys of letters, digits and
+// dashes, so "Spicy 2" becomes "spicy-2", keeping the first of duplicates
+func normalizeDietaryTags(values []string) ([]string, error) {
+	var tags []string
+	for _, v := range values {
+		tag := strings.Join(strings.Fields(strings.ToLower(v)), "-")
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxDietaryTagLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidDietaryInfo, tag, maxDietaryTagLength)
+		}
+		for _, r := range tag {
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return nil, fmt.Errorf("%w: tag %q may only have letters, digits and dashes", ErrInvalidDietaryInfo, tag)
+			}
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxDietaryTags {
+		return nil, fmt.Errorf("%w: at most %d tags", ErrInvalidDietaryInfo, maxDietaryTags)
+	}
+	return tags, nil
+}
+
+// validateNutrition rejects negative or implausibly large values
+func validateNutrition(n *dto.NutritionFacts) error {
+	if n == nil {
+		return nil
+	}
+	check := func(name string, v, limit float64) error {
+		if v < 0 || v > limit {
+			return fmt.Errorf("%w: %s must be between 0 and %g", ErrInvalidDietaryInfo, name, limit)
+		}
+		return nil
+	}
+	if n.Calories != nil {
+		if err := check("calories", float64(*n.Calories), nutritionLimits.calories); err != nil {
+			return err
+		}
+	}
+	if n.SugarGrams != nil {
+		if err := check("sugar", *n.SugarGrams, nutritionLimits.sugarGrams); err != nil {
+			return err
+		}
+	}
+	if n.CaffeineMg != nil {
+		if err := check("caffeine", float64(*n.CaffeineMg), nutritionLimits.caffeineMg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDietaryInfo validates dietary input and sets it on p. Nil input keeps
+// the current value.
+func applyDietaryInfo(p *models.Product, nutrition *dto.NutritionFacts, allergens, tags []string) error {
+	if err := validateNutrition(nutrition); err != nil {
+		return err
+	}
+	if allergens != nil {
+		normalized, err := normalizeAllergens(allergens)
+		if err != nil {
+			return err
+		}
+		p.Allergens = normalized
+	}
+	if tags != nil {
+		normalized, err := normalizeDietaryTags(tags)
+		if err != nil {
+			return err
+		}
+		p.DietaryTags = normalized
+	}
+	if nutrition != nil {
+		p.Calories = nutrition.Calories
+		p.SugarGrams = nutrition.SugarGrams
+		p.CaffeineMg = nutrition.CaffeineMg
+	}
+	return nil
+}
+
+// bundleAllergens returns the allergens of p; a bundle contains those of its
+// components too. The components have to be loaded.
+func bundleAllergens(p *models.Product) []string {
+	seen := make(map[string]bool, len(p.Allergens))
+	for _, a := range p.Allergens {
+		seen[a] = true
+	}
+	for _, item := range p.BundleItems {
+		if item.Component != nil {
+			for _, a := range item.Component.Allergens {
+				seen[a] = true
+			}
+		}
+	}
+	allergens := []string{}
+	for _, a := range models.Allergens {
+		if seen[a] {
+			allergens = append(allergens, a)
+		}
+	}
+	return allergens
+}
+
+// splitDietaryFilter splits a comma-separated filter value
+func splitDietaryFilter(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// toNutritionFacts returns nil when nothing is known about p
+func toNutritionFacts(p *models.Product) *dto.NutritionFacts {
+	if p.Calories == nil && p.SugarGrams == nil && p.CaffeineMg == nil {
+		return nil
+	}
+	return &dto.NutritionFacts{Calories: p.Calories, SugarGrams: p.SugarGrams, CaffeineMg: p.CaffeineMg}
+}
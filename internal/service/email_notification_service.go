@@ -22,14 +22,17 @@ type EmailNotificationService struct {
 	cfg              *config.EmailConfig
 	notificationRepo repository.OrderNotificationRepositoryInterface
 	orderTemplate    *template.Template
+	lowStockTemplate *template.Template
 	maxRetries       int
 	retryDelay       time.Duration
 	jobs             chan emailNotificationJob
 }
 
+// emailNotificationJob carries either an order or a low-stock alert
 type emailNotificationJob struct {
 	notificationID uint
 	order          *dto.OrderResponse
+	lowStock       *dto.LowStockAlert
 }
 
 func NewEmailNotificationService(cfg *config.EmailConfig, notificationRepo repository.OrderNotificationRepositoryInterface) *EmailNotificationService {
@@ -63,6 +66,7 @@ func NewEmailNotificationService(cfg *config.EmailConfig, notificationRepo repos
 		cfg:              cfg,
 		notificationRepo: notificationRepo,
 		orderTemplate:    tpl,
+		lowStockTemplate: template.Must(template.New("low-stock-email").Parse(lowStockEmailTemplate)),
 		maxRetries:       maxRetries,
 		retryDelay:       retryDelay,
 		jobs:             make(chan emailNotificationJob, queueSize),
@@ -108,12 +112,54 @@ func (s *EmailNotificationService) NotifyNewOrderAsync(order *dto.OrderResponse)
 	}
 }
 
+// NotifyLowStockAsync emails the alert to the admin recipient. Low-stock
+// alerts are not tied to an order, so failures are only logged.
+func (s *EmailNotificationService) NotifyLowStockAsync(alert *dto.LowStockAlert) {
+	if s == nil || alert == nil || len(alert.Products) == 0 || !s.cfg.Enabled {
+		return
+	}
+	if strings.TrimSpace(s.cfg.AdminRecipient) == "" {
+		log.Printf("[notification] low-stock email disabled because admin_recipient is empty")
+		return
+	}
+
+	select {
+	case s.jobs <- emailNotificationJob{lowStock: cloneLowStockAlert(alert)}:
+	default:
+		log.Printf("[notification] queue full, dropping low-stock email for %d products", len(alert.Products))
+	}
+}
+
 func (s *EmailNotificationService) worker() {
 	for job := range s.jobs {
+		if job.lowStock != nil {
+			s.sendLowStockWithRetry(job.lowStock)
+			continue
+		}
 		s.sendWithRetry(job.notificationID, job.order)
 	}
 }
 
+func (s *EmailNotificationService) sendLowStockWithRetry(alert *dto.LowStockAlert) {
+	var buf bytes.Buffer
+	if err := s.lowStockTemplate.Execute(&buf, alert); err != nil {
+		log.Printf("[notification] failed to render low-stock email: %v", err)
+		return
+	}
+
+	subject := s.buildLowStockSubject(alert)
+	var lastErr error
+	for attempt := 1; attempt <= s.maxRetries; attempt++ {
+		if lastErr = s.sendHTMLEmail(subject, buf.String()); lastErr == nil {
+			return
+		}
+		if attempt < s.maxRetries {
+			time.Sleep(time.Duration(attempt) * s.retryDelay)
+		}
+	}
+	log.Printf("[notification] failed to send low-stock email: %v", lastErr)
+}
+
 func (s *EmailNotificationService) sendWithRetry(notificationID uint, order *dto.OrderResponse) {
 	body, err := s.renderOrderTemplate(order)
 	if err != nil {
@@ -223,6 +269,18 @@ func (s *EmailNotificationService) buildSubject(orderNumber string) string {
 	return fmt.Sprintf("%s New order %s", prefix, orderNumber)
 }
 
+func (s *EmailNotificationService) buildLowStockSubject(alert *dto.LowStockAlert) string {
+	subject := fmt.Sprintf("Low stock: %d products", len(alert.Products))
+	if alert.Digest {
+		subject = fmt.Sprintf("Daily low-stock digest: %d products", len(alert.Products))
+	}
+	prefix := strings.TrimSpace(s.cfg.SubjectPrefix)
+	if prefix == "" {
+		return subject
+	}
+	return prefix + " " + subject
+}
+
 func parseOrderTemplate(path string) *template.Template {
 	funcMap := template.FuncMap{
 		"formatPrice": func(price float64) string {
@@ -293,8 +351,12 @@ const defaultOrderEmailTemplate = `<!DOCTYPE html>
     <tbody>
       {{ range .Order.Items }}
       <tr>
-        <td>{{ .ProductName }}</td>
-        <td align="right">{{ formatPrice .ProductPrice }}</td>
+        <td>
+          {{ .ProductName }}
+          {{ range .Modifiers }}<br/><small>+ {{ .GroupName }}: {{ .OptionName }} ({{ formatPrice .Price }})</small>{{ end }}
+          {{ range .Components }}<br/><small>• {{ .ProductName }} x{{ .Quantity }}</small>{{ end }}
+        </td>
+        <td align="right">{{ formatPrice .UnitPrice }}</td>
         <td align="right">{{ .Quantity }}</td>
         <td align="right">{{ formatPrice .Subtotal }}</td>
       </tr>
@@ -303,3 +365,38 @@ const defaultOrderEmailTemplate = `<!DOCTYPE html>
   </table>
 </body>
 </html>`
+
+const lowStockEmailTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8" />
+  <title>Low Stock</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.5; color: #222;">
+  {{ if .Digest }}
+  <h2>Products at or below their low-stock threshold</h2>
+  {{ else }}
+  <h2>Products just reached their low-stock threshold</h2>
+  {{ end }}
+  <table border="1" cellpadding="8" cellspacing="0" style="border-collapse: collapse; width: 100%;">
+    <thead>
+      <tr>
+        <th align="left">Product</th>
+        <th align="right">Stock</th>
+        <th align="right">Threshold</th>
+        <th align="left">Status</th>
+      </tr>
+    </thead>
+    <tbody>
+      {{ range .Products }}
+      <tr>
+        <td>{{ .Name }}</td>
+        <td align="right">{{ .Stock }}</td>
+        <td align="right">{{ .Threshold }}</td>
+        <td>{{ .Status }}</td>
+      </tr>
+      {{ end }}
+    </tbody>
+  </table>
+</body>
+</html>`
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidStockMovement = errors.New("invalid stock movement")
+
+// InventoryService records deliveries and waste in the stock ledger and
+// checks the ledger against product stock
+type InventoryService struct {
+	productRepo *repository.ProductRepository
+	stockAlerts *StockAlertService
+	listeners   []CatalogListener
+}
+
+func NewInventoryService(productRepo *repository.ProductRepository, stockAlerts *StockAlertService, listeners ...CatalogListener) *InventoryService {
+	return &InventoryService{productRepo: productRepo, stockAlerts: stockAlerts, listeners: listeners}
+}
+
+// Receive adds a delivery of the product to its stock
+func (s *InventoryService) Receive(adminID uint, req *dto.AdminStockMovementRequest) (*dto.StockMovementResponse, error) {
+	return s.record(adminID, req, models.StockReasonRestock, req.Quantity)
+}
+
+// Waste writes off spoiled or damaged stock
+func (s *InventoryService) Waste(adminID uint, req *dto.AdminStockMovementRequest) (*dto.StockMovementResponse, error) {
+	return s.record(adminID, req, models.StockReasonWaste, -req.Quantity)
+}
+
+func (s *InventoryService) record(adminID uint, req *dto.AdminStockMovementRequest, reason string, delta int) (*dto.StockMovementResponse, error) {
+	if req.Quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1", ErrInvalidStockMovement)
+	}
+	product, err := s.productRepo.FindByID(req.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if product.IsBundle {
+		return nil, fmt.Errorf("%w: bundles take their stock from the components", ErrInvalidStockMovement)
+	}
+
+	movement := &models.StockMovement{ProductID: product.ID, Delta: delta, Reason: reason}
+	if note := strings.TrimSpace(req.Note); note != "" {
+		movement.Note = &note
+	}
+	if adminID > 0 {
+		movement.CreatedBy = &adminID
+	}
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		if err := applyStockMovement(repo, movement); err != nil {
+			return err
+		}
+		return repo.SyncBundleStock([]uint{product.ID})
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	s.stockAlerts.StockChanged([]uint{product.ID})
+	for _, l := range s.listeners {
+		l.CatalogChanged()
+	}
+
+	movement.Product = product
+	return toStockMovementResponse(movement), nil
+}
+
+func (s *InventoryService) ListMovements(req *dto.AdminStockMovementListRequest) (*dto.PaginatedResponse, error) {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	movements, total, err := s.productRepo.ListStockMovements(repository.StockMovementListParams{
+		Offset:    (req.Page - 1) * req.PageSize,
+		Limit:     req.PageSize,
+		ProductID: req.ProductID,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+
+	items := make([]dto.StockMovementResponse, len(movements))
+	for i := range movements {
+		items[i] = *toStockMovementResponse(&movements[i])
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &dto.PaginatedResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Reconcile returns the products whose stock no longer equals the sum of
+// their ledger entries, which means stock was changed outside the ledger
+func (s *InventoryService) Reconcile() ([]dto.StockLedgerMismatch, error) {
+	rows, err := s.productRepo.StockLedgerMismatches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile stock: %w", err)
+	}
+	result := make([]dto.StockLedgerMismatch, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, dto.StockLedgerMismatch{
+			ProductID: row.ProductID,
+			Name:      row.Name,
+			Stock:     row.Stock,
+			Ledger:    row.Ledger,
+		})
+	}
+	return result, nil
+}
+
+// applyStockMovement is ProductRepository.ApplyStockMovement with a refused
+// movement reported as ErrInsufficientStock
+func applyStockMovement(repo *repository.ProductRepository, m *models.StockMovement) error {
+	applied, err := repo.ApplyStockMovement(m)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("%w: product %d", ErrInsufficientStock, m.ProductID)
+	}
+	return nil
+}
+
+func stockNote(note string) *string {
+	return &note
+}
+
+func toStockMovementResponse(m *models.StockMovement) *dto.StockMovementResponse {
+	resp := &dto.StockMovementResponse{
+		ID:          m.ID,
+		ProductID:   m.ProductID,
+		Delta:       m.Delta,
+		Reason:      m.Reason,
+		ReferenceID: m.ReferenceID,
+		Note:        m.Note,
+		CreatedBy:   m.CreatedBy,
+		CreatedAt:   m.CreatedAt,
+	}
+	if m.Product != nil {
+		resp.ProductName = m.Product.Name
+	}
+	return resp
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+func productStock(t *testing.T, db *gorm.DB, id uint) int {
+	t.Helper()
+	var p models.Product
+	if err := db.First(&p, id).Error; err != nil {
+		t.Fatalf("query product %d: %v", id, err)
+	}
+	return p.Stock
+}
+
+func TestInventoryService_LedgerFollowsEveryStockChange(t *testing.T) {
+	t.Parallel()
+
+	orderSvc, db, _ := setupOrderServiceTest(t)
+	productRepo := repository.NewProductRepository(db)
+	productSvc := NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil)
+	listener := &countingListener{}
+	svc := NewInventoryService(productRepo, nil, listener)
+
+	// The seeded product was inserted without a ledger entry
+	mismatches, err := svc.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(mismatches) != 1 || mismatches[0].ProductID != 1 || mismatches[0].Stock != 10 || mismatches[0].Ledger != 0 {
+		t.Fatalf("mismatches = %+v, want product 1 with stock 10 and ledger 0", mismatches)
+	}
+	if err := db.Create(&models.StockMovement{ProductID: 1, Delta: 10, Reason: models.StockReasonAdjustment}).Error; err != nil {
+		t.Fatalf("seed opening balance: %v", err)
+	}
+
+	created, err := productSvc.Create(&dto.CreateProductRequest{CategoryID: 1, Name: "Tra Da", Classify: models.ClassifyDrink, Price: 5000, Stock: 7}, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	stock := 4
+	if _, err := productSvc.Update(0, created.ID, &dto.UpdateProductRequest{Stock: &stock}, nil, false); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if _, err := svc.Receive(9, &dto.AdminStockMovementRequest{ProductID: 1, Quantity: 5, Note: " Phiếu nhập 12 "}); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if _, err := svc.Waste(9, &dto.AdminStockMovementRequest{ProductID: 1, Quantity: 20}); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Waste beyond stock error = %v, want ErrInsufficientStock", err)
+	}
+	wasted, err := svc.Waste(9, &dto.AdminStockMovementRequest{ProductID: 1, Quantity: 3})
+	if err != nil {
+		t.Fatalf("Waste: %v", err)
+	}
+	if wasted.Delta != -3 || wasted.Reason != models.StockReasonWaste || wasted.CreatedBy == nil || *wasted.CreatedBy != 9 {
+		t.Fatalf("waste movement = %+v", wasted)
+	}
+	if listener.calls != 2 {
+		t.Fatalf("listener calls = %d, want 2", listener.calls)
+	}
+	if got := productStock(t, db, 1); got != 12 {
+		t.Fatalf("stock after receive and waste = %d, want 12", got)
+	}
+
+	// The cart holds 2 pho; cancelling the order puts them back
+	order, err := orderSvc.CreateOrderFromCart(1, &dto.CreateOrderRequest{ShippingAddress: "123 Le Loi", ShippingPhone: "0901234567"})
+	if err != nil {
+		t.Fatalf("CreateOrderFromCart: %v", err)
+	}
+	if got := productStock(t, db, 1); got != 10 {
+		t.Fatalf("stock after order = %d, want 10", got)
+	}
+	if err := orderSvc.UpdateOrderStatusForAdmin(order.ID, models.OrderStatusCancelled); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+	if got := productStock(t, db, 1); got != 12 {
+		t.Fatalf("stock after cancellation = %d, want 12", got)
+	}
+
+	mismatches, err = svc.Reconcile()
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("Reconcile after ledger writes = %+v, %v", mismatches, err)
+	}
+
+	history, err := svc.ListMovements(&dto.AdminStockMovementListRequest{ProductID: 1})
+	if err != nil {
+		t.Fatalf("ListMovements: %v", err)
+	}
+	movements := history.Items.([]dto.StockMovementResponse)
+	wantReasons := []string{models.StockReasonCancellation, models.StockReasonOrder, models.StockReasonWaste, models.StockReasonRestock, models.StockReasonAdjustment}
+	if len(movements) != len(wantReasons) {
+		t.Fatalf("movements = %+v, want %d", movements, len(wantReasons))
+	}
+	for i, want := range wantReasons {
+		if movements[i].Reason != want {
+			t.Fatalf("movement %d reason = %s, want %s", i, movements[i].Reason, want)
+		}
+	}
+	if ref := movements[0].ReferenceID; ref == nil || *ref != order.ID || movements[0].Delta != 2 {
+		t.Fatalf("cancellation movement = %+v", movements[0])
+	}
+	if note := movements[3].Note; note == nil || *note != "Phiếu nhập 12" {
+		t.Fatalf("restock note = %v", note)
+	}
+
+	adjustments, err := svc.ListMovements(&dto.AdminStockMovementListRequest{ProductID: created.ID, Reason: models.StockReasonAdjustment})
+	if err != nil {
+		t.Fatalf("ListMovements adjustments: %v", err)
+	}
+	if items := adjustments.Items.([]dto.StockMovementResponse); len(items) != 1 || items[0].Delta != -3 {
+		t.Fatalf("adjustments = %+v, want one of -3", items)
+	}
+
+	combo := models.Product{CategoryID: 1, Name: "Combo", Slug: "inventory-combo", Classify: models.ClassifyFood, Price: 50000, Status: models.ProductStatusActive}
+	if err := db.Create(&combo).Error; err != nil {
+		t.Fatalf("seed combo: %v", err)
+	}
+	if _, err := productSvc.SaveBundle(combo.ID, []dto.BundleItemInput{{ProductID: created.ID, Quantity: 1}}); err != nil {
+		t.Fatalf("SaveBundle: %v", err)
+	}
+	if _, err := svc.Receive(9, &dto.AdminStockMovementRequest{ProductID: combo.ID, Quantity: 1}); !errors.Is(err, ErrInvalidStockMovement) {
+		t.Fatalf("Receive for bundle error = %v, want ErrInvalidStockMovement", err)
+	}
+	if _, err := svc.Receive(9, &dto.AdminStockMovementRequest{ProductID: 9999, Quantity: 1}); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Receive for missing product error = %v, want ErrProductNotFound", err)
+	}
+}
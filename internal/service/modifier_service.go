@@ -0,0 +1,438 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrModifierGroupNotFound     = errors.New("modifier group not found")
+	ErrInvalidModifierGroup      = errors.New("invalid modifier group")
+	ErrInvalidModifierSelection  = errors.New("invalid modifier selection")
+	ErrModifierGroupNameRequired = errors.New("modifier group name is required")
+)
+
+// ModifierService handles admin management of modifier groups and the
+// server-side validation of customer selections.
+type ModifierService struct {
+	modifierRepo *repository.ModifierRepository
+	productRepo  *repository.ProductRepository
+}
+
+// NewModifierService creates a new ModifierService
+func NewModifierService(modifierRepo *repository.ModifierRepository, productRepo *repository.ProductRepository) *ModifierService {
+	return &ModifierService{modifierRepo: modifierRepo, productRepo: productRepo}
+}
+
+// ListGroups returns all modifier groups for the admin list page
+func (s *ModifierService) ListGroups() ([]dto.ModifierGroupResponse, error) {
+	groups, err := s.modifierRepo.ListGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list modifier groups: %w", err)
+	}
+	items := make([]dto.ModifierGroupResponse, len(groups))
+	for i := range groups {
+		items[i] = *toModifierGroupResponse(&groups[i], true)
+	}
+	return items, nil
+}
+
+// GetGroup returns a single modifier group
+func (s *ModifierService) GetGroup(id uint) (*dto.ModifierGroupResponse, error) {
+	group, err := s.modifierRepo.FindGroupByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModifierGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to find modifier group: %w", err)
+	}
+	return toModifierGroupResponse(group, true), nil
+}
+
+// CreateGroup creates a modifier group with its options and assignments
+func (s *ModifierService) CreateGroup(req *dto.SaveModifierGroupRequest) (*dto.ModifierGroupResponse, error) {
+	if err := validateModifierGroupRequest(req); err != nil {
+		return nil, err
+	}
+
+	group := &models.ModifierGroup{
+		Name:      strings.TrimSpace(req.Name),
+		MinSelect: req.MinSelect,
+		MaxSelect: req.MaxSelect,
+		SortOrder: req.SortOrder,
+		Status:    req.Status,
+	}
+
+	var groupID uint
+	err := s.modifierRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repoTx := s.modifierRepo.WithTx(tx)
+		if err := repoTx.CreateGroup(group); err != nil {
+			return fmt.Errorf("failed to create modifier group: %w", err)
+		}
+		if err := repoTx.SaveOptions(group.ID, buildModifierOptions(req.Options)); err != nil {
+			return fmt.Errorf("failed to save modifier options: %w", err)
+		}
+		if err := repoTx.ReplaceAssignments(group.ID, buildModifierAssignments(req)); err != nil {
+			return fmt.Errorf("failed to save modifier assignments: %w", err)
+		}
+		groupID = group.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(groupID)
+}
+
+// UpdateGroup updates a modifier group, its options and assignments
+func (s *ModifierService) UpdateGroup(id uint, req *dto.SaveModifierGroupRequest) (*dto.ModifierGroupResponse, error) {
+	if err := validateModifierGroupRequest(req); err != nil {
+		return nil, err
+	}
+
+	group, err := s.modifierRepo.FindGroupByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModifierGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to find modifier group: %w", err)
+	}
+
+	// Option IDs submitted by the form must belong to this group
+	owned := make(map[uint]bool, len(group.Options))
+	for _, opt := range group.Options {
+		owned[opt.ID] = true
+	}
+	for _, opt := range req.Options {
+		if opt.ID != 0 && !owned[opt.ID] {
+			return nil, fmt.Errorf("%w: option %d does not belong to this group", ErrInvalidModifierGroup, opt.ID)
+		}
+	}
+
+	group.Name = strings.TrimSpace(req.Name)
+	group.MinSelect = req.MinSelect
+	group.MaxSelect = req.MaxSelect
+	group.SortOrder = req.SortOrder
+	group.Status = req.Status
+
+	err = s.modifierRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repoTx := s.modifierRepo.WithTx(tx)
+		if err := repoTx.UpdateGroup(group); err != nil {
+			return fmt.Errorf("failed to update modifier group: %w", err)
+		}
+		if err := repoTx.SaveOptions(group.ID, buildModifierOptions(req.Options)); err != nil {
+			return fmt.Errorf("failed to save modifier options: %w", err)
+		}
+		if err := repoTx.ReplaceAssignments(group.ID, buildModifierAssignments(req)); err != nil {
+			return fmt.Errorf("failed to save modifier assignments: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(id)
+}
+
+// DeleteGroup soft deletes a modifier group
+func (s *ModifierService) DeleteGroup(id uint) error {
+	if _, err := s.modifierRepo.FindGroupByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrModifierGroupNotFound
+		}
+		return fmt.Errorf("failed to find modifier group: %w", err)
+	}
+	return s.modifierRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := s.modifierRepo.WithTx(tx).DeleteGroup(id); err != nil {
+			return fmt.Errorf("failed to delete modifier group: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListForProductSlug returns the active groups and options a customer can
+// choose from for an active product.
+func (s *ModifierService) ListForProductSlug(slug string) ([]dto.ModifierGroupResponse, error) {
+	product, err := s.productRepo.FindBySlug(strings.TrimSpace(slug))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if !models.ProductVisible(product.Status) {
+		return nil, ErrProductNotFound
+	}
+
+	groups, err := s.modifierRepo.FindActiveGroupsForProduct(product.ID, product.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find modifier groups: %w", err)
+	}
+
+	items := make([]dto.ModifierGroupResponse, 0, len(groups))
+	for i := range groups {
+		if len(groups[i].Options) == 0 {
+			continue
+		}
+		items = append(items, *toModifierGroupResponse(&groups[i], false))
+	}
+	return items, nil
+}
+
+func validateModifierGroupRequest(req *dto.SaveModifierGroupRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrModifierGroupNameRequired
+	}
+	if req.Status == "" {
+		req.Status = models.ModifierStatusActive
+	}
+	if req.Status != models.ModifierStatusActive && req.Status != models.ModifierStatusInactive {
+		return fmt.Errorf("%w: invalid status", ErrInvalidModifierGroup)
+	}
+	if req.MinSelect < 0 || req.MaxSelect < 1 || req.MinSelect > req.MaxSelect {
+		return fmt.Errorf("%w: selection rule must satisfy 0 <= min <= max and max >= 1", ErrInvalidModifierGroup)
+	}
+
+	named := 0
+	for _, opt := range req.Options {
+		if strings.TrimSpace(opt.Name) == "" {
+			continue
+		}
+		if opt.Price < 0 {
+			return fmt.Errorf("%w: option price cannot be negative", ErrInvalidModifierGroup)
+		}
+		named++
+	}
+	if named == 0 {
+		return fmt.Errorf("%w: at least one option is required", ErrInvalidModifierGroup)
+	}
+	if req.MinSelect > named {
+		return fmt.Errorf("%w: min selection exceeds the number of options", ErrInvalidModifierGroup)
+	}
+	return nil
+}
+
+func buildModifierOptions(inputs []dto.ModifierOptionInput) []models.ModifierOption {
+	options := make([]models.ModifierOption, 0, len(inputs))
+	for _, in := range inputs {
+		name := strings.TrimSpace(in.Name)
+		if name == "" {
+			continue
+		}
+		options = append(options, models.ModifierOption{
+			ID:        in.ID,
+			Name:      name,
+			Price:     in.Price,
+			SortOrder: in.SortOrder,
+			Status:    models.ModifierStatusActive,
+		})
+	}
+	return options
+}
+
+func buildModifierAssignments(req *dto.SaveModifierGroupRequest) []models.ModifierGroupAssignment {
+	assignments := make([]models.ModifierGroupAssignment, 0, len(req.ProductIDs)+len(req.CategoryIDs))
+	seenProducts := map[uint]bool{}
+	for _, id := range req.ProductIDs {
+		if id == 0 || seenProducts[id] {
+			continue
+		}
+		seenProducts[id] = true
+		productID := id
+		assignments = append(assignments, models.ModifierGroupAssignment{ProductID: &productID})
+	}
+	seenCategories := map[uint]bool{}
+	for _, id := range req.CategoryIDs {
+		if id == 0 || seenCategories[id] {
+			continue
+		}
+		seenCategories[id] = true
+		categoryID := id
+		assignments = append(assignments, models.ModifierGroupAssignment{CategoryID: &categoryID})
+	}
+	return assignments
+}
+
+func toModifierGroupResponse(group *models.ModifierGroup, includeAdmin bool) *dto.ModifierGroupResponse {
+	resp := &dto.ModifierGroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		MinSelect: group.MinSelect,
+		MaxSelect: group.MaxSelect,
+		SortOrder: group.SortOrder,
+		Status:    group.Status,
+		Options:   make([]dto.ModifierOptionResponse, 0, len(group.Options)),
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+	}
+	for _, opt := range group.Options {
+		if !includeAdmin && opt.Status != models.ModifierStatusActive {
+			continue
+		}
+		resp.Options = append(resp.Options, dto.ModifierOptionResponse{
+			ID:        opt.ID,
+			Name:      opt.Name,
+			Price:     opt.Price,
+			SortOrder: opt.SortOrder,
+			Status:    opt.Status,
+		})
+	}
+	if includeAdmin {
+		for _, a := range group.Assignments {
+			if a.ProductID != nil {
+				resp.ProductIDs = append(resp.ProductIDs, *a.ProductID)
+			}
+			if a.CategoryID != nil {
+				resp.CategoryIDs = append(resp.CategoryIDs, *a.CategoryID)
+			}
+		}
+	}
+	return resp
+}
+
+// resolveProductModifiers validates optionIDs against the groups currently
+// assigned to the product. Both the cart and checkout go through it so an
+// order always snapshots live option prices.
+func resolveProductModifiers(modifierRepo *repository.ModifierRepository, product *models.Product, optionIDs []uint) ([]selectedModifier, error) {
+	if modifierRepo == nil {
+		if len(optionIDs) > 0 {
+			return nil, fmt.Errorf("%w: modifiers are not available", ErrInvalidModifierSelection)
+		}
+		return nil, nil
+	}
+
+	groups, err := modifierRepo.FindActiveGroupsForProduct(product.ID, product.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find modifier groups: %w", err)
+	}
+	return resolveModifierSelection(groups, optionIDs)
+}
+
+// selectedModifier is an option chosen by the customer together with the name
+// of the group it belongs to, used for pricing and order snapshots.
+type selectedModifier struct {
+	OptionID   uint
+	GroupName  string
+	OptionName string
+	Price      float64
+}
+
+// resolveModifierSelection validates the requested option IDs against the
+// active groups that apply to a product, enforcing each group's min/max rule.
+// Prices always come from the database, never from the client.
+func resolveModifierSelection(groups []models.ModifierGroup, optionIDs []uint) ([]selectedModifier, error) {
+	type optionRef struct {
+		group  *models.ModifierGroup
+		option *models.ModifierOption
+	}
+
+	available := make(map[uint]optionRef)
+	for gi := range groups {
+		for oi := range groups[gi].Options {
+			opt := &groups[gi].Options[oi]
+			if opt.Status != models.ModifierStatusActive {
+				continue
+			}
+			available[opt.ID] = optionRef{group: &groups[gi], option: opt}
+		}
+	}
+
+	chosen := make(map[uint]bool, len(optionIDs))
+	perGroup := make(map[uint]int)
+	for _, id := range optionIDs {
+		if chosen[id] {
+			return nil, fmt.Errorf("%w: option %d selected more than once", ErrInvalidModifierSelection, id)
+		}
+		ref, ok := available[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: option %d is not available for this product", ErrInvalidModifierSelection, id)
+		}
+		chosen[id] = true
+		perGroup[ref.group.ID]++
+	}
+
+	selected := make([]selectedModifier, 0, len(optionIDs))
+	for gi := range groups {
+		group := &groups[gi]
+		count := perGroup[group.ID]
+		hasOptions := false
+		for oi := range group.Options {
+			opt := &group.Options[oi]
+			if opt.Status != models.ModifierStatusActive {
+				continue
+			}
+			hasOptions = true
+			if chosen[opt.ID] {
+				selected = append(selected, selectedModifier{
+					OptionID:   opt.ID,
+					GroupName:  group.Name,
+					OptionName: opt.Name,
+					Price:      opt.Price,
+				})
+			}
+		}
+		// A group whose options are all disabled cannot be satisfied, so it is skipped.
+		if !hasOptions {
+			continue
+		}
+		if count < group.MinSelect {
+			return nil, fmt.Errorf("%w: %q requires at least %d option(s)", ErrInvalidModifierSelection, group.Name, group.MinSelect)
+		}
+		if group.MaxSelect > 0 && count > group.MaxSelect {
+			return nil, fmt.Errorf("%w: %q allows at most %d option(s)", ErrInvalidModifierSelection, group.Name, group.MaxSelect)
+		}
+	}
+
+	return selected, nil
+}
+
+// modifierKey builds the canonical cart line key for a set of selected options
+func modifierKey(selected []selectedModifier) string {
+	ids := make([]uint, len(selected))
+	for i, m := range selected {
+		ids[i] = m.OptionID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseModifierKey is the inverse of modifierKey; malformed entries are skipped.
+func parseModifierKey(key string) []uint {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil
+	}
+	parts := strings.Split(key, ",")
+	ids := make([]uint, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+func sumModifierPrice(selected []selectedModifier) float64 {
+	total := 0.0
+	for _, m := range selected {
+		total += m.Price
+	}
+	return total
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+func seedModifierGroupForTest(t *testing.T, db *gorm.DB, product *models.Product, minSelect, maxSelect int, options ...dto.ModifierOptionInput) *dto.ModifierGroupResponse {
+	t.Helper()
+	svc := NewModifierService(repository.NewModifierRepository(db), repository.NewProductRepository(db))
+	group, err := svc.CreateGroup(&dto.SaveModifierGroupRequest{
+		Name:       "Topping",
+		MinSelect:  minSelect,
+		MaxSelect:  maxSelect,
+		Options:    options,
+		ProductIDs: []uint{product.ID},
+	})
+	if err != nil {
+		t.Fatalf("seed modifier group: %v", err)
+	}
+	return group
+}
+
+func TestResolveModifierSelection(t *testing.T) {
+	t.Parallel()
+
+	groups := []models.ModifierGroup{
+		{ID: 1, Name: "Size", MinSelect: 1, MaxSelect: 1, Options: []models.ModifierOption{
+			{ID: 10, GroupID: 1, Name: "M", Price: 0, Status: models.ModifierStatusActive},
+			{ID: 11, GroupID: 1, Name: "L", Price: 5000, Status: models.ModifierStatusActive},
+		}},
+		{ID: 2, Name: "Topping", MinSelect: 0, MaxSelect: 2, Options: []models.ModifierOption{
+			{ID: 20, GroupID: 2, Name: "Pearl", Price: 7000, Status: models.ModifierStatusActive},
+			{ID: 21, GroupID: 2, Name: "Jelly", Price: 6000, Status: models.ModifierStatusActive},
+			{ID: 22, GroupID: 2, Name: "Pudding", Price: 8000, Status: models.ModifierStatusActive},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		optionIDs []uint
+		wantKey   string
+		wantPrice float64
+		wantErr   bool
+	}{
+		{name: "required group satisfied", optionIDs: []uint{11}, wantKey: "11", wantPrice: 5000},
+		{name: "order independent key", optionIDs: []uint{21, 11, 20}, wantKey: "11,20,21", wantPrice: 18000},
+		{name: "missing required group", optionIDs: []uint{20}, wantErr: true},
+		{name: "exceeds max", optionIDs: []uint{10, 20, 21, 22}, wantErr: true},
+		{name: "two options in single choice group", optionIDs: []uint{10, 11}, wantErr: true},
+		{name: "duplicate option", optionIDs: []uint{10, 20, 20}, wantErr: true},
+		{name: "unknown option", optionIDs: []uint{10, 99}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			selected, err := resolveModifierSelection(groups, tt.optionIDs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidModifierSelection) {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidModifierSelection)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key := modifierKey(selected); key != tt.wantKey {
+				t.Fatalf("key = %q, want %q", key, tt.wantKey)
+			}
+			if price := sumModifierPrice(selected); price != tt.wantPrice {
+				t.Fatalf("price = %v, want %v", price, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestParseModifierKey(t *testing.T) {
+	t.Parallel()
+
+	if ids := parseModifierKey(""); len(ids) != 0 {
+		t.Fatalf("empty key = %v, want none", ids)
+	}
+	ids := parseModifierKey("3,12,40")
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 12 || ids[2] != 40 {
+		t.Fatalf("parseModifierKey = %v", ids)
+	}
+}
+
+func TestModifierService_CreateGroup_Validation(t *testing.T) {
+	t.Parallel()
+	db := newCartServiceTestDB(t)
+	svc := NewModifierService(repository.NewModifierRepository(db), repository.NewProductRepository(db))
+
+	if _, err := svc.CreateGroup(&dto.SaveModifierGroupRequest{MaxSelect: 1}); !errors.Is(err, ErrModifierGroupNameRequired) {
+		t.Fatalf("error = %v, want %v", err, ErrModifierGroupNameRequired)
+	}
+
+	invalid := []dto.SaveModifierGroupRequest{
+		{Name: "No options", MaxSelect: 1},
+		{Name: "Min over max", MinSelect: 2, MaxSelect: 1, Options: []dto.ModifierOptionInput{{Name: "A"}, {Name: "B"}}},
+		{Name: "Min over options", MinSelect: 2, MaxSelect: 3, Options: []dto.ModifierOptionInput{{Name: "A"}}},
+		{Name: "Negative price", MaxSelect: 1, Options: []dto.ModifierOptionInput{{Name: "A", Price: -1}}},
+	}
+	for _, req := range invalid {
+		req := req
+		if _, err := svc.CreateGroup(&req); !errors.Is(err, ErrInvalidModifierGroup) {
+			t.Fatalf("%s: error = %v, want %v", req.Name, err, ErrInvalidModifierGroup)
+		}
+	}
+}
+
+func TestModifierService_UpdateGroup_DeactivatesRemovedOptions(t *testing.T) {
+	t.Parallel()
+	db := newCartServiceTestDB(t)
+	svc := NewModifierService(repository.NewModifierRepository(db), repository.NewProductRepository(db))
+	p := seedProductForCartTest(t, db, "modifier-update", 5)
+
+	group := seedModifierGroupForTest(t, db, p, 0, 2,
+		dto.ModifierOptionInput{Name: "Pearl", Price: 7000},
+		dto.ModifierOptionInput{Name: "Jelly", Price: 6000},
+	)
+	kept := group.Options[0]
+
+	updated, err := svc.UpdateGroup(group.ID, &dto.SaveModifierGroupRequest{
+		Name:       "Topping",
+		MaxSelect:  2,
+		Options:    []dto.ModifierOptionInput{{ID: kept.ID, Name: "Pearl", Price: 8000}},
+		ProductIDs: []uint{p.ID},
+	})
+	if err != nil {
+		t.Fatalf("UpdateGroup: %v", err)
+	}
+
+	active := 0
+	for _, opt := range updated.Options {
+		if opt.Status == models.ModifierStatusActive {
+			active++
+			if opt.ID != kept.ID || opt.Price != 8000 {
+				t.Fatalf("unexpected active option %+v", opt)
+			}
+		}
+	}
+	if active != 1 || len(updated.Options) != 2 {
+		t.Fatalf("active = %d total = %d, want 1 active of 2", active, len(updated.Options))
+	}
+
+	public, err := svc.ListForProductSlug(p.Slug)
+	if err != nil {
+		t.Fatalf("ListForProductSlug: %v", err)
+	}
+	if len(public) != 1 || len(public[0].Options) != 1 {
+		t.Fatalf("public groups = %+v, want 1 group with 1 option", public)
+	}
+}
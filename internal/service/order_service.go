@@ -25,23 +25,36 @@ var (
 	ErrInvalidStatusFilter = errors.New("invalid order status filter")
 )
 
+// topProductsLimit is the number of best sellers shown with the statistics
+const topProductsLimit = 10
+
 type OrderService struct {
-	orderRepo   *repository.OrderRepository
-	cartRepo    *repository.CartRepository
-	productRepo *repository.ProductRepository
-	notifier    OrderNotifier
+	orderRepo    *repository.OrderRepository
+	cartRepo     *repository.CartRepository
+	productRepo  *repository.ProductRepository
+	modifierRepo *repository.ModifierRepository
+	notifier     OrderNotifier
+	cursors      *CursorCodec
+	availability *AvailabilityService
+	stockAlerts  *StockAlertService
+	pricing      *PricingService
 }
 
 type OrderNotifier interface {
 	NotifyNewOrderAsync(order *dto.OrderResponse)
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, notifier OrderNotifier) *OrderService {
+func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, modifierRepo *repository.ModifierRepository, notifier OrderNotifier, cursors *CursorCodec, availability *AvailabilityService, stockAlerts *StockAlertService, pricing *PricingService) *OrderService {
 	return &OrderService{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
-		notifier:    notifier,
+		orderRepo:    orderRepo,
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		modifierRepo: modifierRepo,
+		notifier:     notifier,
+		cursors:      cursors,
+		availability: availability,
+		stockAlerts:  stockAlerts,
+		pricing:      pricing,
 	}
 }
 
@@ -53,6 +66,7 @@ func (s *OrderService) CreateOrderFromCart(userID uint, req *dto.CreateOrderRequ
 	}
 
 	var createdOrderID uint
+	var stockChanged []uint
 	err := s.cartRepo.GetDB().Transaction(func(tx *gorm.DB) error {
 		cartRepoTx := s.cartRepo.WithTx(tx)
 		productRepoTx := s.productRepo.WithTx(tx)
@@ -74,33 +88,132 @@ func (s *OrderService) CreateOrderFromCart(userID uint, req *dto.CreateOrderRequ
 		cartItems := make([]models.CartItem, len(cart.Items))
 		copy(cartItems, cart.Items)
 		sort.Slice(cartItems, func(i, j int) bool {
-			return cartItems[i].ProductID < cartItems[j].ProductID
+			if cartItems[i].ProductID != cartItems[j].ProductID {
+				return cartItems[i].ProductID < cartItems[j].ProductID
+			}
+			return cartItems[i].ID < cartItems[j].ID
 		})
 
+		lineProductIDs := make([]uint, 0, len(cartItems))
 		for _, item := range cartItems {
-			product, err := productRepoTx.FindByIDForUpdate(item.ProductID)
+			if len(lineProductIDs) == 0 || lineProductIDs[len(lineProductIDs)-1] != item.ProductID {
+				lineProductIDs = append(lineProductIDs, item.ProductID)
+			}
+		}
+		bundleItems, err := productRepoTx.FindBundleItems(lineProductIDs)
+		if err != nil {
+			return fmt.Errorf("failed to find bundle items: %w", err)
+		}
+		components := make(map[uint][]models.BundleItem)
+		for _, bi := range bundleItems {
+			components[bi.BundleID] = append(components[bi.BundleID], bi)
+		}
+
+		// Several lines may share a product when their modifiers differ, and
+		// a product may also come as part of bundles, so stock is checked
+		// against the total requested per product. Bundles hold no stock of
+		// their own; their components are requested instead.
+		requested := make(map[uint]int)
+		for _, item := range cartItems {
+			if parts, ok := components[item.ProductID]; ok {
+				for _, part := range parts {
+					requested[part.ComponentID] += part.Quantity * item.Quantity
+				}
+				continue
+			}
+			requested[item.ProductID] += item.Quantity
+		}
+
+		// Lock every product involved in ascending id order, the same order
+		// concurrent checkouts use, so they cannot deadlock each other
+		lockIDs := append([]uint(nil), lineProductIDs...)
+		for id := range requested {
+			lockIDs = append(lockIDs, id)
+		}
+		sort.Slice(lockIDs, func(i, j int) bool { return lockIDs[i] < lockIDs[j] })
+
+		products := make(map[uint]*models.Product)
+		for _, id := range lockIDs {
+			if _, ok := products[id]; ok {
+				continue
+			}
+			product, err := productRepoTx.FindByIDForUpdate(id)
 			if err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
 					return ErrProductNotFound
 				}
 				return fmt.Errorf("failed to find product: %w", err)
 			}
+			products[id] = product
+		}
 
+		for _, id := range lineProductIDs {
+			product := products[id]
 			if product.Status != models.ProductStatusActive {
 				return ErrProductNotFound
 			}
-			if product.Stock < item.Quantity {
-				return fmt.Errorf("%w: available %d, requested %d", ErrInsufficientStock, product.Stock, item.Quantity)
+			// Lines added during serving hours may have stayed in the cart past them
+			if err := s.availability.Check(product); err != nil {
+				return err
+			}
+		}
+
+		stockIDs := make([]uint, 0, len(requested))
+		for id := range requested {
+			stockIDs = append(stockIDs, id)
+		}
+		sort.Slice(stockIDs, func(i, j int) bool { return stockIDs[i] < stockIDs[j] })
+		for _, id := range stockIDs {
+			product := products[id]
+			available := product.Stock
+			if product.Status != models.ProductStatusActive {
+				available = 0
+			}
+			if available < requested[id] {
+				return fmt.Errorf("%w: available %d, requested %d", ErrInsufficientStock, available, requested[id])
 			}
+		}
 
-			subtotal := product.Price * float64(item.Quantity)
+		var modifierRepoTx *repository.ModifierRepository
+		if s.modifierRepo != nil {
+			modifierRepoTx = s.modifierRepo.WithTx(tx)
+		}
+
+		for _, item := range cartItems {
+			product := products[item.ProductID]
+
+			selected, err := resolveProductModifiers(modifierRepoTx, product, parseModifierKey(item.ModifierKey))
+			if err != nil {
+				return err
+			}
+
+			// The sale running at checkout is the price the order keeps
+			quote, err := s.pricing.Quote(product)
+			if err != nil {
+				return err
+			}
+			unitPrice := quote.Price + sumModifierPrice(selected)
+			subtotal := unitPrice * float64(item.Quantity)
 			totalAmount += subtotal
+
+			modifiers := make([]models.OrderItemModifier, 0, len(selected))
+			for _, m := range selected {
+				modifiers = append(modifiers, models.OrderItemModifier{
+					ModifierOptionID: m.OptionID,
+					GroupName:        m.GroupName,
+					OptionName:       m.OptionName,
+					Price:            m.Price,
+				})
+			}
+
 			orderItems = append(orderItems, models.OrderItem{
 				ProductID:    product.ID,
 				ProductName:  product.Name,
-				ProductPrice: product.Price,
+				ProductPrice: quote.Price,
 				Quantity:     item.Quantity,
 				Subtotal:     subtotal,
+				Modifiers:    modifiers,
+				Components:   bundleComponents(components[product.ID], products, item.Quantity, subtotal),
 			})
 		}
 
@@ -135,26 +248,36 @@ func (s *OrderService) CreateOrderFromCart(userID uint, req *dto.CreateOrderRequ
 			return fmt.Errorf("failed to create order items: %w", err)
 		}
 
-		for _, item := range cartItems {
-			updated, err := productRepoTx.DecreaseStock(item.ProductID, item.Quantity)
+		for _, id := range stockIDs {
+			updated, err := productRepoTx.ApplyStockMovement(&models.StockMovement{
+				ProductID:   id,
+				Delta:       -requested[id],
+				Reason:      models.StockReasonOrder,
+				ReferenceID: &order.ID,
+			})
 			if err != nil {
 				return fmt.Errorf("failed to update stock: %w", err)
 			}
 			if !updated {
-				return fmt.Errorf("%w: product %d", ErrInsufficientStock, item.ProductID)
+				return fmt.Errorf("%w: product %d", ErrInsufficientStock, id)
 			}
 		}
+		if err := productRepoTx.SyncBundleStock(stockIDs); err != nil {
+			return fmt.Errorf("failed to update bundle stock: %w", err)
+		}
 
 		if err := cartRepoTx.ClearCartItems(cart.ID); err != nil {
 			return fmt.Errorf("failed to clear cart: %w", err)
 		}
 
 		createdOrderID = order.ID
+		stockChanged = stockIDs
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	s.stockAlerts.StockChanged(stockChanged)
 
 	order, err := s.GetOrderDetail(userID, createdOrderID)
 	if err != nil {
@@ -181,17 +304,49 @@ func (s *OrderService) ListOrders(userID uint, req *dto.OrderListRequest) (*dto.
 		return nil, err
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	orders, total, err := s.orderRepo.ListByUserID(repository.OrderListParams{
-		Offset:   offset,
+	params := repository.OrderListParams{
+		Offset:   (req.Page - 1) * req.PageSize,
 		Limit:    req.PageSize,
 		UserID:   userID,
 		Status:   req.Status,
 		FromDate: fromDate,
 		ToDate:   toDate,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list orders: %w", err)
+		SortBy:   req.SortBy,
+		SortDir:  req.SortDir,
+	}
+	sortBy, sortDir := orderSortKey(req.SortBy), cursorSortDir(req.SortDir)
+
+	var orders []models.Order
+	var total int64
+	resp := &dto.PaginatedResponse{PageSize: req.PageSize}
+	if req.Cursor != "" {
+		after, err := s.cursors.keyset(req.Cursor, sortBy, sortDir, orderSortValue(&models.Order{}, sortBy))
+		if err != nil {
+			return nil, err
+		}
+		// One extra row tells whether another page follows
+		params.Limit = req.PageSize + 1
+		if orders, err = s.orderRepo.ListByUserIDAfter(params, after); err != nil {
+			return nil, fmt.Errorf("failed to list orders: %w", err)
+		}
+		if len(orders) > req.PageSize {
+			orders = orders[:req.PageSize]
+			last := &orders[len(orders)-1]
+			resp.NextCursor = s.cursors.keysetCursor(sortBy, sortDir, orderSortValue(last, sortBy), last.ID)
+		}
+	} else {
+		if orders, total, err = s.orderRepo.ListByUserID(params); err != nil {
+			return nil, fmt.Errorf("failed to list orders: %w", err)
+		}
+		totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		resp.Total, resp.Page, resp.TotalPages = total, req.Page, totalPages
+		if req.Page < totalPages && len(orders) > 0 {
+			last := &orders[len(orders)-1]
+			resp.NextCursor = s.cursors.keysetCursor(sortBy, sortDir, orderSortValue(last, sortBy), last.ID)
+		}
 	}
 
 	orderIDs := make([]uint, 0, len(orders))
@@ -208,19 +363,61 @@ func (s *OrderService) ListOrders(userID uint, req *dto.OrderListRequest) (*dto.
 		items[i] = *s.toResponse(&order, false)
 		items[i].ItemCount = itemCounts[order.ID]
 	}
+	resp.Items = items
 
-	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
-	if totalPages == 0 {
-		totalPages = 1
+	return resp, nil
+}
+
+// bundleComponents snapshots the components of a bundle order line. The
+// line subtotal is split across them in proportion to their list prices so
+// that sales statistics can credit each component; the last one takes the
+// rounding remainder.
+func bundleComponents(parts []models.BundleItem, products map[uint]*models.Product, quantity int, subtotal float64) []models.OrderItemComponent {
+	if len(parts) == 0 {
+		return nil
 	}
+	weight := 0.0
+	for _, part := range parts {
+		weight += products[part.ComponentID].Price * float64(part.Quantity)
+	}
+
+	result := make([]models.OrderItemComponent, 0, len(parts))
+	remaining := subtotal
+	for i, part := range parts {
+		component := products[part.ComponentID]
+		share := remaining
+		if i < len(parts)-1 {
+			if weight > 0 {
+				share = subtotal * component.Price * float64(part.Quantity) / weight
+			} else {
+				share = subtotal / float64(len(parts))
+			}
+			share = math.Round(share*100) / 100
+		}
+		remaining -= share
+		result = append(result, models.OrderItemComponent{
+			ProductID:   component.ID,
+			ProductName: component.Name,
+			Quantity:    part.Quantity * quantity,
+			Subtotal:    share,
+		})
+	}
+	return result
+}
 
-	return &dto.PaginatedResponse{
-		Items:      items,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+// orderSortKey mirrors the sort columns OrderRepository.ListByUserID accepts
+func orderSortKey(sortBy string) string {
+	if sortBy == "total_amount" {
+		return sortBy
+	}
+	return "created_at"
+}
+
+func orderSortValue(o *models.Order, key string) interface{} {
+	if key == "total_amount" {
+		return o.TotalAmount
+	}
+	return o.CreatedAt
 }
 
 func (s *OrderService) GetOrderDetail(userID, orderID uint) (*dto.OrderResponse, error) {
@@ -303,6 +500,7 @@ func (s *OrderService) UpdateOrderStatusForAdmin(orderID uint, status string) er
 		return ErrInvalidOrderStatus
 	}
 
+	var restocked []uint
 	err := s.orderRepo.GetDB().Transaction(func(tx *gorm.DB) error {
 		orderRepoTx := s.orderRepo.WithTx(tx)
 
@@ -317,6 +515,11 @@ func (s *OrderService) UpdateOrderStatusForAdmin(orderID uint, status string) er
 		if !canTransitionOrderStatus(order.Status, status) {
 			return ErrInvalidOrderStatus
 		}
+		if status == models.OrderStatusCancelled && order.Status != models.OrderStatusCancelled {
+			if restocked, err = s.restock(tx, order.ID); err != nil {
+				return err
+			}
+		}
 
 		order.Status = status
 		if err := orderRepoTx.Update(order); err != nil {
@@ -328,9 +531,53 @@ func (s *OrderService) UpdateOrderStatusForAdmin(orderID uint, status string) er
 	if err != nil {
 		return err
 	}
+	s.stockAlerts.StockChanged(restocked)
 	return nil
 }
 
+// restock returns the stock taken by a cancelled order, crediting bundle
+// lines to their components as they were when ordered. It returns the
+// restocked products.
+func (s *OrderService) restock(tx *gorm.DB, orderID uint) ([]uint, error) {
+	order, err := s.orderRepo.WithTx(tx).FindByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order items: %w", err)
+	}
+
+	returned := make(map[uint]int)
+	for _, item := range order.Items {
+		if len(item.Components) > 0 {
+			for _, c := range item.Components {
+				returned[c.ProductID] += c.Quantity
+			}
+			continue
+		}
+		returned[item.ProductID] += item.Quantity
+	}
+	ids := make([]uint, 0, len(returned))
+	for id := range returned {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	productRepoTx := s.productRepo.WithTx(tx)
+	for _, id := range ids {
+		// A product deleted since the order is skipped
+		if _, err := productRepoTx.ApplyStockMovement(&models.StockMovement{
+			ProductID:   id,
+			Delta:       returned[id],
+			Reason:      models.StockReasonCancellation,
+			ReferenceID: &orderID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to restock: %w", err)
+		}
+	}
+	if err := productRepoTx.SyncBundleStock(ids); err != nil {
+		return nil, fmt.Errorf("failed to update bundle stock: %w", err)
+	}
+	return ids, nil
+}
+
 func (s *OrderService) GetStatisticsForAdmin(req *dto.AdminOrderStatisticsRequest) (*dto.AdminOrderStatisticsResponse, error) {
 	if req == nil {
 		req = &dto.AdminOrderStatisticsRequest{}
@@ -371,6 +618,20 @@ func (s *OrderService) GetStatisticsForAdmin(req *dto.AdminOrderStatisticsReques
 		return nil, fmt.Errorf("failed to get order statistics series: %w", err)
 	}
 
+	salesRows, err := s.orderRepo.GetProductSales(params, topProductsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+	topProducts := make([]dto.AdminProductSales, 0, len(salesRows))
+	for _, row := range salesRows {
+		topProducts = append(topProducts, dto.AdminProductSales{
+			ProductID:     row.ProductID,
+			ProductName:   row.ProductName,
+			Quantity:      row.Quantity,
+			RevenueAmount: row.RevenueAmount,
+		})
+	}
+
 	series := make([]dto.AdminOrderStatisticsPoint, 0, len(seriesRows))
 	for _, row := range seriesRows {
 		series = append(series, dto.AdminOrderStatisticsPoint{
@@ -388,7 +649,8 @@ func (s *OrderService) GetStatisticsForAdmin(req *dto.AdminOrderStatisticsReques
 			DeliveredCount: summaryRow.DeliveredCount,
 			CancelledCount: summaryRow.CancelledCount,
 		},
-		Series: series,
+		Series:      series,
+		TopProducts: topProducts,
 	}, nil
 }
 
@@ -415,14 +677,32 @@ func (s *OrderService) toResponse(order *models.Order, includeItems bool) *dto.O
 		resp.Items = make([]dto.OrderItemResponse, 0, len(order.Items))
 		resp.ItemCount = len(order.Items)
 		for _, item := range order.Items {
-			resp.Items = append(resp.Items, dto.OrderItemResponse{
+			itemResp := dto.OrderItemResponse{
 				ID:           item.ID,
 				ProductID:    item.ProductID,
 				ProductName:  item.ProductName,
 				ProductPrice: item.ProductPrice,
+				UnitPrice:    item.ProductPrice,
 				Quantity:     item.Quantity,
 				Subtotal:     item.Subtotal,
-			})
+			}
+			for _, m := range item.Modifiers {
+				itemResp.Modifiers = append(itemResp.Modifiers, dto.OrderItemModifierResponse{
+					OptionID:   m.ModifierOptionID,
+					GroupName:  m.GroupName,
+					OptionName: m.OptionName,
+					Price:      m.Price,
+				})
+				itemResp.UnitPrice += m.Price
+			}
+			for _, c := range item.Components {
+				itemResp.Components = append(itemResp.Components, dto.OrderItemComponentResponse{
+					ProductID:   c.ProductID,
+					ProductName: c.ProductName,
+					Quantity:    c.Quantity,
+				})
+			}
+			resp.Items = append(resp.Items, itemResp)
 		}
 	}
 
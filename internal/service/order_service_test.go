@@ -44,6 +44,14 @@ func setupOrderServiceTest(t *testing.T) (*OrderService, *gorm.DB, *orderTestNot
 		&models.CartItem{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderItemModifier{},
+		&models.OrderItemComponent{},
+		&models.BundleItem{},
+		&models.StockMovement{},
+		&models.ModifierGroup{},
+		&models.ModifierOption{},
+		&models.ModifierGroupAssignment{},
+		&models.ProductRevision{},
 	); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -84,9 +92,10 @@ func setupOrderServiceTest(t *testing.T) (*OrderService, *gorm.DB, *orderTestNot
 	orderRepo := repository.NewOrderRepository(db)
 	cartRepo := repository.NewCartRepository(db)
 	productRepo := repository.NewProductRepository(db)
+	modifierRepo := repository.NewModifierRepository(db)
 	notifier := &orderTestNotifier{}
 
-	return NewOrderService(orderRepo, cartRepo, productRepo, notifier), db, notifier
+	return NewOrderService(orderRepo, cartRepo, productRepo, modifierRepo, notifier, NewCursorCodec("test-secret"), nil, nil, nil), db, notifier
 }
 
 // ─── generateOrderNumber ────────────────────────────────────────────────────
@@ -367,3 +376,143 @@ func TestOrderService_ValidationBranches(t *testing.T) {
 		t.Fatalf("GetStatisticsForAdmin expected ErrInvalidDateFilter, got %v", err)
 	}
 }
+
+func TestOrderService_CreateOrderFromCart_SnapshotsModifiers(t *testing.T) {
+	t.Parallel()
+
+	svc, db, _ := setupOrderServiceTest(t)
+
+	var product models.Product
+	if err := db.First(&product, 1).Error; err != nil {
+		t.Fatalf("query product: %v", err)
+	}
+	group := seedModifierGroupForTest(t, db, &product, 0, 1, dto.ModifierOptionInput{Name: "Extra beef", Price: 20000})
+	optionID := group.Options[0].ID
+
+	line := models.CartItem{CartID: 1, ProductID: product.ID, Quantity: 1, ModifierKey: fmt.Sprintf("%d", optionID)}
+	if err := db.Create(&line).Error; err != nil {
+		t.Fatalf("seed modifier cart item: %v", err)
+	}
+
+	order, err := svc.CreateOrderFromCart(1, &dto.CreateOrderRequest{
+		ShippingAddress: "123 Le Loi",
+		ShippingPhone:   "0901234567",
+	})
+	if err != nil {
+		t.Fatalf("CreateOrderFromCart returned error: %v", err)
+	}
+	if order.TotalAmount != 170000 {
+		t.Fatalf("total amount = %v, want 170000", order.TotalAmount)
+	}
+	if len(order.Items) != 2 {
+		t.Fatalf("items = %d, want 2", len(order.Items))
+	}
+
+	var withModifier *dto.OrderItemResponse
+	for i := range order.Items {
+		if len(order.Items[i].Modifiers) > 0 {
+			withModifier = &order.Items[i]
+		}
+	}
+	if withModifier == nil || withModifier.UnitPrice != 70000 || withModifier.Modifiers[0].OptionName != "Extra beef" {
+		t.Fatalf("unexpected modifier item: %+v", withModifier)
+	}
+
+	if err := db.First(&product, product.ID).Error; err != nil {
+		t.Fatalf("query product: %v", err)
+	}
+	if product.Stock != 7 {
+		t.Fatalf("product stock = %d, want 7", product.Stock)
+	}
+}
+
+func TestOrderService_CreateOrderFromCart_Bundle(t *testing.T) {
+	t.Parallel()
+
+	svc, db, _ := setupOrderServiceTest(t)
+	productSvc := NewProductService(repository.NewProductRepository(db), repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil)
+
+	coke := models.Product{CategoryID: 1, Name: "Coke", Slug: "coke", Classify: models.ClassifyDrink, Price: 15000, Stock: 10, Status: models.ProductStatusActive}
+	combo := models.Product{CategoryID: 1, Name: "Pho Combo", Slug: "pho-combo", Classify: models.ClassifyFood, Price: 60000, Status: models.ProductStatusActive}
+	for _, p := range []*models.Product{&coke, &combo} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("seed product %s: %v", p.Slug, err)
+		}
+	}
+
+	bundle, err := productSvc.SaveBundle(combo.ID, []dto.BundleItemInput{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: coke.ID, Quantity: 1},
+		{ProductID: coke.ID, Quantity: 1}, // merged with the row above
+		{},
+	})
+	if err != nil {
+		t.Fatalf("SaveBundle: %v", err)
+	}
+	if !bundle.IsBundle || bundle.Stock != 5 || len(bundle.Components) != 2 || bundle.Components[1].Quantity != 2 {
+		t.Fatalf("bundle = %+v, want 2 components and stock 5", bundle)
+	}
+
+	other := models.Product{CategoryID: 1, Name: "Family Combo", Slug: "family-combo", Classify: models.ClassifyFood, Price: 150000, Status: models.ProductStatusActive}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+	if _, err := productSvc.SaveBundle(other.ID, []dto.BundleItemInput{{ProductID: combo.ID, Quantity: 1}}); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("nested bundle error = %v, want ErrInvalidBundle", err)
+	}
+	if _, err := productSvc.SaveBundle(1, []dto.BundleItemInput{{ProductID: coke.ID, Quantity: 1}}); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("component turned bundle error = %v, want ErrInvalidBundle", err)
+	}
+
+	// The cart already holds 2 pho
+	if err := db.Create(&models.CartItem{CartID: 1, ProductID: combo.ID, Quantity: 3}).Error; err != nil {
+		t.Fatalf("seed bundle cart item: %v", err)
+	}
+	order, err := svc.CreateOrderFromCart(1, &dto.CreateOrderRequest{ShippingAddress: "123 Le Loi", ShippingPhone: "0901234567"})
+	if err != nil {
+		t.Fatalf("CreateOrderFromCart returned error: %v", err)
+	}
+	if order.TotalAmount != 280000 {
+		t.Fatalf("total amount = %v, want 280000", order.TotalAmount)
+	}
+	var comboLine *dto.OrderItemResponse
+	for i := range order.Items {
+		if order.Items[i].ProductID == combo.ID {
+			comboLine = &order.Items[i]
+		}
+	}
+	if comboLine == nil || len(comboLine.Components) != 2 ||
+		comboLine.Components[0].ProductName != "Pho Bo" || comboLine.Components[0].Quantity != 3 ||
+		comboLine.Components[1].ProductName != "Coke" || comboLine.Components[1].Quantity != 6 {
+		t.Fatalf("combo line = %+v", comboLine)
+	}
+
+	stocks := map[uint]int{1: 5, coke.ID: 4, combo.ID: 2}
+	for id, want := range stocks {
+		var p models.Product
+		if err := db.First(&p, id).Error; err != nil {
+			t.Fatalf("query product %d: %v", id, err)
+		}
+		if p.Stock != want {
+			t.Fatalf("product %s stock = %d, want %d", p.Slug, p.Stock, want)
+		}
+	}
+
+	// Bundle sales are credited to the components, revenue split by list price
+	sales, err := repository.NewOrderRepository(db).GetProductSales(repository.OrderStatisticsParams{}, 10)
+	if err != nil {
+		t.Fatalf("GetProductSales: %v", err)
+	}
+	if len(sales) != 2 ||
+		sales[0].ProductID != coke.ID || sales[0].Quantity != 6 || sales[0].RevenueAmount != 67500 ||
+		sales[1].ProductID != 1 || sales[1].Quantity != 5 || sales[1].RevenueAmount != 212500 {
+		t.Fatalf("product sales = %+v", sales)
+	}
+
+	if err := db.Create(&models.CartItem{CartID: 1, ProductID: combo.ID, Quantity: 3}).Error; err != nil {
+		t.Fatalf("seed bundle cart item: %v", err)
+	}
+	if _, err := svc.CreateOrderFromCart(1, &dto.CreateOrderRequest{ShippingAddress: "123 Le Loi", ShippingPhone: "0901234567"}); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("second order error = %v, want ErrInsufficientStock", err)
+	}
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPriceRuleNotFound = errors.New("price rule not found")
+	ErrInvalidPriceRule  = errors.New("invalid price rule")
+)
+
+const (
+	// PriceRuleTimeLayout is how rule start and end times are submitted
+	PriceRuleTimeLayout = "2006-01-02T15:04"
+	// priceRulesTTL bounds how long rule edits made directly in the database
+	// and category moves take to reach prices
+	priceRulesTTL = time.Minute
+)
+
+// Price rule states shown in the admin
+const (
+	PriceRuleScheduled = "scheduled"
+	PriceRuleRunning   = "running"
+	PriceRuleEnded     = "ended"
+)
+
+// PriceQuote is the price a product sells for at one moment
+type PriceQuote struct {
+	Price float64
+	// OriginalPrice is the regular price while a rule lowers Price, otherwise 0
+	OriginalPrice float64
+	// EndsAt is when the running sale stops
+	EndsAt *time.Time
+}
+
+// PricingService resolves scheduled price rules into the price products sell
+// for. Unended rules are kept in memory so that quoting a page of products
+// costs no queries. A nil *PricingService always quotes the regular price.
+type PricingService struct {
+	repo         *repository.PriceRuleRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	loc          *time.Location
+	listeners    []CatalogListener
+	now          func() time.Time
+
+	mu       sync.Mutex
+	rules    []models.PriceRule
+	tree     *categoryTree
+	loadedAt time.Time
+}
+
+// NewPricingService creates a PricingService reading daily windows and
+// submitted times in loc, the timezone of serving hours
+func NewPricingService(repo *repository.PriceRuleRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, loc *time.Location, listeners ...CatalogListener) *PricingService {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &PricingService{
+		repo:         repo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		loc:          loc,
+		listeners:    listeners,
+		now:          time.Now,
+	}
+}
+
+// Quote returns the price p sells for now: the lowest price any running rule
+// of the product, its category or an ancestor category gives, or the regular
+// price when none is lower
+func (s *PricingService) Quote(p *models.Product) (PriceQuote, error) {
+	quote := PriceQuote{Price: p.Price}
+	if s == nil {
+		return quote, nil
+	}
+	rules, tree, err := s.load()
+	if err != nil {
+		return quote, err
+	}
+
+	categories := map[uint]bool{p.CategoryID: true}
+	for _, c := range tree.path(p.CategoryID) {
+		categories[c.ID] = true
+	}
+	now := s.now().In(s.loc)
+	for i := range rules {
+		r := &rules[i]
+		own := r.ProductID != nil && *r.ProductID == p.ID
+		inherited := r.CategoryID != nil && categories[*r.CategoryID]
+		if !own && !inherited {
+			continue
+		}
+		until, running := priceRuleRunUntil(r, now)
+		if !running {
+			continue
+		}
+		if price := priceRuleApply(r, p.Price); price < quote.Price {
+			until = until.In(s.loc)
+			quote.Price = price
+			quote.OriginalPrice = p.Price
+			quote.EndsAt = &until
+		}
+	}
+	return quote, nil
+}
+
+// LastTransition returns the latest time, not after now, at which a rule
+// started or stopped lowering prices. Responses that show prices are still
+// valid while it does not change. It is zero when no rule has started.
+func (s *PricingService) LastTransition() (time.Time, error) {
+	if s == nil {
+		return time.Time{}, nil
+	}
+	rules, _, err := s.load()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := s.now().In(s.loc)
+	var last time.Time
+	consider := func(at time.Time) {
+		if !at.After(now) && at.After(last) {
+			last = at
+		}
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	for i := range rules {
+		r := &rules[i]
+		consider(r.StartsAt)
+		consider(r.EndsAt)
+		if r.DailyStart == "" {
+			continue
+		}
+		// Daily windows open and close within the rule's dates only
+		for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+			for _, hm := range []string{r.DailyStart, r.DailyEnd} {
+				minute, _ := parseMinuteOfDay(hm)
+				at := day.Add(time.Duration(minute) * time.Minute)
+				if !at.Before(r.StartsAt) && at.Before(r.EndsAt) {
+					consider(at)
+				}
+			}
+		}
+	}
+	return last, nil
+}
+
+// List returns every rule for the admin, those ending last first
+func (s *PricingService) List() ([]dto.PriceRuleResponse, error) {
+	rules, err := s.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price rules: %w", err)
+	}
+	now := s.now()
+	items := make([]dto.PriceRuleResponse, len(rules))
+	for i := range rules {
+		items[i] = s.toResponse(&rules[i], now)
+	}
+	return items, nil
+}
+
+// Create validates and saves a rule
+func (s *PricingService) Create(req *dto.PriceRuleRequest) (*dto.PriceRuleResponse, error) {
+	rule, err := s.buildRule(req)
+	if err != nil {
+		return nil, err
+	}
+	if rule.ProductID != nil {
+		if _, err := s.productRepo.FindByID(*rule.ProductID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrProductNotFound
+			}
+			return nil, fmt.Errorf("failed to find product: %w", err)
+		}
+	} else if _, err := s.categoryRepo.FindByID(*rule.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to find category: %w", err)
+	}
+
+	if err := s.repo.Create(rule); err != nil {
+		return nil, fmt.Errorf("failed to save price rule: %w", err)
+	}
+	s.changed()
+	resp := s.toResponse(rule, s.now())
+	return &resp, nil
+}
+
+// Delete removes a rule, ending its sale at once
+func (s *PricingService) Delete(id uint) error {
+	rule, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPriceRuleNotFound
+		}
+		return fmt.Errorf("failed to find price rule: %w", err)
+	}
+	if err := s.repo.Delete(rule); err != nil {
+		return fmt.Errorf("failed to delete price rule: %w", err)
+	}
+	s.changed()
+	return nil
+}
+
+func (s *PricingService) buildRule(req *dto.PriceRuleRequest) (*models.PriceRule, error) {
+	rule := &models.PriceRule{
+		Name:       strings.TrimSpace(req.Name),
+		Kind:       req.Kind,
+		Value:      req.Value,
+		DailyStart: strings.TrimSpace(req.DailyStart),
+		DailyEnd:   strings.TrimSpace(req.DailyEnd),
+	}
+	switch {
+	case req.ProductID != 0 && req.CategoryID == 0:
+		rule.ProductID = &req.ProductID
+	case req.CategoryID != 0 && req.ProductID == 0:
+		rule.CategoryID = &req.CategoryID
+	default:
+		return nil, fmt.Errorf("%w: choose either a product or a category", ErrInvalidPriceRule)
+	}
+	if rule.Name == "" || len(rule.Name) > 100 {
+		return nil, fmt.Errorf("%w: name is required, up to 100 characters", ErrInvalidPriceRule)
+	}
+	switch rule.Kind {
+	case models.PriceRuleFixed:
+		if rule.Value <= 0 {
+			return nil, fmt.Errorf("%w: the sale price must be positive", ErrInvalidPriceRule)
+		}
+	case models.PriceRulePercent:
+		if rule.Value <= 0 || rule.Value >= 100 {
+			return nil, fmt.Errorf("%w: the discount must be between 0 and 100 percent", ErrInvalidPriceRule)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidPriceRule, rule.Kind)
+	}
+
+	startsAt, errStart := time.ParseInLocation(PriceRuleTimeLayout, strings.TrimSpace(req.StartsAt), s.loc)
+	endsAt, errEnd := time.ParseInLocation(PriceRuleTimeLayout, strings.TrimSpace(req.EndsAt), s.loc)
+	if errStart != nil || errEnd != nil {
+		return nil, fmt.Errorf("%w: start and end must be YYYY-MM-DDTHH:MM", ErrInvalidPriceRule)
+	}
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: the end must be after the start", ErrInvalidPriceRule)
+	}
+	// Stored in UTC so rules compare correctly whatever the database timezone
+	rule.StartsAt, rule.EndsAt = startsAt.UTC(), endsAt.UTC()
+
+	if rule.DailyStart != "" || rule.DailyEnd != "" {
+		start, okStart := parseMinuteOfDay(rule.DailyStart)
+		end, okEnd := parseMinuteOfDay(rule.DailyEnd)
+		if !okStart || !okEnd {
+			return nil, fmt.Errorf("%w: daily times must be HH:MM, or both empty for all day", ErrInvalidPriceRule)
+		}
+		if start == end {
+			return nil, fmt.Errorf("%w: the daily window cannot start and end at %s", ErrInvalidPriceRule, rule.DailyStart)
+		}
+	}
+	return rule, nil
+}
+
+func (s *PricingService) toResponse(r *models.PriceRule, now time.Time) dto.PriceRuleResponse {
+	resp := dto.PriceRuleResponse{
+		ID:         r.ID,
+		ProductID:  r.ProductID,
+		CategoryID: r.CategoryID,
+		Name:       r.Name,
+		Kind:       r.Kind,
+		Value:      r.Value,
+		StartsAt:   r.StartsAt.In(s.loc),
+		EndsAt:     r.EndsAt.In(s.loc),
+		DailyStart: r.DailyStart,
+		DailyEnd:   r.DailyEnd,
+		Status:     PriceRuleRunning,
+	}
+	if r.Product != nil {
+		resp.ProductName = r.Product.Name
+	}
+	if r.Category != nil {
+		resp.CategoryName = r.Category.Name
+	}
+	switch {
+	case now.Before(r.StartsAt):
+		resp.Status = PriceRuleScheduled
+	case !now.Before(r.EndsAt):
+		resp.Status = PriceRuleEnded
+	}
+	return resp
+}
+
+// load returns the rules that had not ended when last read, with the
+// category hierarchy they apply through
+func (s *PricingService) load() ([]models.PriceRule, *categoryTree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.tree == nil || now.Sub(s.loadedAt) >= priceRulesTTL {
+		rules, err := s.repo.ListEndingAfter(now.UTC())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load price rules: %w", err)
+		}
+		categories, err := s.categoryRepo.ListAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load categories: %w", err)
+		}
+		s.rules, s.tree, s.loadedAt = rules, newCategoryTree(categories), now
+	}
+	return s.rules, s.tree, nil
+}
+
+// changed drops the loaded rules and tells listeners, since prices are part
+// of catalog responses
+func (s *PricingService) changed() {
+	s.mu.Lock()
+	s.tree = nil
+	s.mu.Unlock()
+	for _, l := range s.listeners {
+		l.CatalogChanged()
+	}
+}
+
+// priceRuleRunUntil reports whether r lowers prices at t, given in the shop
+// timezone, and when that stretch ends: the end of today's daily window or
+// of the rule, whichever comes first
+func priceRuleRunUntil(r *models.PriceRule, t time.Time) (time.Time, bool) {
+	if t.Before(r.StartsAt) || !t.Before(r.EndsAt) {
+		return time.Time{}, false
+	}
+	if r.DailyStart == "" {
+		return r.EndsAt, true
+	}
+	start, okStart := parseMinuteOfDay(r.DailyStart)
+	end, okEnd := parseMinuteOfDay(r.DailyEnd)
+	if !okStart || !okEnd {
+		return time.Time{}, false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	var until time.Time
+	switch overnight := end <= start; {
+	case !overnight && minute >= start && minute < end:
+		until = day.Add(time.Duration(end) * time.Minute)
+	case overnight && minute >= start:
+		until = day.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute)
+	case overnight && minute < end:
+		until = day.Add(time.Duration(end) * time.Minute)
+	default:
+		return time.Time{}, false
+	}
+	if r.EndsAt.Before(until) {
+		until = r.EndsAt
+	}
+	return until, true
+}
+
+// priceRuleApply returns the price r gives a product regularly sold at
+// price, in whole đồng
+func priceRuleApply(r *models.PriceRule, price float64) float64 {
+	if r.Kind == models.PriceRulePercent {
+		return math.Round(price * (100 - r.Value) / 100)
+	}
+	return r.Value
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newPricingServiceTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, DisableForeignKeyConstraintWhenMigrating: true})
+	if err != nil {
+		t.Fatalf("open pricing test db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductImage{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderItemModifier{},
+		&models.OrderItemComponent{},
+		&models.BundleItem{},
+		&models.StockMovement{},
+		&models.PriceRule{},
+	); err != nil {
+		t.Fatalf("migrate pricing test db: %v", err)
+	}
+	return db
+}
+
+func TestPriceRuleRunUntil(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("ICT", 7*3600)
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 5, day, hour, minute, 0, 0, loc) }
+	rule := &models.PriceRule{StartsAt: at(1, 0, 0), EndsAt: at(10, 0, 0)}
+	overnight := &models.PriceRule{StartsAt: at(1, 0, 0), EndsAt: at(10, 0, 0), DailyStart: "22:00", DailyEnd: "02:00"}
+
+	tests := []struct {
+		name    string
+		rule    *models.PriceRule
+		t       time.Time
+		running bool
+		until   time.Time
+	}{
+		{"before start", rule, at(1, 0, 0).Add(-time.Minute), false, time.Time{}},
+		{"all day", rule, at(4, 15, 20), true, at(10, 0, 0)},
+		{"at end", rule, at(10, 0, 0), false, time.Time{}},
+		{"overnight evening", overnight, at(4, 23, 0), true, at(5, 2, 0)},
+		{"overnight morning", overnight, at(4, 1, 0), true, at(4, 2, 0)},
+		{"overnight daytime", overnight, at(4, 12, 0), false, time.Time{}},
+		{"window cut by end", overnight, at(9, 23, 0), true, at(10, 0, 0)},
+	}
+	for _, tt := range tests {
+		until, running := priceRuleRunUntil(tt.rule, tt.t)
+		if running != tt.running || !until.Equal(tt.until) {
+			t.Errorf("%s: got %v, %v; want %v, %v", tt.name, until, running, tt.until, tt.running)
+		}
+	}
+}
+
+func TestPricingService_QuoteCartAndOrder(t *testing.T) {
+	t.Parallel()
+
+	db := newPricingServiceTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	listener := &countingListener{}
+	loc := time.FixedZone("ICT", 7*3600)
+	svc := NewPricingService(repository.NewPriceRuleRepository(db), productRepo, categoryRepo, loc, listener)
+	// Monday 2026-05-04 15:20 in the shop timezone
+	now := time.Date(2026, 5, 4, 15, 20, 0, 0, loc)
+	svc.now = func() time.Time { return now }
+
+	drinks := &models.Category{Name: "Drinks", Slug: "pricing-drinks", Status: models.CategoryStatusActive}
+	if err := db.Create(drinks).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	coffee := &models.Category{Name: "Coffee", Slug: "pricing-coffee", ParentID: &drinks.ID, Status: models.CategoryStatusActive}
+	if err := db.Create(coffee).Error; err != nil {
+		t.Fatalf("create subcategory: %v", err)
+	}
+	latte := &models.Product{CategoryID: coffee.ID, Name: "Latte", Slug: "pricing-latte", Classify: models.ClassifyDrink, Price: 30000, Stock: 10, Status: models.ProductStatusActive}
+	if err := db.Create(latte).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	user := &models.User{Email: "pricing@example.com", FullName: "Bargain Hunter", Role: models.RoleUser, Status: models.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if quote, err := svc.Quote(latte); err != nil || quote.Price != 30000 || quote.OriginalPrice != 0 || quote.EndsAt != nil {
+		t.Fatalf("Quote without rules = %+v, %v", quote, err)
+	}
+
+	invalid := []dto.PriceRuleRequest{
+		{ProductID: latte.ID, CategoryID: drinks.ID, Name: "Both", Kind: models.PriceRuleFixed, Value: 1000, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00"},
+		{ProductID: latte.ID, Name: "Too much", Kind: models.PriceRulePercent, Value: 100, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00"},
+		{ProductID: latte.ID, Name: "Backwards", Kind: models.PriceRuleFixed, Value: 1000, StartsAt: "2026-05-10T00:00", EndsAt: "2026-05-01T00:00"},
+		{ProductID: latte.ID, Name: "Half window", Kind: models.PriceRuleFixed, Value: 1000, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00", DailyStart: "15:00"},
+	}
+	for _, req := range invalid {
+		if _, err := svc.Create(&req); !errors.Is(err, ErrInvalidPriceRule) {
+			t.Fatalf("Create %q error = %v, want ErrInvalidPriceRule", req.Name, err)
+		}
+	}
+	if _, err := svc.Create(&dto.PriceRuleRequest{ProductID: 9999, Name: "Ghost", Kind: models.PriceRuleFixed, Value: 1000, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00"}); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Create for missing product error = %v", err)
+	}
+
+	// A rule on the parent category reaches products of its subcategories
+	if _, err := svc.Create(&dto.PriceRuleRequest{CategoryID: drinks.ID, Name: "Drinks week", Kind: models.PriceRulePercent, Value: 10, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00"}); err != nil {
+		t.Fatalf("create category rule: %v", err)
+	}
+	quote, err := svc.Quote(latte)
+	if err != nil || quote.Price != 27000 || quote.OriginalPrice != 30000 {
+		t.Fatalf("Quote with category rule = %+v, %v", quote, err)
+	}
+	if want := time.Date(2026, 5, 10, 0, 0, 0, 0, loc); quote.EndsAt == nil || !quote.EndsAt.Equal(want) {
+		t.Fatalf("sale ends at %v, want %v", quote.EndsAt, want)
+	}
+
+	// The lowest running price wins
+	happyHour, err := svc.Create(&dto.PriceRuleRequest{ProductID: latte.ID, Name: "Happy hour", Kind: models.PriceRuleFixed, Value: 25000, StartsAt: "2026-05-01T00:00", EndsAt: "2026-05-10T00:00", DailyStart: "15:00", DailyEnd: "16:00"})
+	if err != nil {
+		t.Fatalf("create product rule: %v", err)
+	}
+	if happyHour.Status != PriceRuleRunning || listener.calls != 2 {
+		t.Fatalf("status = %q, listener calls = %d", happyHour.Status, listener.calls)
+	}
+	quote, err = svc.Quote(latte)
+	if err != nil || quote.Price != 25000 || quote.OriginalPrice != 30000 {
+		t.Fatalf("Quote in happy hour = %+v, %v", quote, err)
+	}
+	if want := time.Date(2026, 5, 4, 16, 0, 0, 0, loc); quote.EndsAt == nil || !quote.EndsAt.Equal(want) {
+		t.Fatalf("happy hour ends at %v, want %v", quote.EndsAt, want)
+	}
+	last, err := svc.LastTransition()
+	if want := time.Date(2026, 5, 4, 15, 0, 0, 0, loc); err != nil || !last.Equal(want) {
+		t.Fatalf("LastTransition = %v, %v; want %v", last, err, want)
+	}
+
+	cartSvc := NewCartService(repository.NewCartRepository(db), productRepo, nil, nil, svc)
+	cart, err := cartSvc.AddItem(user.ID, &dto.AddCartItemRequest{ProductID: latte.ID, Quantity: 2})
+	if err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if cart.TotalAmount != 50000 || cart.Items[0].OriginalPrice != 30000 {
+		t.Fatalf("cart = %+v", cart)
+	}
+	orderSvc := NewOrderService(repository.NewOrderRepository(db), repository.NewCartRepository(db), productRepo, nil, nil, NewCursorCodec("test-secret"), nil, nil, svc)
+	order, err := orderSvc.CreateOrderFromCart(user.ID, &dto.CreateOrderRequest{ShippingAddress: "1 Hai Ba Trung", ShippingPhone: "0901234567"})
+	if err != nil {
+		t.Fatalf("CreateOrderFromCart: %v", err)
+	}
+	if order.TotalAmount != 50000 {
+		t.Fatalf("order total = %v, want 50000", order.TotalAmount)
+	}
+
+	// After the daily window only the category discount is left
+	now = time.Date(2026, 5, 4, 16, 30, 0, 0, loc)
+	if quote, err := svc.Quote(latte); err != nil || quote.Price != 27000 {
+		t.Fatalf("Quote after happy hour = %+v, %v", quote, err)
+	}
+	if last, err := svc.LastTransition(); err != nil || !last.Equal(time.Date(2026, 5, 4, 16, 0, 0, 0, loc)) {
+		t.Fatalf("LastTransition after happy hour = %v, %v", last, err)
+	}
+
+	if err := svc.Delete(9999); !errors.Is(err, ErrPriceRuleNotFound) {
+		t.Fatalf("Delete missing rule error = %v", err)
+	}
+	rules, err := svc.List()
+	if err != nil || len(rules) != 2 || rules[0].CategoryName != "Drinks" && rules[1].CategoryName != "Drinks" {
+		t.Fatalf("List = %+v, %v", rules, err)
+	}
+	for _, r := range rules {
+		if err := svc.Delete(r.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+	if quote, err := svc.Quote(latte); err != nil || quote.Price != 30000 || quote.OriginalPrice != 0 {
+		t.Fatalf("Quote after deleting rules = %+v, %v", quote, err)
+	}
+}
@@ -0,0 +1,860 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/spreadsheet"
+	appvalidator "github.com/kha/foods-drinks/pkg/validator"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrImportInvalidFile = errors.New("invalid import file")
+	ErrImportEmpty       = errors.New("import file has no product rows")
+	ErrImportTooManyRows = errors.New("import file has too many rows")
+	ErrImportNotFound    = errors.New("import preview not found or expired")
+	ErrImportHasErrors   = errors.New("import has invalid rows")
+	ErrImportJobNotFound = errors.New("import job not found")
+	// ErrImportPriceUnconfirmed keeps the preview so it can be applied again
+	// with its large price changes confirmed
+	ErrImportPriceUnconfirmed = errors.New("import has unconfirmed price changes")
+)
+
+// Import job statuses
+const (
+	ProductImportRunning = "running"
+	ProductImportDone    = "done"
+	ProductImportFailed  = "failed"
+)
+
+// Row actions of an import preview
+const (
+	ProductImportCreate = "create"
+	ProductImportUpdate = "update"
+)
+
+const (
+	productImportMaxRows   = 5000
+	productImportMaxImages = 10
+	// Imports with more rows than this are applied in the background
+	productImportBackgroundRows = 200
+	// productImportBatchRows are applied per transaction, so a large file
+	// does not keep product rows locked from checkout for its whole run
+	productImportBatchRows  = 200
+	productImportPreviewTTL = 30 * time.Minute
+	productImportJobTTL     = 24 * time.Hour
+	productExportPageSize   = 100
+	// productImageSeparator separates the image URLs of a cell
+	productImageSeparator = "|"
+)
+
+// ProductSheetColumns are the columns of an exported file, in order. An
+// import file needs a header row naming its columns, in any order; slug,
+// stock, status, low_stock_threshold, description and image_urls may be
+// left out.
+var ProductSheetColumns = []string{
+	"slug", "name", "category_slug", "classify", "price", "stock",
+	"status", "low_stock_threshold", "description", "image_urls",
+}
+
+var productSheetRequired = []string{"name", "category_slug", "classify", "price"}
+
+// ProductImportService exports the product list to spreadsheets and imports
+// spreadsheets that create or update products by slug. An import is
+// previewed first; the preview is kept in memory under a token until it is
+// applied, in one transaction, or expires. Like edits in the admin, updates
+// are added to the products' revision history and large price changes have
+// to be confirmed.
+type ProductImportService struct {
+	products *ProductService
+	validate *validator.Validate
+	now      func() time.Time
+	// priceConfirmPercent is the price change, in percent of the old price,
+	// above which an updated row has to be confirmed
+	priceConfirmPercent float64
+
+	// backgroundRows is the size above which Apply returns before the
+	// import is done
+	backgroundRows int
+	// batchRows is the number of rows saved per transaction
+	batchRows int
+
+	mu       sync.Mutex
+	previews map[string]*productImportBatch
+	jobs     map[string]*dto.ProductImportJob
+}
+
+type productImportBatch struct {
+	rows      []productSheetRow
+	expiresAt time.Time
+}
+
+// productSheetRow holds the non-empty cells of a data row by column
+type productSheetRow struct {
+	line  int
+	cells map[string]string
+}
+
+// productImportPlan is a validated row and what applying it changes
+type productImportPlan struct {
+	line      int
+	req       dto.CreateProductRequest
+	existing  *models.Product
+	imageURLs []string
+	errors    []string
+	// priceChange is set when an update moves the price past the threshold
+	priceChange *dto.ProductImportPriceChange
+
+	// Optional cells left empty keep the current value of an updated product
+	hasStock, hasStatus, hasThreshold, hasDescription, hasImages bool
+}
+
+func NewProductImportService(products *ProductService, priceConfirmPercent float64) *ProductImportService {
+	if priceConfirmPercent <= 0 {
+		priceConfirmPercent = DefaultPriceConfirmPercent
+	}
+	v := validator.New()
+	// The rules are the binding tags of dto.CreateProductRequest, reported
+	// under the names of the file's columns
+	v.SetTagName("binding")
+	_ = appvalidator.RegisterCustomValidators(v)
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "category_id" {
+			return "category_slug"
+		}
+		return name
+	})
+	return &ProductImportService{
+		products:            products,
+		validate:            v,
+		now:                 time.Now,
+		priceConfirmPercent: priceConfirmPercent,
+		backgroundRows:      productImportBackgroundRows,
+		batchRows:           productImportBatchRows,
+		previews:            make(map[string]*productImportBatch),
+		jobs:                make(map[string]*dto.ProductImportJob),
+	}
+}
+
+// Export returns the products matching the filters of req as rows, headed
+// by ProductSheetColumns. Paging fields of req are ignored.
+func (s *ProductImportService) Export(req *dto.ProductListRequest) ([][]string, error) {
+	rows := [][]string{ProductSheetColumns}
+	page := *req
+	page.PageSize = productExportPageSize
+	page.Cursor = ""
+	page.Facets = false
+	for page.Page = 1; ; page.Page++ {
+		result, err := s.products.List(&page)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := result.Items.([]dto.ProductResponse)
+		if len(items) == 0 {
+			break
+		}
+		ids := make([]uint, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		products, err := s.products.productRepo.FindByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load products: %w", err)
+		}
+		images, err := s.products.productRepo.FindImagesByProductIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load images: %w", err)
+		}
+		byID := make(map[uint]*models.Product, len(products))
+		for i := range products {
+			byID[products[i].ID] = &products[i]
+		}
+		urls := make(map[uint][]string, len(ids))
+		for _, img := range images {
+			urls[img.ProductID] = append(urls[img.ProductID], img.ImageURL)
+		}
+		for _, id := range ids {
+			if p, ok := byID[id]; ok {
+				rows = append(rows, productSheetValues(p, urls[id]))
+			}
+		}
+		if page.Page >= result.TotalPages {
+			break
+		}
+	}
+	return rows, nil
+}
+
+func productSheetValues(p *models.Product, imageURLs []string) []string {
+	categorySlug := ""
+	if p.Category != nil {
+		categorySlug = p.Category.Slug
+	}
+	description := ""
+	if p.Description != nil {
+		description = *p.Description
+	}
+	return []string{
+		p.Slug,
+		p.Name,
+		categorySlug,
+		p.Classify,
+		strconv.FormatFloat(p.Price, 'f', -1, 64),
+		strconv.Itoa(p.Stock),
+		p.Status,
+		strconv.Itoa(p.LowStockThreshold),
+		description,
+		strings.Join(imageURLs, productImageSeparator),
+	}
+}
+
+// Preview validates an import file without changing anything
+func (s *ProductImportService) Preview(data []byte, format string) (*dto.ProductImportPreview, error) {
+	rows, err := spreadsheet.Read(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportInvalidFile, err)
+	}
+	sheetRows, err := parseProductSheet(rows)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.plan(sheetRows)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &dto.ProductImportPreview{Rows: make([]dto.ProductImportRow, len(plans)), PriceThreshold: s.priceConfirmPercent}
+	for i, plan := range plans {
+		row := dto.ProductImportRow{Line: plan.line, Slug: plan.req.Slug, Name: plan.req.Name, Action: ProductImportCreate, PriceChange: plan.priceChange, Errors: plan.errors}
+		if plan.existing != nil {
+			row.Action = ProductImportUpdate
+		}
+		switch {
+		case len(plan.errors) > 0:
+			preview.Invalid++
+		case plan.existing != nil:
+			preview.Updates++
+		default:
+			preview.Creates++
+		}
+		if plan.priceChange != nil && len(plan.errors) == 0 {
+			preview.PriceChanges++
+		}
+		preview.Rows[i] = row
+	}
+	if preview.Invalid > 0 {
+		return preview, nil
+	}
+
+	token, err := newImportID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	preview.Token = token
+	preview.ExpiresAt = now.Add(productImportPreviewTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(now)
+	s.previews[token] = &productImportBatch{rows: sheetRows, expiresAt: preview.ExpiresAt}
+	return preview, nil
+}
+
+// Apply imports a previewed file as adminID. The rows are validated again
+// since the catalog may have changed after the preview; any invalid row
+// fails the whole import. Updates moving a price past the threshold are
+// only applied with confirmPrices; without it the preview is kept. Large
+// imports continue in the background: the returned job is then still
+// running and GetJob reports its progress.
+func (s *ProductImportService) Apply(adminID uint, token string, confirmPrices bool) (*dto.ProductImportJob, error) {
+	now := s.now()
+	s.mu.Lock()
+	batch, ok := s.previews[token]
+	s.mu.Unlock()
+	if !ok || now.After(batch.expiresAt) {
+		return nil, ErrImportNotFound
+	}
+
+	plans, err := s.plan(batch.rows)
+	if err != nil {
+		return nil, err
+	}
+	priceChanges := 0
+	for _, plan := range plans {
+		if len(plan.errors) > 0 {
+			s.discard(token)
+			return nil, fmt.Errorf("%w: line %d: %s", ErrImportHasErrors, plan.line, strings.Join(plan.errors, "; "))
+		}
+		if plan.priceChange != nil {
+			priceChanges++
+		}
+	}
+	if priceChanges > 0 && !confirmPrices {
+		return nil, fmt.Errorf("%w: %d rows", ErrImportPriceUnconfirmed, priceChanges)
+	}
+	// Only one of two concurrent applies of a preview gets to run it
+	if !s.discard(token) {
+		return nil, ErrImportNotFound
+	}
+
+	id, err := newImportID()
+	if err != nil {
+		return nil, err
+	}
+	job := &dto.ProductImportJob{ID: id, Status: ProductImportRunning, Total: len(plans), StartedAt: now}
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.mu.Unlock()
+
+	if len(plans) > s.backgroundRows {
+		go s.run(adminID, job, plans)
+	} else {
+		s.run(adminID, job, plans)
+	}
+	return s.GetJob(id)
+}
+
+// GetJob returns a snapshot of an import job
+func (s *ProductImportService) GetJob(id string) (*dto.ProductImportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrImportJobNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// discard drops a preview and reports whether it was still there
+func (s *ProductImportService) discard(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.previews[token]
+	delete(s.previews, token)
+	return ok
+}
+
+// prune drops expired previews and old finished jobs. Callers hold s.mu.
+func (s *ProductImportService) prune(now time.Time) {
+	for token, batch := range s.previews {
+		if now.After(batch.expiresAt) {
+			delete(s.previews, token)
+		}
+	}
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > productImportJobTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *ProductImportService) run(adminID uint, job *dto.ProductImportJob, plans []productImportPlan) {
+	var created, updated int
+	// A panic on a bad row fails the job instead of taking the server down
+	// with the background goroutine; its batch is already rolled back
+	defer func() {
+		if r := recover(); r != nil {
+			s.finish(job, fmt.Errorf("unexpected error: %v", r), created, updated)
+			log.Printf("[product-import] job %s panicked: %v", job.ID, r)
+		}
+	}()
+
+	for start := 0; start < len(plans); start += s.batchRows {
+		batch := plans[start:min(start+s.batchRows, len(plans))]
+		saved, batchCreated, err := s.applyBatch(adminID, job, start, batch)
+		if err != nil {
+			s.finish(job, err, created, updated)
+			log.Printf("[product-import] job %s failed, %d rows saved before it: %v", job.ID, created+updated, err)
+			return
+		}
+		created += batchCreated
+		updated += len(saved) - batchCreated
+		s.savedBatch(job, saved)
+	}
+
+	s.finish(job, nil, created, updated)
+	log.Printf("[product-import] job %s done: %d created, %d updated", job.ID, job.Created, job.Updated)
+}
+
+// applyBatch saves plans in one transaction. The products it updates are
+// locked up front in ascending id order. done is the number of rows of the
+// job saved before the batch.
+func (s *ProductImportService) applyBatch(adminID uint, job *dto.ProductImportJob, done int, plans []productImportPlan) ([]models.Product, int, error) {
+	var (
+		saved   []models.Product
+		created int
+	)
+	err := s.products.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.products.productRepo.WithTx(tx)
+		var existingIDs []uint
+		for i := range plans {
+			if plans[i].existing != nil {
+				existingIDs = append(existingIDs, plans[i].existing.ID)
+			}
+		}
+		slices.Sort(existingIDs)
+		existingIDs = slices.Compact(existingIDs)
+		// The plans were made before the import ran, possibly long before
+		// when it runs in the background, so the rows are read again:
+		// orders placed since then count in the stock adjustments and
+		// other edits are kept
+		rows, err := repo.FindByIDsForUpdate(existingIDs)
+		if err != nil {
+			return fmt.Errorf("failed to reload products: %w", err)
+		}
+		current := make(map[uint]*models.Product, len(rows))
+		for i := range rows {
+			current[rows[i].ID] = &rows[i]
+		}
+
+		before := make(map[uint]models.ProductSnapshot)
+		for i := range plans {
+			p, snapshot, err := applyProductImportPlan(repo, &plans[i], current)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", plans[i].line, err)
+			}
+			saved = append(saved, *p)
+			if snapshot == nil {
+				created++
+			} else if _, ok := before[p.ID]; !ok {
+				before[p.ID] = *snapshot
+			}
+			s.mu.Lock()
+			job.Processed = done + i + 1
+			s.mu.Unlock()
+		}
+		ids := productIDs(saved)
+		if err := repo.SyncStockStatus(ids); err != nil {
+			return fmt.Errorf("failed to update stock status: %w", err)
+		}
+		if err := repo.SyncBundleStock(ids); err != nil {
+			return fmt.Errorf("failed to update bundle stock: %w", err)
+		}
+		// Read back so the revisions have the status the sync settled on
+		reloaded, err := repo.FindByIDsForUpdate(existingIDs)
+		if err != nil {
+			return fmt.Errorf("failed to reload products: %w", err)
+		}
+		for i := range reloaded {
+			p := &reloaded[i]
+			if err := recordProductRevision(repo, p.ID, before[p.ID], p.Snapshot(), adminID, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		saved, created = nil, 0
+		s.mu.Lock()
+		job.Processed = done
+		s.mu.Unlock()
+	}
+	return saved, created, err
+}
+
+// savedBatch updates the search index and stock alerts for a committed batch
+func (s *ProductImportService) savedBatch(job *dto.ProductImportJob, saved []models.Product) {
+	if s.products.index != nil {
+		// Updated rows keep the translations they already had
+		translations, err := s.products.translations.texts(models.TranslationEntityProduct, productIDs(saved))
+		if err != nil {
+			log.Printf("[product-import] job %s indexed without translations: %v", job.ID, err)
+		}
+		for i := range saved {
+			s.products.index.Upsert(productDocument(&saved[i], translations[saved[i].ID]))
+		}
+	}
+	s.products.notifyChanged()
+	s.products.stockAlerts.StockChanged(productIDs(saved))
+}
+
+// finish records the outcome of a job. A finished job keeps its outcome,
+// so a panic after the import was saved does not report it as failed.
+func (s *ProductImportService) finish(job *dto.ProductImportJob, err error, created, updated int) {
+	finished := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.FinishedAt != nil {
+		return
+	}
+	job.FinishedAt = &finished
+	// A failed job still reports the rows of the batches saved before it
+	job.Created = created
+	job.Updated = updated
+	if err != nil {
+		job.Status = ProductImportFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = ProductImportDone
+}
+
+// applyProductImportPlan saves one row. An update is applied to the locked
+// row in current, and also returns the product as it was before, for its
+// revision.
+func applyProductImportPlan(repo *repository.ProductRepository, plan *productImportPlan, current map[uint]*models.Product) (*models.Product, *models.ProductSnapshot, error) {
+	req := &plan.req
+	var description *string
+	if d := strings.TrimSpace(req.Description); d != "" {
+		description = &d
+	}
+	note := stockNote("Nhập từ file")
+
+	if plan.existing == nil {
+		status := req.Status
+		if status == "" {
+			status = models.ProductStatusActive
+		}
+		p := &models.Product{
+			CategoryID:        req.CategoryID,
+			Name:              req.Name,
+			Slug:              req.Slug,
+			Description:       description,
+			Classify:          req.Classify,
+			Price:             req.Price,
+			Status:            status,
+			LowStockThreshold: req.LowStockThreshold,
+		}
+		if err := repo.Create(p); err != nil {
+			return nil, nil, fmt.Errorf("failed to create product: %w", err)
+		}
+		if err := applyStockMovement(repo, &models.StockMovement{ProductID: p.ID, Delta: req.Stock, Reason: models.StockReasonRestock, Note: note}); err != nil {
+			return nil, nil, fmt.Errorf("failed to set stock: %w", err)
+		}
+		if err := addImportedImages(repo, p.ID, plan.imageURLs); err != nil {
+			return nil, nil, err
+		}
+		p.Stock = req.Stock
+		return p, nil, nil
+	}
+
+	p, ok := current[plan.existing.ID]
+	if !ok {
+		return nil, nil, fmt.Errorf("product %q no longer exists", plan.existing.Slug)
+	}
+	before := p.Snapshot()
+	p.CategoryID = req.CategoryID
+	p.Name = req.Name
+	p.Classify = req.Classify
+	p.Price = req.Price
+	if plan.hasStatus {
+		p.Status = req.Status
+	}
+	if plan.hasThreshold {
+		p.LowStockThreshold = req.LowStockThreshold
+	}
+	if plan.hasDescription {
+		p.Description = description
+	}
+	if err := repo.Update(p); err != nil {
+		return nil, nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	// A bundle's stock is derived from its components
+	if plan.hasStock && !p.IsBundle && req.Stock != p.Stock {
+		adjustment := &models.StockMovement{ProductID: p.ID, Delta: req.Stock - p.Stock, Reason: models.StockReasonAdjustment, Note: note}
+		if err := applyStockMovement(repo, adjustment); err != nil {
+			return nil, nil, fmt.Errorf("failed to adjust stock: %w", err)
+		}
+		p.Stock = req.Stock
+	}
+	if plan.hasImages {
+		if err := repo.DeleteImagesByProductID(p.ID); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete old images: %w", err)
+		}
+		if err := addImportedImages(repo, p.ID, plan.imageURLs); err != nil {
+			return nil, nil, err
+		}
+	}
+	return p, &before, nil
+}
+
+func addImportedImages(repo *repository.ProductRepository, productID uint, urls []string) error {
+	for i, u := range urls {
+		img := &models.ProductImage{ProductID: productID, ImageURL: u, SortOrder: i, IsPrimary: i == 0}
+		if err := repo.AddImage(img); err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+	}
+	return nil
+}
+
+func productIDs(products []models.Product) []uint {
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// parseProductSheet maps the data rows of a file to its header. Blank rows
+// are skipped; line numbers still count them.
+func parseProductSheet(rows [][]string) ([]productSheetRow, error) {
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	known := make(map[string]bool, len(ProductSheetColumns))
+	for _, col := range ProductSheetColumns {
+		known[col] = true
+	}
+	header := make([]string, len(rows[0]))
+	seen := make(map[string]bool, len(rows[0]))
+	for i, cell := range rows[0] {
+		col := strings.ToLower(strings.TrimSpace(cell))
+		if col == "" {
+			continue
+		}
+		if !known[col] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrImportInvalidFile, cell)
+		}
+		if seen[col] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrImportInvalidFile, cell)
+		}
+		seen[col] = true
+		header[i] = col
+	}
+	for _, col := range productSheetRequired {
+		if !seen[col] {
+			return nil, fmt.Errorf("%w: missing column %q", ErrImportInvalidFile, col)
+		}
+	}
+
+	var result []productSheetRow
+	for i, row := range rows[1:] {
+		cells := make(map[string]string)
+		for j, value := range row {
+			value = strings.TrimSpace(value)
+			if j < len(header) && header[j] != "" && value != "" {
+				cells[header[j]] = value
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if len(result) == productImportMaxRows {
+			return nil, fmt.Errorf("%w: more than %d", ErrImportTooManyRows, productImportMaxRows)
+		}
+		result = append(result, productSheetRow{line: i + 2, cells: cells})
+	}
+	if len(result) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return result, nil
+}
+
+// plan validates every row against the current catalog
+func (s *ProductImportService) plan(rows []productSheetRow) ([]productImportPlan, error) {
+	plans := make([]productImportPlan, len(rows))
+	slugs := make([]string, 0, len(rows))
+	for i, row := range rows {
+		plans[i] = s.parseRow(row)
+		if plans[i].req.Slug != "" {
+			slugs = append(slugs, plans[i].req.Slug)
+		}
+	}
+
+	existing, err := s.products.productRepo.FindBySlugs(slugs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products: %w", err)
+	}
+	bySlug := make(map[string]*models.Product, len(existing))
+	for i := range existing {
+		bySlug[existing[i].Slug] = &existing[i]
+	}
+	categories := make(map[string]*models.Category)
+	firstLine := make(map[string]int, len(rows))
+
+	for i := range plans {
+		plan := &plans[i]
+		if slug := plan.req.Slug; slug != "" {
+			if line, dup := firstLine[slug]; dup {
+				plan.errors = append(plan.errors, fmt.Sprintf("slug: trùng với dòng %d", line))
+			} else {
+				firstLine[slug] = plan.line
+			}
+			if p := bySlug[slug]; p != nil {
+				if p.DeletedAt.Valid {
+					plan.errors = append(plan.errors, "slug: thuộc về một sản phẩm đã xoá")
+				} else {
+					plan.existing = p
+				}
+			}
+		}
+		// Empty optional cells keep the product's values, which also
+		// lets them pass validation
+		if p := plan.existing; p != nil {
+			if !plan.hasStock {
+				plan.req.Stock = p.Stock
+			}
+			if !plan.hasThreshold {
+				plan.req.LowStockThreshold = p.LowStockThreshold
+			}
+			if percent, large := LargePriceChange(p.Price, plan.req.Price, s.priceConfirmPercent); large {
+				plan.priceChange = &dto.ProductImportPriceChange{Old: p.Price, New: plan.req.Price, Percent: percent}
+			}
+		}
+
+		if catSlug := rows[i].cells["category_slug"]; catSlug != "" {
+			cat, ok := categories[catSlug]
+			if !ok {
+				cat, err = s.products.categoryRepo.FindBySlug(catSlug)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fmt.Errorf("failed to find category: %w", err)
+				}
+				categories[catSlug] = cat
+			}
+			if cat == nil {
+				plan.errors = append(plan.errors, fmt.Sprintf("category_slug: không có danh mục %q", catSlug))
+			} else {
+				plan.req.CategoryID = cat.ID
+			}
+		}
+
+		if err := s.validate.Struct(&plan.req); err != nil {
+			var ve validator.ValidationErrors
+			if !errors.As(err, &ve) {
+				return nil, err
+			}
+			for _, fe := range ve {
+				if fe.Field() == "category_slug" && rows[i].cells["category_slug"] != "" {
+					continue // already reported as unknown
+				}
+				if !plan.reported(fe.Field()) {
+					plan.errors = append(plan.errors, fe.Field()+": "+validationMessage(fe))
+				}
+			}
+		}
+	}
+	return plans, nil
+}
+
+// parseRow reads the cells of a row into a request; cells that are not
+// numbers are reported here and skipped by validation
+func (s *ProductImportService) parseRow(row productSheetRow) productImportPlan {
+	cells := row.cells
+	plan := productImportPlan{line: row.line}
+	plan.req = dto.CreateProductRequest{
+		Name:        cells["name"],
+		Description: cells["description"],
+		Classify:    cells["classify"],
+		Status:      cells["status"],
+	}
+
+	slugSrc := cells["slug"]
+	if slugSrc == "" {
+		slugSrc = cells["name"]
+	}
+	plan.req.Slug = s.products.generateSlug(slugSrc)
+	if plan.req.Slug == "" && slugSrc != "" {
+		plan.errors = append(plan.errors, "slug: không tạo được slug")
+	}
+
+	if v := cells["price"]; v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			plan.errors = append(plan.errors, "price: phải là số")
+		}
+		plan.req.Price = price
+	}
+	intCell := func(col string, dst *int) bool {
+		v := cells[col]
+		if v == "" {
+			return false
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			plan.errors = append(plan.errors, col+": phải là số nguyên")
+		}
+		*dst = n
+		return true
+	}
+	plan.hasStock = intCell("stock", &plan.req.Stock)
+	plan.hasThreshold = intCell("low_stock_threshold", &plan.req.LowStockThreshold)
+	plan.hasStatus = plan.req.Status != ""
+	plan.hasDescription = plan.req.Description != ""
+
+	if v := cells["image_urls"]; v != "" {
+		plan.hasImages = true
+		for _, u := range strings.Split(v, productImageSeparator) {
+			u = strings.TrimSpace(u)
+			if u == "" {
+				continue
+			}
+			if !isImportableImageURL(u) {
+				plan.errors = append(plan.errors, fmt.Sprintf("image_urls: %q không phải URL hợp lệ", u))
+				continue
+			}
+			plan.imageURLs = append(plan.imageURLs, u)
+		}
+		if len(plan.imageURLs) > productImportMaxImages {
+			plan.errors = append(plan.errors, fmt.Sprintf("image_urls: tối đa %d ảnh", productImportMaxImages))
+		}
+	}
+	return plan
+}
+
+// reported tells whether a parse error was already recorded for a column
+func (p *productImportPlan) reported(column string) bool {
+	for _, e := range p.errors {
+		if strings.HasPrefix(e, column+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// isImportableImageURL accepts absolute http(s) URLs and paths on this site
+func isImportableImageURL(raw string) bool {
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validationMessage(fe validator.FieldError) string {
+	isText := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "bắt buộc"
+	case "min":
+		if isText {
+			return fmt.Sprintf("ít nhất %s ký tự", fe.Param())
+		}
+		return fmt.Sprintf("không được nhỏ hơn %s", fe.Param())
+	case "max":
+		if isText {
+			return fmt.Sprintf("tối đa %s ký tự", fe.Param())
+		}
+		return fmt.Sprintf("không được lớn hơn %s", fe.Param())
+	case "oneof":
+		return "phải là một trong: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+	return "không hợp lệ (" + fe.Tag() + ")"
+}
+
+func newImportID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate import id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
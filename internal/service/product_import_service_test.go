@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/spreadsheet"
+	"gorm.io/gorm"
+)
+
+func importCSV(t *testing.T, rows ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var records [][]string
+	for _, row := range rows {
+		records = append(records, strings.Split(row, ";"))
+	}
+	if err := spreadsheet.Write(&buf, spreadsheet.FormatCSV, records); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProductImportService_PreviewApplyAndExport(t *testing.T) {
+	t.Parallel()
+
+	_, db, _ := setupOrderServiceTest(t)
+	productRepo := repository.NewProductRepository(db)
+	listener := &countingListener{}
+	products := NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil, listener)
+	svc := NewProductImportService(products, 0)
+
+	header := "Name;category_slug;classify;price;stock;slug;image_urls"
+	preview, err := svc.Preview(importCSV(t,
+		header,
+		"Pho Bo Dac Biet;foods;food;55000;4;pho-bo;",
+		"Tra Da;foods;drink;5000;7;;https://cdn.example.com/tra.jpg|/uploads/tra-2.jpg",
+		";;;;;;",
+		"Banh Mi;bakery;food;abc;1;;",
+		"Tra Da Chanh;foods;snack;8000;-1;tra-da;ftp://example.com/x.jpg",
+	), spreadsheet.FormatCSV)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if preview.Token != "" || preview.Creates != 1 || preview.Updates != 1 || preview.Invalid != 2 {
+		t.Fatalf("preview = %+v, want 1 create, 1 update, 2 invalid and no token", preview)
+	}
+	if row := preview.Rows[0]; row.Line != 2 || row.Action != ProductImportUpdate || row.Slug != "pho-bo" {
+		t.Fatalf("update row = %+v", row)
+	}
+	// The blank row is skipped but still counted in line numbers
+	wantErrors := map[int][]string{
+		5: {"price: phải là số", `category_slug: không có danh mục "bakery"`},
+		6: {`image_urls: "ftp://example.com/x.jpg" không phải URL hợp lệ`, "slug: trùng với dòng 3", "classify: phải là một trong: food, drink", "stock: không được nhỏ hơn 0"},
+	}
+	for _, row := range preview.Rows[2:] {
+		if strings.Join(row.Errors, "\n") != strings.Join(wantErrors[row.Line], "\n") {
+			t.Fatalf("line %d errors = %q, want %q", row.Line, row.Errors, wantErrors[row.Line])
+		}
+	}
+
+	preview, err = svc.Preview(importCSV(t,
+		header,
+		"Pho Bo Dac Biet;foods;food;55000;4;pho-bo;",
+		"Tra Da;foods;drink;5000;7;;https://cdn.example.com/tra.jpg|/uploads/tra-2.jpg",
+	), spreadsheet.FormatCSV)
+	if err != nil || preview.Token == "" {
+		t.Fatalf("Preview of valid file = %+v, %v", preview, err)
+	}
+	job, err := svc.Apply(0, preview.Token, false)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if job.Status != ProductImportDone || job.Processed != 2 || job.Created != 1 || job.Updated != 1 {
+		t.Fatalf("job = %+v", job)
+	}
+	if _, err := svc.Apply(0, preview.Token, false); !errors.Is(err, ErrImportNotFound) {
+		t.Fatalf("second Apply error = %v, want ErrImportNotFound", err)
+	}
+	if listener.calls != 1 {
+		t.Fatalf("listener calls = %d, want 1", listener.calls)
+	}
+
+	pho, err := productRepo.FindBySlug("pho-bo")
+	if err != nil {
+		t.Fatalf("find pho-bo: %v", err)
+	}
+	if pho.Name != "Pho Bo Dac Biet" || pho.Price != 55000 || pho.Stock != 4 || pho.Status != models.ProductStatusActive {
+		t.Fatalf("updated product = %+v", pho)
+	}
+	tra, err := productRepo.FindBySlug("tra-da")
+	if err != nil {
+		t.Fatalf("find tra-da: %v", err)
+	}
+	if tra.Stock != 7 || len(tra.Images) != 2 || !tra.Images[0].IsPrimary || tra.Images[1].ImageURL != "/uploads/tra-2.jpg" {
+		t.Fatalf("created product = %+v", tra)
+	}
+	var movements []models.StockMovement
+	if err := db.Order("product_id ASC").Find(&movements).Error; err != nil {
+		t.Fatalf("query movements: %v", err)
+	}
+	if len(movements) != 2 || movements[0].Delta != -6 || movements[0].Reason != models.StockReasonAdjustment ||
+		movements[1].Delta != 7 || movements[1].Reason != models.StockReasonRestock {
+		t.Fatalf("movements = %+v", movements)
+	}
+
+	rows, err := svc.Export(&dto.ProductListRequest{Classify: models.ClassifyDrink})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	want := []string{"tra-da", "Tra Da", "foods", "drink", "5000", "7", "active", "0", "", "https://cdn.example.com/tra.jpg|/uploads/tra-2.jpg"}
+	if len(rows) != 2 || strings.Join(rows[0], ",") != strings.Join(ProductSheetColumns, ",") || strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Fatalf("export = %q", rows)
+	}
+
+	// An exported file imports back unchanged, here in the background
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, spreadsheet.FormatXLSX, rows); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	svc.backgroundRows = 0
+	preview, err = svc.Preview(buf.Bytes(), spreadsheet.FormatXLSX)
+	if err != nil || preview.Token == "" || preview.Updates != 1 {
+		t.Fatalf("Preview of export = %+v, %v", preview, err)
+	}
+	job, err = svc.Apply(0, preview.Token, false)
+	if err != nil {
+		t.Fatalf("Apply in background: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == ProductImportRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = svc.GetJob(job.ID); err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+	}
+	if job.Status != ProductImportDone || job.Updated != 1 || job.Processed != job.Total {
+		t.Fatalf("background job = %+v", job)
+	}
+	if got := productStock(t, db, tra.ID); got != 7 {
+		t.Fatalf("stock after re-import = %d, want 7", got)
+	}
+
+	if _, err := svc.Preview(importCSV(t, "name;price;colour"), spreadsheet.FormatCSV); !errors.Is(err, ErrImportInvalidFile) {
+		t.Fatalf("Preview with unknown column error = %v, want ErrImportInvalidFile", err)
+	}
+}
+
+func TestProductImportService_RunRereadsProducts(t *testing.T) {
+	t.Parallel()
+
+	_, db, _ := setupOrderServiceTest(t)
+	productRepo := repository.NewProductRepository(db)
+	svc := NewProductImportService(NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil), 0)
+	pho, err := productRepo.FindBySlug("pho-bo")
+	if err != nil {
+		t.Fatalf("find pho-bo: %v", err)
+	}
+
+	planFile := func() []productImportPlan {
+		t.Helper()
+		rows, err := spreadsheet.Read(importCSV(t, "slug;name;category_slug;classify;price;stock", "pho-bo;Pho Bo Tai;foods;food;52000;8"), spreadsheet.FormatCSV)
+		if err != nil {
+			t.Fatalf("read csv: %v", err)
+		}
+		sheetRows, err := parseProductSheet(rows)
+		if err != nil {
+			t.Fatalf("parse sheet: %v", err)
+		}
+		plans, err := svc.plan(sheetRows)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		return plans
+	}
+
+	// An order and an admin edit land between planning and a background run
+	plans := planFile()
+	if ok, err := productRepo.ApplyStockMovement(&models.StockMovement{ProductID: pho.ID, Delta: -3, Reason: models.StockReasonOrder}); err != nil || !ok {
+		t.Fatalf("ApplyStockMovement = %v, %v", ok, err)
+	}
+	db.Model(&models.Product{}).Where("id = ?", pho.ID).Update("low_stock_threshold", 5)
+
+	job := &dto.ProductImportJob{ID: "fresh", Status: ProductImportRunning, Total: len(plans)}
+	svc.run(0, job, plans)
+	if job.Status != ProductImportDone {
+		t.Fatalf("job = %+v", job)
+	}
+	var saved models.Product
+	db.First(&saved, pho.ID)
+	if saved.Stock != 8 || saved.LowStockThreshold != 5 || saved.Name != "Pho Bo Tai" {
+		t.Fatalf("product = stock %d, threshold %d, name %q; want 8, 5 and the imported name", saved.Stock, saved.LowStockThreshold, saved.Name)
+	}
+	var adjustment models.StockMovement
+	db.Where("product_id = ? AND reason = ?", pho.ID, models.StockReasonAdjustment).Last(&adjustment)
+	if adjustment.Delta != 1 {
+		t.Fatalf("adjustment delta = %d, want 1 from the stock left after the order", adjustment.Delta)
+	}
+
+	// A panic while saving fails the job and leaves the catalog alone
+	plans = planFile()
+	if err := db.Callback().Update().Before("gorm:update").Register("test:panic", func(*gorm.DB) { panic("boom") }); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	job = &dto.ProductImportJob{ID: "panic", Status: ProductImportRunning, Total: len(plans)}
+	svc.run(0, job, plans)
+	if job.Status != ProductImportFailed || !strings.Contains(job.Error, "boom") || job.FinishedAt == nil {
+		t.Fatalf("panicking job = %+v", job)
+	}
+}
+
+func TestProductImportService_RunInBatches(t *testing.T) {
+	t.Parallel()
+
+	_, db, _ := setupOrderServiceTest(t)
+	productRepo := repository.NewProductRepository(db)
+	svc := NewProductImportService(NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil), 0)
+	svc.batchRows = 1
+
+	planFile := func() []productImportPlan {
+		t.Helper()
+		rows, err := spreadsheet.Read(importCSV(t, "slug;name;category_slug;classify;price;stock", "tra-da;Tra Da;foods;drink;5000;20", "pho-bo;Pho Bo;foods;food;50000;8"), spreadsheet.FormatCSV)
+		if err != nil {
+			t.Fatalf("read csv: %v", err)
+		}
+		sheetRows, err := parseProductSheet(rows)
+		if err != nil {
+			t.Fatalf("parse sheet: %v", err)
+		}
+		plans, err := svc.plan(sheetRows)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		return plans
+	}
+
+	plans := planFile()
+	job := &dto.ProductImportJob{ID: "batches", Status: ProductImportRunning, Total: len(plans)}
+	svc.run(0, job, plans)
+	if job.Status != ProductImportDone || job.Created != 1 || job.Updated != 1 || job.Processed != 2 {
+		t.Fatalf("job = %+v, want 1 created and 1 updated", job)
+	}
+
+	// A failing batch is rolled back, the batches before it are kept
+	plans = planFile()
+	db.Where("slug = ?", "pho-bo").Delete(&models.Product{})
+	job = &dto.ProductImportJob{ID: "partial", Status: ProductImportRunning, Total: len(plans)}
+	svc.run(0, job, plans)
+	if job.Status != ProductImportFailed || !strings.Contains(job.Error, "line 3") || job.Updated != 1 || job.Processed != 1 {
+		t.Fatalf("partial job = %+v, want a failure on line 3 after 1 saved row", job)
+	}
+}
+
+func TestProductImportService_PriceChangesAndRevisions(t *testing.T) {
+	t.Parallel()
+
+	_, db, _ := setupOrderServiceTest(t)
+	productRepo := repository.NewProductRepository(db)
+	svc := NewProductImportService(NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "", "", nil, nil, nil, nil, nil, nil), 50)
+	admin := models.User{Email: "import-admin@example.com", FullName: "Import Admin", Role: models.RoleAdmin, Status: models.UserStatusActive}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatalf("seed admin: %v", err)
+	}
+
+	// 50000 to 90000 is an 80% change; the new drink has no old price
+	preview, err := svc.Preview(importCSV(t,
+		"slug;name;category_slug;classify;price",
+		"pho-bo;Pho Bo;foods;food;90000",
+		"tra-da;Tra Da;foods;drink;5000",
+	), spreadsheet.FormatCSV)
+	if err != nil || preview.Token == "" {
+		t.Fatalf("Preview = %+v, %v", preview, err)
+	}
+	change := preview.Rows[0].PriceChange
+	if preview.PriceChanges != 1 || preview.PriceThreshold != 50 || change == nil || change.Old != 50000 || change.New != 90000 || change.Percent != 80 {
+		t.Fatalf("preview = %+v, price change = %+v", preview, change)
+	}
+	if preview.Rows[1].PriceChange != nil {
+		t.Fatalf("created row price change = %+v, want none", preview.Rows[1].PriceChange)
+	}
+
+	// Unconfirmed, nothing is saved and the preview can still be applied
+	if _, err := svc.Apply(admin.ID, preview.Token, false); !errors.Is(err, ErrImportPriceUnconfirmed) {
+		t.Fatalf("unconfirmed Apply error = %v, want ErrImportPriceUnconfirmed", err)
+	}
+	pho, err := productRepo.FindBySlug("pho-bo")
+	if err != nil || pho.Price != 50000 {
+		t.Fatalf("pho-bo after unconfirmed apply = %+v, %v", pho, err)
+	}
+	job, err := svc.Apply(admin.ID, preview.Token, true)
+	if err != nil || job.Status != ProductImportDone {
+		t.Fatalf("confirmed Apply = %+v, %v", job, err)
+	}
+
+	// Only the update is in the history, as made by the admin
+	var revisions []models.ProductRevision
+	if err := db.Find(&revisions).Error; err != nil {
+		t.Fatalf("query revisions: %v", err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("revisions = %+v, want one", revisions)
+	}
+	rev := revisions[0]
+	if rev.ProductID != pho.ID || rev.CreatedBy == nil || *rev.CreatedBy != admin.ID || rev.Before.Price != 50000 || rev.After.Price != 90000 {
+		t.Fatalf("revision = %+v", rev)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Fields compared between product snapshots, in display order
+const (
+	ProductFieldCategory          = "category"
+	ProductFieldName              = "name"
+	ProductFieldSlug              = "slug"
+	ProductFieldDescription       = "description"
+	ProductFieldClassify          = "classify"
+	ProductFieldPrice             = "price"
+	ProductFieldStatus            = "status"
+	ProductFieldLowStockThreshold = "low_stock_threshold"
+	ProductFieldCalories          = "calories"
+	ProductFieldSugarGrams        = "sugar_grams"
+	ProductFieldCaffeineMg        = "caffeine_mg"
+	ProductFieldAllergens         = "allergens"
+	ProductFieldDietaryTags       = "dietary_tags"
+)
+
+// History returns a product's revisions, newest first
+func (s *ProductService) History(productID uint) ([]dto.ProductRevisionResponse, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	revisions, err := s.productRepo.ListRevisions(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	categories, err := s.categoryRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	items := make([]dto.ProductRevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		items[i] = dto.ProductRevisionResponse{
+			ID:         rev.ID,
+			ProductID:  rev.ProductID,
+			CreatedAt:  rev.CreatedAt,
+			RollbackOf: rev.RollbackOf,
+			Changes:    productFieldChanges(rev.Before, rev.After, names),
+		}
+		if rev.Admin != nil {
+			items[i].AdminName = rev.Admin.FullName
+		}
+	}
+	return items, nil
+}
+
+// Rollback puts back the values a revision replaced. The rollback is saved
+// as a new revision, so it can itself be rolled back.
+func (s *ProductService) Rollback(adminID, productID, revisionID uint) (*dto.ProductResponse, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	rev, err := s.productRepo.FindRevision(productID, revisionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to find revision: %w", err)
+	}
+
+	old := rev.Before
+	if _, err := s.categoryRepo.FindByID(old.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductCategoryDeleted
+		}
+		return nil, fmt.Errorf("failed to find category: %w", err)
+	}
+	description := ""
+	if old.Description != nil {
+		description = *old.Description
+	}
+	req := &dto.UpdateProductRequest{
+		CategoryID:        &old.CategoryID,
+		Name:              &old.Name,
+		Slug:              &old.Slug,
+		Description:       &description,
+		Classify:          &old.Classify,
+		Price:             &old.Price,
+		Status:            &old.Status,
+		LowStockThreshold: &old.LowStockThreshold,
+	}
+	// Revisions from before dietary info was recorded hold none, so it is
+	// only put back where this revision changed it
+	for _, change := range productFieldChanges(old, rev.After, nil) {
+		switch change.Field {
+		case ProductFieldCalories, ProductFieldSugarGrams, ProductFieldCaffeineMg:
+			req.Nutrition = &dto.NutritionFacts{Calories: old.Calories, SugarGrams: old.SugarGrams, CaffeineMg: old.CaffeineMg}
+		case ProductFieldAllergens:
+			req.Allergens = append([]string{}, old.Allergens...)
+		case ProductFieldDietaryTags:
+			req.DietaryTags = append([]string{}, old.DietaryTags...)
+		}
+	}
+	return s.update(adminID, productID, req, nil, false, &rev.ID)
+}
+
+// recordProductRevision adds a revision when the snapshots differ. A zero
+// adminID leaves the author unknown.
+func recordProductRevision(repo *repository.ProductRepository, productID uint, before, after models.ProductSnapshot, adminID uint, rollbackOf *uint) error {
+	if len(productFieldChanges(before, after, nil)) == 0 {
+		return nil
+	}
+	rev := &models.ProductRevision{ProductID: productID, Before: before, After: after, RollbackOf: rollbackOf}
+	if adminID != 0 {
+		rev.CreatedBy = &adminID
+	}
+	return repo.CreateRevision(rev)
+}
+
+// productFieldChanges lists the fields that differ between two snapshots.
+// Categories are shown by name when categoryNames has them.
+func productFieldChanges(before, after models.ProductSnapshot, categoryNames map[uint]string) []dto.ProductFieldChange {
+	var changes []dto.ProductFieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, dto.ProductFieldChange{Field: field, Before: old, After: new})
+		}
+	}
+	category := func(id uint) string {
+		if name, ok := categoryNames[id]; ok {
+			return name
+		}
+		return "#" + strconv.FormatUint(uint64(id), 10)
+	}
+	description := func(d *string) string {
+		if d == nil {
+			return ""
+		}
+		return *d
+	}
+	price := func(p float64) string {
+		return strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	optionalInt := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	optionalFloat := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return price(*v)
+	}
+
+	if before.CategoryID != after.CategoryID {
+		add(ProductFieldCategory, category(before.CategoryID), category(after.CategoryID))
+	}
+	add(ProductFieldName, before.Name, after.Name)
+	add(ProductFieldSlug, before.Slug, after.Slug)
+	add(ProductFieldDescription, description(before.Description), description(after.Description))
+	add(ProductFieldClassify, before.Classify, after.Classify)
+	add(ProductFieldPrice, price(before.Price), price(after.Price))
+	add(ProductFieldStatus, before.Status, after.Status)
+	add(ProductFieldLowStockThreshold, strconv.Itoa(before.LowStockThreshold), strconv.Itoa(after.LowStockThreshold))
+	add(ProductFieldCalories, optionalInt(before.Calories), optionalInt(after.Calories))
+	add(ProductFieldSugarGrams, optionalFloat(before.SugarGrams), optionalFloat(after.SugarGrams))
+	add(ProductFieldCaffeineMg, optionalInt(before.CaffeineMg), optionalInt(after.CaffeineMg))
+	add(ProductFieldAllergens, strings.Join(before.Allergens, ", "), strings.Join(after.Allergens, ", "))
+	add(ProductFieldDietaryTags, strings.Join(before.DietaryTags, ", "), strings.Join(after.DietaryTags, ", "))
+	return changes
+}
@@ -3,14 +3,19 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
 	"math"
+	"mime/multipart"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kha/foods-drinks/internal/dto"
 	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
 	"gorm.io/gorm"
 )
 
@@ -18,8 +23,45 @@ var (
 	ErrProductNotFound   = errors.New("product not found")
 	ErrProductSlugExists = errors.New("slug already exists")
 	ErrProductEmptySlug  = errors.New("slug cannot be empty after generation")
+
+	ErrProductImageNotFound = errors.New("product image not found")
+	ErrInvalidImageOrder    = errors.New("image order must list every image of the product exactly once")
+
+	ErrSearchIndexUnavailable = errors.New("search index is not configured")
+
+	ErrInvalidBundle = errors.New("invalid bundle")
+
+	ErrProductCategoryDeleted = errors.New("product's category is deleted; restore it or move the product first")
+	ErrProductHasOrders       = errors.New("product appears in orders and cannot be purged")
+
+	ErrProductRevisionNotFound = errors.New("product revision not found")
 )
 
+// maxBundleComponentQuantity caps how many units of one component a bundle holds
+const maxBundleComponentQuantity = 99
+
+// ProductSortRelevance orders search results by how well they match
+const ProductSortRelevance = "relevance"
+
+// productPriceFacetBounds split prices (VND) into the ranges counted by the
+// price facet: under 20k, 20k-50k, 50k-100k, 100k-200k and 200k or more
+var productPriceFacetBounds = []float64{20000, 50000, 100000, 200000}
+
+// SearchIndex finds products by free text. search.InvertedIndex is the
+// built-in implementation.
+type SearchIndex interface {
+	Upsert(doc search.Document)
+	Remove(id uint)
+	Replace(docs []search.Document)
+	Search(query string) []uint
+}
+
+// CatalogListener is told when products or categories change so that data
+// derived from the catalog can be refreshed
+type CatalogListener interface {
+	CatalogChanged()
+}
+
 var (
 	productSlugNonAlnum  = regexp.MustCompile(`[^a-z0-9\-]+`)
 	productSlugMultiHyph = regexp.MustCompile(`-{2,}`)
@@ -28,13 +70,23 @@ var (
 type ProductService struct {
 	productRepo  *repository.ProductRepository
 	categoryRepo *repository.CategoryRepository
+	uploads      *UploadService
+	index        SearchIndex
+	cursors      *CursorCodec
+	availability *AvailabilityService
+	stockAlerts  *StockAlertService
+	pricing      *PricingService
+	translations *TranslationService
+	listeners    []CatalogListener
 	baseURL      string
+	apiURL       string
 }
 
-func NewProductService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, baseURL string) *ProductService {
+func NewProductService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, uploads *UploadService, baseURL, apiURL string, cursors *CursorCodec, index SearchIndex, availability *AvailabilityService, stockAlerts *StockAlertService, pricing *PricingService, translations *TranslationService, listeners ...CatalogListener) *ProductService {
 	baseURL = strings.TrimSpace(baseURL)
 	baseURL = strings.TrimRight(baseURL, "/")
-	return &ProductService{productRepo: productRepo, categoryRepo: categoryRepo, baseURL: baseURL}
+	apiURL = strings.TrimRight(strings.TrimSpace(apiURL), "/")
+	return &ProductService{productRepo: productRepo, categoryRepo: categoryRepo, uploads: uploads, index: index, cursors: cursors, availability: availability, stockAlerts: stockAlerts, pricing: pricing, translations: translations, listeners: listeners, baseURL: baseURL, apiURL: apiURL}
 }
 
 func (s *ProductService) Create(req *dto.CreateProductRequest, imageURLs []string) (*dto.ProductResponse, error) {
@@ -50,6 +102,10 @@ func (s *ProductService) Create(req *dto.CreateProductRequest, imageURLs []strin
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate unique slug: %w", err)
 	}
+	translations, err := s.translations.validate(models.TranslationEntityProduct, req.Translations)
+	if err != nil {
+		return nil, err
+	}
 
 	status := models.ProductStatusActive
 	if req.Status != "" {
@@ -64,15 +120,41 @@ func (s *ProductService) Create(req *dto.CreateProductRequest, imageURLs []strin
 		Price:      req.Price,
 		Stock:      req.Stock,
 		Status:     status,
+
+		LowStockThreshold: req.LowStockThreshold,
 	}
 	if strings.TrimSpace(req.Description) != "" {
 		d := strings.TrimSpace(req.Description)
 		product.Description = &d
 	}
+	if err := applyDietaryInfo(product, req.Nutrition, req.Allergens, req.DietaryTags); err != nil {
+		return nil, err
+	}
 
-	if err := s.productRepo.Create(product); err != nil {
+	// The initial stock enters through the ledger like any later delivery
+	initial := &models.StockMovement{Delta: product.Stock, Reason: models.StockReasonRestock, Note: stockNote("Tồn kho ban đầu")}
+	product.Stock = 0
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		if err := repo.Create(product); err != nil {
+			return err
+		}
+		initial.ProductID = product.ID
+		if err := applyStockMovement(repo, initial); err != nil {
+			return err
+		}
+		// A product created without stock is not put on sale
+		if err := repo.SyncStockStatus([]uint{product.ID}); err != nil {
+			return err
+		}
+		return s.translations.withTx(tx).save(models.TranslationEntityProduct, product.ID, translations)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
+	product.Stock = initial.Delta
+	s.indexProduct(product)
+	s.stockAlerts.StockChanged([]uint{product.ID})
 
 	for i, url := range imageURLs {
 		url = strings.TrimSpace(url)
@@ -112,18 +194,216 @@ func (s *ProductService) GetBySlug(slug string) (*dto.ProductResponse, error) {
 		}
 		return nil, fmt.Errorf("failed to find product: %w", err)
 	}
-	return s.toResponse(p), nil
+	return s.toAvailableResponse(p)
 }
 
-func (s *ProductService) Update(id uint, req *dto.UpdateProductRequest, imageURLs []string, replaceImages bool) (*dto.ProductResponse, error) {
-	p, err := s.productRepo.FindByID(id)
+// CatalogVersion identifies the data behind a public catalog response, for
+// building HTTP validators
+type CatalogVersion struct {
+	Tag          string
+	LastModified time.Time
+}
+
+// ListVersion returns the version of the whole catalog. Any product or
+// category change gives a new tag, since it can move rows between pages.
+func (s *ProductService) ListVersion() (*CatalogVersion, error) {
+	stamp, err := s.productRepo.CatalogStamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog version: %w", err)
+	}
+	transition, err := s.ScheduleEpoch()
+	if err != nil {
+		return nil, err
+	}
+	lastModified := latest(stamp.ProductUpdatedAt, stamp.CategoryUpdatedAt, transition)
+	return &CatalogVersion{
+		Tag: fmt.Sprintf("p%d.%d.%d-c%d.%d.%d-a%d",
+			stamp.ProductCount, stamp.ProductMaxID, stamp.ProductUpdatedAt.UnixNano(),
+			stamp.CategoryCount, stamp.CategoryMaxID, stamp.CategoryUpdatedAt.UnixNano(),
+			transition.Unix()),
+		LastModified: lastModified,
+	}, nil
+}
+
+// ScheduleEpoch is the last time any product opened or closed, or a sale
+// started or stopped. It belongs in cache keys and validators of responses
+// carrying available_now or prices.
+func (s *ProductService) ScheduleEpoch() (time.Time, error) {
+	opened, err := s.availability.LastTransition()
+	if err != nil {
+		return time.Time{}, err
+	}
+	priced, err := s.pricing.LastTransition()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return latest(opened, priced), nil
+}
+
+// GetBySlugWithVersion is GetBySlug in locale plus the version of the
+// product, its images and its category
+func (s *ProductService) GetBySlugWithVersion(slug, locale string) (*dto.ProductResponse, *CatalogVersion, error) {
+	p, err := s.productRepo.FindBySlug(slug)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrProductNotFound
+			return nil, nil, ErrProductNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	var tag strings.Builder
+	fmt.Fprintf(&tag, "p%d.%d", p.ID, p.UpdatedAt.UnixNano())
+	lastModified := p.UpdatedAt
+	// Image changes do not touch the product row
+	for _, img := range p.Images {
+		fmt.Fprintf(&tag, "-i%d.%d.%t", img.ID, img.SortOrder, img.IsPrimary)
+	}
+	// The breadcrumbs show every ancestor of the category
+	tree, err := s.categoryTree()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range tree.path(p.CategoryID) {
+		fmt.Fprintf(&tag, "-c%d.%d", c.ID, c.UpdatedAt.UnixNano())
+		lastModified = latest(lastModified, c.UpdatedAt)
+	}
+	transition, err := s.ScheduleEpoch()
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(&tag, "-a%d", transition.Unix())
+	lastModified = latest(lastModified, transition)
+
+	resp, err := s.toAvailableResponse(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	localized := []dto.ProductResponse{*resp}
+	if err := s.translations.localizeProducts(locale, localized); err != nil {
+		return nil, nil, err
+	}
+	return &localized[0], &CatalogVersion{Tag: tag.String(), LastModified: lastModified}, nil
+}
+
+func latest(times ...time.Time) time.Time {
+	var max time.Time
+	for _, t := range times {
+		if t.After(max) {
+			max = t
+		}
+	}
+	return max
+}
+
+// DefaultPriceConfirmPercent is the price change, in percent of the old
+// price, above which an edit has to be confirmed when none is configured
+const DefaultPriceConfirmPercent = 50
+
+// LargePriceChange returns a change from oldPrice to newPrice in percent of
+// oldPrice and whether it is above threshold. Setting the first price of a
+// free product is never large.
+func LargePriceChange(oldPrice, newPrice, threshold float64) (float64, bool) {
+	if oldPrice <= 0 || newPrice == oldPrice {
+		return 0, false
+	}
+	percent := math.Abs(newPrice-oldPrice) / oldPrice * 100
+	return percent, percent > threshold
+}
+
+// Update edits a product and adds the change to its revision history as
+// made by adminID
+func (s *ProductService) Update(adminID, id uint, req *dto.UpdateProductRequest, imageURLs []string, replaceImages bool) (*dto.ProductResponse, error) {
+	return s.update(adminID, id, req, imageURLs, replaceImages, nil)
+}
+
+func (s *ProductService) update(adminID, id uint, req *dto.UpdateProductRequest, imageURLs []string, replaceImages bool, rollbackOf *uint) (*dto.ProductResponse, error) {
+	translations, err := s.translations.validate(models.TranslationEntityProduct, req.Translations)
+	if err != nil {
+		return nil, err
+	}
+
+	var p *models.Product
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		// Locked so that the stock adjustment is computed from a value no
+		// order can change before the commit
+		locked, err := repo.FindByIDForUpdate(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("failed to find product: %w", err)
+		}
+		p = locked
+		before := p.Snapshot()
+		if err := s.applyUpdate(repo, p, req); err != nil {
+			return err
+		}
+		// A bundle's stock is derived, so an edited value is simply recomputed
+		var adjustment *models.StockMovement
+		if req.Stock != nil && !p.IsBundle && *req.Stock != p.Stock {
+			adjustment = &models.StockMovement{ProductID: id, Delta: *req.Stock - p.Stock, Reason: models.StockReasonAdjustment, Note: stockNote("Sửa sản phẩm")}
+		}
+
+		if err := repo.Update(p); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		if adjustment != nil {
+			if err := applyStockMovement(repo, adjustment); err != nil {
+				return fmt.Errorf("failed to adjust stock: %w", err)
+			}
+		}
+		// An edited status is only kept if it agrees with the stock; inactive always is
+		if err := repo.SyncStockStatus([]uint{id}); err != nil {
+			return fmt.Errorf("failed to update stock status: %w", err)
+		}
+		// Covers a bundle being recomputed and the bundles using this product
+		if err := repo.SyncBundleStock([]uint{id}); err != nil {
+			return fmt.Errorf("failed to update bundle stock: %w", err)
+		}
+		// Read back so the revision has the status the sync settled on
+		saved, err := repo.FindByIDForUpdate(id)
+		if err != nil {
+			return fmt.Errorf("failed to reload product: %w", err)
+		}
+		if err := recordProductRevision(repo, id, before, saved.Snapshot(), adminID, rollbackOf); err != nil {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+		return s.translations.withTx(tx).save(models.TranslationEntityProduct, id, translations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.indexProduct(p)
+	// The threshold may have changed as well as the stock
+	s.stockAlerts.StockChanged([]uint{id})
+
+	if replaceImages && len(imageURLs) > 0 {
+		if err := s.productRepo.DeleteImagesByProductID(id); err != nil {
+			return nil, fmt.Errorf("failed to delete old images: %w", err)
+		}
+		for i, url := range imageURLs {
+			url = strings.TrimSpace(url)
+			if url == "" {
+				continue
+			}
+			img := &models.ProductImage{
+				ProductID: id,
+				ImageURL:  url,
+				SortOrder: i,
+				IsPrimary: i == 0,
+			}
+			if err := s.productRepo.AddImage(img); err != nil {
+				return nil, fmt.Errorf("failed to save image: %w", err)
+			}
 		}
-		return nil, fmt.Errorf("failed to find product: %w", err)
 	}
 
+	return s.GetByID(id)
+}
+
+// applyUpdate copies the fields set in req onto p, checking a new slug with repo
+func (s *ProductService) applyUpdate(repo *repository.ProductRepository, p *models.Product, req *dto.UpdateProductRequest) error {
 	if req.CategoryID != nil {
 		p.CategoryID = *req.CategoryID
 	}
@@ -133,15 +413,15 @@ func (s *ProductService) Update(id uint, req *dto.UpdateProductRequest, imageURL
 	if req.Slug != nil && strings.TrimSpace(*req.Slug) != "" {
 		newSlug := s.generateSlug(*req.Slug)
 		if newSlug == "" {
-			return nil, ErrProductEmptySlug
+			return ErrProductEmptySlug
 		}
 		if newSlug != p.Slug {
-			exists, err := s.productRepo.ExistsBySlug(newSlug, id)
+			exists, err := repo.ExistsBySlug(newSlug, p.ID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to check slug: %w", err)
+				return fmt.Errorf("failed to check slug: %w", err)
 			}
 			if exists {
-				return nil, ErrProductSlugExists
+				return ErrProductSlugExists
 			}
 			p.Slug = newSlug
 		}
@@ -160,39 +440,306 @@ func (s *ProductService) Update(id uint, req *dto.UpdateProductRequest, imageURL
 	if req.Price != nil {
 		p.Price = *req.Price
 	}
-	if req.Stock != nil {
-		p.Stock = *req.Stock
-	}
 	if req.Status != nil {
 		p.Status = *req.Status
 	}
+	if req.LowStockThreshold != nil {
+		p.LowStockThreshold = *req.LowStockThreshold
+	}
+	return applyDietaryInfo(p, req.Nutrition, req.Allergens, req.DietaryTags)
+}
 
-	if err := s.productRepo.Update(p); err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+// UploadImages stores the uploaded files with their renditions and appends
+// them after the existing images. The first image becomes primary when the
+// product has none yet.
+func (s *ProductService) UploadImages(productID uint, files []*multipart.FileHeader) (*dto.ProductResponse, error) {
+	if s.uploads == nil {
+		return nil, ErrUploadUnavailable
+	}
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
 	}
 
-	if replaceImages && len(imageURLs) > 0 {
-		if err := s.productRepo.DeleteImagesByProductID(id); err != nil {
-			return nil, fmt.Errorf("failed to delete old images: %w", err)
+	images, err := s.productRepo.FindImagesByProductID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load images: %w", err)
+	}
+	nextOrder := 0
+	hasPrimary := false
+	for _, img := range images {
+		nextOrder = max(nextOrder, img.SortOrder+1)
+		hasPrimary = hasPrimary || img.IsPrimary
+	}
+
+	stored := make([]*StoredImage, 0, len(files))
+	for _, file := range files {
+		img, err := s.uploads.SaveImage(file, "products")
+		if err != nil {
+			for _, saved := range stored {
+				s.uploads.RemoveImage(saved)
+			}
+			return nil, err
 		}
-		for i, url := range imageURLs {
-			url = strings.TrimSpace(url)
-			if url == "" {
-				continue
+		stored = append(stored, img)
+	}
+
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		for i, img := range stored {
+			medium, thumb := img.MediumURL, img.ThumbnailURL
+			record := &models.ProductImage{
+				ProductID:    productID,
+				ImageURL:     img.URL,
+				MediumURL:    &medium,
+				ThumbnailURL: &thumb,
+				SortOrder:    nextOrder + i,
+				IsPrimary:    !hasPrimary && i == 0,
 			}
-			img := &models.ProductImage{
-				ProductID: id,
-				ImageURL:  url,
-				SortOrder: i,
-				IsPrimary: i == 0,
+			if err := repo.AddImage(record); err != nil {
+				return err
 			}
-			if err := s.productRepo.AddImage(img); err != nil {
-				return nil, fmt.Errorf("failed to save image: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		for _, saved := range stored {
+			s.uploads.RemoveImage(saved)
+		}
+		return nil, fmt.Errorf("failed to save images: %w", err)
+	}
+	s.notifyChanged()
+
+	return s.GetByID(productID)
+}
+
+// ReorderImages rewrites sort_order following imageIDs, which must contain
+// every image of the product exactly once.
+func (s *ProductService) ReorderImages(productID uint, imageIDs []uint) error {
+	if _, err := s.findProduct(productID); err != nil {
+		return err
+	}
+
+	images, err := s.productRepo.FindImagesByProductID(productID)
+	if err != nil {
+		return fmt.Errorf("failed to load images: %w", err)
+	}
+	if len(imageIDs) != len(images) {
+		return ErrInvalidImageOrder
+	}
+	known := make(map[uint]bool, len(images))
+	for _, img := range images {
+		known[img.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !known[id] {
+			return ErrInvalidImageOrder
+		}
+		delete(known, id)
+	}
+
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		for i, id := range imageIDs {
+			if err := repo.UpdateImageSortOrder(productID, id, i); err != nil {
+				return fmt.Errorf("failed to update image order: %w", err)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	s.notifyChanged()
+	return nil
+}
 
-	return s.GetByID(id)
+// SetPrimaryImage marks the given image as the product's primary image
+func (s *ProductService) SetPrimaryImage(productID, imageID uint) error {
+	if _, err := s.findImage(productID, imageID); err != nil {
+		return err
+	}
+	err := s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := s.productRepo.WithTx(tx).SetPrimaryImage(productID, imageID); err != nil {
+			return fmt.Errorf("failed to set primary image: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyChanged()
+	return nil
+}
+
+// DeleteImage removes an image and its uploaded files. When the primary image
+// is deleted the next image in order is promoted.
+func (s *ProductService) DeleteImage(productID, imageID uint) error {
+	img, err := s.findImage(productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		if err := repo.DeleteImage(productID, imageID); err != nil {
+			return err
+		}
+		if !img.IsPrimary {
+			return nil
+		}
+		rest, err := repo.FindImagesByProductID(productID)
+		if err != nil || len(rest) == 0 {
+			return err
+		}
+		return repo.SetPrimaryImage(productID, rest[0].ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	s.notifyChanged()
+
+	if s.uploads.IsStoredURL(img.ImageURL) {
+		s.uploads.RemoveImage(storedImageFromModel(img))
+	}
+	return nil
+}
+
+// ListBundleCandidates returns the products that can be added to the bundle
+// builder of productID: every product except bundles and the product itself
+func (s *ProductService) ListBundleCandidates(productID uint) ([]dto.ProductResponse, error) {
+	products, err := s.productRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	candidates := make([]models.Product, 0, len(products))
+	for _, p := range products {
+		if !p.IsBundle && p.ID != productID {
+			candidates = append(candidates, p)
+		}
+	}
+	return s.toResponses(candidates), nil
+}
+
+// SaveBundle replaces the components of a product. An empty list turns the
+// bundle back into a regular product with no stock. Bundles
+// cannot be nested, so neither a bundle nor a product already used as a
+// component elsewhere can take part.
+func (s *ProductService) SaveBundle(productID uint, inputs []dto.BundleItemInput) (*dto.ProductResponse, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int)
+	order := make([]uint, 0, len(inputs))
+	for _, in := range inputs {
+		if in.ProductID == 0 {
+			continue
+		}
+		if in.Quantity < 1 {
+			return nil, fmt.Errorf("%w: quantity must be at least 1", ErrInvalidBundle)
+		}
+		if in.ProductID == productID {
+			return nil, fmt.Errorf("%w: a bundle cannot contain itself", ErrInvalidBundle)
+		}
+		if _, ok := quantities[in.ProductID]; !ok {
+			order = append(order, in.ProductID)
+		}
+		quantities[in.ProductID] += in.Quantity
+		if quantities[in.ProductID] > maxBundleComponentQuantity {
+			return nil, fmt.Errorf("%w: quantity must be at most %d", ErrInvalidBundle, maxBundleComponentQuantity)
+		}
+	}
+
+	if len(order) > 0 {
+		used, err := s.productRepo.CountBundlesContaining(productID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check bundles: %w", err)
+		}
+		if used > 0 {
+			return nil, fmt.Errorf("%w: the product is a component of another bundle", ErrInvalidBundle)
+		}
+		components, err := s.productRepo.FindByIDs(order)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find components: %w", err)
+		}
+		if len(components) != len(order) {
+			return nil, fmt.Errorf("%w: component not found", ErrInvalidBundle)
+		}
+		for _, c := range components {
+			if c.IsBundle {
+				return nil, fmt.Errorf("%w: %q is itself a bundle", ErrInvalidBundle, c.Name)
+			}
+		}
+	}
+
+	items := make([]models.BundleItem, 0, len(order))
+	for _, id := range order {
+		items = append(items, models.BundleItem{ComponentID: id, Quantity: quantities[id]})
+	}
+	err = s.productRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.productRepo.WithTx(tx)
+		// The product's own stock leaves the ledger once it is derived
+		if len(items) > 0 && !product.IsBundle && product.Stock != 0 {
+			writeOff := &models.StockMovement{ProductID: productID, Delta: -product.Stock, Reason: models.StockReasonAdjustment, Note: stockNote("Chuyển thành combo")}
+			if err := applyStockMovement(repo, writeOff); err != nil {
+				return err
+			}
+		}
+		if err := repo.ReplaceBundleItems(productID, items); err != nil {
+			return err
+		}
+		if err := repo.SyncBundleStock([]uint{productID}); err != nil {
+			return err
+		}
+		// A reverted bundle is left without stock
+		return repo.SyncStockStatus([]uint{productID})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save bundle: %w", err)
+	}
+	s.notifyChanged()
+
+	return s.GetByID(productID)
+}
+
+func (s *ProductService) findProduct(id uint) (*models.Product, error) {
+	p, err := s.productRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	return p, nil
+}
+
+func (s *ProductService) findImage(productID, imageID uint) (*models.ProductImage, error) {
+	img, err := s.productRepo.FindImageByID(productID, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductImageNotFound
+		}
+		return nil, fmt.Errorf("failed to find image: %w", err)
+	}
+	return img, nil
+}
+
+func storedImageFromModel(img *models.ProductImage) *StoredImage {
+	return storedImageFromURLs(img.ImageURL, img.MediumURL, img.ThumbnailURL)
+}
+
+// storedImageFromURLs rebuilds a StoredImage from saved URLs; missing
+// renditions fall back to the original
+func storedImageFromURLs(imageURL string, mediumURL, thumbnailURL *string) *StoredImage {
+	stored := &StoredImage{URL: imageURL, MediumURL: imageURL, ThumbnailURL: imageURL}
+	if mediumURL != nil {
+		stored.MediumURL = *mediumURL
+	}
+	if thumbnailURL != nil {
+		stored.ThumbnailURL = *thumbnailURL
+	}
+	return stored
 }
 
 func (s *ProductService) Delete(id uint) error {
@@ -203,17 +750,110 @@ func (s *ProductService) Delete(id uint) error {
 		}
 		return fmt.Errorf("failed to find product: %w", err)
 	}
-	return s.productRepo.Delete(id)
+	if err := s.productRepo.Delete(id); err != nil {
+		return err
+	}
+	if err := s.productRepo.SyncBundleStock([]uint{id}); err != nil {
+		return fmt.Errorf("failed to update bundle stock: %w", err)
+	}
+	if s.index != nil {
+		s.index.Remove(id)
+	}
+	s.notifyChanged()
+	return nil
+}
+
+// ListDeleted returns the products in the trash
+func (s *ProductService) ListDeleted() ([]dto.TrashedProductResponse, error) {
+	products, err := s.productRepo.ListDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted products: %w", err)
+	}
+	items := make([]dto.TrashedProductResponse, len(products))
+	for i := range products {
+		p := &products[i]
+		items[i] = dto.TrashedProductResponse{
+			ProductResponse: *s.toResponse(p),
+			DeletedAt:       p.DeletedAt.Time,
+			CategoryDeleted: p.Category == nil || p.Category.DeletedAt.Valid,
+		}
+	}
+	return items, nil
+}
+
+// Restore takes a product out of the trash. Its category must not be deleted.
+func (s *ProductService) Restore(id uint) error {
+	p, err := s.productRepo.FindDeletedByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to find product: %w", err)
+	}
+	if _, err := s.categoryRepo.FindByID(p.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductCategoryDeleted
+		}
+		return fmt.Errorf("failed to find category: %w", err)
+	}
+	if err := s.productRepo.Restore(id); err != nil {
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+	if err := s.productRepo.SyncBundleStock([]uint{id}); err != nil {
+		return fmt.Errorf("failed to update bundle stock: %w", err)
+	}
+	s.indexProduct(p)
+	return nil
 }
 
-func (s *ProductService) List(req *dto.ProductListRequest) (*dto.PaginatedResponse, error) {
+// Purge permanently deletes a product from the trash. Products that were
+// ordered stay, so order history keeps pointing at them.
+func (s *ProductService) Purge(id uint) error {
+	if _, err := s.productRepo.FindDeletedByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to find product: %w", err)
+	}
+	ordered, err := s.productRepo.CountOrderItems(id)
+	if err != nil {
+		return fmt.Errorf("failed to count order items: %w", err)
+	}
+	if ordered > 0 {
+		return ErrProductHasOrders
+	}
+	if err := s.productRepo.Purge(id); err != nil {
+		return fmt.Errorf("failed to purge product: %w", err)
+	}
+	return s.translations.deleteAll(models.TranslationEntityProduct, id)
+}
+
+func (s *ProductService) List(req *dto.ProductListRequest) (*dto.ProductListResponse, error) {
 	offset := (req.Page - 1) * req.PageSize
 
+	category := req.Category
+	if req.CategorySlug != "" {
+		c, err := s.categoryRepo.FindBySlug(req.CategorySlug)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to find category: %w", err)
+		}
+		// An unknown slug, or one contradicting category_id, matches nothing
+		if c == nil || (category != 0 && category != c.ID) {
+			return &dto.ProductListResponse{PaginatedResponse: dto.PaginatedResponse{
+				Items:      []dto.ProductResponse{},
+				Page:       req.Page,
+				PageSize:   req.PageSize,
+				TotalPages: 1,
+			}}, nil
+		}
+		category = c.ID
+	}
+
 	params := repository.ProductListParams{
 		Offset:    offset,
 		Limit:     req.PageSize,
 		Classify:  req.Classify,
-		Category:  req.Category,
+		Category:  category,
 		MinPrice:  req.MinPrice,
 		MaxPrice:  req.MaxPrice,
 		MinRating: req.MinRating,
@@ -221,30 +861,384 @@ func (s *ProductService) List(req *dto.ProductListRequest) (*dto.PaginatedRespon
 		Search:    req.Search,
 		SortBy:    req.SortBy,
 		SortDir:   req.SortDir,
+
+		MaxCalories: req.MaxCalories,
+	}
+	if req.StartsWith != "" {
+		params.StartsWith = search.Initial(req.StartsWith)
+	}
+	var err error
+	if params.ExcludeAllergens, err = normalizeAllergens(splitDietaryFilter(req.ExcludeAllergens)); err != nil {
+		return nil, err
+	}
+	if params.Tags, err = normalizeDietaryTags(splitDietaryFilter(req.Tags)); err != nil {
+		return nil, err
+	}
+	if category != 0 {
+		tree, err := s.categoryTree()
+		if err != nil {
+			return nil, err
+		}
+		params.Subcategories = tree.descendants(category)
+	}
+
+	var ranked []uint
+	relevance := false
+	if req.Search != "" && s.index != nil {
+		ranked = s.index.Search(req.Search)
+		params.Search = ""
+		params.IDs = append([]uint{}, ranked...)
+		relevance = req.SortBy == "" || req.SortBy == ProductSortRelevance
 	}
 
-	products, total, err := s.productRepo.List(params)
+	resp := &dto.ProductListResponse{}
+	if req.Cursor != "" {
+		var products []models.Product
+		var next string
+		if products, next, err = s.listFromCursor(req, params, ranked, relevance); err != nil {
+			return nil, err
+		}
+		items, err := s.toAvailableResponses(products)
+		if err != nil {
+			return nil, err
+		}
+		resp.PaginatedResponse = dto.PaginatedResponse{
+			Items:      items,
+			PageSize:   req.PageSize,
+			NextCursor: next,
+		}
+	} else {
+		var products []models.Product
+		var total int64
+		if relevance {
+			products, total, err = s.listByRelevance(params, ranked)
+		} else {
+			products, total, err = s.productRepo.List(params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list products: %w", err)
+		}
+
+		totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		items, err := s.toAvailableResponses(products)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.PaginatedResponse = dto.PaginatedResponse{
+			Items:      items,
+			Total:      total,
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			TotalPages: totalPages,
+		}
+		if req.Page < totalPages && len(products) > 0 {
+			if relevance {
+				resp.NextCursor = s.cursors.offsetCursor(ProductSortRelevance, "desc", offset+req.PageSize)
+			} else {
+				resp.NextCursor = s.productCursor(&products[len(products)-1], req.SortBy, req.SortDir)
+			}
+		}
+	}
+	if items, ok := resp.Items.([]dto.ProductResponse); ok {
+		if err := s.translations.localizeProducts(req.Locale, items); err != nil {
+			return nil, err
+		}
+	}
+	if req.Facets {
+		if resp.Facets, err = s.buildFacets(params, req.Locale); err != nil {
+			return nil, fmt.Errorf("failed to count facets: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// buildFacets counts products per filter value. Classify, category, rating and
+// initial list every possible value so the UI can grey out empty ones.
+// Category names are given in locale.
+func (s *ProductService) buildFacets(params repository.ProductListParams, locale string) (*dto.ProductFacets, error) {
+	rows, err := s.productRepo.Facets(params, productPriceFacetBounds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list products: %w", err)
+		return nil, err
+	}
+	categories, _, err := s.categoryRepo.List(repository.CategoryListParams{Status: models.CategoryStatusActive, Limit: -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	facets := &dto.ProductFacets{}
+
+	classify := facetCountMap(rows.Classify)
+	for _, value := range []string{models.ClassifyFood, models.ClassifyDrink} {
+		facets.Classify = append(facets.Classify, dto.FacetCount{Value: value, Count: classify[value]})
+	}
+
+	// Filtering by a category matches its subcategories too, so count them in
+	tree, err := s.categoryTree()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(categories))
+	for i, c := range categories {
+		ids[i] = c.ID
+	}
+	translated, err := s.translations.lookup(models.TranslationEntityCategory, ids, locale)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := facetCountMap(rows.Category)
+	for _, c := range categories {
+		count := byCategory[strconv.FormatUint(uint64(c.ID), 10)]
+		for _, id := range tree.descendants(c.ID) {
+			count += byCategory[strconv.FormatUint(uint64(id), 10)]
+		}
+		name := c.Name
+		if t := translated[c.ID][models.TranslationFieldName]; t != "" {
+			name = t
+		}
+		facets.Category = append(facets.Category, dto.CategoryFacetCount{
+			ID:    c.ID,
+			Name:  name,
+			Slug:  c.Slug,
+			Count: count,
+		})
+	}
+
+	for stars := 4; stars >= 1; stars-- {
+		facets.Rating = append(facets.Rating, dto.RatingFacetCount{MinRating: float64(stars), Count: rows.RatingAtLeast[stars-1]})
+	}
+
+	lower := 0.0
+	for i, count := range rows.PriceBuckets {
+		bucket := dto.PriceRangeFacetCount{Min: lower, Count: count}
+		if i < len(productPriceFacetBounds) {
+			upper := productPriceFacetBounds[i]
+			bucket.Max = &upper
+			lower = upper
+		}
+		facets.Price = append(facets.Price, bucket)
+	}
+
+	initials := facetCountMap(rows.Initial)
+	for c := 'a'; c <= 'z'; c++ {
+		facets.StartsWith = append(facets.StartsWith, dto.FacetCount{Value: string(c), Count: initials[string(c)]})
+	}
+	facets.StartsWith = append(facets.StartsWith, dto.FacetCount{Value: search.OtherInitial, Count: initials[search.OtherInitial]})
+
+	return facets, nil
+}
+
+func facetCountMap(rows []repository.FacetCountRow) map[string]int64 {
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Value] += r.Count
+	}
+	return counts
+}
+
+// listFromCursor returns the page after req.Cursor without counting, and the
+// cursor for the page after that, empty on the last page
+func (s *ProductService) listFromCursor(req *dto.ProductListRequest, params repository.ProductListParams, ranked []uint, relevance bool) ([]models.Product, string, error) {
+	if relevance {
+		// The ranking lives in memory, so relevance pages resume by position
+		pos, err := s.cursors.position(req.Cursor, ProductSortRelevance, "desc")
+		if err != nil {
+			return nil, "", err
+		}
+		params.Offset = pos.Offset
+		products, total, err := s.listByRelevance(params, ranked)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list products: %w", err)
+		}
+		next := ""
+		if end := pos.Offset + params.Limit; int64(end) < total {
+			next = s.cursors.offsetCursor(ProductSortRelevance, "desc", end)
+		}
+		return products, next, nil
+	}
+
+	sortBy, sortDir := repository.ProductSortColumn(req.SortBy), cursorSortDir(req.SortDir)
+	after, err := s.cursors.keyset(req.Cursor, sortBy, sortDir, productSortValue(&models.Product{}, sortBy))
+	if err != nil {
+		return nil, "", err
+	}
+	// One extra row tells whether another page follows
+	params.Limit = req.PageSize + 1
+	products, err := s.productRepo.ListAfter(params, after)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list products: %w", err)
+	}
+	if len(products) <= req.PageSize {
+		return products, "", nil
 	}
+	products = products[:req.PageSize]
+	return products, s.productCursor(&products[len(products)-1], req.SortBy, req.SortDir), nil
+}
+
+// productCursor returns the cursor for the page after product
+func (s *ProductService) productCursor(p *models.Product, sortBy, sortDir string) string {
+	key := repository.ProductSortColumn(sortBy)
+	return s.cursors.keysetCursor(key, cursorSortDir(sortDir), productSortValue(p, key), p.ID)
+}
 
+func productSortValue(p *models.Product, key string) interface{} {
+	switch key {
+	case "price":
+		return p.Price
+	case "rating_average":
+		return p.RatingAverage
+	case "name":
+		return p.Name
+	}
+	return p.CreatedAt
+}
+
+func (s *ProductService) toResponses(products []models.Product) []dto.ProductResponse {
 	items := make([]dto.ProductResponse, len(products))
 	for i, p := range products {
 		items[i] = *s.toResponse(&p)
 	}
+	return items
+}
+
+// toAvailableResponses is toResponses with available_now read from the schedules
+func (s *ProductService) toAvailableResponses(products []models.Product) ([]dto.ProductResponse, error) {
+	available, err := s.availability.AvailableNow(products)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.categoryTree()
+	if err != nil {
+		return nil, err
+	}
+	items := s.toResponses(products)
+	for i := range items {
+		items[i].AvailableNow = available[items[i].ID]
+		for _, c := range tree.path(items[i].CategoryID) {
+			items[i].Breadcrumbs = append(items[i].Breadcrumbs, dto.CategoryBreadcrumb{ID: c.ID, Name: c.Name, Slug: c.Slug})
+		}
+	}
+	return items, nil
+}
 
-	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
-	if totalPages == 0 {
-		totalPages = 1
+// categoryTree loads the category hierarchy
+func (s *ProductService) categoryTree() (*categoryTree, error) {
+	categories, err := s.categoryRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
 	}
+	return newCategoryTree(categories), nil
+}
 
-	return &dto.PaginatedResponse{
-		Items:      items,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+func (s *ProductService) toAvailableResponse(p *models.Product) (*dto.ProductResponse, error) {
+	items, err := s.toAvailableResponses([]models.Product{*p})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// listByRelevance applies the filters to the ranked search hits and returns
+// the requested page in rank order
+func (s *ProductService) listByRelevance(params repository.ProductListParams, ranked []uint) ([]models.Product, int64, error) {
+	matching, err := s.productRepo.ListIDs(params)
+	if err != nil {
+		return nil, 0, err
+	}
+	keep := make(map[uint]bool, len(matching))
+	for _, id := range matching {
+		keep[id] = true
+	}
+	ordered := make([]uint, 0, len(matching))
+	for _, id := range ranked {
+		if keep[id] {
+			ordered = append(ordered, id)
+		}
+	}
+
+	total := int64(len(ordered))
+	start := min(params.Offset, len(ordered))
+	end := min(start+params.Limit, len(ordered))
+	page := ordered[start:end]
+
+	found, err := s.productRepo.FindByIDs(page)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	products := make([]models.Product, 0, len(page))
+	for _, id := range page {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, total, nil
+}
+
+// RebuildSearchIndex reloads every product into the search index and returns
+// the number of indexed products
+func (s *ProductService) RebuildSearchIndex() (int, error) {
+	if s.index == nil {
+		return 0, ErrSearchIndexUnavailable
+	}
+	products, err := s.productRepo.ListAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load products: %w", err)
+	}
+	translations, err := s.translations.texts(models.TranslationEntityProduct, nil)
+	if err != nil {
+		return 0, err
+	}
+	docs := make([]search.Document, len(products))
+	for i := range products {
+		docs[i] = productDocument(&products[i], translations[products[i].ID])
+	}
+	s.index.Replace(docs)
+	s.notifyChanged()
+	return len(docs), nil
+}
+
+func (s *ProductService) indexProduct(p *models.Product) {
+	if s.index != nil {
+		translations, err := s.translations.texts(models.TranslationEntityProduct, []uint{p.ID})
+		if err != nil {
+			log.Printf("[search] product %d indexed without translations: %v", p.ID, err)
+		}
+		s.index.Upsert(productDocument(p, translations[p.ID]))
+	}
+	s.notifyChanged()
+}
+
+func (s *ProductService) notifyChanged() {
+	for _, l := range s.listeners {
+		l.CatalogChanged()
+	}
+}
+
+// productDocument indexes the product's text in every locale, so a search
+// finds it whatever language the shopper types in
+func productDocument(p *models.Product, translations []models.Translation) search.Document {
+	doc := search.Document{
+		ID:     p.ID,
+		Fields: []search.Field{{Text: p.Name, Weight: search.WeightName}},
+	}
+	if p.Description != nil {
+		doc.Fields = append(doc.Fields, search.Field{Text: *p.Description, Weight: search.WeightDescription})
+	}
+	for _, t := range translations {
+		weight := search.WeightDescription
+		if t.Field == models.TranslationFieldName {
+			weight = search.WeightName
+		}
+		doc.Fields = append(doc.Fields, search.Field{Text: t.Value, Weight: weight})
+	}
+	return doc
 }
 
 func (s *ProductService) generateSlug(input string) string {
@@ -285,29 +1279,61 @@ func (s *ProductService) toResponse(p *models.Product) *dto.ProductResponse {
 		Slug:          p.Slug,
 		Description:   p.Description,
 		Classify:      p.Classify,
-		Price:         p.Price,
+		Nutrition:     toNutritionFacts(p),
+		Allergens:     bundleAllergens(p),
+		DietaryTags:   append([]string{}, p.DietaryTags...),
 		Stock:         p.Stock,
 		RatingAverage: p.RatingAverage,
 		RatingCount:   p.RatingCount,
 		Status:        p.Status,
+		AvailableNow:  true,
+		IsBundle:      p.IsBundle,
 		SocialShare:   s.buildSocialShare(p),
 		CreatedAt:     p.CreatedAt,
 		UpdatedAt:     p.UpdatedAt,
+
+		LowStockThreshold: p.LowStockThreshold,
+		DeclaredAllergens: append([]string{}, p.Allergens...),
 	}
 
 	if p.Category != nil {
 		resp.CategoryName = p.Category.Name
 	}
 
+	// A failed lookup only costs the sale; checkout reports it
+	quote, err := s.pricing.Quote(p)
+	if err != nil {
+		log.Printf("[pricing] product %d quoted at its regular price: %v", p.ID, err)
+	}
+	resp.Price = quote.Price
+	resp.OriginalPrice = quote.OriginalPrice
+	resp.SaleEndsAt = quote.EndsAt
+
+	for _, item := range p.BundleItems {
+		if item.Component == nil {
+			continue
+		}
+		resp.Components = append(resp.Components, dto.BundleComponentResponse{
+			ProductID: item.ComponentID,
+			Name:      item.Component.Name,
+			Slug:      item.Component.Slug,
+			Price:     item.Component.Price,
+			Quantity:  item.Quantity,
+			Allergens: item.Component.Allergens,
+		})
+	}
+
 	if len(p.Images) > 0 {
 		resp.Images = make([]dto.ProductImageResponse, len(p.Images))
 		for i, img := range p.Images {
 			resp.Images[i] = dto.ProductImageResponse{
-				ID:        img.ID,
-				ImageURL:  img.ImageURL,
-				AltText:   img.AltText,
-				SortOrder: img.SortOrder,
-				IsPrimary: img.IsPrimary,
+				ID:           img.ID,
+				ImageURL:     img.ImageURL,
+				MediumURL:    img.MediumURL,
+				ThumbnailURL: img.ThumbnailURL,
+				AltText:      img.AltText,
+				SortOrder:    img.SortOrder,
+				IsPrimary:    img.IsPrimary,
 			}
 			if img.IsPrimary {
 				imgResp := resp.Images[i]
@@ -323,7 +1349,7 @@ func (s *ProductService) toResponse(p *models.Product) *dto.ProductResponse {
 }
 
 func (s *ProductService) buildSocialShare(p *models.Product) dto.ProductSocialShareResponse {
-	productURL := s.buildProductURL(p.Slug)
+	productURL := s.buildShareURL(p.Slug)
 	shareText := fmt.Sprintf("Khám phá %s tại Foods & Drinks", strings.TrimSpace(p.Name))
 
 	encodedProductURL := url.QueryEscape(productURL)
@@ -348,3 +1374,32 @@ func (s *ProductService) buildProductURL(slug string) string {
 
 	return baseURL + "/products/" + url.PathEscape(slug)
 }
+
+// buildShareURL is the share page of a product, whose Open Graph tags
+// crawlers can read. Without an API URL it is the frontend page itself.
+func (s *ProductService) buildShareURL(slug string) string {
+	slug = strings.TrimSpace(slug)
+	if s.apiURL == "" || slug == "" {
+		return s.buildProductURL(slug)
+	}
+	return s.apiURL + "/p/" + url.PathEscape(slug)
+}
+
+// ProductURL is the frontend page of a product
+func (s *ProductService) ProductURL(slug string) string {
+	return s.buildProductURL(slug)
+}
+
+// SitemapEntries lists the frontend pages of visible products with the time
+// they last changed
+func (s *ProductService) SitemapEntries() ([]dto.SitemapEntry, error) {
+	products, err := s.productRepo.ListVisibleSlugs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	entries := make([]dto.SitemapEntry, len(products))
+	for i, p := range products {
+		entries[i] = dto.SitemapEntry{URL: s.buildProductURL(p.Slug), LastModified: p.UpdatedAt}
+	}
+	return entries, nil
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/url"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/kha/foods-drinks/internal/dto"
 	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
 	"gorm.io/gorm"
 )
 
@@ -26,7 +29,7 @@ func setupProductServiceTest(t *testing.T) (*ProductService, *repository.Product
 		t.Fatalf("open sqlite db: %v", err)
 	}
 
-	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}); err != nil {
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}, &models.BundleItem{}, &models.StockMovement{}, &models.User{}, &models.ProductRevision{}); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
 
@@ -41,7 +44,7 @@ func setupProductServiceTest(t *testing.T) (*ProductService, *repository.Product
 
 	productRepo := repository.NewProductRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
-	service := NewProductService(productRepo, categoryRepo, "https://foods.example.com/")
+	service := NewProductService(productRepo, categoryRepo, nil, "https://foods.example.com/", "", nil, nil, nil, nil, nil, nil)
 
 	return service, productRepo, db
 }
@@ -78,7 +81,7 @@ func TestBuildProductURL(t *testing.T) {
 
 	t.Run("uses normalized base url and path escapes slug", func(t *testing.T) {
 		t.Parallel()
-		svc := NewProductService(nil, nil, "https://foods.example.com/")
+		svc := NewProductService(nil, nil, nil, "https://foods.example.com/", "", nil, nil, nil, nil, nil, nil)
 		got := svc.buildProductURL("tra sua dac biet")
 		want := "https://foods.example.com/products/tra%20sua%20dac%20biet"
 		if got != want {
@@ -88,7 +91,7 @@ func TestBuildProductURL(t *testing.T) {
 
 	t.Run("falls back to localhost when base url is empty", func(t *testing.T) {
 		t.Parallel()
-		svc := NewProductService(nil, nil, "")
+		svc := NewProductService(nil, nil, nil, "", "", nil, nil, nil, nil, nil, nil)
 		got := svc.buildProductURL("pho")
 		want := "http://localhost:8000/products/pho"
 		if got != want {
@@ -98,7 +101,7 @@ func TestBuildProductURL(t *testing.T) {
 
 	t.Run("returns base url when slug is blank", func(t *testing.T) {
 		t.Parallel()
-		svc := NewProductService(nil, nil, "https://foods.example.com")
+		svc := NewProductService(nil, nil, nil, "https://foods.example.com", "", nil, nil, nil, nil, nil, nil)
 		got := svc.buildProductURL("   ")
 		want := "https://foods.example.com"
 		if got != want {
@@ -110,7 +113,7 @@ func TestBuildProductURL(t *testing.T) {
 func TestBuildSocialShare(t *testing.T) {
 	t.Parallel()
 
-	svc := NewProductService(nil, nil, "https://foods.example.com")
+	svc := NewProductService(nil, nil, nil, "https://foods.example.com", "", nil, nil, nil, nil, nil, nil)
 	product := &models.Product{Name: "Pho Bo", Slug: "pho-bo"}
 
 	share := svc.buildSocialShare(product)
@@ -136,6 +139,16 @@ func TestBuildSocialShare(t *testing.T) {
 	if got := twURL.Query().Get("text"); got != wantText {
 		t.Fatalf("twitter share text = %q, want %q", got, wantText)
 	}
+
+	// With an API URL the links go to the share page, which has the Open Graph tags
+	svc = NewProductService(nil, nil, nil, "https://foods.example.com", " https://api.foods.example.com/ ", nil, nil, nil, nil, nil, nil)
+	fbURL, err = url.Parse(svc.buildSocialShare(product).Facebook)
+	if err != nil {
+		t.Fatalf("parse facebook share url: %v", err)
+	}
+	if got, want := fbURL.Query().Get("u"), "https://api.foods.example.com/p/pho-bo"; got != want {
+		t.Fatalf("facebook shared url with api url = %q, want %q", got, want)
+	}
 }
 
 func TestEnsureUniqueSlug(t *testing.T) {
@@ -219,7 +232,7 @@ func TestProductService_CreateGetUpdateDeleteList(t *testing.T) {
 	newPrice := 35000.0
 	newStock := 15
 	newDesc := "  fresh and cold  "
-	updated, err := svc.Update(created.ID, &dto.UpdateProductRequest{
+	updated, err := svc.Update(0, created.ID, &dto.UpdateProductRequest{
 		Name:        &newName,
 		Price:       &newPrice,
 		Stock:       &newStock,
@@ -290,14 +303,374 @@ func TestProductService_CreateInvalidSlugAndUpdateDuplicateSlug(t *testing.T) {
 	}
 
 	dup := "dup-slug"
-	_, err = svc.Update(p2.ID, &dto.UpdateProductRequest{Slug: &dup}, nil, false)
+	_, err = svc.Update(0, p2.ID, &dto.UpdateProductRequest{Slug: &dup}, nil, false)
 	if !errors.Is(err, ErrProductSlugExists) {
 		t.Fatalf("update duplicate slug err = %v, want ErrProductSlugExists", err)
 	}
 
 	// keep same slug on same product should pass
-	_, err = svc.Update(p1.ID, &dto.UpdateProductRequest{Slug: &dup}, nil, false)
+	_, err = svc.Update(0, p1.ID, &dto.UpdateProductRequest{Slug: &dup}, nil, false)
 	if err != nil {
 		t.Fatalf("update same slug on same product should pass, got %v", err)
 	}
 }
+
+func TestProductService_ImageManagement(t *testing.T) {
+	t.Parallel()
+
+	svc, _, _ := setupProductServiceTest(t)
+	svc.uploads = newUploadServiceForTest(t)
+
+	created, err := svc.Create(&dto.CreateProductRequest{
+		CategoryID: 1,
+		Name:       "Banh Mi",
+		Classify:   models.ClassifyFood,
+		Price:      25000,
+		Stock:      5,
+	}, []string{"https://img.example.com/banh-mi.jpg"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	uploaded, err := svc.UploadImages(created.ID, []*multipart.FileHeader{
+		pngFileHeader(t, "image_files", "a.png", 900, 900),
+		pngFileHeader(t, "image_files", "b.png", 100, 100),
+	})
+	if err != nil {
+		t.Fatalf("UploadImages: %v", err)
+	}
+	if len(uploaded.Images) != 3 {
+		t.Fatalf("images = %d, want 3", len(uploaded.Images))
+	}
+	if uploaded.PrimaryImage == nil || uploaded.PrimaryImage.ID != uploaded.Images[0].ID {
+		t.Fatal("existing primary image should be kept after upload")
+	}
+	large := uploaded.Images[1]
+	if large.ThumbnailURL == nil || *large.ThumbnailURL == large.ImageURL {
+		t.Fatalf("expected a generated thumbnail, got %+v", large)
+	}
+
+	ids := []uint{uploaded.Images[2].ID, uploaded.Images[0].ID, uploaded.Images[1].ID}
+	if err := svc.ReorderImages(created.ID, ids); err != nil {
+		t.Fatalf("ReorderImages: %v", err)
+	}
+	if err := svc.ReorderImages(created.ID, ids[:2]); !errors.Is(err, ErrInvalidImageOrder) {
+		t.Fatalf("ReorderImages partial err = %v, want ErrInvalidImageOrder", err)
+	}
+
+	if err := svc.SetPrimaryImage(created.ID, large.ID); err != nil {
+		t.Fatalf("SetPrimaryImage: %v", err)
+	}
+	if err := svc.SetPrimaryImage(created.ID, 9999); !errors.Is(err, ErrProductImageNotFound) {
+		t.Fatalf("SetPrimaryImage unknown err = %v, want ErrProductImageNotFound", err)
+	}
+
+	got, err := svc.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	for i, want := range ids {
+		if got.Images[i].ID != want {
+			t.Fatalf("image[%d] = %d, want %d", i, got.Images[i].ID, want)
+		}
+	}
+	if got.PrimaryImage == nil || got.PrimaryImage.ID != large.ID {
+		t.Fatalf("primary image = %+v, want id %d", got.PrimaryImage, large.ID)
+	}
+
+	if err := svc.DeleteImage(created.ID, large.ID); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	got, err = svc.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetByID after delete: %v", err)
+	}
+	if len(got.Images) != 2 {
+		t.Fatalf("images after delete = %d, want 2", len(got.Images))
+	}
+	if got.PrimaryImage == nil || got.PrimaryImage.ID != ids[0] || !got.PrimaryImage.IsPrimary {
+		t.Fatalf("expected first remaining image to be promoted, got %+v", got.PrimaryImage)
+	}
+}
+
+func TestProductService_SearchUsesIndex(t *testing.T) {
+	t.Parallel()
+
+	svc, _, db := setupProductServiceTest(t)
+	svc.index = search.NewInvertedIndex()
+
+	var category models.Category
+	if err := db.First(&category).Error; err != nil {
+		t.Fatalf("load category: %v", err)
+	}
+	create := func(name, description string, price float64) uint {
+		t.Helper()
+		p, err := svc.Create(&dto.CreateProductRequest{
+			CategoryID:  category.ID,
+			Name:        name,
+			Description: description,
+			Classify:    models.ClassifyDrink,
+			Price:       price,
+			Stock:       10,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Create %q: %v", name, err)
+		}
+		return p.ID
+	}
+	milkTea := create("Trà sữa trân châu", "", 30000)
+	coffee := create("Cà phê sữa đá", "Không dùng trà", 25000)
+	create("Nước cam", "", 20000)
+
+	list := func(req *dto.ProductListRequest) []uint {
+		t.Helper()
+		req.Page, req.PageSize = 1, 10
+		result, err := svc.List(req)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		var ids []uint
+		for _, item := range result.Items.([]dto.ProductResponse) {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	if got := list(&dto.ProductListRequest{Search: "tran chau"}); len(got) != 1 || got[0] != milkTea {
+		t.Fatalf("search 'tran chau' = %v, want [%d]", got, milkTea)
+	}
+	// Relevance puts the name match before the description match
+	if got := list(&dto.ProductListRequest{Search: "tra sua"}); len(got) != 2 || got[0] != milkTea || got[1] != coffee {
+		t.Fatalf("search 'tra sua' = %v, want [%d %d]", got, milkTea, coffee)
+	}
+	if got := list(&dto.ProductListRequest{Search: "sua", SortBy: "price", SortDir: "asc"}); len(got) != 2 || got[0] != coffee {
+		t.Fatalf("search 'sua' by price = %v", got)
+	}
+	if got := list(&dto.ProductListRequest{Search: "sua", MaxPrice: 26000}); len(got) != 1 || got[0] != coffee {
+		t.Fatalf("search 'sua' with max price = %v", got)
+	}
+
+	newName := "Trà đào"
+	if _, err := svc.Update(0, milkTea, &dto.UpdateProductRequest{Name: &newName}, nil, false); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := list(&dto.ProductListRequest{Search: "dao"}); len(got) != 1 || got[0] != milkTea {
+		t.Fatalf("search after update = %v", got)
+	}
+	if err := svc.Delete(coffee); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := list(&dto.ProductListRequest{Search: "ca phe"}); len(got) != 0 {
+		t.Fatalf("deleted product still found: %v", got)
+	}
+
+	svc.index = search.NewInvertedIndex()
+	n, err := svc.RebuildSearchIndex()
+	if err != nil || n != 2 {
+		t.Fatalf("RebuildSearchIndex = %d, %v; want 2", n, err)
+	}
+	if got := list(&dto.ProductListRequest{Search: "cam"}); len(got) != 1 {
+		t.Fatalf("search after rebuild = %v", got)
+	}
+}
+
+func TestProductService_RestoreAndPurge(t *testing.T) {
+	svc, productRepo, db := setupProductServiceTest(t)
+	if err := db.AutoMigrate(&models.OrderItem{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	created, err := svc.Create(&dto.CreateProductRequest{CategoryID: 1, Name: "Pho Bo", Classify: "food", Price: 50000, Stock: 3}, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ordered, _ := svc.Create(&dto.CreateProductRequest{CategoryID: 1, Name: "Bun Cha", Classify: "food", Price: 40000, Stock: 3}, nil)
+	db.Create(&models.OrderItem{OrderID: 1, ProductID: ordered.ID, ProductName: "Bun Cha", ProductPrice: 40000, Quantity: 1, Subtotal: 40000})
+	for _, id := range []uint{created.ID, ordered.ID} {
+		if err := svc.Delete(id); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	trash, err := svc.ListDeleted()
+	if err != nil || len(trash) != 2 || trash[0].CategoryName != "Foods" || trash[0].CategoryDeleted {
+		t.Fatalf("ListDeleted = %+v, %v", trash, err)
+	}
+
+	// A product cannot come back into a deleted category
+	db.Delete(&models.Category{}, 1)
+	if err := svc.Restore(created.ID); !errors.Is(err, ErrProductCategoryDeleted) {
+		t.Fatalf("Restore into deleted category err = %v, want ErrProductCategoryDeleted", err)
+	}
+	db.Unscoped().Model(&models.Category{}).Where("id = ?", 1).Update("deleted_at", nil)
+	if err := svc.Restore(created.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := svc.GetByID(created.ID); err != nil {
+		t.Fatalf("restored product not found: %v", err)
+	}
+
+	if err := svc.Purge(ordered.ID); !errors.Is(err, ErrProductHasOrders) {
+		t.Fatalf("Purge of ordered product err = %v, want ErrProductHasOrders", err)
+	}
+	if err := svc.Purge(created.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Purge of live product err = %v, want ErrProductNotFound", err)
+	}
+	svc.Delete(created.ID)
+	if err := svc.Purge(created.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := productRepo.FindDeletedByID(created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("purged product still stored: %v", err)
+	}
+}
+
+func TestProductService_RevisionsAndRollback(t *testing.T) {
+	svc, _, db := setupProductServiceTest(t)
+	admin := models.User{Email: "admin@example.com", FullName: "Admin A", Role: models.RoleAdmin}
+	db.Create(&admin)
+
+	created, err := svc.Create(&dto.CreateProductRequest{CategoryID: 1, Name: "Ca Phe Sua", Classify: "drink", Price: 25000, Stock: 5}, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The mistyped price, then a stock-only edit that is not a revision
+	typo := 2500.0
+	if _, err := svc.Update(admin.ID, created.ID, &dto.UpdateProductRequest{Price: &typo}, nil, false); err != nil {
+		t.Fatalf("Update price: %v", err)
+	}
+	stock := 9
+	if _, err := svc.Update(admin.ID, created.ID, &dto.UpdateProductRequest{Stock: &stock}, nil, false); err != nil {
+		t.Fatalf("Update stock: %v", err)
+	}
+	history, err := svc.History(created.ID)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 1 || history[0].AdminName != "Admin A" || len(history[0].Changes) != 1 {
+		t.Fatalf("history = %+v, want one price revision by Admin A", history)
+	}
+	if got := history[0].Changes[0]; got.Field != ProductFieldPrice || got.Before != "25000" || got.After != "2500" {
+		t.Fatalf("change = %+v", got)
+	}
+
+	restored, err := svc.Rollback(admin.ID, created.ID, history[0].ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if restored.Price != 25000 || restored.Stock != 9 {
+		t.Fatalf("after rollback price %v stock %d, want 25000 with stock untouched", restored.Price, restored.Stock)
+	}
+	history, _ = svc.History(created.ID)
+	if len(history) != 2 || history[0].RollbackOf == nil || *history[0].RollbackOf != history[1].ID {
+		t.Fatalf("history = %+v, want the rollback added on top", history)
+	}
+
+	if _, err := svc.Rollback(admin.ID, created.ID, 999); !errors.Is(err, ErrProductRevisionNotFound) {
+		t.Fatalf("Rollback of unknown revision err = %v, want ErrProductRevisionNotFound", err)
+	}
+}
+
+func TestProductService_DietaryInfo(t *testing.T) {
+	svc, _, db := setupProductServiceTest(t)
+	var category models.Category
+	if err := db.First(&category).Error; err != nil {
+		t.Fatalf("load category: %v", err)
+	}
+	intPtr := func(v int) *int { return &v }
+
+	create := func(name string, calories *int, allergens, tags []string) *dto.ProductResponse {
+		t.Helper()
+		p, err := svc.Create(&dto.CreateProductRequest{
+			CategoryID: category.ID, Name: name, Classify: models.ClassifyFood, Price: 30000, Stock: 5,
+			Nutrition: &dto.NutritionFacts{Calories: calories}, Allergens: allergens, DietaryTags: tags,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Create %q: %v", name, err)
+		}
+		return p
+	}
+	satay := create("Sate dau phong", intPtr(450), []string{"sesame", "Peanut"}, []string{"Spicy 2"})
+	salad := create("Goi cuon chay", intPtr(180), nil, []string{"vegan", "spicy-2"})
+	flan := create("Banh flan", nil, []string{"milk", "egg"}, []string{"vegetarian"})
+
+	if got := strings.Join(satay.Allergens, ","); got != "peanut,sesame" {
+		t.Fatalf("allergens = %q, want the fixed list order", got)
+	}
+	if satay.Nutrition == nil || *satay.Nutrition.Calories != 450 || flan.Nutrition != nil {
+		t.Fatalf("nutrition = %+v and %+v", satay.Nutrition, flan.Nutrition)
+	}
+	if _, err := svc.Create(&dto.CreateProductRequest{CategoryID: category.ID, Name: "Bad", Classify: models.ClassifyFood, Price: 1, DietaryTags: []string{"100%"}}, nil); !errors.Is(err, ErrInvalidDietaryInfo) {
+		t.Fatalf("Create with invalid tag error = %v", err)
+	}
+
+	list := func(req *dto.ProductListRequest) []uint {
+		t.Helper()
+		req.Page, req.PageSize, req.SortBy, req.SortDir = 1, 10, "name", "asc"
+		result, err := svc.List(req)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		var ids []uint
+		for _, item := range result.Items.([]dto.ProductResponse) {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+	if got := list(&dto.ProductListRequest{ExcludeAllergens: "peanut,milk"}); len(got) != 1 || got[0] != salad.ID {
+		t.Fatalf("excluding peanut and milk = %v, want [%d]", got, salad.ID)
+	}
+	if got := list(&dto.ProductListRequest{Tags: "spicy-2"}); len(got) != 2 {
+		t.Fatalf("tag spicy-2 = %v, want 2 products", got)
+	}
+	if got := list(&dto.ProductListRequest{Tags: "spicy-2,vegan"}); len(got) != 1 || got[0] != salad.ID {
+		t.Fatalf("tags spicy-2 and vegan = %v", got)
+	}
+	if got := list(&dto.ProductListRequest{MaxCalories: 200}); len(got) != 1 || got[0] != salad.ID {
+		t.Fatalf("max 200 kcal = %v", got)
+	}
+	if _, err := svc.List(&dto.ProductListRequest{Page: 1, PageSize: 10, ExcludeAllergens: "celery"}); !errors.Is(err, ErrInvalidDietaryInfo) {
+		t.Fatalf("unknown allergen filter error = %v", err)
+	}
+
+	// A combo contains what its components contain
+	combo := create("Combo an vat", nil, []string{"soy"}, nil)
+	if _, err := svc.SaveBundle(combo.ID, []dto.BundleItemInput{{ProductID: salad.ID, Quantity: 1}, {ProductID: satay.ID, Quantity: 2}}); err != nil {
+		t.Fatalf("SaveBundle: %v", err)
+	}
+	got, err := svc.GetByID(combo.ID)
+	if err != nil {
+		t.Fatalf("GetByID combo: %v", err)
+	}
+	if strings.Join(got.Allergens, ",") != "peanut,soy,sesame" || strings.Join(got.DeclaredAllergens, ",") != "soy" {
+		t.Fatalf("combo allergens = %q, declared %q", got.Allergens, got.DeclaredAllergens)
+	}
+	if ids := list(&dto.ProductListRequest{ExcludeAllergens: "peanut"}); slices.Contains(ids, combo.ID) {
+		t.Fatalf("excluding peanut = %v, still has the combo", ids)
+	}
+	if ids := list(&dto.ProductListRequest{ExcludeAllergens: "milk"}); !slices.Contains(ids, combo.ID) {
+		t.Fatalf("excluding milk = %v, want the combo", ids)
+	}
+
+	// Dietary edits are revisions, and rolling back another field leaves them alone
+	if _, err := svc.Update(0, flan.ID, &dto.UpdateProductRequest{Allergens: []string{"milk"}}, nil, false); err != nil {
+		t.Fatalf("Update allergens: %v", err)
+	}
+	price := 35000.0
+	if _, err := svc.Update(0, flan.ID, &dto.UpdateProductRequest{Price: &price}, nil, false); err != nil {
+		t.Fatalf("Update price: %v", err)
+	}
+	history, err := svc.History(flan.ID)
+	if err != nil || len(history) != 2 || history[1].Changes[0].Field != ProductFieldAllergens {
+		t.Fatalf("history = %+v, %v", history, err)
+	}
+	restored, err := svc.Rollback(0, flan.ID, history[0].ID)
+	if err != nil || restored.Price != 30000 || strings.Join(restored.Allergens, ",") != "milk" {
+		t.Fatalf("after price rollback = %+v, %v", restored, err)
+	}
+	restored, err = svc.Rollback(0, flan.ID, history[1].ID)
+	if err != nil || strings.Join(restored.Allergens, ",") != "milk,egg" {
+		t.Fatalf("after allergen rollback = %+v, %v", restored, err)
+	}
+}
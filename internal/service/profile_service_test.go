@@ -9,11 +9,11 @@ import (
 )
 
 func newProfileServiceForHelperTest(uploadPath string, maxSize int64, allowed []string) *ProfileService {
-	return NewProfileService(nil, &config.UploadConfig{
+	return NewProfileService(nil, NewUploadService(&config.UploadConfig{
 		Path:         uploadPath,
 		MaxSize:      maxSize,
 		AllowedTypes: allowed,
-	}, "/uploads")
+	}, nil, "/uploads"))
 }
 
 func TestProfileService_IsAllowedExtension(t *testing.T) {
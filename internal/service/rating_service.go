@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"mime/multipart"
 	"strings"
+	"time"
 
 	"github.com/kha/foods-drinks/internal/dto"
 	"github.com/kha/foods-drinks/internal/models"
 	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
 	"gorm.io/gorm"
 )
 
@@ -16,15 +19,53 @@ var (
 	ErrRatingNotFound      = errors.New("rating not found")
 	ErrRatingAlreadyExists = errors.New("rating already exists")
 	ErrProductNotPurchased = errors.New("product not purchased")
+	ErrRatingPhotoLimit    = errors.New("too many rating photos")
+	ErrRatingPhotoNotFound = errors.New("rating photo not found")
+	ErrRatingOwnVote       = errors.New("cannot vote on own rating")
+	ErrRatingOwnReport     = errors.New("cannot report own rating")
+	ErrRatingReported      = errors.New("rating already reported")
 )
 
+const defaultMaxRatingPhotos = 5
+
+// ratingSortColumns maps the list orders to the column ratings are sorted by,
+// descending. The column also names the cursors of that order.
+var ratingSortColumns = map[string]string{
+	dto.RatingSortNewest:     "created_at",
+	dto.RatingSortHelpful:    "helpful_count",
+	dto.RatingSortWithPhotos: "photo_count",
+}
+
 type RatingService struct {
 	ratingRepo  *repository.RatingRepository
 	productRepo *repository.ProductRepository
+	uploads     *UploadService
+	cursors     *CursorCodec
+	maxPhotos   int
+	bannedWords []bannedWord
+}
+
+// NewRatingService creates a RatingService. maxPhotos caps the photos of a
+// rating, defaultMaxRatingPhotos when not positive. Ratings whose comment
+// contains one of bannedWords, ignoring case and diacritics, are flagged for
+// moderation.
+func NewRatingService(ratingRepo *repository.RatingRepository, productRepo *repository.ProductRepository, uploads *UploadService, cursors *CursorCodec, maxPhotos int, bannedWords []string) *RatingService {
+	if maxPhotos <= 0 {
+		maxPhotos = defaultMaxRatingPhotos
+	}
+	s := &RatingService{ratingRepo: ratingRepo, productRepo: productRepo, uploads: uploads, cursors: cursors, maxPhotos: maxPhotos}
+	for _, word := range bannedWords {
+		if folded := normalizeModerationText(word); folded != "" {
+			label := strings.Join(strings.Fields(strings.ToLower(word)), " ")
+			s.bannedWords = append(s.bannedWords, bannedWord{label: label, folded: folded})
+		}
+	}
+	return s
 }
 
-func NewRatingService(ratingRepo *repository.RatingRepository, productRepo *repository.ProductRepository) *RatingService {
-	return &RatingService{ratingRepo: ratingRepo, productRepo: productRepo}
+// MaxPhotos is the number of photos a rating may carry
+func (s *RatingService) MaxPhotos() int {
+	return s.maxPhotos
 }
 
 func (s *RatingService) CreateByProductSlug(userID uint, productSlug string, req *dto.CreateRatingRequest) (*dto.RatingResponse, error) {
@@ -57,7 +98,9 @@ func (s *RatingService) CreateByProductSlug(userID uint, productSlug string, req
 		OrderID:   orderID,
 		Rating:    req.Rating,
 		Comment:   comment,
+		Status:    models.RatingStatusVisible,
 	}
+	s.flagBannedWords(rating)
 
 	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
 		ratingRepoTx := s.ratingRepo.WithTx(tx)
@@ -69,29 +112,13 @@ func (s *RatingService) CreateByProductSlug(userID uint, productSlug string, req
 			return fmt.Errorf("failed to create rating: %w", err)
 		}
 
-		avg, count, err := ratingRepoTx.CalcProductRatingStats(productID)
-		if err != nil {
-			return fmt.Errorf("failed to calculate rating stats: %w", err)
-		}
-		if err := ratingRepoTx.UpdateProductRatingStats(productID, avg, count); err != nil {
-			return fmt.Errorf("failed to update product rating stats: %w", err)
-		}
-		return nil
+		return refreshProductRatingStats(ratingRepoTx, productID)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.RatingResponse{
-		ID:        rating.ID,
-		UserID:    userID,
-		ProductID: productID,
-		OrderID:   orderID,
-		Rating:    rating.Rating,
-		Comment:   rating.Comment,
-		CreatedAt: rating.CreatedAt,
-		UpdatedAt: rating.UpdatedAt,
-	}, nil
+	return toRatingResponse(rating), nil
 }
 
 func (s *RatingService) UpdateByProductSlug(userID uint, productSlug string, req *dto.UpdateRatingRequest) (*dto.RatingResponse, error) {
@@ -111,6 +138,7 @@ func (s *RatingService) UpdateByProductSlug(userID uint, productSlug string, req
 
 	rating.Rating = req.Rating
 	rating.Comment = normalizeComment(req.Comment)
+	s.flagBannedWords(rating)
 
 	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
 		ratingRepoTx := s.ratingRepo.WithTx(tx)
@@ -119,12 +147,267 @@ func (s *RatingService) UpdateByProductSlug(userID uint, productSlug string, req
 			return fmt.Errorf("failed to update rating: %w", err)
 		}
 
-		avg, count, err := ratingRepoTx.CalcProductRatingStats(rating.ProductID)
+		return refreshProductRatingStats(ratingRepoTx, rating.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toRatingResponse(rating), nil
+}
+
+func (s *RatingService) ListByProductSlug(productSlug string, req *dto.RatingListRequest) (*dto.RatingListResponse, error) {
+	product, err := s.findProductBySlug(productSlug)
+	if err != nil {
+		return nil, err
+	}
+	productID := product.ID
+
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+	column, ok := ratingSortColumns[req.Sort]
+	if !ok {
+		column = ratingSortColumns[dto.RatingSortNewest]
+	}
+	// The cursor keeps the value of the sort column of the last row
+	cursorValue := func(r *models.Rating) interface{} {
+		switch column {
+		case "helpful_count":
+			return float64(r.HelpfulCount)
+		case "photo_count":
+			return float64(r.PhotoCount)
+		}
+		return r.CreatedAt
+	}
+	var sample interface{} = float64(0)
+	if column == "created_at" {
+		sample = time.Time{}
+	}
+
+	var ratings []models.Rating
+	var total int64
+	resp := &dto.RatingListResponse{PaginatedResponse: dto.PaginatedResponse{PageSize: req.PageSize}}
+	if req.Cursor != "" {
+		after, err := s.cursors.keyset(req.Cursor, column, "desc", sample)
+		if err != nil {
+			return nil, err
+		}
+		// One extra row tells whether another page follows
+		if ratings, err = s.ratingRepo.ListByProductIDAfter(productID, column, after, req.PageSize+1); err != nil {
+			return nil, fmt.Errorf("failed to list ratings: %w", err)
+		}
+		if len(ratings) > req.PageSize {
+			ratings = ratings[:req.PageSize]
+			last := &ratings[len(ratings)-1]
+			resp.NextCursor = s.cursors.keysetCursor(column, "desc", cursorValue(last), last.ID)
+		}
+	} else {
+		offset := (req.Page - 1) * req.PageSize
+		if ratings, total, err = s.ratingRepo.ListByProductID(productID, column, offset, req.PageSize); err != nil {
+			return nil, fmt.Errorf("failed to list ratings: %w", err)
+		}
+		totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		resp.Total, resp.Page, resp.TotalPages = total, req.Page, totalPages
+		if req.Page < totalPages && len(ratings) > 0 {
+			last := &ratings[len(ratings)-1]
+			resp.NextCursor = s.cursors.keysetCursor(column, "desc", cursorValue(last), last.ID)
+		}
+	}
+
+	items := make([]dto.RatingResponse, len(ratings))
+	for i := range ratings {
+		items[i] = *toRatingResponse(&ratings[i])
+	}
+	resp.Items = items
+
+	if resp.Summary, err = s.summary(productID); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// summary builds the rating histogram of a product
+func (s *RatingService) summary(productID uint) (dto.RatingSummary, error) {
+	counts, err := s.ratingRepo.CountByStar(productID)
+	if err != nil {
+		return dto.RatingSummary{}, fmt.Errorf("failed to count ratings: %w", err)
+	}
+	summary := dto.RatingSummary{Distribution: make([]dto.RatingStarCount, 0, 5)}
+	var sum int64
+	for star := uint8(5); star >= 1; star-- {
+		summary.Distribution = append(summary.Distribution, dto.RatingStarCount{Star: star, Count: counts[star]})
+		summary.Count += counts[star]
+		sum += int64(star) * counts[star]
+	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(sum)/float64(summary.Count)*100) / 100
+	}
+	return summary, nil
+}
+
+// AddPhotos stores the uploaded files with their renditions and attaches
+// them to the user's rating of the product
+func (s *RatingService) AddPhotos(userID uint, productSlug string, files []*multipart.FileHeader) (*dto.RatingResponse, error) {
+	if s.uploads == nil {
+		return nil, ErrUploadUnavailable
+	}
+	rating, err := s.findOwnRating(userID, productSlug)
+	if err != nil {
+		return nil, err
+	}
+	if len(rating.Photos)+len(files) > s.maxPhotos {
+		return nil, fmt.Errorf("%w: at most %d per rating", ErrRatingPhotoLimit, s.maxPhotos)
+	}
+	// Validate every file before storing any of them
+	for _, file := range files {
+		if _, err := s.uploads.ValidateImage(file); err != nil {
+			return nil, err
+		}
+	}
+
+	stored := make([]*StoredImage, 0, len(files))
+	for _, file := range files {
+		img, err := s.uploads.SaveImage(file, "ratings")
+		if err != nil {
+			for _, saved := range stored {
+				s.uploads.RemoveImage(saved)
+			}
+			return nil, err
+		}
+		stored = append(stored, img)
+	}
+
+	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		// Recount under the rating's lock, photos may have been added
+		// while the files were stored
+		locked, err := repo.FindByIDForUpdate(rating.ID)
 		if err != nil {
-			return fmt.Errorf("failed to calculate rating stats: %w", err)
+			return err
 		}
-		if err := ratingRepoTx.UpdateProductRatingStats(rating.ProductID, avg, count); err != nil {
-			return fmt.Errorf("failed to update product rating stats: %w", err)
+		if len(locked.Photos)+len(stored) > s.maxPhotos {
+			return fmt.Errorf("%w: at most %d per rating", ErrRatingPhotoLimit, s.maxPhotos)
+		}
+		nextOrder := 0
+		for _, photo := range locked.Photos {
+			nextOrder = max(nextOrder, photo.SortOrder+1)
+		}
+		for i, img := range stored {
+			medium, thumb := img.MediumURL, img.ThumbnailURL
+			photo := &models.RatingPhoto{
+				RatingID:     rating.ID,
+				ImageURL:     img.URL,
+				MediumURL:    &medium,
+				ThumbnailURL: &thumb,
+				SortOrder:    nextOrder + i,
+			}
+			if err := repo.AddPhoto(photo); err != nil {
+				return err
+			}
+		}
+		return repo.SyncPhotoCount(rating.ID)
+	})
+	if err != nil {
+		for _, saved := range stored {
+			s.uploads.RemoveImage(saved)
+		}
+		if errors.Is(err, ErrRatingPhotoLimit) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save rating photos: %w", err)
+	}
+
+	return s.reloadRating(rating.ID)
+}
+
+// DeletePhoto removes a photo from the user's rating of the product
+func (s *RatingService) DeletePhoto(userID uint, productSlug string, photoID uint) (*dto.RatingResponse, error) {
+	rating, err := s.findOwnRating(userID, productSlug)
+	if err != nil {
+		return nil, err
+	}
+	photo, err := s.ratingRepo.FindPhoto(rating.ID, photoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingPhotoNotFound
+		}
+		return nil, fmt.Errorf("failed to find rating photo: %w", err)
+	}
+
+	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		if err := repo.DeletePhoto(rating.ID, photo.ID); err != nil {
+			return err
+		}
+		return repo.SyncPhotoCount(rating.ID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete rating photo: %w", err)
+	}
+	// The files go only once no record points at them
+	s.uploads.RemoveImage(storedImageFromURLs(photo.ImageURL, photo.MediumURL, photo.ThumbnailURL))
+
+	return s.reloadRating(rating.ID)
+}
+
+// Vote marks a rating as helpful for the user. Voting twice keeps one vote.
+func (s *RatingService) Vote(userID, ratingID uint) (*dto.RatingVoteResponse, error) {
+	rating, err := s.findRating(ratingID)
+	if err != nil {
+		return nil, err
+	}
+	if rating.UserID == userID {
+		return nil, ErrRatingOwnVote
+	}
+	return s.changeVote(rating.ID, true, func(repo *repository.RatingRepository) (bool, error) {
+		return repo.AddVote(rating.ID, userID)
+	})
+}
+
+// Unvote withdraws the user's helpful vote, if any
+func (s *RatingService) Unvote(userID, ratingID uint) (*dto.RatingVoteResponse, error) {
+	rating, err := s.findRating(ratingID)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeVote(rating.ID, false, func(repo *repository.RatingRepository) (bool, error) {
+		return repo.DeleteVote(rating.ID, userID)
+	})
+}
+
+// Report records the user's complaint about another user's rating and puts
+// the rating in the moderation queue
+func (s *RatingService) Report(userID, ratingID uint, req *dto.ReportRatingRequest) (*dto.RatingReportResponse, error) {
+	rating, err := s.findRating(ratingID)
+	if err != nil {
+		return nil, err
+	}
+	if rating.UserID == userID {
+		return nil, ErrRatingOwnReport
+	}
+
+	report := &models.RatingReport{RatingID: rating.ID, UserID: userID, Reason: req.Reason, Note: normalizeComment(req.Note)}
+	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		added, err := repo.AddReport(report)
+		if err != nil {
+			return fmt.Errorf("failed to record report: %w", err)
+		}
+		if !added {
+			return ErrRatingReported
+		}
+		if err := repo.SyncReportCount(rating.ID); err != nil {
+			return fmt.Errorf("failed to count reports: %w", err)
+		}
+		if err := repo.Flag(rating.ID, "Bị báo cáo: "+req.Reason); err != nil {
+			return fmt.Errorf("failed to flag rating: %w", err)
 		}
 		return nil
 	})
@@ -132,53 +415,104 @@ func (s *RatingService) UpdateByProductSlug(userID uint, productSlug string, req
 		return nil, err
 	}
 
-	return &dto.RatingResponse{
-		ID:        rating.ID,
-		UserID:    rating.UserID,
-		ProductID: rating.ProductID,
-		OrderID:   rating.OrderID,
-		Rating:    rating.Rating,
-		Comment:   rating.Comment,
-		CreatedAt: rating.CreatedAt,
-		UpdatedAt: rating.UpdatedAt,
-	}, nil
+	return &dto.RatingReportResponse{RatingID: rating.ID, Reason: report.Reason, CreatedAt: report.CreatedAt}, nil
+}
+
+func (s *RatingService) changeVote(ratingID uint, voted bool, change func(*repository.RatingRepository) (bool, error)) (*dto.RatingVoteResponse, error) {
+	resp := &dto.RatingVoteResponse{RatingID: ratingID, Voted: voted}
+	err := s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		if _, err := change(repo); err != nil {
+			return err
+		}
+		count, err := repo.SyncHelpfulCount(ratingID)
+		resp.HelpfulCount = count
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record vote: %w", err)
+	}
+	return resp, nil
 }
 
-func (s *RatingService) ListByProductSlug(productSlug string, req *dto.RatingListRequest) (*dto.PaginatedResponse, error) {
+// findRating loads a visible rating of a visible product
+func (s *RatingService) findRating(ratingID uint) (*models.Rating, error) {
+	rating, err := s.ratingRepo.FindByID(ratingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("failed to find rating: %w", err)
+	}
+	if rating.Status == models.RatingStatusHidden {
+		return nil, ErrRatingNotFound
+	}
+	product, err := s.productRepo.FindByID(rating.ProductID)
+	if err != nil || !models.ProductVisible(product.Status) {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	return rating, nil
+}
+
+// findOwnRating loads the user's rating of the product
+func (s *RatingService) findOwnRating(userID uint, productSlug string) (*models.Rating, error) {
 	product, err := s.findProductBySlug(productSlug)
 	if err != nil {
 		return nil, err
 	}
-	productID := product.ID
+	rating, err := s.ratingRepo.FindByUserAndProduct(userID, product.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("failed to find rating: %w", err)
+	}
+	return rating, nil
+}
 
+func (s *RatingService) reloadRating(ratingID uint) (*dto.RatingResponse, error) {
+	rating, err := s.ratingRepo.FindByID(ratingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rating: %w", err)
+	}
+	return toRatingResponse(rating), nil
+}
+
+func (s *RatingService) ListForAdmin(req *dto.AdminRatingListRequest) (*dto.PaginatedResponse, error) {
 	if req.Page == 0 {
 		req.Page = 1
 	}
 	if req.PageSize == 0 {
-		req.PageSize = 20
+		req.PageSize = 15
 	}
 
 	offset := (req.Page - 1) * req.PageSize
-	ratings, total, err := s.ratingRepo.ListByProductID(productID, offset, req.PageSize)
+	ratings, total, err := s.ratingRepo.ListForAdmin(repository.RatingListParams{
+		Offset: offset,
+		Limit:  req.PageSize,
+		Filter: req.Status,
+		Search: req.Search,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ratings: %w", err)
 	}
 
-	items := make([]dto.RatingResponse, len(ratings))
-	for i, rating := range ratings {
-		items[i] = dto.RatingResponse{
-			ID:        rating.ID,
-			UserID:    rating.UserID,
-			ProductID: rating.ProductID,
-			OrderID:   rating.OrderID,
-			Rating:    rating.Rating,
-			Comment:   rating.Comment,
-			CreatedAt: rating.CreatedAt,
-			UpdatedAt: rating.UpdatedAt,
+	items := make([]dto.AdminRatingResponse, len(ratings))
+	for i := range ratings {
+		rating := &ratings[i]
+		items[i] = dto.AdminRatingResponse{
+			RatingResponse: *toRatingResponse(rating),
+			Status:         rating.Status,
+			Flagged:        rating.Flagged,
+			FlagReason:     rating.FlagReason,
+			ReportCount:    rating.ReportCount,
 		}
-		if rating.User != nil {
-			items[i].UserName = rating.User.FullName
-			items[i].UserAvatar = rating.User.AvatarURL
+		if rating.Product != nil {
+			items[i].ProductName = rating.Product.Name
+			items[i].ProductSlug = rating.Product.Slug
 		}
 	}
 
@@ -196,6 +530,161 @@ func (s *RatingService) ListByProductSlug(productSlug string, req *dto.RatingLis
 	}, nil
 }
 
+// HideForAdmin takes a rating out of listings and product stats
+func (s *RatingService) HideForAdmin(id uint) error {
+	return s.setStatus(id, models.RatingStatusHidden)
+}
+
+// UnhideForAdmin shows a rating again. On a visible rating it only clears the
+// moderation flag, keeping the rating as it is.
+func (s *RatingService) UnhideForAdmin(id uint) error {
+	return s.setStatus(id, models.RatingStatusVisible)
+}
+
+func (s *RatingService) setStatus(id uint, status string) error {
+	rating, err := s.findRatingForAdmin(id)
+	if err != nil {
+		return err
+	}
+	return s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		if err := repo.SetStatus(rating.ID, status); err != nil {
+			return fmt.Errorf("failed to update rating: %w", err)
+		}
+		return refreshProductRatingStats(repo, rating.ProductID)
+	})
+}
+
+// DeleteForAdmin removes a rating with its photos, votes and reports
+func (s *RatingService) DeleteForAdmin(id uint) error {
+	rating, err := s.findRatingForAdmin(id)
+	if err != nil {
+		return err
+	}
+	err = s.ratingRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+		repo := s.ratingRepo.WithTx(tx)
+		if err := repo.Delete(rating.ID); err != nil {
+			return fmt.Errorf("failed to delete rating: %w", err)
+		}
+		return refreshProductRatingStats(repo, rating.ProductID)
+	})
+	if err != nil {
+		return err
+	}
+	for _, photo := range rating.Photos {
+		s.uploads.RemoveImage(storedImageFromURLs(photo.ImageURL, photo.MediumURL, photo.ThumbnailURL))
+	}
+	return nil
+}
+
+// ReplyForAdmin sets the shop's official reply to a rating. An empty reply
+// removes it.
+func (s *RatingService) ReplyForAdmin(id uint, reply string) error {
+	rating, err := s.findRatingForAdmin(id)
+	if err != nil {
+		return err
+	}
+	content := normalizeComment(&reply)
+	var repliedAt *time.Time
+	if content != nil {
+		now := time.Now()
+		repliedAt = &now
+	}
+	if err := s.ratingRepo.SetReply(rating.ID, content, repliedAt); err != nil {
+		return fmt.Errorf("failed to save reply: %w", err)
+	}
+	return nil
+}
+
+func (s *RatingService) findRatingForAdmin(id uint) (*models.Rating, error) {
+	rating, err := s.ratingRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("failed to find rating: %w", err)
+	}
+	return rating, nil
+}
+
+// flagBannedWords flags the rating for moderation when its comment contains
+// banned words. A clean comment leaves an earlier flag in place.
+func (s *RatingService) flagBannedWords(rating *models.Rating) {
+	if rating.Comment == nil || len(s.bannedWords) == 0 {
+		return
+	}
+	// Padding with spaces matches whole words and phrases only
+	text := " " + normalizeModerationText(*rating.Comment) + " "
+	var found []string
+	for _, word := range s.bannedWords {
+		if strings.Contains(text, " "+word.folded+" ") {
+			found = append(found, word.label)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	reason := truncateRunes("Chứa từ cấm: "+strings.Join(found, ", "), 255)
+	rating.Flagged = true
+	rating.FlagReason = &reason
+}
+
+// bannedWord is a configured banned word or phrase: label as configured,
+// for the flag reason, and folded as matched
+type bannedWord struct {
+	label, folded string
+}
+
+// normalizeModerationText folds case and diacritics like product search, so
+// a re-accented word still matches, and collapses everything that is not a
+// letter or digit into single spaces
+func normalizeModerationText(text string) string {
+	return strings.Join(search.Tokenize(text), " ")
+}
+
+// refreshProductRatingStats recomputes the product's rating average and
+// count from its visible ratings
+func refreshProductRatingStats(repo *repository.RatingRepository, productID uint) error {
+	avg, count, err := repo.CalcProductRatingStats(productID)
+	if err != nil {
+		return fmt.Errorf("failed to calculate rating stats: %w", err)
+	}
+	if err := repo.UpdateProductRatingStats(productID, avg, count); err != nil {
+		return fmt.Errorf("failed to update product rating stats: %w", err)
+	}
+	return nil
+}
+
+func toRatingResponse(rating *models.Rating) *dto.RatingResponse {
+	resp := &dto.RatingResponse{
+		ID:           rating.ID,
+		UserID:       rating.UserID,
+		ProductID:    rating.ProductID,
+		OrderID:      rating.OrderID,
+		Rating:       rating.Rating,
+		Comment:      rating.Comment,
+		HelpfulCount: rating.HelpfulCount,
+		CreatedAt:    rating.CreatedAt,
+		UpdatedAt:    rating.UpdatedAt,
+	}
+	if rating.User != nil {
+		resp.UserName = rating.User.FullName
+		resp.UserAvatar = rating.User.AvatarURL
+	}
+	if rating.ShopReply != nil && rating.RepliedAt != nil {
+		resp.ShopReply = &dto.RatingReplyResponse{Content: *rating.ShopReply, RepliedAt: *rating.RepliedAt}
+	}
+	for _, photo := range rating.Photos {
+		resp.Photos = append(resp.Photos, dto.RatingPhotoResponse{
+			ID:           photo.ID,
+			ImageURL:     photo.ImageURL,
+			MediumURL:    photo.MediumURL,
+			ThumbnailURL: photo.ThumbnailURL,
+		})
+	}
+	return resp
+}
+
 func normalizeComment(comment *string) *string {
 	if comment == nil {
 		return nil
@@ -226,7 +715,7 @@ func (s *RatingService) findProductBySlug(productSlug string) (*models.Product,
 		}
 		return nil, fmt.Errorf("failed to find product: %w", err)
 	}
-	if product.Status != models.ProductStatusActive {
+	if !models.ProductVisible(product.Status) {
 		return nil, ErrProductNotFound
 	}
 	return product, nil
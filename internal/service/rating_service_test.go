@@ -3,7 +3,9 @@ package service
 import (
 	"errors"
 	"fmt"
+	"mime/multipart"
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"github.com/kha/foods-drinks/internal/dto"
@@ -28,6 +30,9 @@ func newRatingServiceTestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Rating{},
+		&models.RatingPhoto{},
+		&models.RatingVote{},
+		&models.RatingReport{},
 	); err != nil {
 		t.Fatalf("migrate rating test db: %v", err)
 	}
@@ -37,7 +42,7 @@ func newRatingServiceTestDB(t *testing.T) *gorm.DB {
 func newRatingServiceForTest(db *gorm.DB) *RatingService {
 	ratingRepo := repository.NewRatingRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	return NewRatingService(ratingRepo, productRepo)
+	return NewRatingService(ratingRepo, productRepo, nil, NewCursorCodec("test-secret"), 0, nil)
 }
 
 func TestNormalizeComment(t *testing.T) {
@@ -81,7 +86,7 @@ func TestRatingServiceFindProductBySlug(t *testing.T) {
 
 	db := newRatingServiceTestDB(t)
 	productRepo := repository.NewProductRepository(db)
-	svc := NewRatingService(nil, productRepo)
+	svc := NewRatingService(nil, productRepo, nil, nil, 0, nil)
 
 	cat := &models.Category{Name: "Rating Category", Slug: "rating-cat"}
 	if err := db.Create(cat).Error; err != nil {
@@ -176,6 +181,385 @@ func TestRatingService_CreateUpdateList(t *testing.T) {
 	}
 }
 
+func TestRatingService_ListByProductSlug_Cursor(t *testing.T) {
+	t.Parallel()
+
+	db := newRatingServiceTestDB(t)
+	svc := newRatingServiceForTest(db)
+
+	cat := &models.Category{Name: "Rating", Slug: "rating-cursor-cat"}
+	if err := db.Create(cat).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := &models.Product{CategoryID: cat.ID, Name: "Bun Cha", Slug: "bun-cha-cursor", Classify: models.ClassifyFood, Price: 40000, Stock: 10, Status: models.ProductStatusActive}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	newRating := func(i int, at time.Time) uint {
+		user := &models.User{Email: fmt.Sprintf("cursor-%d@example.com", i), FullName: "Cursor", Role: models.RoleUser, Status: models.UserStatusActive}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		r := &models.Rating{UserID: user.ID, ProductID: product.ID, Rating: 5, CreatedAt: at}
+		if err := db.Create(r).Error; err != nil {
+			t.Fatalf("create rating: %v", err)
+		}
+		return r.ID
+	}
+	// Two ratings share each timestamp; newest first, ties by id descending
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		ids = append(ids, newRating(i, base.Add(time.Duration(i/2)*time.Hour)))
+	}
+	want := []uint{ids[4], ids[3], ids[2], ids[1], ids[0]}
+
+	first, err := svc.ListByProductSlug("bun-cha-cursor", &dto.RatingListRequest{Page: 1, PageSize: 2})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if first.Total != 5 || first.NextCursor == "" {
+		t.Fatalf("first page total = %d, next = %q", first.Total, first.NextCursor)
+	}
+
+	// A rating arriving mid-scroll must not shift the following pages
+	newRating(99, base.Add(24*time.Hour))
+
+	var got []uint
+	page := first
+	for {
+		for _, it := range page.Items.([]dto.RatingResponse) {
+			got = append(got, it.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if page, err = svc.ListByProductSlug("bun-cha-cursor", &dto.RatingListRequest{PageSize: 2, Cursor: page.NextCursor}); err != nil {
+			t.Fatalf("cursor page: %v", err)
+		}
+		if page.Total != 0 {
+			t.Fatalf("cursor page total = %d, want 0", page.Total)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("ids = %v, want %v", got, want)
+	}
+
+	if _, err := svc.ListByProductSlug("bun-cha-cursor", &dto.RatingListRequest{PageSize: 2, Cursor: "bogus"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("bogus cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestRatingService_PhotosVotesAndSummary(t *testing.T) {
+	t.Parallel()
+
+	db := newRatingServiceTestDB(t)
+	svc := NewRatingService(repository.NewRatingRepository(db), repository.NewProductRepository(db), newUploadServiceForTest(t), NewCursorCodec("test-secret"), 2, nil)
+
+	cat := &models.Category{Name: "Rating", Slug: "rating-photo-cat"}
+	if err := db.Create(cat).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := &models.Product{CategoryID: cat.ID, Name: "Banh Xeo", Slug: "banh-xeo-photos", Classify: models.ClassifyFood, Price: 35000, Stock: 10, Status: models.ProductStatusActive}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	var users []uint
+	var ratings []uint
+	for i, stars := range []uint8{5, 4, 5, 1} {
+		user := &models.User{Email: fmt.Sprintf("photo-%d@example.com", i), FullName: "Photo", Role: models.RoleUser, Status: models.UserStatusActive}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		r := &models.Rating{UserID: user.ID, ProductID: product.ID, Rating: stars, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		if err := db.Create(r).Error; err != nil {
+			t.Fatalf("create rating: %v", err)
+		}
+		users = append(users, user.ID)
+		ratings = append(ratings, r.ID)
+	}
+
+	// The first rating gets photos, up to the limit
+	withPhotos, err := svc.AddPhotos(users[0], product.Slug, []*multipart.FileHeader{
+		pngFileHeader(t, "photos", "a.png", 900, 900),
+		pngFileHeader(t, "photos", "b.png", 100, 100),
+	})
+	if err != nil {
+		t.Fatalf("AddPhotos: %v", err)
+	}
+	if len(withPhotos.Photos) != 2 || withPhotos.Photos[0].ThumbnailURL == nil || *withPhotos.Photos[0].ThumbnailURL == withPhotos.Photos[0].ImageURL {
+		t.Fatalf("photos = %+v, want two with a thumbnail for the large one", withPhotos.Photos)
+	}
+	if _, err := svc.AddPhotos(users[0], product.Slug, []*multipart.FileHeader{pngFileHeader(t, "photos", "c.png", 10, 10)}); !errors.Is(err, ErrRatingPhotoLimit) {
+		t.Fatalf("third photo error = %v, want ErrRatingPhotoLimit", err)
+	}
+	afterDelete, err := svc.DeletePhoto(users[0], product.Slug, withPhotos.Photos[1].ID)
+	if err != nil || len(afterDelete.Photos) != 1 {
+		t.Fatalf("DeletePhoto = %+v, %v", afterDelete, err)
+	}
+	if _, err := svc.AddPhotos(users[0], product.Slug, []*multipart.FileHeader{multipartFileHeader(t, "photos", "x.png", []byte("not an image"))}); !errors.Is(err, ErrInvalidFileType) {
+		t.Fatalf("invalid photo error = %v, want ErrInvalidFileType", err)
+	}
+	if _, err := svc.DeletePhoto(users[1], product.Slug, withPhotos.Photos[0].ID); !errors.Is(err, ErrRatingPhotoNotFound) {
+		t.Fatalf("deleting another user's photo error = %v, want ErrRatingPhotoNotFound", err)
+	}
+
+	// One vote per user, never on one's own rating
+	if _, err := svc.Vote(users[1], ratings[1]); !errors.Is(err, ErrRatingOwnVote) {
+		t.Fatalf("own vote error = %v, want ErrRatingOwnVote", err)
+	}
+	for _, voter := range []uint{users[0], users[2], users[2]} {
+		if _, err := svc.Vote(voter, ratings[1]); err != nil {
+			t.Fatalf("Vote: %v", err)
+		}
+	}
+	vote, err := svc.Vote(users[3], ratings[2])
+	if err != nil || vote.HelpfulCount != 1 {
+		t.Fatalf("Vote = %+v, %v", vote, err)
+	}
+	if vote, err := svc.Unvote(users[3], ratings[2]); err != nil || vote.HelpfulCount != 0 || vote.Voted {
+		t.Fatalf("Unvote = %+v, %v", vote, err)
+	}
+	if _, err := svc.Vote(users[0], 9999); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("vote on unknown rating error = %v, want ErrRatingNotFound", err)
+	}
+
+	order := func(sort string) []uint {
+		t.Helper()
+		var ids []uint
+		req := &dto.RatingListRequest{Page: 1, PageSize: 3, Sort: sort}
+		for {
+			page, err := svc.ListByProductSlug(product.Slug, req)
+			if err != nil {
+				t.Fatalf("list %s: %v", sort, err)
+			}
+			for _, it := range page.Items.([]dto.RatingResponse) {
+				ids = append(ids, it.ID)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			req = &dto.RatingListRequest{PageSize: 3, Sort: sort, Cursor: page.NextCursor}
+		}
+	}
+	tests := []struct {
+		sort string
+		want []uint
+	}{
+		{"", []uint{ratings[3], ratings[2], ratings[1], ratings[0]}},
+		{dto.RatingSortHelpful, []uint{ratings[1], ratings[3], ratings[2], ratings[0]}},
+		{dto.RatingSortWithPhotos, []uint{ratings[0], ratings[3], ratings[2], ratings[1]}},
+	}
+	for _, tt := range tests {
+		if got := order(tt.sort); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("sort %q = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+	if _, err := svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{PageSize: 3, Sort: dto.RatingSortHelpful, Cursor: svc.cursors.keysetCursor("created_at", "desc", base, ratings[0])}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("cursor of another sort error = %v, want ErrInvalidCursor", err)
+	}
+
+	list, err := svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{Page: 1, PageSize: 1})
+	if err != nil {
+		t.Fatalf("ListByProductSlug: %v", err)
+	}
+	if list.Summary.Count != 4 || list.Summary.Average != 3.75 {
+		t.Fatalf("summary = %+v, want 4 ratings averaging 3.75", list.Summary)
+	}
+	if got := fmt.Sprint(list.Summary.Distribution); got != "[{5 2} {4 1} {3 0} {2 0} {1 1}]" {
+		t.Fatalf("distribution = %s", got)
+	}
+}
+
+func TestRatingService_Moderation(t *testing.T) {
+	t.Parallel()
+
+	db := newRatingServiceTestDB(t)
+	svc := NewRatingService(repository.NewRatingRepository(db), repository.NewProductRepository(db), nil, NewCursorCodec("test-secret"), 0, []string{"Lừa đảo", " hàng giả "})
+
+	cat := &models.Category{Name: "Moderation", Slug: "moderation-cat"}
+	if err := db.Create(cat).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := &models.Product{CategoryID: cat.ID, Name: "Pho", Slug: "pho-moderation", Classify: models.ClassifyFood, Price: 45000, Stock: 10, Status: models.ProductStatusActive}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	var users []uint
+	for i := 0; i < 3; i++ {
+		user := &models.User{Email: fmt.Sprintf("moderation-%d@example.com", i), FullName: "Moderation", Role: models.RoleUser, Status: models.UserStatusActive}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		order := &models.Order{UserID: user.ID, OrderNumber: fmt.Sprintf("ORD-MOD-%d", i), TotalAmount: product.Price, Status: models.OrderStatusDelivered, ShippingAddress: "HN", ShippingPhone: "0900"}
+		if err := db.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+		if err := db.Create(&models.OrderItem{OrderID: order.ID, ProductID: product.ID, ProductName: product.Name, ProductPrice: product.Price, Quantity: 1, Subtotal: product.Price}).Error; err != nil {
+			t.Fatalf("create order item: %v", err)
+		}
+		users = append(users, user.ID)
+	}
+	create := func(userID uint, stars uint8, comment string) uint {
+		t.Helper()
+		resp, err := svc.CreateByProductSlug(userID, product.Slug, &dto.CreateRatingRequest{Rating: stars, Comment: &comment})
+		if err != nil {
+			t.Fatalf("CreateByProductSlug: %v", err)
+		}
+		return resp.ID
+	}
+	flagged := func(id uint) models.Rating {
+		t.Helper()
+		var r models.Rating
+		if err := db.First(&r, id).Error; err != nil {
+			t.Fatalf("reload rating: %v", err)
+		}
+		return r
+	}
+
+	// Banned words match whole words whatever the case and punctuation
+	scam := create(users[0], 1, "Shop LỪA ĐẢO, đừng mua!")
+	if r := flagged(scam); !r.Flagged || r.FlagReason == nil || *r.FlagReason != "Chứa từ cấm: lừa đảo" {
+		t.Fatalf("banned word rating flagged=%v reason=%v", r.Flagged, r.FlagReason)
+	}
+	// and whatever the diacritics
+	reaccented := "Shop lua dao, đưng mua"
+	r := models.Rating{Comment: &reaccented}
+	svc.flagBannedWords(&r)
+	if !r.Flagged || r.FlagReason == nil || *r.FlagReason != "Chứa từ cấm: lừa đảo" {
+		t.Fatalf("re-accented banned word rating flagged=%v reason=%v", r.Flagged, r.FlagReason)
+	}
+	clean := create(users[1], 5, "Ngon, giao hàng giảm giá nhanh")
+	if flagged(clean).Flagged {
+		t.Fatal("rating with a banned word only as part of a phrase should not be flagged")
+	}
+
+	// Reports flag the rating once per user, never one's own
+	if _, err := svc.Report(users[1], clean, &dto.ReportRatingRequest{Reason: models.RatingReportSpam}); !errors.Is(err, ErrRatingOwnReport) {
+		t.Fatalf("own report error = %v, want ErrRatingOwnReport", err)
+	}
+	if _, err := svc.Report(users[2], clean, &dto.ReportRatingRequest{Reason: models.RatingReportOffTopic}); err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if _, err := svc.Report(users[2], clean, &dto.ReportRatingRequest{Reason: models.RatingReportSpam}); !errors.Is(err, ErrRatingReported) {
+		t.Fatalf("second report error = %v, want ErrRatingReported", err)
+	}
+	if r := flagged(clean); !r.Flagged || r.ReportCount != 1 || *r.FlagReason != "Bị báo cáo: off_topic" {
+		t.Fatalf("reported rating = %+v", r)
+	}
+
+	queue, err := svc.ListForAdmin(&dto.AdminRatingListRequest{Status: repository.RatingFilterFlagged})
+	if err != nil || queue.Total != 2 {
+		t.Fatalf("moderation queue = %+v, %v", queue, err)
+	}
+
+	// Hidden ratings leave the listing, the histogram and the product stats
+	if err := svc.HideForAdmin(scam); err != nil {
+		t.Fatalf("HideForAdmin: %v", err)
+	}
+	if err := svc.UnhideForAdmin(clean); err != nil {
+		t.Fatalf("UnhideForAdmin: %v", err)
+	}
+	var reloaded models.Product
+	db.First(&reloaded, product.ID)
+	if reloaded.RatingCount != 1 || reloaded.RatingAverage != 5 {
+		t.Fatalf("product stats = %d ratings averaging %v, want the visible one only", reloaded.RatingCount, reloaded.RatingAverage)
+	}
+	list, err := svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{})
+	if err != nil || list.Total != 1 || list.Summary.Count != 1 {
+		t.Fatalf("public list = %+v, %v", list, err)
+	}
+	if _, err := svc.Vote(users[1], scam); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("vote on hidden rating error = %v, want ErrRatingNotFound", err)
+	}
+	if queue, _ := svc.ListForAdmin(&dto.AdminRatingListRequest{Status: repository.RatingFilterFlagged}); queue.Total != 0 {
+		t.Fatalf("queue after moderation has %d ratings, want 0", queue.Total)
+	}
+
+	// The shop reply shows under the rating until it is cleared
+	if err := svc.ReplyForAdmin(clean, "  Cảm ơn bạn!  "); err != nil {
+		t.Fatalf("ReplyForAdmin: %v", err)
+	}
+	list, _ = svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{})
+	if items := list.Items.([]dto.RatingResponse); items[0].ShopReply == nil || items[0].ShopReply.Content != "Cảm ơn bạn!" {
+		t.Fatalf("shop reply = %+v", items[0].ShopReply)
+	}
+	if err := svc.ReplyForAdmin(clean, ""); err != nil || flagged(clean).ShopReply != nil {
+		t.Fatalf("clearing the reply: %v", err)
+	}
+
+	if err := svc.DeleteForAdmin(clean); err != nil {
+		t.Fatalf("DeleteForAdmin: %v", err)
+	}
+	var reports int64
+	db.Model(&models.RatingReport{}).Where("rating_id = ?", clean).Count(&reports)
+	db.First(&reloaded, product.ID)
+	if reports != 0 || reloaded.RatingCount != 0 {
+		t.Fatalf("after delete: %d reports, %d ratings counted", reports, reloaded.RatingCount)
+	}
+	if err := svc.HideForAdmin(clean); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("hide deleted rating error = %v, want ErrRatingNotFound", err)
+	}
+}
+
+func TestRatingService_SoldOutProductKeepsRatings(t *testing.T) {
+	t.Parallel()
+
+	db := newRatingServiceTestDB(t)
+	if err := db.AutoMigrate(&models.StockMovement{}); err != nil {
+		t.Fatalf("migrate stock movements: %v", err)
+	}
+	svc := newRatingServiceForTest(db)
+	productRepo := repository.NewProductRepository(db)
+
+	cat := &models.Category{Name: "Sold out", Slug: "sold-out-cat"}
+	if err := db.Create(cat).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := &models.Product{CategoryID: cat.ID, Name: "Bun Cha", Slug: "bun-cha-sold-out", Classify: models.ClassifyFood, Price: 40000, Stock: 2, Status: models.ProductStatusActive}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	var users []uint
+	for i := 0; i < 2; i++ {
+		user := &models.User{Email: fmt.Sprintf("sold-out-%d@example.com", i), FullName: "Sold Out", Role: models.RoleUser, Status: models.UserStatusActive}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		users = append(users, user.ID)
+	}
+	rating := &models.Rating{UserID: users[0], ProductID: product.ID, Rating: 5}
+	if err := db.Create(rating).Error; err != nil {
+		t.Fatalf("create rating: %v", err)
+	}
+
+	// Selling the last items flips the product to out_of_stock
+	if ok, err := productRepo.ApplyStockMovement(&models.StockMovement{ProductID: product.ID, Delta: -2, Reason: models.StockReasonOrder}); err != nil || !ok {
+		t.Fatalf("ApplyStockMovement = %v, %v", ok, err)
+	}
+	var soldOut models.Product
+	db.First(&soldOut, product.ID)
+	if soldOut.Status != models.ProductStatusOutOfStock {
+		t.Fatalf("status = %s, want out_of_stock", soldOut.Status)
+	}
+
+	list, err := svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{})
+	if err != nil || list.Total != 1 {
+		t.Fatalf("ratings of a sold-out product = %+v, %v", list, err)
+	}
+	if _, err := svc.Vote(users[1], rating.ID); err != nil {
+		t.Fatalf("vote on a sold-out product's rating: %v", err)
+	}
+
+	// Inactive products stay hidden
+	db.Model(&models.Product{}).Where("id = ?", product.ID).Update("status", models.ProductStatusInactive)
+	if _, err := svc.ListByProductSlug(product.Slug, &dto.RatingListRequest{}); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("ratings of an inactive product error = %v, want ErrProductNotFound", err)
+	}
+}
+
 func TestRatingService_CreateByProductSlug_NotPurchased(t *testing.T) {
 	t.Parallel()
 
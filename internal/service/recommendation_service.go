@@ -0,0 +1,426 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// Defaults apply when the configured values are not positive
+	DefaultRecommendLookback      = 180 * 24 * time.Hour
+	DefaultRecommendMinSupport    = 2
+	DefaultRecommendMaxPerProduct = 20
+	defaultRecommendCron          = "0 2 * * *"
+
+	defaultRecommendLimit = 8
+	// recentPurchaseLimit bounds how much order history feeds "for you"
+	recentPurchaseLimit   = 50
+	favoriteCategoryLimit = 3
+	bestSellerWindow      = 30 * 24 * time.Hour
+)
+
+// Reasons a product is recommended
+const (
+	RecommendReasonBoughtTogether   = "bought_together"
+	RecommendReasonSameCategory     = "same_category"
+	RecommendReasonSameClassify     = "same_classify"
+	RecommendReasonHistory          = "based_on_history"
+	RecommendReasonFavoriteCategory = "favorite_category"
+	RecommendReasonPopular          = "popular"
+)
+
+// RecommendBuildOptions controls an association rebuild
+type RecommendBuildOptions struct {
+	Lookback      time.Duration
+	MinSupport    int
+	MaxPerProduct int
+}
+
+// RecommendBuildReport summarises an association rebuild
+type RecommendBuildReport struct {
+	Products     int
+	Associations int
+	Took         time.Duration
+}
+
+// RecommendationService serves related products and the personal feed.
+// Co-purchase scores are read from product_associations; when a product or
+// shopper has no history, lists are filled from catalog fallbacks.
+type RecommendationService struct {
+	recRepo        *repository.RecommendationRepository
+	productRepo    *repository.ProductRepository
+	productService *ProductService
+	now            func() time.Time
+}
+
+func NewRecommendationService(recRepo *repository.RecommendationRepository, productRepo *repository.ProductRepository, productService *ProductService) *RecommendationService {
+	return &RecommendationService{recRepo: recRepo, productRepo: productRepo, productService: productService, now: time.Now}
+}
+
+// Rebuild recomputes every association from delivered orders. The score is
+// the cosine similarity support / sqrt(orders(A) * orders(B)), so pairs of
+// best sellers that merely co-occur rank below pairs that mostly sell together.
+func (s *RecommendationService) Rebuild(opts RecommendBuildOptions) (*RecommendBuildReport, error) {
+	if opts.Lookback <= 0 {
+		opts.Lookback = DefaultRecommendLookback
+	}
+	if opts.MinSupport <= 0 {
+		opts.MinSupport = DefaultRecommendMinSupport
+	}
+	if opts.MaxPerProduct <= 0 {
+		opts.MaxPerProduct = DefaultRecommendMaxPerProduct
+	}
+
+	started := s.now()
+	since := started.Add(-opts.Lookback)
+
+	counts, err := s.recRepo.ProductOrderCounts(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count product orders: %w", err)
+	}
+	orderCount := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		orderCount[c.ProductID] = c.OrderCount
+	}
+
+	pairs, err := s.recRepo.CoPurchases(since, opts.MinSupport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count co-purchases: %w", err)
+	}
+
+	byProduct := make(map[uint][]models.ProductAssociation)
+	for _, p := range pairs {
+		denom := math.Sqrt(float64(orderCount[p.ProductID]) * float64(orderCount[p.RelatedProductID]))
+		if denom == 0 {
+			continue
+		}
+		byProduct[p.ProductID] = append(byProduct[p.ProductID], models.ProductAssociation{
+			ProductID:        p.ProductID,
+			RelatedProductID: p.RelatedProductID,
+			Score:            math.Min(1, float64(p.Support)/denom),
+			Support:          p.Support,
+			ComputedAt:       started,
+		})
+	}
+
+	associations := make([]models.ProductAssociation, 0, len(pairs))
+	for _, list := range byProduct {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].RelatedProductID < list[j].RelatedProductID
+		})
+		if len(list) > opts.MaxPerProduct {
+			list = list[:opts.MaxPerProduct]
+		}
+		associations = append(associations, list...)
+	}
+
+	if err := s.recRepo.ReplaceAll(associations); err != nil {
+		return nil, fmt.Errorf("failed to save associations: %w", err)
+	}
+	return &RecommendBuildReport{
+		Products:     len(byProduct),
+		Associations: len(associations),
+		Took:         s.now().Sub(started),
+	}, nil
+}
+
+// Related returns products to show next to the product with slug: products
+// frequently bought with it, then the best rated of its category, then of
+// its classify
+func (s *RecommendationService) Related(slug string, req *dto.RecommendationRequest) (*dto.RecommendationResponse, error) {
+	product, err := s.productRepo.FindBySlug(strings.TrimSpace(slug))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to find product: %w", err)
+	}
+	if !models.ProductVisible(product.Status) {
+		return nil, ErrProductNotFound
+	}
+
+	picker := newRecommendationPicker(recommendLimit(req), product.ID)
+
+	associations, err := s.recRepo.ListByProductID(product.ID, picker.limit*2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load associations: %w", err)
+	}
+	ids := make([]uint, len(associations))
+	for i, a := range associations {
+		ids[i] = a.RelatedProductID
+	}
+	if err := s.pickByIDs(picker, ids, RecommendReasonBoughtTogether); err != nil {
+		return nil, err
+	}
+
+	if err := s.pickTopRated(picker, repository.ProductListParams{Category: product.CategoryID}, RecommendReasonSameCategory); err != nil {
+		return nil, err
+	}
+	if err := s.pickTopRated(picker, repository.ProductListParams{Classify: product.Classify}, RecommendReasonSameClassify); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(picker, req.Locale)
+}
+
+// ForUser returns the personal feed: products bought with what the user
+// ordered before, then the best rated of their favourite categories, then
+// recent best sellers. Products the user already ordered are left out.
+func (s *RecommendationService) ForUser(userID uint, req *dto.RecommendationRequest) (*dto.RecommendationResponse, error) {
+	purchased, err := s.recRepo.PurchasedProductIDs(userID, recentPurchaseLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order history: %w", err)
+	}
+	picker := newRecommendationPicker(recommendLimit(req), purchased...)
+
+	if len(purchased) > 0 {
+		scored, err := s.recRepo.ScoreRelated(purchased, picker.limit*2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to score associations: %w", err)
+		}
+		ids := make([]uint, len(scored))
+		for i, row := range scored {
+			ids[i] = row.ProductID
+		}
+		if err := s.pickByIDs(picker, ids, RecommendReasonHistory); err != nil {
+			return nil, err
+		}
+
+		categories, err := s.favoriteCategories(purchased)
+		if err != nil {
+			return nil, err
+		}
+		for _, categoryID := range categories {
+			if err := s.pickTopRated(picker, repository.ProductListParams{Category: categoryID}, RecommendReasonFavoriteCategory); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !picker.full() {
+		sellers, err := s.recRepo.BestSellers(s.now().Add(-bestSellerWindow), picker.limit+len(picker.seen))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load best sellers: %w", err)
+		}
+		ids := make([]uint, len(sellers))
+		for i, row := range sellers {
+			ids[i] = row.ProductID
+		}
+		if err := s.pickByIDs(picker, ids, RecommendReasonPopular); err != nil {
+			return nil, err
+		}
+	}
+	// A shop without recent sales still has ratings
+	if err := s.pickTopRated(picker, repository.ProductListParams{}, RecommendReasonPopular); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(picker, req.Locale)
+}
+
+// favoriteCategories ranks the categories of the purchased products by how
+// many of them each holds
+func (s *RecommendationService) favoriteCategories(purchased []uint) ([]uint, error) {
+	products, err := s.productRepo.FindByIDs(purchased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load purchased products: %w", err)
+	}
+	count := make(map[uint]int)
+	for _, p := range products {
+		count[p.CategoryID]++
+	}
+	categories := make([]uint, 0, len(count))
+	for id := range count {
+		categories = append(categories, id)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if count[categories[i]] != count[categories[j]] {
+			return count[categories[i]] > count[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	if len(categories) > favoriteCategoryLimit {
+		categories = categories[:favoriteCategoryLimit]
+	}
+	return categories, nil
+}
+
+// pickByIDs adds the active products among ids, keeping the order of ids
+func (s *RecommendationService) pickByIDs(picker *recommendationPicker, ids []uint, reason string) error {
+	if picker.full() || len(ids) == 0 {
+		return nil
+	}
+	products, err := s.productRepo.FindByIDs(ids)
+	if err != nil {
+		return fmt.Errorf("failed to load products: %w", err)
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	ordered := make([]models.Product, 0, len(products))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	picker.add(ordered, reason)
+	return nil
+}
+
+// pickTopRated fills the picker with the best rated active products matching params
+func (s *RecommendationService) pickTopRated(picker *recommendationPicker, params repository.ProductListParams, reason string) error {
+	if picker.full() {
+		return nil
+	}
+	params.Status = models.ProductStatusActive
+	params.SortBy = "rating_average"
+	params.SortDir = "desc"
+	// Fetch enough to cover products that are already picked
+	params.Limit = picker.limit + len(picker.seen)
+	products, err := s.productRepo.ListAfter(params, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list products: %w", err)
+	}
+	picker.add(products, reason)
+	return nil
+}
+
+func (s *RecommendationService) toResponse(picker *recommendationPicker, locale string) (*dto.RecommendationResponse, error) {
+	products := make([]models.Product, len(picker.picked))
+	for i, pick := range picker.picked {
+		products[i] = pick.product
+	}
+	responses, err := s.productService.toAvailableResponses(products)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productService.translations.localizeProducts(locale, responses); err != nil {
+		return nil, err
+	}
+	items := make([]dto.RecommendedProduct, len(picker.picked))
+	for i, pick := range picker.picked {
+		items[i] = dto.RecommendedProduct{ProductResponse: responses[i], Reason: pick.reason}
+	}
+	return &dto.RecommendationResponse{Items: items}, nil
+}
+
+func recommendLimit(req *dto.RecommendationRequest) int {
+	if req == nil || req.Limit <= 0 {
+		return defaultRecommendLimit
+	}
+	return req.Limit
+}
+
+type pickedProduct struct {
+	product models.Product
+	reason  string
+}
+
+// recommendationPicker collects up to limit distinct active products,
+// skipping the excluded ones, in the order they are offered
+type recommendationPicker struct {
+	limit  int
+	seen   map[uint]bool
+	picked []pickedProduct
+}
+
+func newRecommendationPicker(limit int, exclude ...uint) *recommendationPicker {
+	seen := make(map[uint]bool, len(exclude)+limit)
+	for _, id := range exclude {
+		seen[id] = true
+	}
+	return &recommendationPicker{limit: limit, seen: seen}
+}
+
+func (p *recommendationPicker) full() bool {
+	return len(p.picked) >= p.limit
+}
+
+func (p *recommendationPicker) add(products []models.Product, reason string) {
+	for _, product := range products {
+		if p.full() {
+			return
+		}
+		if p.seen[product.ID] || product.Status != models.ProductStatusActive {
+			continue
+		}
+		p.seen[product.ID] = true
+		p.picked = append(p.picked, pickedProduct{product: product, reason: reason})
+	}
+}
+
+// RecommendationScheduler rebuilds product associations on a cron schedule.
+type RecommendationScheduler struct {
+	cfg     *config.RecommendConfig
+	service *RecommendationService
+	c       *cron.Cron
+}
+
+// NewRecommendationScheduler creates a scheduler but does not start it yet.
+// Returns nil when cfg is nil or disabled.
+func NewRecommendationScheduler(cfg *config.RecommendConfig, service *RecommendationService) *RecommendationScheduler {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return &RecommendationScheduler{cfg: cfg, service: service, c: cron.New()}
+}
+
+// Start registers the cron job and begins the scheduler.
+func (s *RecommendationScheduler) Start() {
+	if s == nil {
+		return
+	}
+
+	cronExpr := strings.TrimSpace(s.cfg.Cron)
+	if cronExpr == "" {
+		cronExpr = defaultRecommendCron
+	}
+
+	_, err := s.c.AddFunc(cronExpr, s.RunOnce)
+	if err != nil {
+		log.Printf("[recommendations] failed to register cron %q: %v", cronExpr, err)
+		return
+	}
+
+	s.c.Start()
+	log.Printf("[recommendations] cron started with expression %q", cronExpr)
+}
+
+// Stop gracefully stops the scheduler.
+func (s *RecommendationScheduler) Stop() {
+	if s == nil {
+		return
+	}
+	ctx := s.c.Stop()
+	<-ctx.Done()
+}
+
+// RunOnce performs a single rebuild using the configured options.
+func (s *RecommendationScheduler) RunOnce() {
+	report, err := s.service.Rebuild(RecommendBuildOptions{
+		Lookback:      s.cfg.Lookback,
+		MinSupport:    s.cfg.MinSupport,
+		MaxPerProduct: s.cfg.MaxPerProduct,
+	})
+	if err != nil {
+		log.Printf("[recommendations] rebuild failed: %v", err)
+		return
+	}
+	log.Printf("[recommendations] products=%d associations=%d took=%s", report.Products, report.Associations, report.Took)
+}
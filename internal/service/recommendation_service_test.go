@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newRecommendationServiceTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open recommendation test db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductImage{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.ProductAssociation{},
+	); err != nil {
+		t.Fatalf("migrate recommendation test db: %v", err)
+	}
+	return db
+}
+
+func TestRecommendationService_RebuildRelatedAndForUser(t *testing.T) {
+	t.Parallel()
+
+	db := newRecommendationServiceTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, repository.NewCategoryRepository(db), nil, "https://foods.example.com/", "", nil, nil, nil, nil, nil, nil)
+	svc := NewRecommendationService(repository.NewRecommendationRepository(db), productRepo, productService)
+
+	food := &models.Category{Name: "Food", Slug: "rec-food"}
+	drink := &models.Category{Name: "Drink", Slug: "rec-drink"}
+	for _, c := range []*models.Category{food, drink} {
+		if err := db.Create(c).Error; err != nil {
+			t.Fatalf("create category: %v", err)
+		}
+	}
+	newProduct := func(slug string, category *models.Category, classify string, rating float64) *models.Product {
+		p := &models.Product{CategoryID: category.ID, Name: slug, Slug: slug, Classify: classify, Price: 10000, Stock: 10, RatingAverage: rating, Status: models.ProductStatusActive}
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("create product %s: %v", slug, err)
+		}
+		return p
+	}
+	pho := newProduct("pho", food, models.ClassifyFood, 3)
+	banhMi := newProduct("banh-mi", food, models.ClassifyFood, 4)
+	newProduct("che", food, models.ClassifyFood, 4.8)
+	tra := newProduct("tra-da", drink, models.ClassifyDrink, 3.5)
+	cafe := newProduct("ca-phe", drink, models.ClassifyDrink, 4.5)
+	nuocMia := newProduct("nuoc-mia", drink, models.ClassifyDrink, 2)
+
+	buyer := &models.User{Email: "rec-buyer@example.com", FullName: "Buyer", Role: models.RoleUser, Status: models.UserStatusActive}
+	shopper := &models.User{Email: "rec-shopper@example.com", FullName: "Shopper", Role: models.RoleUser, Status: models.UserStatusActive}
+	for _, u := range []*models.User{buyer, shopper} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	seq := 0
+	newOrder := func(user *models.User, status string, products ...*models.Product) {
+		seq++
+		order := &models.Order{UserID: user.ID, OrderNumber: fmt.Sprintf("ORD-REC-%03d", seq), TotalAmount: 10000, Status: status, ShippingAddress: "HN", ShippingPhone: "0123"}
+		if err := db.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+		for _, p := range products {
+			item := &models.OrderItem{OrderID: order.ID, ProductID: p.ID, ProductName: p.Name, ProductPrice: p.Price, Quantity: 1, Subtotal: p.Price}
+			if err := db.Create(item).Error; err != nil {
+				t.Fatalf("create order item: %v", err)
+			}
+		}
+	}
+	newOrder(buyer, models.OrderStatusDelivered, pho, tra)
+	newOrder(buyer, models.OrderStatusDelivered, pho, tra)
+	newOrder(buyer, models.OrderStatusDelivered, pho, cafe)
+	newOrder(buyer, models.OrderStatusDelivered, banhMi, cafe)
+	// Cancelled orders are not evidence of anything
+	newOrder(buyer, models.OrderStatusCancelled, pho, banhMi)
+	newOrder(buyer, models.OrderStatusCancelled, pho, banhMi)
+	newOrder(shopper, models.OrderStatusPending, pho)
+
+	report, err := svc.Rebuild(RecommendBuildOptions{MinSupport: 2})
+	if err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	// Only pho <-> tra da reaches the support threshold
+	if report.Products != 2 || report.Associations != 2 {
+		t.Fatalf("report = %+v, want 2 products / 2 associations", report)
+	}
+	var assoc models.ProductAssociation
+	if err := db.Where("product_id = ? AND related_product_id = ?", pho.ID, tra.ID).First(&assoc).Error; err != nil {
+		t.Fatalf("load association: %v", err)
+	}
+	// 2 / sqrt(3 orders of pho * 2 orders of tra da)
+	if assoc.Support != 2 || assoc.Score < 0.816 || assoc.Score > 0.817 {
+		t.Fatalf("association = %+v", assoc)
+	}
+
+	slugsAndReasons := func(resp *dto.RecommendationResponse) []string {
+		out := make([]string, len(resp.Items))
+		for i, item := range resp.Items {
+			out[i] = item.Slug + ":" + item.Reason
+		}
+		return out
+	}
+	assertItems := func(name string, resp *dto.RecommendationResponse, want ...string) {
+		t.Helper()
+		got := slugsAndReasons(resp)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	related, err := svc.Related("pho", &dto.RecommendationRequest{Limit: 3})
+	if err != nil {
+		t.Fatalf("related: %v", err)
+	}
+	assertItems("related(pho)", related, "tra-da:bought_together", "che:same_category", "banh-mi:same_category")
+
+	// No history: falls back to the category, then the classify
+	related, err = svc.Related("nuoc-mia", &dto.RecommendationRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("related without history: %v", err)
+	}
+	assertItems("related(nuoc-mia)", related, "ca-phe:same_category", "tra-da:same_category")
+
+	if _, err := svc.Related("missing", nil); err != ErrProductNotFound {
+		t.Fatalf("related(missing) error = %v, want ErrProductNotFound", err)
+	}
+
+	forShopper, err := svc.ForUser(shopper.ID, &dto.RecommendationRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("for shopper: %v", err)
+	}
+	assertItems("for shopper", forShopper, "tra-da:based_on_history", "che:favorite_category")
+
+	// A user without orders gets recent best sellers
+	forNewcomer, err := svc.ForUser(9999, &dto.RecommendationRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("for newcomer: %v", err)
+	}
+	assertItems("for newcomer", forNewcomer, "pho:popular", "tra-da:popular")
+	for _, item := range forNewcomer.Items {
+		if item.ID == nuocMia.ID {
+			t.Fatal("unsold product should not be a best seller")
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
+)
+
+const (
+	defaultSuggestLimit = 8
+	// suggestRefreshInterval bounds how stale popular queries can get, since
+	// logging a search does not trigger a rebuild
+	suggestRefreshInterval = 10 * time.Minute
+	popularQueryWindow     = 30 * 24 * time.Hour
+	popularQueryLimit      = 200
+	searchInsightsLimit    = 50
+	searchQueryMaxLen      = 255
+)
+
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+// SearchService answers autocomplete lookups and keeps the search log.
+// Suggestions come from an in-memory prefix structure that is rebuilt on the
+// first request after the catalog changes.
+type SearchService struct {
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	queryRepo    *repository.SearchQueryRepository
+	autocomplete *search.Autocomplete
+
+	mu      sync.Mutex
+	stale   bool
+	builtAt time.Time
+	now     func() time.Time
+}
+
+func NewSearchService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, queryRepo *repository.SearchQueryRepository) *SearchService {
+	return &SearchService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		queryRepo:    queryRepo,
+		autocomplete: search.NewAutocomplete(),
+		stale:        true,
+		now:          time.Now,
+	}
+}
+
+// CatalogChanged implements CatalogListener
+func (s *SearchService) CatalogChanged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = true
+}
+
+// Suggest returns a ranked mix of products, categories and popular searches
+// whose words start with the words of req.Q
+func (s *SearchService) Suggest(req *dto.SearchSuggestRequest) (*dto.SearchSuggestResponse, error) {
+	q := strings.TrimSpace(req.Q)
+	if len(search.Tokenize(q)) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+
+	if err := s.ensureFresh(); err != nil {
+		return nil, err
+	}
+
+	entries := s.autocomplete.Suggest(q, limit)
+	suggestions := make([]dto.SearchSuggestion, len(entries))
+	for i, e := range entries {
+		suggestions[i] = dto.SearchSuggestion{Type: e.Kind, ID: e.ID, Text: e.Text, Slug: e.Slug}
+	}
+	return &dto.SearchSuggestResponse{Query: q, Suggestions: suggestions}, nil
+}
+
+// Rebuild reloads the autocomplete candidates from the database
+func (s *SearchService) Rebuild() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rebuildLocked()
+}
+
+func (s *SearchService) ensureFresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stale && s.now().Sub(s.builtAt) < suggestRefreshInterval {
+		return nil
+	}
+	return s.rebuildLocked()
+}
+
+func (s *SearchService) rebuildLocked() error {
+	now := s.now()
+
+	products, err := s.productRepo.ListAll()
+	if err != nil {
+		return fmt.Errorf("failed to load products: %w", err)
+	}
+	categories, _, err := s.categoryRepo.List(repository.CategoryListParams{Status: models.CategoryStatusActive, Limit: -1})
+	if err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	queries, err := s.queryRepo.TopQueries(repository.SearchQueryStatsParams{
+		Since:       now.Add(-popularQueryWindow),
+		Limit:       popularQueryLimit,
+		WithResults: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load popular searches: %w", err)
+	}
+
+	entries := make([]search.Entry, 0, len(products)+len(categories)+len(queries))
+	for _, p := range products {
+		if p.Status != models.ProductStatusActive {
+			continue
+		}
+		entries = append(entries, search.Entry{
+			Kind:   search.KindProduct,
+			ID:     p.ID,
+			Text:   p.Name,
+			Slug:   p.Slug,
+			Weight: p.RatingAverage / 5,
+		})
+	}
+	for _, c := range categories {
+		entries = append(entries, search.Entry{Kind: search.KindCategory, ID: c.ID, Text: c.Name, Slug: c.Slug})
+	}
+	if len(queries) > 0 {
+		// Rows are ordered by count, so the first one is the most popular
+		top := math.Log1p(float64(queries[0].SearchCount))
+		for _, q := range queries {
+			entries = append(entries, search.Entry{
+				Kind:   search.KindQuery,
+				Text:   q.Query,
+				Weight: math.Log1p(float64(q.SearchCount)) / top,
+			})
+		}
+	}
+
+	s.autocomplete.Replace(entries)
+	s.stale = false
+	s.builtAt = now
+	return nil
+}
+
+// LogQuery records a submitted search and how many products it found
+func (s *SearchService) LogQuery(query string, resultCount int64, ip string) error {
+	query = strings.TrimSpace(query)
+	normalized := strings.Join(search.Tokenize(query), " ")
+	if normalized == "" {
+		return nil
+	}
+
+	entry := &models.SearchQuery{
+		Query:       truncateRunes(query, searchQueryMaxLen),
+		Normalized:  truncateRunes(normalized, searchQueryMaxLen),
+		ResultCount: resultCount,
+		IPAddress:   ip,
+	}
+	if err := s.queryRepo.Create(entry); err != nil {
+		return fmt.Errorf("failed to log search query: %w", err)
+	}
+	return nil
+}
+
+// GetInsightsForAdmin summarises the search log over the last req.Days days
+func (s *SearchService) GetInsightsForAdmin(req *dto.AdminSearchInsightsRequest) (*dto.AdminSearchInsightsResponse, error) {
+	days := req.Days
+	if days <= 0 {
+		days = 30
+	}
+	since := s.now().AddDate(0, 0, -days)
+
+	total, err := s.queryRepo.CountSince(since, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count searches: %w", err)
+	}
+	zero, err := s.queryRepo.CountSince(since, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count searches: %w", err)
+	}
+	top, err := s.queryRepo.TopQueries(repository.SearchQueryStatsParams{Since: since, Limit: searchInsightsLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load top searches: %w", err)
+	}
+	zeroRows, err := s.queryRepo.TopQueries(repository.SearchQueryStatsParams{Since: since, Limit: searchInsightsLimit, ZeroResults: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zero-result searches: %w", err)
+	}
+
+	resp := &dto.AdminSearchInsightsResponse{
+		Days:          days,
+		TotalSearches: total,
+		ZeroSearches:  zero,
+		TopQueries:    toSearchQueryStats(top),
+		ZeroQueries:   toSearchQueryStats(zeroRows),
+	}
+	if total > 0 {
+		resp.ZeroRate = math.Round(float64(zero)/float64(total)*1000) / 10
+	}
+	return resp, nil
+}
+
+func toSearchQueryStats(rows []repository.SearchQueryStatRow) []dto.AdminSearchQueryStat {
+	stats := make([]dto.AdminSearchQueryStat, len(rows))
+	for i, r := range rows {
+		stats[i] = dto.AdminSearchQueryStat{
+			Query:          r.Query,
+			SearchCount:    r.SearchCount,
+			AvgResultCount: math.Round(r.AvgResultCount*10) / 10,
+		}
+	}
+	return stats
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
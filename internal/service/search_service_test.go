@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+func setupSearchServiceTest(t *testing.T) (*SearchService, *ProductService, *gorm.DB) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}, &models.StockMovement{}, &models.SearchQuery{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	productRepo := repository.NewProductRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	searchSvc := NewSearchService(productRepo, categoryRepo, repository.NewSearchQueryRepository(db))
+	productSvc := NewProductService(productRepo, categoryRepo, nil, "", "", nil, nil, nil, nil, nil, nil, searchSvc)
+	return searchSvc, productSvc, db
+}
+
+func suggestionTexts(t *testing.T, svc *SearchService, q string) []string {
+	t.Helper()
+	resp, err := svc.Suggest(&dto.SearchSuggestRequest{Q: q})
+	if err != nil {
+		t.Fatalf("Suggest(%q): %v", q, err)
+	}
+	texts := make([]string, len(resp.Suggestions))
+	for i, s := range resp.Suggestions {
+		texts[i] = s.Type + ":" + s.Text
+	}
+	return texts
+}
+
+func TestSearchService_SuggestRebuildsAfterCatalogChange(t *testing.T) {
+	t.Parallel()
+
+	svc, productSvc, db := setupSearchServiceTest(t)
+	category := models.Category{Name: "Trà", Slug: "tra", Status: models.CategoryStatusActive}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("seed category: %v", err)
+	}
+	hidden := models.Product{CategoryID: category.ID, Name: "Trà chanh", Slug: "tra-chanh", Classify: models.ClassifyDrink, Price: 15000, Status: models.ProductStatusInactive}
+	if err := db.Create(&hidden).Error; err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+
+	if got := suggestionTexts(t, svc, "tra"); len(got) != 1 || got[0] != "category:Trà" {
+		t.Fatalf("initial suggestions = %v", got)
+	}
+
+	// Changes through ProductService mark the suggestions stale
+	if _, err := productSvc.Create(&dto.CreateProductRequest{
+		CategoryID: category.ID, Name: "Trà sữa trân châu", Classify: models.ClassifyDrink, Price: 30000, Stock: 5,
+	}, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got := suggestionTexts(t, svc, "tra s")
+	if len(got) != 1 || got[0] != "product:Trà sữa trân châu" {
+		t.Fatalf("suggestions after create = %v", got)
+	}
+
+	// Popular searches that found something are suggested after the refresh interval
+	for i := 0; i < 3; i++ {
+		if err := svc.LogQuery("  Trà đào  ", 2, "127.0.0.1"); err != nil {
+			t.Fatalf("LogQuery: %v", err)
+		}
+	}
+	if err := svc.LogQuery("trà xoài", 0, "127.0.0.1"); err != nil {
+		t.Fatalf("LogQuery: %v", err)
+	}
+	if got := suggestionTexts(t, svc, "tra d"); len(got) != 0 {
+		t.Fatalf("logging alone should not rebuild, got %v", got)
+	}
+	svc.now = func() time.Time { return time.Now().Add(suggestRefreshInterval) }
+	if got := suggestionTexts(t, svc, "tra d"); len(got) != 1 || got[0] != "query:Trà đào" {
+		t.Fatalf("suggestions after refresh = %v", got)
+	}
+	if got := suggestionTexts(t, svc, "tra x"); len(got) != 0 {
+		t.Fatalf("zero-result searches must not be suggested, got %v", got)
+	}
+
+	if _, err := svc.Suggest(&dto.SearchSuggestRequest{Q: " !? "}); err != ErrEmptySearchQuery {
+		t.Fatalf("blank query err = %v, want ErrEmptySearchQuery", err)
+	}
+}
+
+func TestSearchService_Insights(t *testing.T) {
+	t.Parallel()
+
+	svc, _, db := setupSearchServiceTest(t)
+	for _, q := range []struct {
+		text    string
+		results int64
+	}{
+		{"Cà phê", 4}, {"ca phe", 2}, {"CÀ PHÊ", 3}, {"bánh mì", 1}, {"pizza", 0}, {"pizza", 0}, {"sushi", 0},
+	} {
+		if err := svc.LogQuery(q.text, q.results, "10.0.0.1"); err != nil {
+			t.Fatalf("LogQuery: %v", err)
+		}
+	}
+	// Outside the window
+	old := models.SearchQuery{Query: "pizza", Normalized: "pizza", CreatedAt: time.Now().AddDate(0, 0, -10)}
+	if err := db.Create(&old).Error; err != nil {
+		t.Fatalf("seed old query: %v", err)
+	}
+
+	insights, err := svc.GetInsightsForAdmin(&dto.AdminSearchInsightsRequest{Days: 7})
+	if err != nil {
+		t.Fatalf("GetInsightsForAdmin: %v", err)
+	}
+	if insights.TotalSearches != 7 || insights.ZeroSearches != 3 || insights.ZeroRate != 42.9 {
+		t.Fatalf("unexpected summary %+v", insights)
+	}
+	if len(insights.TopQueries) != 4 || insights.TopQueries[0].SearchCount != 3 || insights.TopQueries[0].AvgResultCount != 3 {
+		t.Fatalf("unexpected top queries %+v", insights.TopQueries)
+	}
+	if len(insights.ZeroQueries) != 2 || insights.ZeroQueries[0].Query != "pizza" || insights.ZeroQueries[0].SearchCount != 2 {
+		t.Fatalf("unexpected zero-result queries %+v", insights.ZeroQueries)
+	}
+}
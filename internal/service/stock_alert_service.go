@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/robfig/cron/v3"
+)
+
+const defaultLowStockDigestCron = "0 8 * * *"
+
+// LowStockNotifier delivers low-stock alerts to admins
+type LowStockNotifier interface {
+	NotifyLowStockAsync(alert *dto.LowStockAlert)
+}
+
+// StockAlertService alerts admins when products fall to their low-stock
+// threshold. Each product is reported once per dip: the alert is claimed in
+// the database and released when stock recovers above the threshold.
+type StockAlertService struct {
+	productRepo *repository.ProductRepository
+	notifiers   []LowStockNotifier
+	now         func() time.Time
+}
+
+func NewStockAlertService(productRepo *repository.ProductRepository, notifiers ...LowStockNotifier) *StockAlertService {
+	filtered := make([]LowStockNotifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		if n != nil {
+			filtered = append(filtered, n)
+		}
+	}
+	return &StockAlertService{productRepo: productRepo, notifiers: filtered, now: time.Now}
+}
+
+// StockChanged is called once a change to the products' stock is committed.
+// Failures are logged: the stock change itself already succeeded.
+func (s *StockAlertService) StockChanged(productIDs []uint) {
+	if s == nil || len(productIDs) == 0 {
+		return
+	}
+	if err := s.productRepo.ClearLowStockAlerts(productIDs); err != nil {
+		log.Printf("[stock-alert] failed to clear alerts for products %v: %v", productIDs, err)
+	}
+	products, err := s.productRepo.MarkLowStockAlerts(productIDs, s.now())
+	if err != nil {
+		log.Printf("[stock-alert] failed to check products %v: %v", productIDs, err)
+		return
+	}
+	if len(products) == 0 {
+		return
+	}
+	s.notify(&dto.LowStockAlert{Products: toLowStockProducts(products)})
+}
+
+// SendDigest reports every product still at or below its threshold. Nothing
+// is sent when there is none; the returned alert is nil then.
+func (s *StockAlertService) SendDigest() (*dto.LowStockAlert, error) {
+	products, err := s.productRepo.ListLowStock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low-stock products: %w", err)
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+	alert := &dto.LowStockAlert{Digest: true, Products: toLowStockProducts(products)}
+	s.notify(alert)
+	return alert, nil
+}
+
+func (s *StockAlertService) notify(alert *dto.LowStockAlert) {
+	for _, n := range s.notifiers {
+		n.NotifyLowStockAsync(alert)
+	}
+}
+
+func toLowStockProducts(products []models.Product) []dto.LowStockProduct {
+	result := make([]dto.LowStockProduct, len(products))
+	for i, p := range products {
+		result[i] = dto.LowStockProduct{
+			ProductID: p.ID,
+			Name:      p.Name,
+			Slug:      p.Slug,
+			Stock:     p.Stock,
+			Threshold: p.LowStockThreshold,
+			Status:    p.Status,
+		}
+	}
+	return result
+}
+
+func cloneLowStockAlert(alert *dto.LowStockAlert) *dto.LowStockAlert {
+	if alert == nil {
+		return nil
+	}
+	copyAlert := *alert
+	copyAlert.Products = append([]dto.LowStockProduct(nil), alert.Products...)
+	return &copyAlert
+}
+
+// LowStockDigestScheduler sends the low-stock digest on a cron schedule.
+type LowStockDigestScheduler struct {
+	cfg     *config.InventoryConfig
+	service *StockAlertService
+	c       *cron.Cron
+}
+
+// NewLowStockDigestScheduler creates a scheduler but does not start it yet.
+// Returns nil when cfg is nil or the digest is disabled.
+func NewLowStockDigestScheduler(cfg *config.InventoryConfig, service *StockAlertService) *LowStockDigestScheduler {
+	if cfg == nil || !cfg.DigestEnabled {
+		return nil
+	}
+	return &LowStockDigestScheduler{cfg: cfg, service: service, c: cron.New()}
+}
+
+// Start registers the cron job and begins the scheduler.
+func (s *LowStockDigestScheduler) Start() {
+	if s == nil {
+		return
+	}
+
+	cronExpr := strings.TrimSpace(s.cfg.DigestCron)
+	if cronExpr == "" {
+		cronExpr = defaultLowStockDigestCron
+	}
+
+	_, err := s.c.AddFunc(cronExpr, s.RunOnce)
+	if err != nil {
+		log.Printf("[stock-alert] failed to register digest cron %q: %v", cronExpr, err)
+		return
+	}
+
+	s.c.Start()
+	log.Printf("[stock-alert] digest cron started with expression %q", cronExpr)
+}
+
+// Stop gracefully stops the scheduler.
+func (s *LowStockDigestScheduler) Stop() {
+	if s == nil {
+		return
+	}
+	ctx := s.c.Stop()
+	<-ctx.Done()
+}
+
+// RunOnce sends a single digest.
+func (s *LowStockDigestScheduler) RunOnce() {
+	alert, err := s.service.SendDigest()
+	if err != nil {
+		log.Printf("[stock-alert] digest failed: %v", err)
+		return
+	}
+	if alert == nil {
+		log.Printf("[stock-alert] digest skipped: no product is low on stock")
+		return
+	}
+	log.Printf("[stock-alert] digest sent for %d products", len(alert.Products))
+}
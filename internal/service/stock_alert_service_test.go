@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+)
+
+type recordingLowStockNotifier struct {
+	alerts []*dto.LowStockAlert
+}
+
+func (n *recordingLowStockNotifier) NotifyLowStockAsync(alert *dto.LowStockAlert) {
+	n.alerts = append(n.alerts, alert)
+}
+
+func TestStockAlertService_AlertsOncePerDipAndTogglesStatus(t *testing.T) {
+	t.Parallel()
+
+	_, db, _ := setupOrderServiceTest(t)
+	if err := db.Model(&models.Product{}).Where("id = ?", 1).Update("low_stock_threshold", 8).Error; err != nil {
+		t.Fatalf("set threshold: %v", err)
+	}
+	productRepo := repository.NewProductRepository(db)
+	notifier := &recordingLowStockNotifier{}
+	alerts := NewStockAlertService(productRepo, notifier)
+	inventory := NewInventoryService(productRepo, alerts)
+	orders := NewOrderService(repository.NewOrderRepository(db), repository.NewCartRepository(db), productRepo, repository.NewModifierRepository(db), nil, NewCursorCodec("test-secret"), nil, alerts, nil)
+
+	product := func() models.Product {
+		t.Helper()
+		var p models.Product
+		if err := db.First(&p, 1).Error; err != nil {
+			t.Fatalf("query product: %v", err)
+		}
+		return p
+	}
+	move := func(delta int) {
+		t.Helper()
+		req := &dto.AdminStockMovementRequest{ProductID: 1, Quantity: delta}
+		var err error
+		if delta > 0 {
+			_, err = inventory.Receive(1, req)
+		} else {
+			req.Quantity = -delta
+			_, err = inventory.Waste(1, req)
+		}
+		if err != nil {
+			t.Fatalf("stock movement %+d: %v", delta, err)
+		}
+	}
+
+	move(-1) // 9, above the threshold
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("alerts above threshold = %d, want 0", len(notifier.alerts))
+	}
+	move(-1) // 8, reaches the threshold
+	move(-1) // 7, still the same dip
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("alerts after crossing = %d, want 1", len(notifier.alerts))
+	}
+	if got := notifier.alerts[0]; got.Digest || len(got.Products) != 1 || got.Products[0].Stock != 8 || got.Products[0].Threshold != 8 {
+		t.Fatalf("alert = %+v", got)
+	}
+
+	move(5) // 12, recovered
+	if p := product(); p.LowStockAlertedAt != nil {
+		t.Fatalf("alert marker kept after restock: %v", p.LowStockAlertedAt)
+	}
+
+	// The cart holds 2 pho: 10 left, above the threshold
+	if _, err := orders.CreateOrderFromCart(1, &dto.CreateOrderRequest{ShippingAddress: "123 Le Loi", ShippingPhone: "0901234567"}); err != nil {
+		t.Fatalf("CreateOrderFromCart: %v", err)
+	}
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("alerts after order = %d, want 1", len(notifier.alerts))
+	}
+
+	move(-10)
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("alerts after selling out = %d, want 2", len(notifier.alerts))
+	}
+	if got := notifier.alerts[1].Products[0]; got.Stock != 0 || got.Status != models.ProductStatusOutOfStock {
+		t.Fatalf("sold-out alert = %+v", got)
+	}
+	if p := product(); p.Status != models.ProductStatusOutOfStock {
+		t.Fatalf("status at zero stock = %s, want out_of_stock", p.Status)
+	}
+
+	move(3)
+	if p := product(); p.Status != models.ProductStatusActive {
+		t.Fatalf("status after restock = %s, want active", p.Status)
+	}
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("alerts while still below threshold = %d, want 2", len(notifier.alerts))
+	}
+
+	// Hidden products stay hidden and out of the digest
+	hidden := models.Product{CategoryID: 1, Name: "Bun Cha", Slug: "bun-cha", Classify: models.ClassifyFood, Price: 40000, Status: models.ProductStatusInactive}
+	if err := db.Create(&hidden).Error; err != nil {
+		t.Fatalf("seed hidden product: %v", err)
+	}
+	if err := productRepo.SyncStockStatus([]uint{hidden.ID}); err != nil {
+		t.Fatalf("SyncStockStatus: %v", err)
+	}
+	digest, err := alerts.SendDigest()
+	if err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+	if digest == nil || !digest.Digest || len(digest.Products) != 1 || digest.Products[0].ProductID != 1 || digest.Products[0].Stock != 3 {
+		t.Fatalf("digest = %+v", digest)
+	}
+	var status string
+	if err := db.Model(&models.Product{}).Where("id = ?", hidden.ID).Pluck("status", &status).Error; err != nil || status != models.ProductStatusInactive {
+		t.Fatalf("hidden product status = %q, %v", status, err)
+	}
+}
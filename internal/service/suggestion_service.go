@@ -41,6 +41,17 @@ func (s *SuggestionService) Create(userID uint, req *dto.CreateSuggestionRequest
 		}
 	}
 
+	allergens, err := normalizeAllergens(req.Allergens)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeDietaryTags(req.DietaryTags)
+	if err != nil {
+		return nil, err
+	}
+	suggestion.Allergens = allergens
+	suggestion.DietaryTags = tags
+
 	if req.CategoryID != nil && *req.CategoryID > 0 {
 		cat, err := s.categoryRepo.FindByID(*req.CategoryID)
 		if err != nil {
@@ -153,6 +164,8 @@ func toSuggestionResponse(suggestion *models.Suggestion) *dto.SuggestionResponse
 		Description: suggestion.Description,
 		Classify:    suggestion.Classify,
 		CategoryID:  suggestion.CategoryID,
+		Allergens:   append([]string{}, suggestion.Allergens...),
+		DietaryTags: append([]string{}, suggestion.DietaryTags...),
 		Status:      suggestion.Status,
 		AdminNote:   suggestion.AdminNote,
 		CreatedAt:   suggestion.CreatedAt,
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -106,6 +107,8 @@ func TestSuggestionServiceCreate(t *testing.T) {
 		Description: &desc,
 		Classify:    models.ClassifyFood,
 		CategoryID:  &cat.ID,
+		Allergens:   []string{"Milk", "gluten", "milk"},
+		DietaryTags: []string{"Vegetarian", " Low Sugar "},
 	})
 	if err != nil {
 		t.Fatalf("Create: %v", err)
@@ -116,6 +119,17 @@ func TestSuggestionServiceCreate(t *testing.T) {
 	if resp.Description == nil || *resp.Description != "add this item please" {
 		t.Fatalf("description = %v, want trimmed text", resp.Description)
 	}
+	if got := strings.Join(resp.Allergens, ","); got != "milk,gluten" {
+		t.Fatalf("allergens = %q, want milk,gluten", got)
+	}
+	if got := strings.Join(resp.DietaryTags, ","); got != "vegetarian,low-sugar" {
+		t.Fatalf("dietary tags = %q, want vegetarian,low-sugar", got)
+	}
+
+	_, err = svc.Create(u.ID, &dto.CreateSuggestionRequest{Name: "Satay", Classify: models.ClassifyFood, Allergens: []string{"celery"}})
+	if !errors.Is(err, ErrInvalidDietaryInfo) {
+		t.Fatalf("unknown allergen error = %v, want ErrInvalidDietaryInfo", err)
+	}
 }
 
 func TestSuggestionServiceCreate_CategoryNotFound(t *testing.T) {
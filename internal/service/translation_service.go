@@ -0,0 +1,331 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// DefaultLocale is the language of product and category records when none
+// is configured
+const DefaultLocale = "vi"
+
+// defaultLocales are offered when no locales are configured
+var defaultLocales = []string{"vi", "en"}
+
+// translationLimits caps translated text at the lengths the records accept
+var translationLimits = map[string]struct{ name, description int }{
+	models.TranslationEntityProduct:  {name: 255, description: 5000},
+	models.TranslationEntityCategory: {name: 255, description: 2000},
+}
+
+// TranslationService keeps product and category names and descriptions in
+// locales other than the default one and puts them into public responses.
+// Slugs are not translated. A nil *TranslationService serves the default
+// locale only.
+type TranslationService struct {
+	repo          *repository.TranslationRepository
+	defaultLocale string
+	// locales are the supported locales, the default one first
+	locales []string
+}
+
+// NewTranslationService creates a TranslationService. Locales are language
+// codes such as "en"; region subtags are ignored.
+func NewTranslationService(repo *repository.TranslationRepository, defaultLocale string, locales []string) *TranslationService {
+	defaultLocale = normalizeLocale(defaultLocale)
+	if defaultLocale == "" {
+		defaultLocale = DefaultLocale
+	}
+	if len(locales) == 0 {
+		locales = defaultLocales
+	}
+	supported := []string{defaultLocale}
+	for _, l := range locales {
+		if l = normalizeLocale(l); l != "" && !slices.Contains(supported, l) {
+			supported = append(supported, l)
+		}
+	}
+	return &TranslationService{repo: repo, defaultLocale: defaultLocale, locales: supported}
+}
+
+// DefaultLocale returns the language of the records themselves
+func (s *TranslationService) DefaultLocale() string {
+	if s == nil {
+		return DefaultLocale
+	}
+	return s.defaultLocale
+}
+
+// Locales returns the supported locales, the default one first
+func (s *TranslationService) Locales() []string {
+	if s == nil {
+		return []string{DefaultLocale}
+	}
+	return s.locales
+}
+
+// Negotiate picks the locale of a response: lang when it is supported,
+// otherwise the supported language the Accept-Language header prefers most,
+// otherwise the default locale
+func (s *TranslationService) Negotiate(lang, acceptLanguage string) string {
+	if s == nil {
+		return DefaultLocale
+	}
+	if l := normalizeLocale(lang); slices.Contains(s.locales, l) {
+		return l
+	}
+
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if l := normalizeLocale(tag); q > 0 && slices.Contains(s.locales, l) {
+			candidates = append(candidates, candidate{locale: l, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) > 0 {
+		return candidates[0].locale
+	}
+	return s.defaultLocale
+}
+
+// Get returns the translations of a record keyed by locale, for the admin
+func (s *TranslationService) Get(entityType string, id uint) (map[string]dto.TranslationInput, error) {
+	values := make(map[string]dto.TranslationInput)
+	if s == nil {
+		return values, nil
+	}
+	rows, err := s.repo.ListByEntity(entityType, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load translations: %w", err)
+	}
+	for _, row := range rows {
+		in := values[row.Locale]
+		switch row.Field {
+		case models.TranslationFieldName:
+			in.Name = row.Value
+		case models.TranslationFieldDescription:
+			in.Description = row.Value
+		}
+		values[row.Locale] = in
+	}
+	return values, nil
+}
+
+// validate trims submitted translations and checks their locales and lengths
+// before the record they belong to is saved
+func (s *TranslationService) validate(entityType string, values map[string]dto.TranslationInput) (map[string]dto.TranslationInput, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if s == nil {
+		return nil, fmt.Errorf("%w: only %s is supported", ErrInvalidTranslation, DefaultLocale)
+	}
+	limits := translationLimits[entityType]
+	cleaned := make(map[string]dto.TranslationInput, len(values))
+	for locale, in := range values {
+		l := normalizeLocale(locale)
+		if l == s.defaultLocale || !slices.Contains(s.locales, l) {
+			return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidTranslation, locale)
+		}
+		in.Name = strings.TrimSpace(in.Name)
+		in.Description = strings.TrimSpace(in.Description)
+		if utf8.RuneCountInString(in.Name) > limits.name {
+			return nil, fmt.Errorf("%w: the %s name is longer than %d characters", ErrInvalidTranslation, l, limits.name)
+		}
+		if utf8.RuneCountInString(in.Description) > limits.description {
+			return nil, fmt.Errorf("%w: the %s description is longer than %d characters", ErrInvalidTranslation, l, limits.description)
+		}
+		cleaned[l] = in
+	}
+	return cleaned, nil
+}
+
+// withTx returns a service writing within the transaction tx
+func (s *TranslationService) withTx(tx *gorm.DB) *TranslationService {
+	if s == nil {
+		return nil
+	}
+	bound := *s
+	bound.repo = s.repo.WithTx(tx)
+	return &bound
+}
+
+// save stores translations returned by validate. Empty fields are removed so
+// they fall back to the default locale.
+func (s *TranslationService) save(entityType string, id uint, values map[string]dto.TranslationInput) error {
+	for locale, in := range values {
+		fields := make(map[string]string, 2)
+		if in.Name != "" {
+			fields[models.TranslationFieldName] = in.Name
+		}
+		if in.Description != "" {
+			fields[models.TranslationFieldDescription] = in.Description
+		}
+		if err := s.repo.Replace(entityType, id, locale, fields); err != nil {
+			return fmt.Errorf("failed to save %s translation: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// deleteAll removes the translations of a purged record
+func (s *TranslationService) deleteAll(entityType string, id uint) error {
+	if s == nil {
+		return nil
+	}
+	if err := s.repo.DeleteByEntity(entityType, id); err != nil {
+		return fmt.Errorf("failed to delete translations: %w", err)
+	}
+	return nil
+}
+
+// texts returns every translation of the given records, or of all records of
+// the type when ids is nil, grouped by record
+func (s *TranslationService) texts(entityType string, ids []uint) (map[uint][]models.Translation, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var rows []models.Translation
+	var err error
+	if ids == nil {
+		rows, err = s.repo.ListByType(entityType)
+	} else {
+		rows, err = s.repo.ListByEntities(entityType, ids, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load translations: %w", err)
+	}
+	grouped := make(map[uint][]models.Translation)
+	for _, row := range rows {
+		grouped[row.EntityID] = append(grouped[row.EntityID], row)
+	}
+	return grouped, nil
+}
+
+// lookup returns the text of records in locale by record and field. Nothing
+// is looked up for the default locale.
+func (s *TranslationService) lookup(entityType string, ids []uint, locale string) (map[uint]map[string]string, error) {
+	if s == nil || locale == "" || locale == s.defaultLocale || len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.repo.ListByEntities(entityType, ids, locale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load translations: %w", err)
+	}
+	found := make(map[uint]map[string]string)
+	for _, row := range rows {
+		if found[row.EntityID] == nil {
+			found[row.EntityID] = make(map[string]string)
+		}
+		found[row.EntityID][row.Field] = row.Value
+	}
+	return found, nil
+}
+
+// localizeProducts puts the locale's names and descriptions of products,
+// their components and their categories into items
+func (s *TranslationService) localizeProducts(locale string, items []dto.ProductResponse) error {
+	if s == nil || locale == "" || locale == s.defaultLocale || len(items) == 0 {
+		return nil
+	}
+	var productIDs, categoryIDs []uint
+	for _, item := range items {
+		productIDs = append(productIDs, item.ID)
+		for _, c := range item.Components {
+			productIDs = append(productIDs, c.ProductID)
+		}
+		categoryIDs = append(categoryIDs, item.CategoryID)
+		for _, b := range item.Breadcrumbs {
+			categoryIDs = append(categoryIDs, b.ID)
+		}
+	}
+	products, err := s.lookup(models.TranslationEntityProduct, productIDs, locale)
+	if err != nil {
+		return err
+	}
+	categories, err := s.lookup(models.TranslationEntityCategory, categoryIDs, locale)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if name := products[item.ID][models.TranslationFieldName]; name != "" {
+			item.Name = name
+		}
+		if description := products[item.ID][models.TranslationFieldDescription]; description != "" {
+			item.Description = &description
+		}
+		if name := categories[item.CategoryID][models.TranslationFieldName]; name != "" && item.CategoryName != "" {
+			item.CategoryName = name
+		}
+		for j := range item.Breadcrumbs {
+			if name := categories[item.Breadcrumbs[j].ID][models.TranslationFieldName]; name != "" {
+				item.Breadcrumbs[j].Name = name
+			}
+		}
+		for j := range item.Components {
+			if name := products[item.Components[j].ProductID][models.TranslationFieldName]; name != "" {
+				item.Components[j].Name = name
+			}
+		}
+	}
+	return nil
+}
+
+// localizeCategories puts the locale's names and descriptions into items
+func (s *TranslationService) localizeCategories(locale string, items []*dto.CategoryResponse) error {
+	if s == nil || locale == "" || locale == s.defaultLocale || len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	found, err := s.lookup(models.TranslationEntityCategory, ids, locale)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if name := found[item.ID][models.TranslationFieldName]; name != "" {
+			item.Name = name
+		}
+		if description := found[item.ID][models.TranslationFieldDescription]; description != "" {
+			item.Description = &description
+		}
+	}
+	return nil
+}
+
+// normalizeLocale reduces a language tag such as "en-US" to its language
+func normalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag, _, _ = strings.Cut(tag, "-")
+	tag, _, _ = strings.Cut(tag, "_")
+	return tag
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/dto"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/search"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTranslationService_Negotiate(t *testing.T) {
+	t.Parallel()
+
+	svc := NewTranslationService(nil, "vi", []string{"en", "EN-us", "vi"})
+	if got := svc.Locales(); len(got) != 2 || got[0] != "vi" || got[1] != "en" {
+		t.Fatalf("Locales() = %v, want [vi en]", got)
+	}
+
+	tests := []struct {
+		lang   string
+		accept string
+		want   string
+	}{
+		{"", "", "vi"},
+		{"en", "vi", "en"},
+		{"fr", "en-US,en;q=0.9", "en"},
+		{"", "fr-FR, vi;q=0.5, en;q=0.8", "en"},
+		{"", "en;q=0", "vi"},
+		{"", "de, ja;q=0.7", "vi"},
+	}
+	for _, tt := range tests {
+		if got := svc.Negotiate(tt.lang, tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q, %q) = %q, want %q", tt.lang, tt.accept, got, tt.want)
+		}
+	}
+
+	var disabled *TranslationService
+	if got := disabled.Negotiate("en", "en"); got != DefaultLocale {
+		t.Errorf("nil service Negotiate = %q, want %q", got, DefaultLocale)
+	}
+}
+
+func TestTranslationService_ProductsAndCategories(t *testing.T) {
+	t.Parallel()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, DisableForeignKeyConstraintWhenMigrating: true})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductImage{}, &models.StockMovement{}, &models.BundleItem{}, &models.ProductRevision{}, &models.Translation{}, &models.ProductAssociation{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	productRepo := repository.NewProductRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	translations := NewTranslationService(repository.NewTranslationRepository(db), "vi", []string{"vi", "en"})
+	products := NewProductService(productRepo, categoryRepo, nil, "", "", nil, nil, nil, nil, nil, translations)
+	products.index = search.NewInvertedIndex()
+	categories := NewCategoryService(categoryRepo, nil, products, translations)
+
+	if _, err := categories.Create(&dto.CreateCategoryRequest{
+		Name:         "Đồ uống",
+		Translations: map[string]dto.TranslationInput{"fr": {Name: "Boissons"}},
+	}); !errors.Is(err, ErrInvalidTranslation) {
+		t.Fatalf("Create with unsupported locale error = %v, want ErrInvalidTranslation", err)
+	}
+	category, err := categories.Create(&dto.CreateCategoryRequest{
+		Name:         "Đồ uống",
+		Translations: map[string]dto.TranslationInput{"en": {Name: " Drinks "}},
+	})
+	if err != nil {
+		t.Fatalf("Create category: %v", err)
+	}
+	product, err := products.Create(&dto.CreateProductRequest{
+		CategoryID:   category.ID,
+		Name:         "Trà sữa trân châu",
+		Description:  "Trà sữa với trân châu đen",
+		Classify:     models.ClassifyDrink,
+		Price:        30000,
+		Stock:        10,
+		Translations: map[string]dto.TranslationInput{"en": {Name: "Bubble milk tea"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Create product: %v", err)
+	}
+
+	got, _, err := products.GetBySlugWithVersion(product.Slug, "en")
+	if err != nil {
+		t.Fatalf("GetBySlugWithVersion: %v", err)
+	}
+	// The untranslated description falls back to the default locale
+	if got.Name != "Bubble milk tea" || got.CategoryName != "Drinks" || got.Description == nil || *got.Description != "Trà sữa với trân châu đen" {
+		t.Fatalf("english product = %q in %q, description %v", got.Name, got.CategoryName, got.Description)
+	}
+	if got, _, err := products.GetBySlugWithVersion(product.Slug, "vi"); err != nil || got.Name != "Trà sữa trân châu" {
+		t.Fatalf("vietnamese product = %+v, %v", got, err)
+	}
+
+	// Both locales are searchable
+	for _, q := range []string{"bubble", "tran chau"} {
+		result, err := products.List(&dto.ProductListRequest{Search: q, Page: 1, PageSize: 10, Locale: "en"})
+		if err != nil {
+			t.Fatalf("List %q: %v", q, err)
+		}
+		items := result.Items.([]dto.ProductResponse)
+		if len(items) != 1 || items[0].Name != "Bubble milk tea" {
+			t.Fatalf("search %q = %+v", q, items)
+		}
+	}
+
+	browse, err := categories.ListActive(3, "en")
+	if err != nil || len(browse) != 1 || browse[0].Name != "Drinks" || len(browse[0].Products) != 1 || browse[0].Products[0].Name != "Bubble milk tea" {
+		t.Fatalf("ListActive en = %+v, %v", browse, err)
+	}
+
+	// Clearing a translation falls back to the default locale
+	if _, err := products.Update(0, product.ID, &dto.UpdateProductRequest{
+		Translations: map[string]dto.TranslationInput{"en": {}},
+	}, nil, false); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _, err := products.GetBySlugWithVersion(product.Slug, "en"); err != nil || got.Name != "Trà sữa trân châu" {
+		t.Fatalf("product after clearing translation = %+v, %v", got, err)
+	}
+	if result, err := products.List(&dto.ProductListRequest{Search: "bubble", Page: 1, PageSize: 10}); err != nil || len(result.Items.([]dto.ProductResponse)) != 0 {
+		t.Fatalf("cleared translation still found: %+v, %v", result, err)
+	}
+
+	// Recommendations are localized like the product list
+	lemonTea, err := products.Create(&dto.CreateProductRequest{
+		CategoryID:   category.ID,
+		Name:         "Trà chanh",
+		Classify:     models.ClassifyDrink,
+		Price:        20000,
+		Stock:        10,
+		Translations: map[string]dto.TranslationInput{"en": {Name: "Lemon tea"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Create lemon tea: %v", err)
+	}
+	recommendations := NewRecommendationService(repository.NewRecommendationRepository(db), productRepo, products)
+	related, err := recommendations.Related(product.Slug, &dto.RecommendationRequest{Limit: 5, Locale: "en"})
+	if err != nil {
+		t.Fatalf("Related: %v", err)
+	}
+	if len(related.Items) != 1 || related.Items[0].ID != lemonTea.ID || related.Items[0].Name != "Lemon tea" || related.Items[0].CategoryName != "Drinks" {
+		t.Fatalf("related in english = %+v", related.Items)
+	}
+
+	// Translations are saved with the product, so a failed save keeps neither
+	if err := db.Migrator().DropTable(&models.Translation{}); err != nil {
+		t.Fatalf("drop translations: %v", err)
+	}
+	renamed := "Trà chanh mật ong"
+	if _, err := products.Update(0, lemonTea.ID, &dto.UpdateProductRequest{
+		Name:         &renamed,
+		Translations: map[string]dto.TranslationInput{"en": {Name: "Honey lemon tea"}},
+	}, nil, false); err == nil {
+		t.Fatal("Update without a translations table succeeded")
+	}
+	var stored models.Product
+	if err := db.First(&stored, lemonTea.ID).Error; err != nil || stored.Name != "Trà chanh" {
+		t.Fatalf("product after failed translation save = %q, %v", stored.Name, err)
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/repository"
+	"github.com/kha/foods-drinks/internal/storage"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// DefaultUploadGCGracePeriod keeps files uploaded within the last day
+	DefaultUploadGCGracePeriod = 24 * time.Hour
+	defaultUploadGCCron        = "30 3 * * *"
+)
+
+var ErrInvalidGracePeriod = errors.New("grace period cannot be negative")
+
+// UploadGCOptions controls a garbage collection run
+type UploadGCOptions struct {
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+// UploadGCReport summarises a garbage collection run. In dry-run mode Deleted
+// and BytesReclaimed describe what would have been deleted.
+type UploadGCReport struct {
+	DryRun         bool
+	Scanned        int
+	Referenced     int
+	Recent         int
+	Deleted        []storage.Object
+	Failed         int
+	BytesReclaimed int64
+}
+
+// UploadGCService deletes stored uploads that no record references anymore,
+// e.g. avatars that were replaced or images dropped by a product update.
+type UploadGCService struct {
+	uploads    *UploadService
+	uploadRepo *repository.UploadRepository
+	now        func() time.Time
+}
+
+// NewUploadGCService creates a new UploadGCService
+func NewUploadGCService(uploads *UploadService, uploadRepo *repository.UploadRepository) *UploadGCService {
+	return &UploadGCService{uploads: uploads, uploadRepo: uploadRepo, now: time.Now}
+}
+
+// Run scans storage and deletes unreferenced files older than the grace period
+func (s *UploadGCService) Run(ctx context.Context, opts UploadGCOptions) (*UploadGCReport, error) {
+	if s.uploads == nil || s.uploads.store == nil {
+		return nil, ErrUploadUnavailable
+	}
+	if opts.GracePeriod < 0 {
+		return nil, ErrInvalidGracePeriod
+	}
+
+	// References are loaded before listing: a file uploaded after this point is
+	// newer than the cutoff and therefore skipped by the grace period.
+	cutoff := s.now().Add(-opts.GracePeriod)
+	urls, err := s.uploadRepo.ListReferencedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load referenced uploads: %w", err)
+	}
+	referenced := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		if key, ok := s.uploads.KeyFromURL(strings.TrimSpace(u)); ok {
+			referenced[key] = true
+		}
+	}
+
+	report := &UploadGCReport{DryRun: opts.DryRun}
+	var orphans []storage.Object
+	err = s.uploads.store.List(ctx, func(obj storage.Object) error {
+		report.Scanned++
+		switch {
+		case referenced[obj.Key]:
+			report.Referenced++
+		case obj.ModTime.After(cutoff):
+			report.Recent++
+		default:
+			orphans = append(orphans, obj)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uploads: %w", err)
+	}
+
+	for _, obj := range orphans {
+		if !opts.DryRun {
+			if err := s.uploads.store.Delete(ctx, obj.Key); err != nil {
+				log.Printf("[upload-gc] failed to delete %s: %v", obj.Key, err)
+				report.Failed++
+				continue
+			}
+		}
+		report.Deleted = append(report.Deleted, obj)
+		report.BytesReclaimed += obj.Size
+	}
+
+	return report, nil
+}
+
+// UploadGCScheduler runs UploadGCService on a cron schedule.
+type UploadGCScheduler struct {
+	cfg     *config.UploadGCConfig
+	service *UploadGCService
+	c       *cron.Cron
+}
+
+// NewUploadGCScheduler creates a scheduler but does not start it yet.
+// Returns nil when cfg is nil or disabled.
+func NewUploadGCScheduler(cfg *config.UploadGCConfig, service *UploadGCService) *UploadGCScheduler {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return &UploadGCScheduler{cfg: cfg, service: service, c: cron.New()}
+}
+
+// Start registers the cron job and begins the scheduler.
+func (s *UploadGCScheduler) Start() {
+	if s == nil {
+		return
+	}
+
+	cronExpr := strings.TrimSpace(s.cfg.Cron)
+	if cronExpr == "" {
+		cronExpr = defaultUploadGCCron
+	}
+
+	_, err := s.c.AddFunc(cronExpr, s.RunOnce)
+	if err != nil {
+		log.Printf("[upload-gc] failed to register cron %q: %v", cronExpr, err)
+		return
+	}
+
+	s.c.Start()
+	log.Printf("[upload-gc] cron started with expression %q", cronExpr)
+}
+
+// Stop gracefully stops the scheduler.
+func (s *UploadGCScheduler) Stop() {
+	if s == nil {
+		return
+	}
+	ctx := s.c.Stop()
+	<-ctx.Done()
+}
+
+// RunOnce performs a single collection using the configured options.
+func (s *UploadGCScheduler) RunOnce() {
+	grace := s.cfg.GracePeriod
+	if grace <= 0 {
+		grace = DefaultUploadGCGracePeriod
+	}
+
+	report, err := s.service.Run(context.Background(), UploadGCOptions{GracePeriod: grace, DryRun: s.cfg.DryRun})
+	if err != nil {
+		log.Printf("[upload-gc] run failed: %v", err)
+		return
+	}
+	log.Printf("[upload-gc] scanned=%d referenced=%d recent=%d deleted=%d failed=%d reclaimed=%s dry_run=%t",
+		report.Scanned, report.Referenced, report.Recent, len(report.Deleted), report.Failed,
+		FormatBytes(report.BytesReclaimed), report.DryRun)
+}
+
+// FormatBytes renders a byte count for logs and reports (e.g. "1.5 MB").
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/models"
+	"github.com/kha/foods-drinks/internal/repository"
+	"gorm.io/gorm"
+)
+
+func setupUploadGCTest(t *testing.T) (*UploadGCService, *memoryStorage, *gorm.DB) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Category{}, &models.Product{}, &models.ProductImage{}, &models.RatingPhoto{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	store := newMemoryStorage()
+	uploads := NewUploadService(&config.UploadConfig{Path: t.TempDir()}, store, "/uploads")
+	return NewUploadGCService(uploads, repository.NewUploadRepository(db)), store, db
+}
+
+func TestUploadGCService_Run(t *testing.T) {
+	t.Parallel()
+
+	svc, store, db := setupUploadGCTest(t)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	old := now.Add(-48 * time.Hour)
+	objects := map[string]time.Time{
+		"avatar.jpg":                 old,
+		"products/a.jpg":             old,
+		"products/a_thumb.jpg":       old,
+		"categories/c_medium.jpg":    old,
+		"deleted-category.jpg":       old,
+		"orphan-old.jpg":             old,
+		"products/orphan_old.png":    old,
+		"products/just-uploaded.jpg": now.Add(-time.Hour),
+	}
+	for key, modTime := range objects {
+		store.objects[key] = []byte("1234")
+		store.modTimes[key] = modTime
+	}
+
+	avatar := "/uploads/avatar.jpg"
+	if err := db.Create(&models.User{Email: "gc@example.com", FullName: "GC", AvatarURL: &avatar}).Error; err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	medium := "/uploads/categories/c_medium.jpg"
+	category := models.Category{Name: "Drinks", Slug: "drinks", ImageURL: &medium, Status: models.CategoryStatusActive}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("seed category: %v", err)
+	}
+	deletedURL := "/uploads/deleted-category.jpg"
+	deleted := models.Category{Name: "Old", Slug: "old", ImageURL: &deletedURL, Status: models.CategoryStatusActive}
+	if err := db.Create(&deleted).Error; err != nil {
+		t.Fatalf("seed deleted category: %v", err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("soft delete category: %v", err)
+	}
+	thumb := "/uploads/products/a_thumb.jpg"
+	if err := db.Create(&models.ProductImage{ProductID: 1, ImageURL: "/uploads/products/a.jpg", ThumbnailURL: &thumb}).Error; err != nil {
+		t.Fatalf("seed product image: %v", err)
+	}
+
+	report, err := svc.Run(context.Background(), UploadGCOptions{GracePeriod: 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("Run dry-run: %v", err)
+	}
+	if report.Scanned != 8 || report.Referenced != 5 || report.Recent != 1 || len(report.Deleted) != 2 {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	if report.BytesReclaimed != 8 {
+		t.Fatalf("BytesReclaimed = %d, want 8", report.BytesReclaimed)
+	}
+	if len(store.objects) != 8 {
+		t.Fatalf("dry-run must not delete anything, %d objects left", len(store.objects))
+	}
+
+	report, err = svc.Run(context.Background(), UploadGCOptions{GracePeriod: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Deleted) != 2 || report.Failed != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, key := range []string{"orphan-old.jpg", "products/orphan_old.png"} {
+		if _, ok := store.objects[key]; ok {
+			t.Fatalf("expected %s to be deleted", key)
+		}
+	}
+	if len(store.objects) != 6 {
+		t.Fatalf("objects left = %d, want 6", len(store.objects))
+	}
+
+	if _, err := svc.Run(context.Background(), UploadGCOptions{GracePeriod: -time.Hour}); !errors.Is(err, ErrInvalidGracePeriod) {
+		t.Fatalf("negative grace err = %v, want ErrInvalidGracePeriod", err)
+	}
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/storage"
+)
+
+var (
+	ErrFileTooLarge      = errors.New("file too large")
+	ErrInvalidFileType   = errors.New("invalid file type")
+	ErrImageTooLarge     = errors.New("image dimensions too large")
+	ErrUploadUnavailable = errors.New("upload is not configured")
+)
+
+const (
+	// ThumbnailMaxEdge is the longest edge, in pixels, of the thumbnail rendition
+	ThumbnailMaxEdge = 200
+	// MediumMaxEdge is the longest edge, in pixels, of the medium rendition
+	MediumMaxEdge = 800
+	// maxImagePixels guards against decompression bombs before decoding
+	maxImagePixels = 40_000_000
+	jpegQuality    = 85
+)
+
+// StoredImage holds the public URLs of an uploaded image and its renditions.
+// A rendition URL equals URL when the source is already small enough or
+// cannot be decoded (e.g. webp).
+type StoredImage struct {
+	URL          string
+	MediumURL    string
+	ThumbnailURL string
+}
+
+// UploadService validates uploaded files and writes them to the configured
+// storage backend. It is shared by avatar, product and category uploads.
+//
+// Stored URLs always have the form <publicURLPrefix>/<key>, whatever the
+// backend, so records stay valid when files move between backends; the
+// router serves or redirects that prefix.
+type UploadService struct {
+	uploadConfig    *config.UploadConfig
+	store           storage.Storage
+	publicURLPrefix string // e.g. "/uploads" – the route prefix under which files are served
+}
+
+// NewUploadService creates a new UploadService.
+// A nil store falls back to local storage under uploadConfig.Path.
+// publicURLPrefix is the URL path prefix used when serving uploads (e.g. "/uploads").
+func NewUploadService(uploadConfig *config.UploadConfig, store storage.Storage, publicURLPrefix string) *UploadService {
+	if store == nil && uploadConfig != nil {
+		store = storage.NewLocalStorage(uploadConfig.Path, publicURLPrefix)
+	}
+	return &UploadService{
+		uploadConfig:    uploadConfig,
+		store:           store,
+		publicURLPrefix: publicURLPrefix,
+	}
+}
+
+// ValidateImage checks the size, extension and sniffed MIME type of an uploaded
+// file and returns its normalised extension (e.g. ".jpg").
+func (s *UploadService) ValidateImage(file *multipart.FileHeader) (string, error) {
+	if s == nil || s.uploadConfig == nil {
+		return "", ErrUploadUnavailable
+	}
+
+	// Validate file size
+	if file.Size > s.uploadConfig.MaxSize {
+		return "", ErrFileTooLarge
+	}
+
+	// Validate file extension (first-pass, cheap check)
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !s.isAllowedType(ext) {
+		return "", ErrInvalidFileType
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	// Read first 512 bytes for content-type detection (http.DetectContentType only needs up to 512)
+	buf := make([]byte, 512)
+	n, err := src.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	// Validate that the detected MIME type is an allowed image type
+	if !s.isAllowedMIME(http.DetectContentType(buf[:n])) {
+		return "", ErrInvalidFileType
+	}
+
+	return ext, nil
+}
+
+// SaveFile validates and stores the file as-is under dir (relative to the
+// upload root) and returns its public URL.
+func (s *UploadService) SaveFile(file *multipart.FileHeader, dir string) (string, error) {
+	ext, err := s.ValidateImage(file)
+	if err != nil {
+		return "", err
+	}
+
+	name := uuid.New().String() + ext
+	url, err := s.writeUpload(file, dir, name)
+	if err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// SaveImage validates and stores the file under dir together with a medium
+// and a thumbnail rendition resized in pure Go.
+func (s *UploadService) SaveImage(file *multipart.FileHeader, dir string) (*StoredImage, error) {
+	ext, err := s.ValidateImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	base := uuid.New().String()
+	originalURL, err := s.writeUpload(file, dir, base+ext)
+	if err != nil {
+		return nil, err
+	}
+	stored := &StoredImage{URL: originalURL, MediumURL: originalURL, ThumbnailURL: originalURL}
+
+	src, format, err := s.decodeUpload(file)
+	if err != nil {
+		if errors.Is(err, ErrImageTooLarge) {
+			s.Remove(originalURL)
+			return nil, err
+		}
+		// Formats the standard library cannot decode (webp) keep the original only
+		return stored, nil
+	}
+
+	renditions := []struct {
+		suffix  string
+		maxEdge int
+		target  *string
+	}{
+		{suffix: "_medium", maxEdge: MediumMaxEdge, target: &stored.MediumURL},
+		{suffix: "_thumb", maxEdge: ThumbnailMaxEdge, target: &stored.ThumbnailURL},
+	}
+	for _, r := range renditions {
+		bounds := src.Bounds()
+		if bounds.Dx() <= r.maxEdge && bounds.Dy() <= r.maxEdge {
+			continue
+		}
+		url, err := s.writeRendition(resizeToFit(src, r.maxEdge), format, dir, base+r.suffix)
+		if err != nil {
+			s.RemoveImage(stored)
+			return nil, err
+		}
+		*r.target = url
+	}
+
+	return stored, nil
+}
+
+// Remove deletes the file referenced by publicURL from storage. Keys are
+// cleaned so a tampered URL can never address anything outside the storage root.
+func (s *UploadService) Remove(publicURL string) {
+	if s == nil || s.store == nil {
+		return
+	}
+	key, ok := s.KeyFromURL(publicURL)
+	if !ok {
+		return
+	}
+	_ = s.store.Delete(context.Background(), key) // best-effort; ignore error
+}
+
+// KeyFromURL returns the storage key of a stored URL
+func (s *UploadService) KeyFromURL(publicURL string) (string, bool) {
+	if publicURL == "" {
+		return "", false
+	}
+
+	var rel string
+	if s.publicURLPrefix != "" && strings.HasPrefix(publicURL, s.publicURLPrefix+"/") {
+		// Cleaning against "/" drops any ".." segments before they reach the store
+		rel = path.Clean("/" + strings.TrimPrefix(publicURL, s.publicURLPrefix+"/"))
+	} else {
+		// Unknown URL shape – fall back to the base filename only, so any
+		// traversal embedded in a corrupted/tampered URL cannot escape.
+		rel = filepath.Base(filepath.FromSlash(publicURL))
+	}
+
+	key, err := storage.CleanKey(rel)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// RemoveImage deletes an image and its renditions
+func (s *UploadService) RemoveImage(img *StoredImage) {
+	if img == nil {
+		return
+	}
+	s.Remove(img.URL)
+	if img.MediumURL != img.URL {
+		s.Remove(img.MediumURL)
+	}
+	if img.ThumbnailURL != img.URL {
+		s.Remove(img.ThumbnailURL)
+	}
+}
+
+// IsStoredURL reports whether publicURL points at a file managed by the upload storage
+func (s *UploadService) IsStoredURL(publicURL string) bool {
+	return s != nil && s.publicURLPrefix != "" && strings.HasPrefix(publicURL, s.publicURLPrefix+"/")
+}
+
+// IsAllowedExtension is the exported counterpart of isAllowedType, used by the
+// handler layer for early validation before the file reaches the service.
+func (s *UploadService) IsAllowedExtension(ext string) bool {
+	return s.isAllowedType(ext)
+}
+
+// MaxFileSize returns the configured maximum file size in bytes.
+func (s *UploadService) MaxFileSize() int64 {
+	return s.uploadConfig.MaxSize
+}
+
+// MaxSizeHuman returns the configured max file size as a human-readable string (e.g. "2MB").
+func (s *UploadService) MaxSizeHuman() string {
+	const mb = 1024 * 1024
+	const kb = 1024
+	size := s.uploadConfig.MaxSize
+	switch {
+	case size >= mb:
+		return fmt.Sprintf("%dMB", size/mb)
+	case size >= kb:
+		return fmt.Sprintf("%dKB", size/kb)
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
+
+// AllowedTypesHuman returns the configured allowed types as a human-readable string (e.g. "jpg, jpeg, png, webp").
+func (s *UploadService) AllowedTypesHuman() string {
+	return strings.Join(s.uploadConfig.AllowedTypes, ", ")
+}
+
+// isAllowedType checks if the file extension is in the allowed types list.
+// It normalises each configured entry by stripping a leading "." so that
+// both "jpg" and ".jpg" in config are treated identically.
+func (s *UploadService) isAllowedType(ext string) bool {
+	for _, allowed := range s.uploadConfig.AllowedTypes {
+		// Normalise: ensure the configured entry always starts with exactly one dot
+		normalised := "." + strings.TrimPrefix(allowed, ".")
+		if ext == normalised {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMIMETypes maps permitted file extensions to their expected MIME type prefixes.
+// http.DetectContentType returns values like "image/jpeg", "image/png", etc.
+var allowedMIMETypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+}
+
+// isAllowedMIME validates the detected MIME type against the allowed types configured
+// for this service, using magic-byte detection rather than the filename extension.
+func (s *UploadService) isAllowedMIME(detectedMIME string) bool {
+	for _, allowed := range s.uploadConfig.AllowedTypes {
+		// Normalise: strip any leading dot so the map key lookup is consistent
+		key := strings.TrimPrefix(allowed, ".")
+		if expected, ok := allowedMIMETypes[key]; ok {
+			// detectedMIME may carry parameters (e.g. "image/jpeg; charset=…") – use prefix match
+			if strings.HasPrefix(detectedMIME, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeUpload copies the uploaded file to <dir>/<name> in storage
+func (s *UploadService) writeUpload(file *multipart.FileHeader, dir, name string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	return s.put(dir, name, src, file.Size)
+}
+
+func (s *UploadService) writeRendition(img image.Image, format, dir, base string) (string, error) {
+	var buf bytes.Buffer
+	name := base + ".jpg"
+	// PNG keeps transparency; everything else is re-encoded as JPEG
+	if format == "png" || format == "gif" {
+		name = base + ".png"
+		if err := png.Encode(&buf, img); err != nil {
+			return "", fmt.Errorf("failed to encode rendition: %w", err)
+		}
+	} else if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return "", fmt.Errorf("failed to encode rendition: %w", err)
+	}
+	return s.put(dir, name, &buf, int64(buf.Len()))
+}
+
+func (s *UploadService) put(dir, name string, r io.Reader, size int64) (string, error) {
+	key := name
+	if dir != "" {
+		key = strings.Trim(dir, "/") + "/" + name
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if err := s.store.Put(context.Background(), key, r, size, contentType); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// Build the public URL from the configured prefix, never from the
+	// backend location, so OS separators or bucket hosts never leak into records.
+	return s.publicURLPrefix + "/" + key, nil
+}
+
+func (s *UploadService) decodeUpload(file *multipart.FileHeader) (image.Image, string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = src.Close() }()
+
+	cfg, format, err := image.DecodeConfig(src)
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	if seeker, ok := src.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, "", err
+		}
+	} else {
+		_ = src.Close()
+		if src, err = file.Open(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	img, format, err := image.Decode(src)
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// resizeToFit downscales src so its longest edge is maxEdge, averaging every
+// source pixel that falls into a destination pixel (box filter).
+func resizeToFit(src image.Image, maxEdge int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw >= sh {
+		dw = maxEdge
+		dh = max(1, sh*maxEdge/sw)
+	} else {
+		dh = maxEdge
+		dw = max(1, sw*maxEdge/sh)
+	}
+
+	// Normalise the source to premultiplied RGBA so pixels can be read directly
+	rgba, ok := src.(*image.RGBA)
+	if !ok || rgba.Bounds().Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, sw, sh))
+		draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := dy * sh / dh
+		y1 := max(y0+1, (dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := dx * sw / dw
+			x1 := max(x0+1, (dx+1)*sw/dw)
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[y*rgba.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
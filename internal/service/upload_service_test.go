@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+	"github.com/kha/foods-drinks/internal/storage"
+)
+
+func newUploadServiceForTest(t *testing.T) *UploadService {
+	t.Helper()
+	return NewUploadService(&config.UploadConfig{
+		Path:         t.TempDir(),
+		MaxSize:      5 * 1024 * 1024,
+		AllowedTypes: []string{"jpg", "jpeg", "png"},
+	}, nil, "/uploads")
+}
+
+// pngFileHeader encodes a w×h PNG and returns it as an uploaded multipart file
+func pngFileHeader(t *testing.T, field, filename string, w, h int) *multipart.FileHeader {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return multipartFileHeader(t, field, filename, data.Bytes())
+}
+
+func multipartFileHeader(t *testing.T, field, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close multipart writer: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(10 << 20); err != nil {
+		t.Fatalf("parse multipart form: %v", err)
+	}
+	return req.MultipartForm.File[field][0]
+}
+
+func uploadedImageSize(t *testing.T, svc *UploadService, publicURL string) (int, int) {
+	t.Helper()
+
+	rel := strings.TrimPrefix(publicURL, "/uploads/")
+	f, err := os.Open(filepath.Join(svc.uploadConfig.Path, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatalf("open %s: %v", publicURL, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("decode %s: %v", publicURL, err)
+	}
+	return cfg.Width, cfg.Height
+}
+
+func TestUploadService_SaveImageGeneratesRenditions(t *testing.T) {
+	t.Parallel()
+
+	svc := newUploadServiceForTest(t)
+	stored, err := svc.SaveImage(pngFileHeader(t, "image_files", "large.png", 1000, 500), "products")
+	if err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+
+	if !strings.HasPrefix(stored.URL, "/uploads/products/") {
+		t.Fatalf("URL = %s, want /uploads/products/ prefix", stored.URL)
+	}
+	if stored.MediumURL == stored.URL || stored.ThumbnailURL == stored.URL {
+		t.Fatalf("expected distinct renditions, got %+v", stored)
+	}
+
+	if w, h := uploadedImageSize(t, svc, stored.URL); w != 1000 || h != 500 {
+		t.Fatalf("original = %dx%d, want 1000x500", w, h)
+	}
+	if w, h := uploadedImageSize(t, svc, stored.MediumURL); w != MediumMaxEdge || h != 400 {
+		t.Fatalf("medium = %dx%d, want %dx400", w, h, MediumMaxEdge)
+	}
+	if w, h := uploadedImageSize(t, svc, stored.ThumbnailURL); w != ThumbnailMaxEdge || h != 100 {
+		t.Fatalf("thumbnail = %dx%d, want %dx100", w, h, ThumbnailMaxEdge)
+	}
+
+	svc.RemoveImage(stored)
+	for _, u := range []string{stored.URL, stored.MediumURL, stored.ThumbnailURL} {
+		rel := strings.TrimPrefix(u, "/uploads/")
+		if _, err := os.Stat(filepath.Join(svc.uploadConfig.Path, filepath.FromSlash(rel))); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, stat err = %v", u, err)
+		}
+	}
+}
+
+func TestUploadService_SaveImageSmallReusesOriginal(t *testing.T) {
+	t.Parallel()
+
+	svc := newUploadServiceForTest(t)
+	stored, err := svc.SaveImage(pngFileHeader(t, "image_files", "small.png", 120, 80), "categories")
+	if err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+	if stored.MediumURL != stored.URL || stored.ThumbnailURL != stored.URL {
+		t.Fatalf("expected renditions to reuse the original, got %+v", stored)
+	}
+}
+
+func TestUploadService_SaveImageRejectsNonImage(t *testing.T) {
+	t.Parallel()
+
+	svc := newUploadServiceForTest(t)
+	file := multipartFileHeader(t, "image_files", "fake.png", []byte("<html>not an image</html>"))
+	if _, err := svc.SaveImage(file, "products"); !errors.Is(err, ErrInvalidFileType) {
+		t.Fatalf("SaveImage err = %v, want ErrInvalidFileType", err)
+	}
+}
+
+func TestUploadService_RemoveStaysInsideUploadDir(t *testing.T) {
+	t.Parallel()
+
+	svc := newUploadServiceForTest(t)
+	outside := filepath.Join(filepath.Dir(svc.uploadConfig.Path), "outside.txt")
+	if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(outside) })
+
+	svc.Remove("/uploads/../outside.txt")
+	svc.Remove("/uploads/products/../../outside.txt")
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("file outside upload dir was removed: %v", err)
+	}
+}
+
+// memoryStorage records objects in memory to check that uploads go through the Storage interface
+type memoryStorage struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	modTimes map[string]time.Time
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		objects:  map[string][]byte{},
+		types:    map[string]string{},
+		modTimes: map[string]time.Time{},
+	}
+}
+
+func (m *memoryStorage) Put(_ context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	m.types[key] = contentType
+	m.modTimes[key] = time.Now()
+	return nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	delete(m.modTimes, key)
+	return nil
+}
+
+func (m *memoryStorage) List(_ context.Context, fn func(storage.Object) error) error {
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.objects))
+	for key := range m.objects {
+		keys = append(keys, key)
+	}
+	objects := make([]storage.Object, len(keys))
+	sort.Strings(keys)
+	for i, key := range keys {
+		objects[i] = storage.Object{Key: key, Size: int64(len(m.objects[key])), ModTime: m.modTimes[key]}
+	}
+	m.mu.Unlock()
+
+	for _, obj := range objects {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) URL(_ context.Context, key string) (string, error) {
+	return "https://cdn.example.com/" + key, nil
+}
+
+func TestUploadService_UsesConfiguredStorage(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryStorage()
+	svc := NewUploadService(&config.UploadConfig{
+		Path:         t.TempDir(),
+		MaxSize:      5 * 1024 * 1024,
+		AllowedTypes: []string{"png"},
+	}, store, "/uploads")
+
+	stored, err := svc.SaveImage(pngFileHeader(t, "image_files", "large.png", 900, 300), "products")
+	if err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+	if len(store.objects) != 3 {
+		t.Fatalf("objects in storage = %d, want 3", len(store.objects))
+	}
+
+	key, ok := svc.KeyFromURL(stored.ThumbnailURL)
+	if !ok || !strings.HasPrefix(key, "products/") {
+		t.Fatalf("KeyFromURL(%s) = %q, %v", stored.ThumbnailURL, key, ok)
+	}
+	if got := store.types[key]; got != "image/png" {
+		t.Fatalf("content type = %q, want image/png", got)
+	}
+	if _, err := os.Stat(filepath.Join(svc.uploadConfig.Path, "products")); !os.IsNotExist(err) {
+		t.Fatalf("nothing should be written to the local upload dir, stat err = %v", err)
+	}
+
+	svc.RemoveImage(stored)
+	if len(store.objects) != 0 {
+		t.Fatalf("expected all renditions removed, got %d objects", len(store.objects))
+	}
+}
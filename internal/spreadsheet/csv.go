@@ -0,0 +1,28 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM lets Excel recognise exported CSV files as UTF-8
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return r.ReadAll()
+}
@@ -0,0 +1,74 @@
+// Package spreadsheet reads and writes the first sheet of CSV and XLSX files
+// as rows of strings. Only what tabular imports and exports need is
+// supported: no styles, formulas or multiple sheets.
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Supported formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+	ErrInvalidFile       = errors.New("invalid spreadsheet file")
+)
+
+// FormatFromFilename picks the format from a file extension
+func FormatFromFilename(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ContentType is the MIME type served for a format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Write encodes rows in the given format
+func Write(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+// Read decodes the rows of data in the given format. Trailing empty cells
+// are kept as read, so rows may have different lengths.
+func Read(data []byte, format string) ([][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(data)
+	case FormatXLSX:
+		rows, err = readXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,81 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]string{
+		{"slug", "name", "price", "description"},
+		{"pho-bo", "Phở bò", "45000", "Nước dùng \"đậm\", thêm hành\nvà rau thơm"},
+		{"007", "Trà <đá> & chanh", "5000.5", ""},
+	}
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, rows); err != nil {
+			t.Fatalf("%s Write: %v", format, err)
+		}
+		got, err := Read(buf.Bytes(), format)
+		if err != nil {
+			t.Fatalf("%s Read: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, rows) {
+			t.Fatalf("%s round trip = %q, want %q", format, got, rows)
+		}
+	}
+}
+
+func TestRead_XLSXSharedStringsAndGaps(t *testing.T) {
+	t.Parallel()
+
+	// Laid out the way spreadsheet applications save: shared strings, an
+	// absolute sheet target, a skipped row and a skipped cell
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRelationships + `">` +
+			`<sheets><sheet name="Menu" sheetId="3" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="` + nsPackageRels + `">` +
+			`<Relationship Id="rId7" Type="` + nsRelationships + `/worksheet" Target="/xl/worksheets/menu.xml"/>` +
+			`</Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="` + nsMain + `"><si><t>slug</t></si><si><r><t>Bún </t></r><r><t>chả</t></r></si></sst>`,
+		"xl/worksheets/menu.xml": `<worksheet xmlns="` + nsMain + `"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1"><v>12</v></c></row>` +
+			`<row r="3"><c r="B3" t="s"><v>1</v></c><c r="D3" t="str"><v>x</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+
+	got, err := Read(buf.Bytes(), FormatXLSX)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := [][]string{{"slug", "12"}, nil, {"", "Bún chả", "", "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %q, want %q", got, want)
+	}
+
+	if _, err := Read([]byte("not a zip"), FormatXLSX); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("Read garbage error = %v, want ErrInvalidFile", err)
+	}
+	if _, err := FormatFromFilename("menu.xls"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("FormatFromFilename(.xls) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
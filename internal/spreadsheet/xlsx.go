@@ -0,0 +1,295 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxXLSXPartSize bounds the uncompressed size of one part of the
+	// archive so a small upload cannot expand into gigabytes
+	maxXLSXPartSize = 32 << 20
+	// MaxRows is the largest row number read from a sheet
+	MaxRows = 100000
+	// maxColumns is the column count of an Excel sheet (A to XFD)
+	maxColumns = 16384
+)
+
+const (
+	nsMain          = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsPackageRels   = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="` + nsPackageRels + `">` +
+		`<Relationship Id="rId1" Type="` + nsRelationships + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRelationships + `">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="` + nsPackageRels + `">` +
+		`<Relationship Id="rId1" Type="` + nsRelationships + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// writeXLSX writes a single-sheet workbook. Text is stored inline rather
+// than in a shared string table, which keeps the writer streaming.
+func writeXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+part.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<worksheet xmlns="` + nsMain + `"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, i+1)
+		for j, value := range row {
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if isPlainNumber(value) {
+				fmt.Fprintf(&buf, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&buf, []byte(value)); err != nil {
+				return err
+			}
+			buf.WriteString(`</t></is></c>`)
+		}
+		buf.WriteString(`</row>`)
+		if _, err := buf.WriteTo(f); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	if _, err := buf.WriteTo(f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// isPlainNumber reports whether value reads back unchanged when stored as a
+// number, so codes such as "007" stay text
+func isPlainNumber(value string) bool {
+	f, err := strconv.ParseFloat(value, 64)
+	return err == nil && strconv.FormatFloat(f, 'f', -1, 64) == value
+}
+
+// columnName turns a zero-based index into a column letter: 0 is A, 26 is AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// columnIndex is the inverse of columnName for the letters of a cell
+// reference such as "AB12". It returns -1 when ref has no letters.
+func columnIndex(ref string) int {
+	index := 0
+	for i, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if i == 3 {
+			return maxColumns
+		}
+		index = index*26 + int(r-'A') + 1
+	}
+	return index - 1
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins plain and rich text runs
+func (t xlsxText) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.T)
+	for _, r := range t.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			IS xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(f, &shared); err != nil {
+			return nil, fmt.Errorf("shared strings: %w", err)
+		}
+	}
+
+	f, ok := parts[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("missing sheet %s", sheetPath)
+	}
+	var sheet xlsxWorksheet
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, fmt.Errorf("sheet: %w", err)
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// Rows and cells left out of the file are empty
+		rowIndex := len(rows)
+		if row.R > 0 {
+			rowIndex = row.R - 1
+		}
+		if rowIndex < len(rows) || rowIndex >= MaxRows {
+			return nil, fmt.Errorf("row %d out of order or beyond %d rows", rowIndex+1, MaxRows)
+		}
+		for len(rows) < rowIndex {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for _, c := range row.Cells {
+			col := len(values)
+			if c.R != "" {
+				col = columnIndex(c.R)
+			}
+			if col < len(values) || col >= maxColumns {
+				return nil, fmt.Errorf("cell %q out of order", c.R)
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			value := c.V
+			switch c.T {
+			case "s":
+				i, err := strconv.Atoi(c.V)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %q refers to unknown shared string %q", c.R, c.V)
+				}
+				value = shared.Items[i].String()
+			case "inlineStr":
+				value = c.IS.String()
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// firstSheetPath follows the workbook relationships to the first sheet
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	f, ok := parts["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("missing workbook")
+	}
+	var wb xlsxWorkbook
+	if err := decodePart(f, &wb); err != nil {
+		return "", fmt.Errorf("workbook: %w", err)
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("workbook has no sheet")
+	}
+
+	f, ok = parts["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", errors.New("missing workbook relationships")
+	}
+	var rels xlsxRelationships
+	if err := decodePart(f, &rels); err != nil {
+		return "", fmt.Errorf("workbook relationships: %w", err)
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != wb.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("no relationship for sheet %q", wb.Sheets[0].ID)
+}
+
+func decodePart(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > maxXLSXPartSize {
+		return fmt.Errorf("%s is larger than %d bytes", f.Name, maxXLSXPartSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// The declared size is not trusted either
+	return xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v)
+}
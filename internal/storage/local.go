@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files under a directory on the local filesystem. It is
+// only suitable for single-instance deployments.
+type LocalStorage struct {
+	root            string
+	publicURLPrefix string
+}
+
+// NewLocalStorage creates a LocalStorage rooted at root
+func NewLocalStorage(root, publicURLPrefix string) *LocalStorage {
+	return &LocalStorage{root: root, publicURLPrefix: strings.TrimRight(publicURLPrefix, "/")}
+}
+
+// Root returns the directory files are stored in
+func (s *LocalStorage) Root() string {
+	return s.root
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	target, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(target)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(target)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	target, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(_ context.Context, key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.publicURLPrefix + "/" + cleaned, nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, fn func(Object) error) error {
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(Object{Key: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing has been uploaded yet
+		return nil
+	}
+	return err
+}
+
+// resolve maps key to an absolute path that is strictly inside the root
+func (s *LocalStorage) resolve(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	rootAbs, err := filepath.Abs(s.root)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(rootAbs, filepath.FromSlash(cleaned))
+	if !strings.HasPrefix(target, rootAbs+string(os.PathSeparator)) {
+		return "", ErrInvalidKey
+	}
+	return target, nil
+}
@@ -0,0 +1,389 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+)
+
+const (
+	s3Algorithm         = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload   = "UNSIGNED-PAYLOAD"
+	s3DefaultRegion     = "us-east-1"
+	s3DefaultExpiry     = 15 * time.Minute
+	s3MaxPresignExpiry  = 7 * 24 * time.Hour
+	s3DefaultTimeoutSec = 30
+	s3AmzDateFormat     = "20060102T150405Z"
+	s3ShortDateFormat   = "20060102"
+)
+
+// S3Storage talks to any S3-compatible object store using Signature V4.
+// Only the handful of calls the app needs are implemented, which keeps the
+// dependency footprint at the standard library.
+type S3Storage struct {
+	endpoint      *url.URL
+	region        string
+	bucket        string
+	accessKey     string
+	secretKey     string
+	pathStyle     bool
+	publicBaseURL string
+	signedURLs    bool
+	signedExpiry  time.Duration
+	client        *http.Client
+	now           func() time.Time
+}
+
+// NewS3Storage validates cfg and creates an S3Storage
+func NewS3Storage(cfg *config.S3Config) (*S3Storage, error) {
+	if strings.TrimSpace(cfg.Endpoint) == "" || strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, errors.New("s3 storage requires endpoint and bucket")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	expiry := cfg.SignedURLExpiry
+	if expiry <= 0 {
+		expiry = s3DefaultExpiry
+	}
+	if expiry > s3MaxPresignExpiry {
+		expiry = s3MaxPresignExpiry
+	}
+	timeout := cfg.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = s3DefaultTimeoutSec
+	}
+
+	return &S3Storage{
+		endpoint:      endpoint,
+		region:        region,
+		bucket:        cfg.Bucket,
+		accessKey:     cfg.AccessKeyID,
+		secretKey:     cfg.SecretAccessKey,
+		pathStyle:     cfg.UsePathStyle,
+		publicBaseURL: strings.TrimRight(cfg.PublicBaseURL, "/"),
+		signedURLs:    cfg.SignedURLs,
+		signedExpiry:  expiry,
+		client:        &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		now:           time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return s.do(req, http.StatusOK)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, target.String(), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (s *S3Storage) URL(_ context.Context, key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	// Private files are always signed so the bucket never has to expose them
+	if _, private := PrivateOwner(cleaned); s.signedURLs || private {
+		return s.presign(http.MethodGet, cleaned, s.signedExpiry)
+	}
+	if s.publicBaseURL != "" {
+		return s.publicBaseURL + "/" + s3EscapePath(cleaned), nil
+	}
+	target, err := s.objectURL(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return target.String(), nil
+}
+
+// listBucketResult is the subset of the ListObjectsV2 response we read
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Storage) List(ctx context.Context, fn func(Object) error) error {
+	token := ""
+	for {
+		page, err := s.listPage(ctx, token)
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Contents {
+			if err := fn(Object{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *S3Storage) listPage(ctx context.Context, token string) (*listBucketResult, error) {
+	u := *s.endpoint
+	bucketPath := "/"
+	if s.pathStyle {
+		bucketPath = "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(s.endpoint.Path, "/") + bucketPath
+	u.RawPath = ""
+
+	query := url.Values{}
+	query.Set("list-type", "2")
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 list %s: %w", s.bucket, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 list %s: unexpected status %d: %s", s.bucket, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var page listBucketResult
+	if err := xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("s3 list %s: failed to decode response: %w", s.bucket, err)
+	}
+	return &page, nil
+}
+
+// objectURL builds the virtual-hosted or path-style URL of an object
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	u := *s.endpoint
+	objectPath := "/" + cleaned
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(s.endpoint.Path, "/") + objectPath
+	u.RawPath = strings.TrimRight(s.endpoint.EscapedPath(), "/") + s3EscapePath(objectPath)
+	return &u, nil
+}
+
+func (s *S3Storage) do(req *http.Request, okStatus ...int) error {
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, status := range okStatus {
+		if resp.StatusCode == status {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrObjectNotFound
+	}
+	return fmt.Errorf("s3 %s %s: unexpected status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// sign adds Signature V4 headers to req. The payload is not hashed
+// (UNSIGNED-PAYLOAD), so request bodies can be streamed.
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3AmzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	canonicalHeaders, signedHeaders := s3CanonicalHeaders(headers)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+// presign returns a query-string authenticated URL valid for expiry
+func (s *S3Storage) presign(method, key string, expiry time.Duration) (string, error) {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format(s3AmzDateFormat)
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalHeaders, signedHeaders := s3CanonicalHeaders(map[string]string{"host": target.Host})
+	canonicalRequest := strings.Join([]string{
+		method,
+		target.EscapedPath(),
+		s3CanonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+	target.RawQuery = s3CanonicalQuery(query)
+	return target.String(), nil
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(s3ShortDateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+s.secretKey), now.Format(s3ShortDateFormat))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3CanonicalHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+func s3CanonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath URI-encodes each path segment as required by Signature V4
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes everything except the RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
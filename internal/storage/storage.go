@@ -0,0 +1,110 @@
+// Package storage abstracts where uploaded files live so that several server
+// instances can share them. Files are addressed by a slash-separated key
+// relative to the storage root, e.g. "products/<uuid>_thumb.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kha/foods-drinks/internal/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// PrivatePrefix starts the keys of files only their owner and admins may
+// read, laid out as "private/<owner user id>/<name>"
+const PrivatePrefix = "private/"
+
+var (
+	ErrInvalidKey     = errors.New("invalid storage key")
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// Object describes a stored file
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage stores uploaded files
+type Storage interface {
+	// Put writes size bytes from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete removes the object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns a URL a browser can fetch the object from right now.
+	// In signed-URL mode, and for private keys, the result expires and must
+	// not be persisted.
+	URL(ctx context.Context, key string) (string, error)
+	// List calls fn for every stored object; returning an error from fn stops the walk
+	List(ctx context.Context, fn func(Object) error) error
+}
+
+// New builds the backend selected by cfg.Storage.Driver.
+// publicURLPrefix is the route the local backend is served under (e.g. "/uploads").
+func New(cfg *config.UploadConfig, publicURLPrefix string) (Storage, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Storage.Driver)) {
+	case "", DriverLocal:
+		return NewLocalStorage(cfg.Path, publicURLPrefix), nil
+	case DriverS3:
+		return NewS3Storage(&cfg.Storage.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// IsLocal reports whether store keeps files on the local filesystem
+func IsLocal(store Storage) bool {
+	_, ok := store.(*LocalStorage)
+	return ok
+}
+
+// CleanKey normalises a key and rejects keys that are empty or would escape
+// the storage root.
+func CleanKey(key string) (string, error) {
+	if strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// PrivateKey returns the key of a private file owned by the user
+func PrivateKey(ownerID uint, name string) string {
+	return fmt.Sprintf("%s%d/%s", PrivatePrefix, ownerID, strings.TrimPrefix(name, "/"))
+}
+
+// PrivateOwner reports whether a cleaned key is private and, if so, the user
+// owning it. A private key without a valid owner id returns 0, which no user
+// has.
+func PrivateOwner(key string) (uint, bool) {
+	rest, ok := strings.CutPrefix(key, PrivatePrefix)
+	if !ok {
+		return 0, false
+	}
+	owner, _, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseUint(owner, 10, 64)
+	if err != nil {
+		return 0, true
+	}
+	return uint(id), true
+}
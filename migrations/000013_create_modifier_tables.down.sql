@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS `order_item_modifiers`;
+
+DELETE FROM `cart_items` WHERE `modifier_key` <> '';
+ALTER TABLE `cart_items`
+  ADD UNIQUE KEY `uk_cart_product` (`cart_id`, `product_id`),
+  DROP INDEX `uk_cart_product_modifier`,
+  DROP COLUMN `modifier_key`;
+
+DROP TABLE IF EXISTS `modifier_group_assignments`;
+DROP TABLE IF EXISTS `modifier_options`;
+DROP TABLE IF EXISTS `modifier_groups`;
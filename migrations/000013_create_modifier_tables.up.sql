@@ -0,0 +1,67 @@
+-- Create modifier_groups table
+CREATE TABLE `modifier_groups` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(255) NOT NULL,
+  `min_select` INT NOT NULL DEFAULT 0 COMMENT 'Số lựa chọn tối thiểu',
+  `max_select` INT NOT NULL DEFAULT 1 COMMENT 'Số lựa chọn tối đa',
+  `sort_order` INT NOT NULL DEFAULT 0,
+  `status` VARCHAR(50) NOT NULL DEFAULT 'active' COMMENT 'Các giá trị: active, inactive',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` TIMESTAMP NULL,
+
+  INDEX `idx_sort_order` (`sort_order`),
+  INDEX `idx_status` (`status`),
+  INDEX `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Create modifier_options table
+CREATE TABLE `modifier_options` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `group_id` BIGINT UNSIGNED NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `price` DECIMAL(10, 2) NOT NULL DEFAULT 0.00 COMMENT 'Giá cộng thêm vào sản phẩm',
+  `sort_order` INT NOT NULL DEFAULT 0,
+  `status` VARCHAR(50) NOT NULL DEFAULT 'active' COMMENT 'Các giá trị: active, inactive',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX `idx_group_id` (`group_id`),
+  FOREIGN KEY (`group_id`) REFERENCES `modifier_groups`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Create modifier_group_assignments table
+CREATE TABLE `modifier_group_assignments` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `group_id` BIGINT UNSIGNED NOT NULL,
+  `product_id` BIGINT UNSIGNED NULL,
+  `category_id` BIGINT UNSIGNED NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_group_id` (`group_id`),
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_category_id` (`category_id`),
+  FOREIGN KEY (`group_id`) REFERENCES `modifier_groups`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Cart lines are now unique per (cart, product, modifier selection)
+ALTER TABLE `cart_items`
+  ADD COLUMN `modifier_key` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Danh sách modifier_option_id đã sắp xếp' AFTER `quantity`,
+  ADD UNIQUE KEY `uk_cart_product_modifier` (`cart_id`, `product_id`, `modifier_key`),
+  DROP INDEX `uk_cart_product`;
+
+-- Create order_item_modifiers table
+CREATE TABLE `order_item_modifiers` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `order_item_id` BIGINT UNSIGNED NOT NULL,
+  `modifier_option_id` BIGINT UNSIGNED NOT NULL,
+  `group_name` VARCHAR(255) NOT NULL COMMENT 'Lưu tên nhóm tại thời điểm đặt hàng',
+  `option_name` VARCHAR(255) NOT NULL COMMENT 'Lưu tên lựa chọn tại thời điểm đặt hàng',
+  `price` DECIMAL(10, 2) NOT NULL COMMENT 'Lưu giá lựa chọn tại thời điểm đặt hàng',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_order_item_id` (`order_item_id`),
+  FOREIGN KEY (`order_item_id`) REFERENCES `order_items`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
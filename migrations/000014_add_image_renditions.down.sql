@@ -0,0 +1,6 @@
+ALTER TABLE `categories`
+  DROP COLUMN `thumbnail_url`;
+
+ALTER TABLE `product_images`
+  DROP COLUMN `thumbnail_url`,
+  DROP COLUMN `medium_url`;
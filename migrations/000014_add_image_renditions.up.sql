@@ -0,0 +1,7 @@
+-- Resized renditions generated on upload (NULL for pasted external URLs)
+ALTER TABLE `product_images`
+  ADD COLUMN `medium_url` VARCHAR(500) NULL AFTER `image_url`,
+  ADD COLUMN `thumbnail_url` VARCHAR(500) NULL AFTER `medium_url`;
+
+ALTER TABLE `categories`
+  ADD COLUMN `thumbnail_url` VARCHAR(500) NULL AFTER `image_url`;
@@ -0,0 +1,12 @@
+-- Create search_queries table: log of product searches for admin insights and popular suggestions
+CREATE TABLE `search_queries` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `query` VARCHAR(255) NOT NULL COMMENT 'Từ khoá như người dùng nhập',
+  `normalized` VARCHAR(255) NOT NULL COMMENT 'Từ khoá đã bỏ dấu, chữ thường',
+  `result_count` BIGINT NOT NULL DEFAULT 0,
+  `ip_address` VARCHAR(45) NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_created_at` (`created_at`),
+  INDEX `idx_normalized_created` (`normalized`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
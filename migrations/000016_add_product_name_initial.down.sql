@@ -0,0 +1,3 @@
+ALTER TABLE `products`
+  DROP INDEX `idx_name_initial`,
+  DROP COLUMN `name_initial`;
@@ -0,0 +1,37 @@
+-- Folded first letter of the product name, used by the alphabet filter and facet.
+-- The application keeps it in sync on save; the backfill relies on the
+-- accent-insensitive collation (Á = A), with Đ handled explicitly.
+ALTER TABLE `products`
+  ADD COLUMN `name_initial` VARCHAR(1) NOT NULL DEFAULT '#' AFTER `name`,
+  ADD INDEX `idx_name_initial` (`name_initial`);
+
+UPDATE `products` SET `name_initial` = CASE
+    WHEN LEFT(`name`, 1) IN ('đ', 'Đ') THEN 'd'
+    WHEN LEFT(`name`, 1) = 'a' THEN 'a'
+    WHEN LEFT(`name`, 1) = 'b' THEN 'b'
+    WHEN LEFT(`name`, 1) = 'c' THEN 'c'
+    WHEN LEFT(`name`, 1) = 'd' THEN 'd'
+    WHEN LEFT(`name`, 1) = 'e' THEN 'e'
+    WHEN LEFT(`name`, 1) = 'f' THEN 'f'
+    WHEN LEFT(`name`, 1) = 'g' THEN 'g'
+    WHEN LEFT(`name`, 1) = 'h' THEN 'h'
+    WHEN LEFT(`name`, 1) = 'i' THEN 'i'
+    WHEN LEFT(`name`, 1) = 'j' THEN 'j'
+    WHEN LEFT(`name`, 1) = 'k' THEN 'k'
+    WHEN LEFT(`name`, 1) = 'l' THEN 'l'
+    WHEN LEFT(`name`, 1) = 'm' THEN 'm'
+    WHEN LEFT(`name`, 1) = 'n' THEN 'n'
+    WHEN LEFT(`name`, 1) = 'o' THEN 'o'
+    WHEN LEFT(`name`, 1) = 'p' THEN 'p'
+    WHEN LEFT(`name`, 1) = 'q' THEN 'q'
+    WHEN LEFT(`name`, 1) = 'r' THEN 'r'
+    WHEN LEFT(`name`, 1) = 's' THEN 's'
+    WHEN LEFT(`name`, 1) = 't' THEN 't'
+    WHEN LEFT(`name`, 1) = 'u' THEN 'u'
+    WHEN LEFT(`name`, 1) = 'v' THEN 'v'
+    WHEN LEFT(`name`, 1) = 'w' THEN 'w'
+    WHEN LEFT(`name`, 1) = 'x' THEN 'x'
+    WHEN LEFT(`name`, 1) = 'y' THEN 'y'
+    WHEN LEFT(`name`, 1) = 'z' THEN 'z'
+    ELSE '#'
+  END;
@@ -0,0 +1,14 @@
+-- Create product_associations table: co-purchase scores rebuilt nightly from delivered orders
+CREATE TABLE `product_associations` (
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `related_product_id` BIGINT UNSIGNED NOT NULL,
+  `score` DOUBLE NOT NULL COMMENT 'Độ tương đồng cosine giữa tập đơn hàng của hai sản phẩm',
+  `support` INT NOT NULL COMMENT 'Số đơn đã giao chứa cả hai sản phẩm',
+  `computed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (`product_id`, `related_product_id`),
+  INDEX `idx_related_product_id` (`related_product_id`),
+  INDEX `idx_product_score` (`product_id`, `score`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`related_product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,33 @@
+-- Create availability_windows table: weekly serving hours of a product or a category
+CREATE TABLE `availability_windows` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `product_id` BIGINT UNSIGNED NULL,
+  `category_id` BIGINT UNSIGNED NULL,
+  `weekday` TINYINT NOT NULL COMMENT '0 = Chủ nhật, 6 = Thứ bảy',
+  `start_time` VARCHAR(5) NOT NULL COMMENT 'HH:MM theo múi giờ availability.timezone',
+  `end_time` VARCHAR(5) NOT NULL COMMENT 'HH:MM, nhỏ hơn hoặc bằng start_time nghĩa là qua nửa đêm',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_category_id` (`category_id`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Create availability_exceptions table: dates that override the weekly windows
+CREATE TABLE `availability_exceptions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `product_id` BIGINT UNSIGNED NULL,
+  `category_id` BIGINT UNSIGNED NULL,
+  `date` VARCHAR(10) NOT NULL COMMENT 'YYYY-MM-DD',
+  `start_time` VARCHAR(5) NOT NULL DEFAULT '' COMMENT 'Để trống cùng end_time nghĩa là nghỉ cả ngày',
+  `end_time` VARCHAR(5) NOT NULL DEFAULT '',
+  `note` VARCHAR(255) NOT NULL DEFAULT '',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_category_id` (`category_id`),
+  INDEX `idx_date` (`date`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
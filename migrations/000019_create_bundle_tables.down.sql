@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `order_item_components`;
+DROP TABLE IF EXISTS `bundle_items`;
+
+ALTER TABLE `products`
+  DROP INDEX `idx_is_bundle`,
+  DROP COLUMN `is_bundle`;
@@ -0,0 +1,33 @@
+-- Bundle (combo) products: stock is derived from the components
+ALTER TABLE `products`
+  ADD COLUMN `is_bundle` BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Sản phẩm combo, tồn kho tính từ thành phần' AFTER `status`,
+  ADD INDEX `idx_is_bundle` (`is_bundle`);
+
+-- Create bundle_items table
+CREATE TABLE `bundle_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `bundle_id` BIGINT UNSIGNED NOT NULL,
+  `component_id` BIGINT UNSIGNED NOT NULL,
+  `quantity` INT NOT NULL DEFAULT 1 COMMENT 'Số lượng thành phần trong một combo',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE KEY `uk_bundle_component` (`bundle_id`, `component_id`),
+  INDEX `idx_component_id` (`component_id`),
+  FOREIGN KEY (`bundle_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`component_id`) REFERENCES `products`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Create order_item_components table
+CREATE TABLE `order_item_components` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `order_item_id` BIGINT UNSIGNED NOT NULL,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `product_name` VARCHAR(255) NOT NULL COMMENT 'Lưu tên thành phần tại thời điểm đặt hàng',
+  `quantity` INT NOT NULL COMMENT 'Tổng số lượng thành phần của dòng đơn hàng',
+  `subtotal` DECIMAL(10, 2) NOT NULL COMMENT 'Phần doanh thu của dòng phân bổ cho thành phần',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_order_item_id` (`order_item_id`),
+  INDEX `idx_product_id` (`product_id`),
+  FOREIGN KEY (`order_item_id`) REFERENCES `order_items`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,23 @@
+-- Create stock_movements table: append-only inventory ledger
+CREATE TABLE `stock_movements` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `delta` INT NOT NULL COMMENT 'Số lượng thay đổi, âm khi xuất kho',
+  `reason` VARCHAR(30) NOT NULL COMMENT 'Các giá trị: order, cancellation, restock, adjustment, waste',
+  `reference_id` BIGINT UNSIGNED NULL COMMENT 'Mã đơn hàng với order và cancellation',
+  `note` VARCHAR(255) NULL,
+  `created_by` BIGINT UNSIGNED NULL COMMENT 'Admin ghi nhận',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_reason` (`reason`),
+  INDEX `idx_reference_id` (`reference_id`),
+  INDEX `idx_created_at` (`created_at`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Opening balance so the ledger matches the current stock
+INSERT INTO `stock_movements` (`product_id`, `delta`, `reason`, `note`)
+SELECT `id`, `stock`, 'adjustment', 'Số dư đầu kỳ'
+FROM `products`
+WHERE `stock` <> 0 AND `is_bundle` = FALSE;
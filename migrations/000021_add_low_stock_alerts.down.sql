@@ -0,0 +1,3 @@
+ALTER TABLE `products`
+  DROP COLUMN `low_stock_alerted_at`,
+  DROP COLUMN `low_stock_threshold`;
@@ -0,0 +1,9 @@
+-- Low-stock alerts: per-product threshold and the outstanding alert marker
+ALTER TABLE `products`
+  ADD COLUMN `low_stock_threshold` INT NOT NULL DEFAULT 0 COMMENT 'Cảnh báo khi tồn kho giảm xuống mức này hoặc thấp hơn' AFTER `is_bundle`,
+  ADD COLUMN `low_stock_alerted_at` TIMESTAMP NULL COMMENT 'Đã gửi cảnh báo, xoá khi tồn kho vượt ngưỡng' AFTER `low_stock_threshold`;
+
+-- Products already sold out stop showing as active
+UPDATE `products`
+SET `status` = 'out_of_stock'
+WHERE `status` = 'active' AND `stock` <= 0 AND `deleted_at` IS NULL;
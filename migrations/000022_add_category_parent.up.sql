@@ -0,0 +1,5 @@
+-- Nested categories: NULL parent_id marks a top-level category
+ALTER TABLE `categories`
+  ADD COLUMN `parent_id` BIGINT UNSIGNED NULL COMMENT 'Danh mục cha, NULL nếu là danh mục gốc' AFTER `id`,
+  ADD INDEX `idx_parent_id` (`parent_id`),
+  ADD CONSTRAINT `fk_categories_parent` FOREIGN KEY (`parent_id`) REFERENCES `categories`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,15 @@
+-- Create product_revisions table: one snapshot pair per product edit
+CREATE TABLE `product_revisions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `before_data` TEXT NOT NULL COMMENT 'Thông tin sản phẩm trước khi sửa (JSON)',
+  `after_data` TEXT NOT NULL COMMENT 'Thông tin sản phẩm sau khi sửa (JSON)',
+  `rollback_of` BIGINT UNSIGNED NULL COMMENT 'Phiên bản được khôi phục',
+  `created_by` BIGINT UNSIGNED NULL COMMENT 'Admin sửa',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_created_at` (`created_at`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`created_by`) REFERENCES `users`(`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,21 @@
+-- Create price_rules table: scheduled sale prices of a product or a category
+CREATE TABLE `price_rules` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `product_id` BIGINT UNSIGNED NULL,
+  `category_id` BIGINT UNSIGNED NULL COMMENT 'Áp dụng cho cả danh mục con',
+  `name` VARCHAR(100) NOT NULL,
+  `kind` VARCHAR(20) NOT NULL COMMENT 'Các giá trị: fixed (giá bán), percent (phần trăm giảm)',
+  `value` DECIMAL(10,2) NOT NULL,
+  `starts_at` TIMESTAMP NOT NULL,
+  `ends_at` TIMESTAMP NOT NULL,
+  `daily_start` VARCHAR(5) NOT NULL DEFAULT '' COMMENT 'HH:MM theo múi giờ availability.timezone, để trống là cả ngày',
+  `daily_end` VARCHAR(5) NOT NULL DEFAULT '' COMMENT 'HH:MM, nhỏ hơn hoặc bằng daily_start nghĩa là qua nửa đêm',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  INDEX `idx_product_id` (`product_id`),
+  INDEX `idx_category_id` (`category_id`),
+  INDEX `idx_ends_at` (`ends_at`),
+  FOREIGN KEY (`product_id`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,14 @@
+-- Create translations table: product and category text in locales other than the default
+CREATE TABLE `translations` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `entity_type` VARCHAR(20) NOT NULL COMMENT 'Các giá trị: product, category',
+  `entity_id` BIGINT UNSIGNED NOT NULL,
+  `field` VARCHAR(30) NOT NULL COMMENT 'Các giá trị: name, description',
+  `locale` VARCHAR(10) NOT NULL COMMENT 'VD: en',
+  `value` TEXT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  UNIQUE KEY `uk_translation_entry` (`entity_type`, `entity_id`, `field`, `locale`),
+  INDEX `idx_locale` (`entity_type`, `locale`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
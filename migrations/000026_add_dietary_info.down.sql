@@ -0,0 +1,10 @@
+ALTER TABLE `suggestions`
+  DROP COLUMN `dietary_tags`,
+  DROP COLUMN `allergens`;
+
+ALTER TABLE `products`
+  DROP COLUMN `dietary_tags`,
+  DROP COLUMN `allergens`,
+  DROP COLUMN `caffeine_mg`,
+  DROP COLUMN `sugar_grams`,
+  DROP COLUMN `calories`;
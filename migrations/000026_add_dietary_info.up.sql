@@ -0,0 +1,12 @@
+-- Dietary info: nutrition per serving, allergens and dietary tags.
+-- Allergens and tags are JSON arrays of lowercase keys.
+ALTER TABLE `products`
+  ADD COLUMN `calories` INT NULL COMMENT 'kcal mỗi phần, NULL nếu chưa rõ' AFTER `low_stock_alerted_at`,
+  ADD COLUMN `sugar_grams` DECIMAL(6,2) NULL COMMENT 'Gam đường mỗi phần' AFTER `calories`,
+  ADD COLUMN `caffeine_mg` INT NULL COMMENT 'mg caffeine mỗi phần' AFTER `sugar_grams`,
+  ADD COLUMN `allergens` VARCHAR(500) NULL COMMENT 'VD: ["peanut","milk"]' AFTER `caffeine_mg`,
+  ADD COLUMN `dietary_tags` VARCHAR(500) NULL COMMENT 'VD: ["vegan","spicy-2"]' AFTER `allergens`;
+
+ALTER TABLE `suggestions`
+  ADD COLUMN `allergens` VARCHAR(500) NULL COMMENT 'VD: ["peanut","milk"]' AFTER `category_id`,
+  ADD COLUMN `dietary_tags` VARCHAR(500) NULL COMMENT 'VD: ["vegan","spicy-2"]' AFTER `allergens`;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `rating_votes`;
+DROP TABLE IF EXISTS `rating_photos`;
+
+ALTER TABLE `ratings`
+  DROP INDEX `idx_helpful_count`,
+  DROP COLUMN `photo_count`,
+  DROP COLUMN `helpful_count`;
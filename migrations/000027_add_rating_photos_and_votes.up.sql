@@ -0,0 +1,31 @@
+-- Rating photos and helpful votes. The counters on ratings mirror the two
+-- tables so ratings can be sorted by them.
+ALTER TABLE `ratings`
+  ADD COLUMN `helpful_count` INT NOT NULL DEFAULT 0 COMMENT 'Số lượt bình chọn hữu ích' AFTER `comment`,
+  ADD COLUMN `photo_count` INT NOT NULL DEFAULT 0 COMMENT 'Số ảnh đính kèm' AFTER `helpful_count`,
+  ADD INDEX `idx_helpful_count` (`helpful_count`);
+
+CREATE TABLE `rating_photos` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `rating_id` BIGINT UNSIGNED NOT NULL,
+  `image_url` VARCHAR(500) NOT NULL,
+  `medium_url` VARCHAR(500) NULL,
+  `thumbnail_url` VARCHAR(500) NULL,
+  `sort_order` INT NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  INDEX `idx_rating_id` (`rating_id`),
+  FOREIGN KEY (`rating_id`) REFERENCES `ratings`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `rating_votes` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `rating_id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE KEY `uk_rating_user` (`rating_id`, `user_id`),
+  INDEX `idx_user_id` (`user_id`),
+  FOREIGN KEY (`rating_id`) REFERENCES `ratings`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS `rating_reports`;
+
+ALTER TABLE `ratings`
+  DROP INDEX `idx_flagged`,
+  DROP INDEX `idx_status`,
+  DROP COLUMN `replied_at`,
+  DROP COLUMN `shop_reply`,
+  DROP COLUMN `report_count`,
+  DROP COLUMN `flag_reason`,
+  DROP COLUMN `flagged`,
+  DROP COLUMN `status`;
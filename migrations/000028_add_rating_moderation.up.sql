@@ -0,0 +1,25 @@
+-- Review moderation: hidden ratings, the moderation flag, user reports and
+-- the shop's official reply.
+ALTER TABLE `ratings`
+  ADD COLUMN `status` VARCHAR(20) NOT NULL DEFAULT 'visible' COMMENT 'visible | hidden' AFTER `photo_count`,
+  ADD COLUMN `flagged` BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Chờ kiểm duyệt' AFTER `status`,
+  ADD COLUMN `flag_reason` VARCHAR(255) NULL AFTER `flagged`,
+  ADD COLUMN `report_count` INT NOT NULL DEFAULT 0 COMMENT 'Số lượt báo cáo' AFTER `flag_reason`,
+  ADD COLUMN `shop_reply` TEXT NULL COMMENT 'Phản hồi chính thức của cửa hàng' AFTER `report_count`,
+  ADD COLUMN `replied_at` TIMESTAMP NULL AFTER `shop_reply`,
+  ADD INDEX `idx_status` (`status`),
+  ADD INDEX `idx_flagged` (`flagged`);
+
+CREATE TABLE `rating_reports` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `rating_id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `reason` VARCHAR(20) NOT NULL COMMENT 'spam | offensive | off_topic | other',
+  `note` VARCHAR(500) NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE KEY `uk_report_rating_user` (`rating_id`, `user_id`),
+  INDEX `idx_user_id` (`user_id`),
+  FOREIGN KEY (`rating_id`) REFERENCES `ratings`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;